@@ -0,0 +1,86 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcli
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck"
+	"github.com/spf13/pflag"
+)
+
+// BindBaseline binds the baseline flag.
+func BindBaseline(flagSet *pflag.FlagSet, addr *string, flagName string) {
+	flagSet.StringVar(
+		addr,
+		flagName,
+		"",
+		`The baseline file of existing violations to ignore, as written by --write-baseline
+Only violations that are not in the baseline are reported. Baseline entries that no longer match a violation produce a warning`,
+	)
+}
+
+// BindWriteBaseline binds the write-baseline flag.
+func BindWriteBaseline(flagSet *pflag.FlagSet, addr *string, flagName string) {
+	flagSet.StringVar(
+		addr,
+		flagName,
+		"",
+		`Write all current violations to the given baseline file instead of reporting them
+Violations are recorded by rule, file, and enclosing symbol, so the baseline is stable across unrelated edits`,
+	)
+}
+
+// ReadBaselineFile reads the Baseline at the path.
+func ReadBaselineFile(path string) (_ bufcheck.Baseline, retErr error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, file.Close())
+	}()
+	baseline, err := bufcheck.ReadBaseline(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+// WriteBaselineFile writes the Baseline to the path.
+func WriteBaselineFile(path string, baseline bufcheck.Baseline) (retErr error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, file.Close())
+	}()
+	return bufcheck.WriteBaseline(file, baseline)
+}
+
+// WarnStaleBaselineEntries warns for each BaselineEntry that no longer matches a violation.
+func WarnStaleBaselineEntries(logger *slog.Logger, path string, staleBaselineEntries []bufcheck.BaselineEntry) {
+	for _, staleBaselineEntry := range staleBaselineEntries {
+		message := fmt.Sprintf("Baseline entry in %q no longer matches any violation: %s.", path, staleBaselineEntry.String())
+		if count := staleBaselineEntry.Count(); count > 1 {
+			message = fmt.Sprintf("Baseline entry in %q no longer matches %d violations: %s.", path, count, staleBaselineEntry.String())
+		}
+		logger.Warn(message)
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufplugin/bufpluginapi"
 	"github.com/bufbuild/buf/private/bufpkg/bufplugin/bufplugincache"
 	"github.com/bufbuild/buf/private/bufpkg/bufplugin/bufpluginstore"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginstore"
 	"github.com/bufbuild/buf/private/bufpkg/bufregistryapi/bufregistryapimodule"
 	"github.com/bufbuild/buf/private/bufpkg/bufregistryapi/bufregistryapiowner"
 	"github.com/bufbuild/buf/private/bufpkg/bufregistryapi/bufregistryapiplugin"
@@ -62,6 +63,8 @@ var (
 		v3CachePluginRelDirPath,
 		v3CacheWKTRelDirPath,
 		v3CacheWasmRuntimeRelDirPath,
+		v3CacheGenerateRelDirPath,
+		v3CacheGenerateLockRelDirPath,
 	}
 
 	// v1CacheModuleDataRelDirPath is the relative path to the cache directory where module data
@@ -122,6 +125,16 @@ var (
 	//
 	// Normalized.
 	v3CacheWasmRuntimeRelDirPath = normalpath.Join("v3", "wasmruntime")
+	// v3CacheGenerateRelDirPath is the relative path to the cache directory for the
+	// responses of plugins run by buf generate.
+	//
+	// Normalized.
+	v3CacheGenerateRelDirPath = normalpath.Join("v3", "generate")
+	// v3CacheGenerateLockRelDirPath is the relative path to the lock files directory for the
+	// responses of plugins run by buf generate.
+	//
+	// Normalized.
+	v3CacheGenerateLockRelDirPath = normalpath.Join("v3", "generatelocks")
 )
 
 // NewModuleDataProvider returns a new ModuleDataProvider while creating the
@@ -192,6 +205,26 @@ func NewWasmRuntime(ctx context.Context, container appext.Container) (wasm.Runti
 	return wasmRuntime, nil
 }
 
+// NewGenerateResponseStore returns a new bufprotopluginstore.ResponseStore for the
+// responses of plugins run by buf generate, while creating the required cache directories.
+func NewGenerateResponseStore(container appext.Container) (bufprotopluginstore.ResponseStore, error) {
+	if err := createCacheDir(container.CacheDirPath(), v3CacheGenerateRelDirPath); err != nil {
+		return nil, err
+	}
+	if err := createCacheDir(container.CacheDirPath(), v3CacheGenerateLockRelDirPath); err != nil {
+		return nil, err
+	}
+	filelocker, err := filelock.NewLocker(normalpath.Join(container.CacheDirPath(), v3CacheGenerateLockRelDirPath))
+	if err != nil {
+		return nil, err
+	}
+	return bufprotopluginstore.NewResponseStore(
+		container.Logger(),
+		normalpath.Unnormalize(normalpath.Join(container.CacheDirPath(), v3CacheGenerateRelDirPath)),
+		filelocker,
+	), nil
+}
+
 // NewWKTStore returns a new bufwktstore.Store while creating the required cache directories.
 func NewWKTStore(container appext.Container) (bufwktstore.Store, error) {
 	if err := createCacheDir(container.CacheDirPath(), v3CacheWKTRelDirPath); err != nil {
@@ -0,0 +1,127 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcli
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"buf.build/go/app"
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"buf.build/go/standard/xslices"
+	"github.com/bufbuild/buf/private/buf/bufctl"
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufrefactor"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/pkg/storage"
+)
+
+// NewRefactorerForInput returns a new Refactorer for the local source files of the
+// workspace of the input, along with a ReadBucket of the original source files.
+//
+// The input must be a directory or a .proto file, as the source files are rewritten in place.
+// The usage is what requires a writable input, for example "--fix", and is used for error messages.
+func NewRefactorerForInput(
+	ctx context.Context,
+	container appext.Container,
+	controller bufctl.Controller,
+	input string,
+	usage string,
+	options ...bufctl.FunctionOption,
+) (bufrefactor.Refactorer, storage.ReadBucket, error) {
+	// We write over the ExternalPaths of the source files, which is only valid for
+	// directories and .proto files.
+	dirOrProtoFileRef, err := buffetch.NewDirOrProtoFileRefParser(container.Logger()).GetDirOrProtoFileRef(ctx, input)
+	if err != nil {
+		if errors.Is(err, buffetch.ErrModuleFormatDetectedForDirOrProtoFileRef) {
+			return nil, nil, appcmd.NewInvalidArgumentErrorf("invalid input %q when using %s: must be a directory or proto file", input, usage)
+		}
+		return nil, nil, appcmd.NewInvalidArgumentErrorf("invalid input %q when using %s: %v", input, usage, err)
+	}
+	if protoFileRef, ok := dirOrProtoFileRef.(buffetch.ProtoFileRef); ok && protoFileRef.IncludePackageFiles() {
+		return nil, nil, appcmd.NewInvalidArgumentErrorf("cannot use %s with include_package_files=true", usage)
+	}
+	workspace, err := controller.GetWorkspace(ctx, input, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// We build an Image of the entire workspace, so that references from files that
+	// are not targeted are also updated.
+	image, err := controller.GetImageForWorkspace(ctx, workspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Only the files of the target Modules are local, and can be edited.
+	readBucket := bufmodule.ModuleReadBucketToStorageReadBucket(
+		bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFilesForTargetModules(workspace),
+	)
+	refactorer, err := bufrefactor.NewRefactorer(ctx, image, readBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return refactorer, readBucket, nil
+}
+
+// WriteRefactoredBucket writes the files of the refactored ReadBucket over their ExternalPaths.
+//
+// If diff is true, a diff against the original ReadBucket is written to stdout instead, and
+// no files are written.
+func WriteRefactoredBucket(
+	ctx context.Context,
+	container app.StdoutContainer,
+	originalReadBucket storage.ReadBucket,
+	refactoredReadBucket storage.ReadBucket,
+	diff bool,
+) error {
+	if diff {
+		paths, err := storage.AllPaths(ctx, refactoredReadBucket, "")
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+		return storage.Diff(
+			ctx,
+			container.Stdout(),
+			storage.FilterReadBucket(
+				originalReadBucket,
+				storage.MatchOr(xslices.Map(paths, storage.MatchPathEqual)...),
+			),
+			refactoredReadBucket,
+			storage.DiffWithExternalPaths(), // No need to set prefixes as the buckets are from the same location.
+		)
+	}
+	return storage.WalkReadObjects(
+		ctx,
+		refactoredReadBucket,
+		"",
+		func(readObject storage.ReadObject) (retErr error) {
+			// Like buf format --write, we rely on the ExternalPaths of a directory or
+			// .proto file input being writable.
+			file, err := os.OpenFile(readObject.ExternalPath(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				retErr = errors.Join(retErr, file.Close())
+			}()
+			_, err = file.ReadFrom(readObject)
+			return err
+		},
+	)
+}
@@ -16,15 +16,221 @@ package bufconvert
 
 import (
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 
+	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+// MessageReader reads messages.
+type MessageReader interface {
+	// Read reads the next message.
+	//
+	// Returns io.EOF if there are no more messages.
+	Read() (proto.Message, error)
+	// Close closes the underlying reader.
+	Close() error
+}
+
+// NewMessageReader returns a new MessageReader that reads messages of the given type
+// with the given encoding and delimiter.
+//
+// If the delimiter is zero, the reader holds a single message, which is read in full.
+// Otherwise, messages are read one at a time, and errors for a message are prefixed
+// with the index of the message within the stream.
+//
+// For MessageDelimiterEnvelope, compressed messages are not supported, and a message
+// with the end-of-stream flag of Connect ends the stream.
+func NewMessageReader(
+	readCloser io.ReadCloser,
+	messageType protoreflect.MessageType,
+	unmarshaler protoencoding.Unmarshaler,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (MessageReader, error) {
+	return newMessageReader(
+		readCloser,
+		messageType,
+		unmarshaler,
+		messageEncoding,
+		messageDelimiter,
+	)
+}
+
+// MessageDataReader reads the data of messages without unmarshalling them.
+type MessageDataReader interface {
+	// Read reads the data of the next message.
+	//
+	// The data is only valid until the next call to Read.
+	// Returns io.EOF if there are no more messages.
+	Read() ([]byte, error)
+	// Close closes the underlying reader.
+	Close() error
+}
+
+// NewMessageDataReader returns a new MessageDataReader that reads the data of messages
+// with the given encoding and delimiter.
+//
+// See NewMessageReader for how the delimiter is handled.
+func NewMessageDataReader(
+	readCloser io.ReadCloser,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (MessageDataReader, error) {
+	return newMessageDataReader(
+		readCloser,
+		messageEncoding,
+		messageDelimiter,
+	)
+}
+
+// MessageWriter writes messages.
+type MessageWriter interface {
+	// Write writes the message.
+	//
+	// If the delimiter is zero, only a single message can be written.
+	Write(message proto.Message) error
+	// Close closes the underlying writer.
+	Close() error
+}
+
+// NewMessageWriter returns a new MessageWriter that writes messages with the given
+// encoding and delimiter.
+func NewMessageWriter(
+	writeCloser io.WriteCloser,
+	marshaler protoencoding.Marshaler,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (MessageWriter, error) {
+	return newMessageWriter(
+		writeCloser,
+		marshaler,
+		messageEncoding,
+		messageDelimiter,
+	)
+}
+
+// FieldFilter filters the fields of messages.
+type FieldFilter interface {
+	// Filter filters the fields of the message in place.
+	//
+	// The message must be of the type the FieldFilter was created for.
+	//
+	// Messages packed in google.protobuf.Any messages are filtered as well, for which
+	// their types must be resolvable.
+	Filter(message proto.Message) error
+}
+
+// NewFieldFilter returns a new FieldFilter for messages of the given type.
+//
+// Field paths are the names of fields separated by dots, such as "a.b", and are resolved
+// against the message descriptors. A field path can go through singular, repeated, and map
+// fields of messages, in which case the rest of the path applies to every message of the
+// field. The rest of a field path after a google.protobuf.Any field is resolved against
+// the packed message when filtering, and fields that the packed message does not have
+// are ignored. Redact options and the types of packed messages are resolved with the
+// resolver.
+//
+// The field mask is applied first, then the excluded fields, and then the redact options.
+func NewFieldFilter(
+	resolver protoencoding.Resolver,
+	messageDescriptor protoreflect.MessageDescriptor,
+	options ...FieldFilterOption,
+) (FieldFilter, error) {
+	return newFieldFilter(resolver, messageDescriptor, options...)
+}
+
+// FieldFilterOption is an option for a new FieldFilter.
+type FieldFilterOption func(*fieldFilterOptions)
+
+// FieldFilterWithFieldMask returns a new FieldFilterOption that only keeps the fields
+// of the given field paths, and clears all other fields, including unknown fields.
+func FieldFilterWithFieldMask(fieldPaths ...string) FieldFilterOption {
+	return func(fieldFilterOptions *fieldFilterOptions) {
+		fieldFilterOptions.fieldMaskPaths = append(fieldFilterOptions.fieldMaskPaths, fieldPaths...)
+	}
+}
+
+// FieldFilterWithExcludeFields returns a new FieldFilterOption that clears the fields
+// of the given field paths.
+func FieldFilterWithExcludeFields(fieldPaths ...string) FieldFilterOption {
+	return func(fieldFilterOptions *fieldFilterOptions) {
+		fieldFilterOptions.excludePaths = append(fieldFilterOptions.excludePaths, fieldPaths...)
+	}
+}
+
+// FieldFilterWithRedactOption returns a new FieldFilterOption that clears every field
+// and extension that has the given field option set, within the message and all of its
+// nested messages. Unknown fields are cleared as well, as they cannot be checked for the
+// option.
+//
+// The option is either the name of a field of google.protobuf.FieldOptions, such as
+// "debug_redact", or the full name of an extension of google.protobuf.FieldOptions,
+// optionally within parentheses, such as "(acme.sensitive)". Options of type bool must
+// be true, and options of other types must be set.
+func FieldFilterWithRedactOption(option string) FieldFilterOption {
+	return func(fieldFilterOptions *fieldFilterOptions) {
+		fieldFilterOptions.redactOptions = append(fieldFilterOptions.redactOptions, option)
+	}
+}
+
+// WriteRawMessage writes the wire structure of the binary message data to the writer,
+// without a schema.
+//
+// Every field is written with its number and wire type, and groups are written with
+// their nested fields. As the wire format does not say what a length-delimited field
+// holds, it is written as a string if it is printable, as a nested message if it parses
+// as one, and as bytes otherwise.
+//
+// If the data is not valid wire format, the fields before the invalid data are written,
+// and an error with the offset of the invalid data is returned.
+func WriteRawMessage(writer io.Writer, data []byte) error {
+	return writeRawMessage(writer, data)
+}
+
+// MessageTypeGuesser guesses the message type of binary message data.
+type MessageTypeGuesser interface {
+	// Add scores every message type against the binary data of a message.
+	//
+	// For a stream of messages, Add is called for each message, and the scores are
+	// summed across the messages. Message types that fail to unmarshal any of the
+	// messages do not match.
+	Add(data []byte)
+	// Matches returns the message types that match all of the data added, best match first.
+	//
+	// Message types are ordered by the number of bytes that they do not recognize, and
+	// then by the number of fields that they recognize. If any data was added, message
+	// types that do not recognize any field do not match.
+	Matches() []MessageTypeMatch
+}
+
+// NewMessageTypeGuesser returns a new MessageTypeGuesser that guesses between all of the
+// message types of the image, except for map entries and message types that the resolver
+// of the image cannot resolve.
+func NewMessageTypeGuesser(image bufimage.Image) (MessageTypeGuesser, error) {
+	return newMessageTypeGuesser(image)
+}
+
+// MessageTypeMatch is a message type that matches binary message data.
+type MessageTypeMatch interface {
+	// TypeName returns the full name of the message type.
+	TypeName() string
+	// UnrecognizedBytes returns the number of bytes of the data that are unknown
+	// fields of the message type, including within nested messages.
+	UnrecognizedBytes() int
+	// RecognizedFields returns the number of fields of the data that are known fields
+	// of the message type, including within nested messages.
+	RecognizedFields() int
+
+	isMessageTypeMatch()
+}
+
 // ImageWithoutMessageSetWireFormatResolution returns an image with the
 // same contents as the given image, but whose resolver refuses to
 // resolve elements that refer to messages that use the message-set wire
@@ -0,0 +1,446 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	anyFullName         protoreflect.FullName = "google.protobuf.Any"
+	anyTypeURLFieldName protoreflect.Name     = "type_url"
+	anyValueFieldName   protoreflect.Name     = "value"
+)
+
+type fieldFilter struct {
+	resolver    protoencoding.Resolver
+	marshaler   protoencoding.Marshaler
+	unmarshaler protoencoding.Unmarshaler
+	// fieldMask is nil if there is no field mask.
+	fieldMask *fieldPathNode
+	// exclude is nil if there are no excluded fields.
+	exclude *fieldPathNode
+	// redactOptionFullNames are the full names of the redact options.
+	redactOptionFullNames map[protoreflect.FullName]struct{}
+	// redactedFields caches whether fields and extensions have a redact option, by full name.
+	redactedFields map[protoreflect.FullName]bool
+}
+
+func newFieldFilter(
+	resolver protoencoding.Resolver,
+	messageDescriptor protoreflect.MessageDescriptor,
+	options ...FieldFilterOption,
+) (*fieldFilter, error) {
+	fieldFilterOptions := newFieldFilterOptions()
+	for _, option := range options {
+		option(fieldFilterOptions)
+	}
+	fieldFilter := &fieldFilter{
+		resolver:    resolver,
+		marshaler:   protoencoding.NewWireMarshaler(),
+		unmarshaler: protoencoding.NewWireUnmarshaler(resolver),
+	}
+	if len(fieldFilterOptions.fieldMaskPaths) > 0 {
+		fieldMask, err := newFieldPathNode(messageDescriptor, fieldFilterOptions.fieldMaskPaths)
+		if err != nil {
+			return nil, err
+		}
+		fieldFilter.fieldMask = fieldMask
+	}
+	if len(fieldFilterOptions.excludePaths) > 0 {
+		exclude, err := newFieldPathNode(messageDescriptor, fieldFilterOptions.excludePaths)
+		if err != nil {
+			return nil, err
+		}
+		fieldFilter.exclude = exclude
+	}
+	if len(fieldFilterOptions.redactOptions) > 0 {
+		optionFullNames := make(map[protoreflect.FullName]struct{}, len(fieldFilterOptions.redactOptions))
+		for _, redactOption := range fieldFilterOptions.redactOptions {
+			optionFullName, err := getRedactOptionFullName(resolver, redactOption)
+			if err != nil {
+				return nil, err
+			}
+			optionFullNames[optionFullName] = struct{}{}
+		}
+		fieldFilter.redactOptionFullNames = optionFullNames
+		fieldFilter.redactedFields = make(map[protoreflect.FullName]bool)
+	}
+	return fieldFilter, nil
+}
+
+func (f *fieldFilter) Filter(message proto.Message) error {
+	reflectMessage := message.ProtoReflect()
+	if f.fieldMask != nil {
+		if err := f.keepFieldPaths(reflectMessage, f.fieldMask); err != nil {
+			return err
+		}
+	}
+	if f.exclude != nil {
+		if err := f.clearFieldPaths(reflectMessage, f.exclude); err != nil {
+			return err
+		}
+	}
+	if f.redactOptionFullNames != nil {
+		if err := f.clearRedactedFields(reflectMessage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldPathNode is a node of a tree of field paths.
+type fieldPathNode struct {
+	// all is true if the entire field is selected.
+	all bool
+	// children are the selected fields of the messages of the field, by name.
+	children map[protoreflect.Name]*fieldPathNode
+}
+
+// newFieldPathNode returns the root of the tree of the given field paths.
+func newFieldPathNode(messageDescriptor protoreflect.MessageDescriptor, fieldPaths []string) (*fieldPathNode, error) {
+	root := &fieldPathNode{}
+	for _, fieldPath := range fieldPaths {
+		if err := root.addFieldPath(messageDescriptor, fieldPath); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func (n *fieldPathNode) addFieldPath(messageDescriptor protoreflect.MessageDescriptor, fieldPath string) error {
+	if fieldPath == "" {
+		return errors.New("field path is empty")
+	}
+	node := n
+	names := strings.Split(fieldPath, ".")
+	// The type of the message packed in a google.protobuf.Any is only known when filtering,
+	// so the rest of a field path after a google.protobuf.Any is not resolved.
+	resolveNames := true
+	for i, name := range names {
+		if node.all {
+			// A parent field is already selected in its entirety.
+			return nil
+		}
+		if resolveNames {
+			if messageDescriptor == nil {
+				return fmt.Errorf("invalid field path %q: %q is not a message field", fieldPath, strings.Join(names[:i], "."))
+			}
+			if messageDescriptor.FullName() == anyFullName {
+				resolveNames = false
+			} else {
+				field := messageDescriptor.Fields().ByName(protoreflect.Name(name))
+				if field == nil {
+					return fmt.Errorf("invalid field path %q: message %q has no field %q", fieldPath, messageDescriptor.FullName(), name)
+				}
+				messageDescriptor = getFieldMessageDescriptor(field)
+			}
+		}
+		if !protoreflect.Name(name).IsValid() {
+			return fmt.Errorf("invalid field path %q: %q is not a valid field name", fieldPath, name)
+		}
+		if node.children == nil {
+			node.children = make(map[protoreflect.Name]*fieldPathNode)
+		}
+		child, ok := node.children[protoreflect.Name(name)]
+		if !ok {
+			child = &fieldPathNode{}
+			node.children[protoreflect.Name(name)] = child
+		}
+		node = child
+	}
+	node.all = true
+	node.children = nil
+	return nil
+}
+
+// keepFieldPaths clears all fields of the message that are not selected by the node.
+func (f *fieldFilter) keepFieldPaths(message protoreflect.Message, node *fieldPathNode) error {
+	if isAnyMessage(message) {
+		return f.filterAnyMessage(message, func(message protoreflect.Message) error {
+			return f.keepFieldPaths(message, node)
+		})
+	}
+	var fieldsToClear []protoreflect.FieldDescriptor
+	var err error
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		var child *fieldPathNode
+		if !field.IsExtension() {
+			child = node.children[field.Name()]
+		}
+		switch {
+		case child == nil:
+			fieldsToClear = append(fieldsToClear, field)
+		case !child.all:
+			rangeFieldMessages(field, value, func(message protoreflect.Message) {
+				if err == nil {
+					err = f.keepFieldPaths(message, child)
+				}
+			})
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, field := range fieldsToClear {
+		message.Clear(field)
+	}
+	message.SetUnknown(nil)
+	return nil
+}
+
+// clearFieldPaths clears all fields of the message that are selected by the node.
+func (f *fieldFilter) clearFieldPaths(message protoreflect.Message, node *fieldPathNode) error {
+	if isAnyMessage(message) {
+		return f.filterAnyMessage(message, func(message protoreflect.Message) error {
+			return f.clearFieldPaths(message, node)
+		})
+	}
+	fields := message.Descriptor().Fields()
+	for name, child := range node.children {
+		field := fields.ByName(name)
+		if field == nil || !message.Has(field) {
+			continue
+		}
+		if child.all {
+			message.Clear(field)
+			continue
+		}
+		var err error
+		rangeFieldMessages(field, message.Mutable(field), func(message protoreflect.Message) {
+			if err == nil {
+				err = f.clearFieldPaths(message, child)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearRedactedFields clears the redacted fields and extensions of the message and all of
+// its nested messages.
+//
+// Unknown fields cannot be checked for redact options, so they are cleared as well.
+func (f *fieldFilter) clearRedactedFields(message protoreflect.Message) error {
+	if isAnyMessage(message) {
+		return f.filterAnyMessage(message, f.clearRedactedFields)
+	}
+	var fieldsToClear []protoreflect.FieldDescriptor
+	var err error
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		var redacted bool
+		redacted, err = f.isRedacted(field)
+		if err != nil {
+			return false
+		}
+		if redacted {
+			fieldsToClear = append(fieldsToClear, field)
+			return true
+		}
+		rangeFieldMessages(field, value, func(message protoreflect.Message) {
+			if err == nil {
+				err = f.clearRedactedFields(message)
+			}
+		})
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, field := range fieldsToClear {
+		message.Clear(field)
+	}
+	message.SetUnknown(nil)
+	return nil
+}
+
+// isRedacted returns true if the field or extension has any of the redact options.
+func (f *fieldFilter) isRedacted(field protoreflect.FieldDescriptor) (bool, error) {
+	if redacted, ok := f.redactedFields[field.FullName()]; ok {
+		return redacted, nil
+	}
+	redacted, err := hasRedactOption(f.resolver, field, f.redactOptionFullNames)
+	if err != nil {
+		return false, err
+	}
+	f.redactedFields[field.FullName()] = redacted
+	return redacted, nil
+}
+
+// filterAnyMessage unpacks the message of the google.protobuf.Any message, calls filter
+// with it, and packs it back into the google.protobuf.Any message.
+//
+// The type of the packed message is resolved with the resolver. It is an error if it
+// cannot be resolved, as the packed message could not be filtered.
+func (f *fieldFilter) filterAnyMessage(anyMessage protoreflect.Message, filter func(protoreflect.Message) error) error {
+	fields := anyMessage.Descriptor().Fields()
+	typeURLField := fields.ByName(anyTypeURLFieldName)
+	valueField := fields.ByName(anyValueFieldName)
+	if typeURLField == nil || valueField == nil {
+		return fmt.Errorf("message %q does not have the fields of %q", anyMessage.Descriptor().FullName(), anyFullName)
+	}
+	typeURL := anyMessage.Get(typeURLField).String()
+	if typeURL == "" {
+		if anyMessage.Has(valueField) {
+			return fmt.Errorf("cannot filter %q without a type URL", anyFullName)
+		}
+		return nil
+	}
+	messageType, err := f.resolver.FindMessageByURL(typeURL)
+	if err != nil {
+		return fmt.Errorf("cannot filter %q with type URL %q: %w", anyFullName, typeURL, err)
+	}
+	message := messageType.New()
+	if err := f.unmarshaler.Unmarshal(anyMessage.Get(valueField).Bytes(), message.Interface()); err != nil {
+		return fmt.Errorf("cannot filter %q with type URL %q: %w", anyFullName, typeURL, err)
+	}
+	if err := filter(message); err != nil {
+		return err
+	}
+	data, err := f.marshaler.Marshal(message.Interface())
+	if err != nil {
+		return fmt.Errorf("cannot filter %q with type URL %q: %w", anyFullName, typeURL, err)
+	}
+	anyMessage.Set(valueField, protoreflect.ValueOfBytes(data))
+	return nil
+}
+
+func isAnyMessage(message protoreflect.Message) bool {
+	return message.Descriptor().FullName() == anyFullName
+}
+
+// rangeFieldMessages calls f for every message of the value of the field, that is the
+// message of a singular field, the elements of a repeated field, or the values of a map field.
+func rangeFieldMessages(
+	field protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	f func(protoreflect.Message),
+) {
+	switch {
+	case field.IsMap():
+		if field.MapValue().Message() == nil {
+			return
+		}
+		value.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+			f(value.Message())
+			return true
+		})
+	case field.IsList():
+		if field.Message() == nil {
+			return
+		}
+		list := value.List()
+		for i := range list.Len() {
+			f(list.Get(i).Message())
+		}
+	case field.Message() != nil:
+		f(value.Message())
+	}
+}
+
+// getFieldMessageDescriptor returns the descriptor of the messages of the field, or nil
+// if the field does not hold messages.
+func getFieldMessageDescriptor(field protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if field.IsMap() {
+		return field.MapValue().Message()
+	}
+	return field.Message()
+}
+
+// getRedactOptionFullName returns the full name of the field of google.protobuf.FieldOptions
+// or of the extension of google.protobuf.FieldOptions for the redact option.
+func getRedactOptionFullName(resolver protoencoding.Resolver, redactOption string) (protoreflect.FullName, error) {
+	name := redactOption
+	isExtension := strings.HasPrefix(name, "(") && strings.HasSuffix(name, ")")
+	if isExtension {
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "("), ")")
+	}
+	name = strings.TrimPrefix(name, ".")
+	fieldOptionsDescriptor := (&descriptorpb.FieldOptions{}).ProtoReflect().Descriptor()
+	if !isExtension {
+		if field := fieldOptionsDescriptor.Fields().ByName(protoreflect.Name(name)); field != nil {
+			return field.FullName(), nil
+		}
+	}
+	extensionType, err := resolver.FindExtensionByName(protoreflect.FullName(name))
+	if err != nil {
+		if errors.Is(err, protoregistry.NotFound) {
+			return "", fmt.Errorf("invalid redact option %q: no field option or extension named %q", redactOption, name)
+		}
+		return "", fmt.Errorf("invalid redact option %q: %w", redactOption, err)
+	}
+	extensionDescriptor := extensionType.TypeDescriptor()
+	if containingMessageName := extensionDescriptor.ContainingMessage().FullName(); containingMessageName != fieldOptionsDescriptor.FullName() {
+		return "", fmt.Errorf(
+			"invalid redact option %q: extension extends %q, not %q",
+			redactOption,
+			containingMessageName,
+			fieldOptionsDescriptor.FullName(),
+		)
+	}
+	return extensionDescriptor.FullName(), nil
+}
+
+// hasRedactOption returns true if the field has any of the given options, where options
+// of type bool must be true, and options of other types must be set.
+func hasRedactOption(
+	resolver protoencoding.Resolver,
+	field protoreflect.FieldDescriptor,
+	optionFullNames map[protoreflect.FullName]struct{},
+) (bool, error) {
+	options := field.Options()
+	if options == nil || !options.ProtoReflect().IsValid() {
+		return false, nil
+	}
+	// Custom options may be unrecognized fields, so they are parsed with the resolver.
+	options = proto.Clone(options)
+	if err := protoencoding.ReparseExtensions(resolver, options.ProtoReflect()); err != nil {
+		return false, err
+	}
+	var redacted bool
+	options.ProtoReflect().Range(func(optionField protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if _, ok := optionFullNames[optionField.FullName()]; !ok {
+			return true
+		}
+		if optionField.Kind() == protoreflect.BoolKind && !optionField.IsList() {
+			redacted = value.Bool()
+		} else {
+			redacted = true
+		}
+		return !redacted
+	})
+	return redacted, nil
+}
+
+type fieldFilterOptions struct {
+	fieldMaskPaths []string
+	excludePaths   []string
+	redactOptions  []string
+}
+
+func newFieldFilterOptions() *fieldFilterOptions {
+	return &fieldFilterOptions{}
+}
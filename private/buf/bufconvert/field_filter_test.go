@@ -0,0 +1,276 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const fieldFilterTestProto = `syntax = "proto2";
+
+package acme.v1;
+
+import "google/protobuf/any.proto";
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.FieldOptions {
+  optional bool sensitive = 50000;
+}
+
+message User {
+  optional string id = 1;
+  optional string email = 2 [(acme.v1.sensitive) = true];
+  optional Address address = 3;
+  repeated Address previous_addresses = 4;
+  map<string, Address> named_addresses = 5;
+  optional string password = 6 [debug_redact = true];
+  optional string nickname = 7 [(acme.v1.sensitive) = false];
+  repeated google.protobuf.Any attachments = 8;
+
+  extensions 100 to 199;
+}
+
+message Address {
+  optional string street = 1 [(acme.v1.sensitive) = true];
+  optional string city = 2;
+}
+
+extend User {
+  optional string secret_note = 100 [(acme.v1.sensitive) = true];
+  optional string public_note = 101;
+}
+`
+
+const fieldFilterTestUser = `{
+  "id": "1",
+  "email": "a@example.com",
+  "address": {"street": "1 Main St", "city": "Toronto"},
+  "previousAddresses": [{"street": "2 Main St", "city": "Ottawa"}, {"city": "Montreal"}],
+  "namedAddresses": {"work": {"street": "3 Main St", "city": "Toronto"}},
+  "password": "secret",
+  "nickname": "a",
+  "attachments": [
+    {"@type": "type.googleapis.com/acme.v1.Address", "street": "4 Main St", "city": "Halifax"},
+    {"@type": "type.googleapis.com/acme.v1.User", "id": "2", "email": "b@example.com", "address": {"street": "5 Main St"}}
+  ],
+  "[acme.v1.secret_note]": "secret",
+  "[acme.v1.public_note]": "public"
+}`
+
+func TestFieldFilter(t *testing.T) {
+	t.Parallel()
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			FieldFilterWithFieldMask("id", "address.city", "previous_addresses.city", "named_addresses.city"),
+		},
+		`{
+		  "id": "1",
+		  "address": {"city": "Toronto"},
+		  "previousAddresses": [{"city": "Ottawa"}, {"city": "Montreal"}],
+		  "namedAddresses": {"work": {"city": "Toronto"}}
+		}`,
+	)
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			// The entire address is kept, as address is in the field mask.
+			FieldFilterWithFieldMask("address.city", "address", "email"),
+		},
+		`{
+		  "email": "a@example.com",
+		  "address": {"street": "1 Main St", "city": "Toronto"}
+		}`,
+	)
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			// Field paths after a google.protobuf.Any field are resolved against the packed
+			// message, and are ignored if the packed message does not have the field.
+			FieldFilterWithFieldMask("attachments.city", "attachments.address"),
+		},
+		`{
+		  "attachments": [
+		    {"@type": "type.googleapis.com/acme.v1.Address", "city": "Halifax"},
+		    {"@type": "type.googleapis.com/acme.v1.User", "address": {"street": "5 Main St"}}
+		  ]
+		}`,
+	)
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			FieldFilterWithExcludeFields(
+				"email",
+				"password",
+				"nickname",
+				"previous_addresses.street",
+				"named_addresses",
+				"attachments.email",
+			),
+		},
+		`{
+		  "id": "1",
+		  "address": {"street": "1 Main St", "city": "Toronto"},
+		  "previousAddresses": [{"city": "Ottawa"}, {"city": "Montreal"}],
+		  "attachments": [
+		    {"@type": "type.googleapis.com/acme.v1.Address", "street": "4 Main St", "city": "Halifax"},
+		    {"@type": "type.googleapis.com/acme.v1.User", "id": "2", "address": {"street": "5 Main St"}}
+		  ],
+		  "[acme.v1.secret_note]": "secret",
+		  "[acme.v1.public_note]": "public"
+		}`,
+	)
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			FieldFilterWithRedactOption("(acme.v1.sensitive)"),
+			FieldFilterWithRedactOption("debug_redact"),
+		},
+		// Sensitive fields of messages packed in google.protobuf.Any messages and
+		// sensitive extensions are redacted.
+		`{
+		  "id": "1",
+		  "address": {"city": "Toronto"},
+		  "previousAddresses": [{"city": "Ottawa"}, {"city": "Montreal"}],
+		  "namedAddresses": {"work": {"city": "Toronto"}},
+		  "nickname": "a",
+		  "attachments": [
+		    {"@type": "type.googleapis.com/acme.v1.Address", "city": "Halifax"},
+		    {"@type": "type.googleapis.com/acme.v1.User", "id": "2", "address": {}}
+		  ],
+		  "[acme.v1.public_note]": "public"
+		}`,
+	)
+	testFieldFilter(
+		t,
+		[]FieldFilterOption{
+			FieldFilterWithFieldMask("id", "email", "address"),
+			FieldFilterWithExcludeFields("id"),
+			FieldFilterWithRedactOption("acme.v1.sensitive"),
+		},
+		`{
+		  "address": {"city": "Toronto"}
+		}`,
+	)
+}
+
+func TestFieldFilterRedactUnknownFields(t *testing.T) {
+	t.Parallel()
+	resolver := newFieldFilterTestResolver(t)
+	messageType, err := resolver.FindMessageByName("acme.v1.Address")
+	require.NoError(t, err)
+	fieldFilter, err := NewFieldFilter(resolver, messageType.Descriptor(), FieldFilterWithRedactOption("(acme.v1.sensitive)"))
+	require.NoError(t, err)
+	message := messageType.New().Interface()
+	// Field 3 is unknown to acme.v1.Address, so it cannot be checked for the redact option.
+	require.NoError(t, protoencoding.NewWireUnmarshaler(resolver).Unmarshal([]byte("\x12\x01a\x1a\x01b"), message))
+	require.NoError(t, fieldFilter.Filter(message))
+	data, err := protoencoding.NewWireMarshaler().Marshal(message)
+	require.NoError(t, err)
+	assert.Equal(t, "\x12\x01a", string(data))
+}
+
+func TestFieldFilterUnresolvableAny(t *testing.T) {
+	t.Parallel()
+	resolver := newFieldFilterTestResolver(t)
+	messageType, err := resolver.FindMessageByName("acme.v1.User")
+	require.NoError(t, err)
+	fieldFilter, err := NewFieldFilter(resolver, messageType.Descriptor(), FieldFilterWithRedactOption("(acme.v1.sensitive)"))
+	require.NoError(t, err)
+	message := messageType.New().Interface()
+	// attachments holds a google.protobuf.Any with the type URL "type.googleapis.com/acme.v1.Unknown".
+	require.NoError(t, protoencoding.NewWireUnmarshaler(resolver).Unmarshal(
+		[]byte("\x42\x25\x0a\x23type.googleapis.com/acme.v1.Unknown"),
+		message,
+	))
+	err = fieldFilter.Filter(message)
+	assert.ErrorContains(t, err, `cannot filter "google.protobuf.Any" with type URL "type.googleapis.com/acme.v1.Unknown"`)
+}
+
+func TestFieldFilterError(t *testing.T) {
+	t.Parallel()
+	testFieldFilterError(
+		t,
+		FieldFilterWithFieldMask("address.zip"),
+		`invalid field path "address.zip": message "acme.v1.Address" has no field "zip"`,
+	)
+	testFieldFilterError(
+		t,
+		FieldFilterWithExcludeFields("address.city.name"),
+		`invalid field path "address.city.name": "address.city" is not a message field`,
+	)
+	testFieldFilterError(
+		t,
+		FieldFilterWithRedactOption("(acme.v1.unknown)"),
+		`invalid redact option "(acme.v1.unknown)": no field option or extension named "acme.v1.unknown"`,
+	)
+}
+
+func testFieldFilter(
+	t *testing.T,
+	options []FieldFilterOption,
+	expectedJSON string,
+) {
+	resolver := newFieldFilterTestResolver(t)
+	messageType, err := resolver.FindMessageByName("acme.v1.User")
+	require.NoError(t, err)
+	fieldFilter, err := NewFieldFilter(resolver, messageType.Descriptor(), options...)
+	require.NoError(t, err)
+	message := messageType.New().Interface()
+	require.NoError(t, protoencoding.NewJSONUnmarshaler(resolver).Unmarshal([]byte(fieldFilterTestUser), message))
+	require.NoError(t, fieldFilter.Filter(message))
+	data, err := protoencoding.NewJSONMarshaler(resolver).Marshal(message)
+	require.NoError(t, err)
+	assert.JSONEq(t, expectedJSON, string(data))
+}
+
+func testFieldFilterError(
+	t *testing.T,
+	option FieldFilterOption,
+	expectedErrString string,
+) {
+	resolver := newFieldFilterTestResolver(t)
+	messageType, err := resolver.FindMessageByName("acme.v1.User")
+	require.NoError(t, err)
+	_, err = NewFieldFilter(resolver, messageType.Descriptor(), option)
+	assert.EqualError(t, err, expectedErrString)
+}
+
+func newFieldFilterTestResolver(t *testing.T) protoencoding.Resolver {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"acme.proto": fieldFilterTestProto,
+			}),
+		}),
+	}).Compile(context.Background(), "acme.proto")
+	require.NoError(t, err)
+	resolver, err := protoencoding.NewResolver(
+		protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+		protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+		protodesc.ToFileDescriptorProto(files[0]),
+	)
+	require.NoError(t, err)
+	return resolver
+}
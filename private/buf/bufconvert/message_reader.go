@@ -0,0 +1,300 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/syserror"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// maxMessageSize is the maximum size of a delimited message, which is the
+	// maximum size of a protobuf message.
+	maxMessageSize = math.MaxInt32
+	// envelopeHeaderSize is the size of the envelope of gRPC and Connect.
+	envelopeHeaderSize = 5
+	// envelopeFlagCompressed is the flag of the envelope for compressed messages.
+	envelopeFlagCompressed = 0b00000001
+	// envelopeFlagEndStream is the flag of the envelope for the end-of-stream
+	// message of Connect.
+	envelopeFlagEndStream = 0b00000010
+)
+
+func newMessageReader(
+	readCloser io.ReadCloser,
+	messageType protoreflect.MessageType,
+	unmarshaler protoencoding.Unmarshaler,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (*messageReader, error) {
+	messageDataReader, err := newMessageDataReader(readCloser, messageEncoding, messageDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	return &messageReader{
+		messageDataReader: messageDataReader,
+		messageType:       messageType,
+		unmarshaler:       unmarshaler,
+	}, nil
+}
+
+// messageReader unmarshals the data read by a messageDataReader.
+type messageReader struct {
+	messageDataReader *messageDataReader
+	messageType       protoreflect.MessageType
+	unmarshaler       protoencoding.Unmarshaler
+}
+
+func (r *messageReader) Read() (proto.Message, error) {
+	data, err := r.messageDataReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	message := r.messageType.New().Interface()
+	if err := r.unmarshaler.Unmarshal(data, message); err != nil {
+		r.messageDataReader.done = true
+		return nil, r.messageDataReader.wrapError(r.messageDataReader.index-1, err)
+	}
+	return message, nil
+}
+
+func (r *messageReader) Close() error {
+	return r.messageDataReader.Close()
+}
+
+// messageDataReader reads the data of messages.
+//
+// If the reader holds a stream of delimited messages, only a single message is held
+// in memory at a time.
+type messageDataReader struct {
+	readCloser io.ReadCloser
+	reader     *bufio.Reader
+	// readData is nil if the reader holds a single message.
+	readData func(*bufio.Reader, *bytes.Buffer) error
+	buffer   *bytes.Buffer
+	// index is the index of the next message.
+	index int
+	done  bool
+}
+
+func newMessageDataReader(
+	readCloser io.ReadCloser,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (*messageDataReader, error) {
+	messageDataReader := &messageDataReader{
+		readCloser: readCloser,
+	}
+	if messageDelimiter == 0 {
+		return messageDataReader, nil
+	}
+	readData, err := getReadDataFunc(messageEncoding, messageDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	messageDataReader.reader = bufio.NewReader(readCloser)
+	messageDataReader.readData = readData
+	messageDataReader.buffer = bytes.NewBuffer(nil)
+	return messageDataReader, nil
+}
+
+func (r *messageDataReader) Read() ([]byte, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if r.readData == nil {
+		r.done = true
+		r.index++
+		return io.ReadAll(r.readCloser)
+	}
+	r.buffer.Reset()
+	if err := r.readData(r.reader, r.buffer); err != nil {
+		r.done = true
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, r.wrapError(r.index, err)
+	}
+	r.index++
+	return r.buffer.Bytes(), nil
+}
+
+func (r *messageDataReader) Close() error {
+	return r.readCloser.Close()
+}
+
+// wrapError prefixes the error with the index of the message if the reader holds a
+// stream of delimited messages.
+func (r *messageDataReader) wrapError(index int, err error) error {
+	if r.readData == nil {
+		return err
+	}
+	return fmt.Errorf("message %d: %w", index, err)
+}
+
+// getReadDataFunc returns the function that reads the data of the next message into
+// the buffer, and returns io.EOF if there are no more messages.
+func getReadDataFunc(
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (func(*bufio.Reader, *bytes.Buffer) error, error) {
+	switch messageDelimiter {
+	case buffetch.MessageDelimiterVarint:
+		if messageEncoding == buffetch.MessageEncodingBinpb {
+			return readVarintDelimitedData, nil
+		}
+	case buffetch.MessageDelimiterEnvelope:
+		if messageEncoding == buffetch.MessageEncodingBinpb {
+			return readEnvelopeDelimitedData, nil
+		}
+	case buffetch.MessageDelimiterNewline:
+		switch messageEncoding {
+		case buffetch.MessageEncodingJSON:
+			return readNewlineDelimitedData, nil
+		case buffetch.MessageEncodingYAML:
+			return readYAMLDocumentData, nil
+		}
+	}
+	// This is a system error, as the MessageRef validates the delimiter.
+	return nil, syserror.Newf("unsupported MessageDelimiter %v for MessageEncoding %v", messageDelimiter, messageEncoding)
+}
+
+func readVarintDelimitedData(reader *bufio.Reader, buffer *bytes.Buffer) error {
+	size, err := binary.ReadUvarint(reader)
+	if err != nil {
+		// io.EOF if there are no more bytes, io.ErrUnexpectedEOF if the varint is truncated.
+		return err
+	}
+	return readSizedData(reader, buffer, size)
+}
+
+func readEnvelopeDelimitedData(reader *bufio.Reader, buffer *bytes.Buffer) error {
+	var header [envelopeHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		// io.EOF if there are no more bytes, io.ErrUnexpectedEOF if the header is truncated.
+		return err
+	}
+	flags := header[0]
+	if flags&envelopeFlagCompressed != 0 {
+		return errors.New("compressed messages are not supported")
+	}
+	if err := readSizedData(reader, buffer, uint64(binary.BigEndian.Uint32(header[1:]))); err != nil {
+		return err
+	}
+	if flags&envelopeFlagEndStream != 0 {
+		// The end-of-stream message holds the trailers of the stream, not a message.
+		return io.EOF
+	}
+	return nil
+}
+
+func readSizedData(reader *bufio.Reader, buffer *bytes.Buffer, size uint64) error {
+	if size > maxMessageSize {
+		return fmt.Errorf("message size %d exceeds the maximum of %d bytes", size, maxMessageSize)
+	}
+	// CopyN only grows the buffer as data is read, so a corrupt size does not
+	// result in a large allocation.
+	if _, err := io.CopyN(buffer, reader, int64(size)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// readNewlineDelimitedData reads the next line that is not blank.
+func readNewlineDelimitedData(reader *bufio.Reader, buffer *bytes.Buffer) error {
+	for {
+		err := readLine(reader, buffer)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(bytes.TrimSpace(buffer.Bytes())) > 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		buffer.Reset()
+	}
+}
+
+// readYAMLDocumentData reads the next YAML document that is not blank.
+//
+// Documents are separated by lines with the document markers "---" or "...".
+func readYAMLDocumentData(reader *bufio.Reader, buffer *bytes.Buffer) error {
+	for {
+		start := buffer.Len()
+		err := readLine(reader, buffer)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if isYAMLDocumentMarker(buffer.Bytes()[start:]) {
+			buffer.Truncate(start)
+			if !isBlankYAML(buffer.Bytes()) {
+				return nil
+			}
+			buffer.Reset()
+		}
+		if err != nil {
+			if !isBlankYAML(buffer.Bytes()) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// readLine reads the next line, including the newline, into the buffer.
+//
+// Returns io.EOF if the end of the reader was reached, in which case the buffer holds
+// the remainder of the reader, if any.
+func readLine(reader *bufio.Reader, buffer *bytes.Buffer) error {
+	for {
+		line, err := reader.ReadSlice('\n')
+		buffer.Write(line)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
+
+func isYAMLDocumentMarker(line []byte) bool {
+	line = bytes.TrimRight(line, " \t\r\n")
+	return bytes.Equal(line, []byte("---")) || bytes.Equal(line, []byte("..."))
+}
+
+// isBlankYAML returns true if the data only has blank lines and comments.
+func isBlankYAML(data []byte) bool {
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestMessageReaderWriterRoundTrip(t *testing.T) {
+	t.Parallel()
+	resolver, err := protoencoding.NewResolver(
+		protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+	)
+	require.NoError(t, err)
+	messages := []proto.Message{
+		&descriptorpb.FieldDescriptorProto{
+			Name:   proto.String("one"),
+			Number: proto.Int32(1),
+		},
+		&descriptorpb.FieldDescriptorProto{},
+		&descriptorpb.FieldDescriptorProto{
+			Name:     proto.String("two"),
+			JsonName: proto.String("line\nbreak"),
+		},
+	}
+	testCases := []struct {
+		name             string
+		messageEncoding  buffetch.MessageEncoding
+		messageDelimiter buffetch.MessageDelimiter
+		marshaler        protoencoding.Marshaler
+		unmarshaler      protoencoding.Unmarshaler
+	}{
+		{
+			name:             "varint",
+			messageEncoding:  buffetch.MessageEncodingBinpb,
+			messageDelimiter: buffetch.MessageDelimiterVarint,
+			marshaler:        protoencoding.NewWireMarshaler(),
+			unmarshaler:      protoencoding.NewWireUnmarshaler(resolver),
+		},
+		{
+			name:             "envelope",
+			messageEncoding:  buffetch.MessageEncodingBinpb,
+			messageDelimiter: buffetch.MessageDelimiterEnvelope,
+			marshaler:        protoencoding.NewWireMarshaler(),
+			unmarshaler:      protoencoding.NewWireUnmarshaler(resolver),
+		},
+		{
+			name:             "json",
+			messageEncoding:  buffetch.MessageEncodingJSON,
+			messageDelimiter: buffetch.MessageDelimiterNewline,
+			marshaler:        protoencoding.NewJSONMarshaler(resolver),
+			unmarshaler:      protoencoding.NewJSONUnmarshaler(resolver),
+		},
+		{
+			name:             "yaml",
+			messageEncoding:  buffetch.MessageEncodingYAML,
+			messageDelimiter: buffetch.MessageDelimiterNewline,
+			marshaler:        protoencoding.NewYAMLMarshaler(resolver, protoencoding.YAMLMarshalerWithIndent()),
+			unmarshaler:      protoencoding.NewYAMLUnmarshaler(resolver),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			buffer := bytes.NewBuffer(nil)
+			messageWriter, err := NewMessageWriter(
+				nopWriteCloser{buffer},
+				testCase.marshaler,
+				testCase.messageEncoding,
+				testCase.messageDelimiter,
+			)
+			require.NoError(t, err)
+			for _, message := range messages {
+				require.NoError(t, messageWriter.Write(message))
+			}
+			require.NoError(t, messageWriter.Close())
+			messageReader, err := NewMessageReader(
+				io.NopCloser(buffer),
+				(&descriptorpb.FieldDescriptorProto{}).ProtoReflect().Type(),
+				testCase.unmarshaler,
+				testCase.messageEncoding,
+				testCase.messageDelimiter,
+			)
+			require.NoError(t, err)
+			for _, expectedMessage := range messages {
+				message, err := messageReader.Read()
+				require.NoError(t, err)
+				assert.Empty(t, cmp.Diff(expectedMessage, message, protocmp.Transform()))
+			}
+			_, err = messageReader.Read()
+			assert.ErrorIs(t, err, io.EOF)
+			require.NoError(t, messageReader.Close())
+		})
+	}
+}
+
+func TestMessageReaderDelimited(t *testing.T) {
+	t.Parallel()
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingBinpb,
+		buffetch.MessageDelimiterVarint,
+		"\x03\x0a\x01a\x00\x03\x0a\x01b",
+		[]string{"a", "", "b"},
+		"",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingBinpb,
+		buffetch.MessageDelimiterVarint,
+		"\x03\x0a\x01a\x05\x0a\x03",
+		[]string{"a"},
+		"message 1: unexpected EOF",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingBinpb,
+		buffetch.MessageDelimiterEnvelope,
+		"\x00\x00\x00\x00\x03\x0a\x01a\x00\x00\x00\x00\x03\x0a\x01b",
+		[]string{"a", "b"},
+		"",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingBinpb,
+		buffetch.MessageDelimiterEnvelope,
+		// The end-of-stream message of Connect ends the stream.
+		"\x00\x00\x00\x00\x03\x0a\x01a\x02\x00\x00\x00\x02{}\x00\x00\x00\x00\x03\x0a\x01b",
+		[]string{"a"},
+		"",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingBinpb,
+		buffetch.MessageDelimiterEnvelope,
+		"\x00\x00\x00\x00\x03\x0a\x01a\x01\x00\x00\x00\x03\x0a\x01b",
+		[]string{"a"},
+		"message 1: compressed messages are not supported",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingJSON,
+		buffetch.MessageDelimiterNewline,
+		"{\"name\":\"a\"}\n\n  \n{}\r\n{\"name\":\"b\"}",
+		[]string{"a", "", "b"},
+		"",
+	)
+	testMessageReaderDelimited(
+		t,
+		buffetch.MessageEncodingYAML,
+		buffetch.MessageDelimiterNewline,
+		"# A comment.\n---\nname: a\n---\n---\nname: b\n...\n",
+		[]string{"a", "b"},
+		"",
+	)
+}
+
+func TestMessageWriterSingle(t *testing.T) {
+	t.Parallel()
+	buffer := bytes.NewBuffer(nil)
+	messageWriter, err := NewMessageWriter(
+		nopWriteCloser{buffer},
+		protoencoding.NewWireMarshaler(),
+		buffetch.MessageEncodingBinpb,
+		0,
+	)
+	require.NoError(t, err)
+	require.NoError(t, messageWriter.Write(&descriptorpb.FieldDescriptorProto{Name: proto.String("a")}))
+	require.Error(t, messageWriter.Write(&descriptorpb.FieldDescriptorProto{Name: proto.String("b")}))
+	require.NoError(t, messageWriter.Close())
+	assert.Equal(t, "\x0a\x01a", buffer.String())
+}
+
+func testMessageReaderDelimited(
+	t *testing.T,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+	data string,
+	expectedNames []string,
+	expectedErrString string,
+) {
+	resolver, err := protoencoding.NewResolver(
+		protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto),
+	)
+	require.NoError(t, err)
+	var unmarshaler protoencoding.Unmarshaler
+	switch messageEncoding {
+	case buffetch.MessageEncodingBinpb:
+		unmarshaler = protoencoding.NewWireUnmarshaler(resolver)
+	case buffetch.MessageEncodingJSON:
+		unmarshaler = protoencoding.NewJSONUnmarshaler(resolver)
+	case buffetch.MessageEncodingYAML:
+		unmarshaler = protoencoding.NewYAMLUnmarshaler(resolver)
+	}
+	messageReader, err := NewMessageReader(
+		io.NopCloser(strings.NewReader(data)),
+		(&descriptorpb.FieldDescriptorProto{}).ProtoReflect().Type(),
+		unmarshaler,
+		messageEncoding,
+		messageDelimiter,
+	)
+	require.NoError(t, err)
+	var names []string
+	for {
+		message, err := messageReader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				assert.Empty(t, expectedErrString)
+			} else {
+				assert.EqualError(t, err, expectedErrString)
+			}
+			break
+		}
+		fieldDescriptorProto, ok := message.(*descriptorpb.FieldDescriptorProto)
+		require.True(t, ok)
+		names = append(names, fieldDescriptorProto.GetName())
+	}
+	assert.Equal(t, expectedNames, names)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
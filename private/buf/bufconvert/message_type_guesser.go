@@ -0,0 +1,175 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type messageTypeGuesser struct {
+	unmarshaler protoencoding.Unmarshaler
+	candidates  []*messageTypeCandidate
+	dataAdded   bool
+}
+
+func newMessageTypeGuesser(image bufimage.Image) (*messageTypeGuesser, error) {
+	resolver := image.Resolver()
+	var candidates []*messageTypeCandidate
+	for _, imageFile := range image.Files() {
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		rangeMessageDescriptors(fileDescriptor.Messages(), func(messageDescriptor protoreflect.MessageDescriptor) {
+			if messageDescriptor.IsMapEntry() {
+				return
+			}
+			// Message types that cannot be resolved, such as those that use the message-set
+			// wire format, cannot be unmarshalled.
+			messageType, err := resolver.FindMessageByName(messageDescriptor.FullName())
+			if err != nil {
+				return
+			}
+			candidates = append(candidates, &messageTypeCandidate{
+				messageType: messageType,
+			})
+		})
+	}
+	return &messageTypeGuesser{
+		unmarshaler: protoencoding.NewWireUnmarshaler(resolver),
+		candidates:  candidates,
+	}, nil
+}
+
+func (g *messageTypeGuesser) Add(data []byte) {
+	if len(data) > 0 {
+		g.dataAdded = true
+	}
+	for _, candidate := range g.candidates {
+		if candidate.failed {
+			continue
+		}
+		message := candidate.messageType.New()
+		if err := g.unmarshaler.Unmarshal(data, message.Interface()); err != nil {
+			candidate.failed = true
+			continue
+		}
+		candidate.unrecognizedBytes += countUnrecognized(message)
+		candidate.recognizedFields += countRecognized(message)
+	}
+}
+
+func (g *messageTypeGuesser) Matches() []MessageTypeMatch {
+	var candidates []*messageTypeCandidate
+	for _, candidate := range g.candidates {
+		if candidate.failed || (g.dataAdded && candidate.recognizedFields == 0) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	slices.SortFunc(candidates, func(one *messageTypeCandidate, two *messageTypeCandidate) int {
+		if compare := cmp.Compare(one.unrecognizedBytes, two.unrecognizedBytes); compare != 0 {
+			return compare
+		}
+		if compare := cmp.Compare(two.recognizedFields, one.recognizedFields); compare != 0 {
+			return compare
+		}
+		return cmp.Compare(one.TypeName(), two.TypeName())
+	})
+	matches := make([]MessageTypeMatch, len(candidates))
+	for i, candidate := range candidates {
+		matches[i] = candidate
+	}
+	return matches
+}
+
+// messageTypeCandidate is a message type that is scored against the data, and is
+// a MessageTypeMatch if it matches.
+type messageTypeCandidate struct {
+	messageType       protoreflect.MessageType
+	unrecognizedBytes int
+	recognizedFields  int
+	// failed is true if the message type failed to unmarshal any of the data.
+	failed bool
+}
+
+func (c *messageTypeCandidate) TypeName() string {
+	return string(c.messageType.Descriptor().FullName())
+}
+
+func (c *messageTypeCandidate) UnrecognizedBytes() int {
+	return c.unrecognizedBytes
+}
+
+func (c *messageTypeCandidate) RecognizedFields() int {
+	return c.recognizedFields
+}
+
+func (*messageTypeCandidate) isMessageTypeMatch() {}
+
+// rangeMessageDescriptors calls f for every message descriptor, including nested
+// message descriptors.
+func rangeMessageDescriptors(
+	messageDescriptors protoreflect.MessageDescriptors,
+	f func(protoreflect.MessageDescriptor),
+) {
+	for i := range messageDescriptors.Len() {
+		messageDescriptor := messageDescriptors.Get(i)
+		f(messageDescriptor)
+		rangeMessageDescriptors(messageDescriptor.Messages(), f)
+	}
+}
+
+// countUnrecognized returns the number of bytes of unknown fields of the message,
+// including within nested messages.
+//
+// This matches countUnrecognized in bufcurl. Unknown fields of map entries are discarded
+// by the runtime, so only unknown fields within the message values of maps are counted.
+func countUnrecognized(message protoreflect.Message) int {
+	count := len(message.GetUnknown())
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		rangeFieldMessages(field, value, func(fieldMessage protoreflect.Message) {
+			count += countUnrecognized(fieldMessage)
+		})
+		return true
+	})
+	return count
+}
+
+// countRecognized returns the number of known fields set on the message, including
+// within nested messages. Every element of repeated and map fields is counted.
+func countRecognized(message protoreflect.Message) int {
+	var count int
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsMap():
+			count += value.Map().Len()
+		case field.IsList():
+			count += value.List().Len()
+		default:
+			count++
+		}
+		rangeFieldMessages(field, value, func(fieldMessage protoreflect.Message) {
+			count += countRecognized(fieldMessage)
+		})
+		return true
+	})
+	return count
+}
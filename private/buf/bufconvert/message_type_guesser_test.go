@@ -0,0 +1,144 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/protocompile"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+)
+
+const messageTypeGuesserTestProto = `syntax = "proto3";
+
+package acme.v1;
+
+message User {
+  string id = 1;
+  map<string, Address> addresses = 2;
+}
+
+message Address {
+  string street = 1;
+  string city = 2;
+}
+
+message Event {
+  int64 id = 1;
+  bytes data = 2;
+}
+`
+
+func TestMessageTypeGuesser(t *testing.T) {
+	t.Parallel()
+	testMessageTypeGuesser(
+		t,
+		[]string{
+			// User{id: "1", addresses: {"home": {city: "Oslo"}}}
+			"\x0a\x011\x12\x0e\x0a\x04home\x12\x06\x12\x04Oslo",
+		},
+		[]string{
+			"acme.v1.User 0 3",
+			"acme.v1.Address 0 2",
+			"acme.v1.Event 3 1",
+		},
+	)
+	testMessageTypeGuesser(
+		t,
+		[]string{
+			// Field 1 is not valid UTF-8, so User and Address fail to unmarshal, and do
+			// not match even though they match the next message.
+			"\x0a\x02\xff\xfe",
+			"\x12\x01a",
+		},
+		[]string{
+			"acme.v1.Event 4 1",
+		},
+	)
+	testMessageTypeGuesser(
+		t,
+		[]string{
+			"\x18\x01",
+		},
+		nil,
+	)
+	testMessageTypeGuesser(
+		t,
+		[]string{
+			"",
+		},
+		[]string{
+			"acme.v1.Address 0 0",
+			"acme.v1.Event 0 0",
+			"acme.v1.User 0 0",
+		},
+	)
+}
+
+func testMessageTypeGuesser(
+	t *testing.T,
+	datas []string,
+	expectedMatches []string,
+) {
+	messageTypeGuesser, err := NewMessageTypeGuesser(newMessageTypeGuesserTestImage(t))
+	require.NoError(t, err)
+	for _, data := range datas {
+		messageTypeGuesser.Add([]byte(data))
+	}
+	var matches []string
+	for _, messageTypeMatch := range messageTypeGuesser.Matches() {
+		matches = append(
+			matches,
+			fmt.Sprintf(
+				"%s %d %d",
+				messageTypeMatch.TypeName(),
+				messageTypeMatch.UnrecognizedBytes(),
+				messageTypeMatch.RecognizedFields(),
+			),
+		)
+	}
+	assert.Equal(t, expectedMatches, matches)
+}
+
+func newMessageTypeGuesserTestImage(t *testing.T) bufimage.Image {
+	files, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"acme.proto": messageTypeGuesserTestProto,
+			}),
+		},
+	}).Compile(context.Background(), "acme.proto")
+	require.NoError(t, err)
+	imageFile, err := bufimage.NewImageFile(
+		protodesc.ToFileDescriptorProto(files[0]),
+		nil,
+		uuid.UUID{},
+		"acme.proto",
+		"acme.proto",
+		false,
+		false,
+		nil,
+	)
+	require.NoError(t, err)
+	image, err := bufimage.NewImage([]bufimage.ImageFile{imageFile})
+	require.NoError(t, err)
+	return image
+}
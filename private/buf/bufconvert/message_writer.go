@@ -0,0 +1,173 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/syserror"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func newMessageWriter(
+	writeCloser io.WriteCloser,
+	marshaler protoencoding.Marshaler,
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (MessageWriter, error) {
+	if messageDelimiter == 0 {
+		return newSingleMessageWriter(writeCloser, marshaler), nil
+	}
+	delimitData, err := getDelimitDataFunc(messageEncoding, messageDelimiter)
+	if err != nil {
+		return nil, err
+	}
+	return newDelimitedMessageWriter(writeCloser, marshaler, delimitData), nil
+}
+
+// singleMessageWriter writes a single message.
+type singleMessageWriter struct {
+	writeCloser io.WriteCloser
+	marshaler   protoencoding.Marshaler
+	written     bool
+}
+
+func newSingleMessageWriter(
+	writeCloser io.WriteCloser,
+	marshaler protoencoding.Marshaler,
+) *singleMessageWriter {
+	return &singleMessageWriter{
+		writeCloser: writeCloser,
+		marshaler:   marshaler,
+	}
+}
+
+func (w *singleMessageWriter) Write(message proto.Message) error {
+	if w.written {
+		return errors.New("cannot write more than one message without a delimiter")
+	}
+	w.written = true
+	data, err := w.marshaler.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = w.writeCloser.Write(data)
+	return err
+}
+
+func (w *singleMessageWriter) Close() error {
+	return w.writeCloser.Close()
+}
+
+// delimitedMessageWriter writes a stream of delimited messages.
+type delimitedMessageWriter struct {
+	writeCloser io.WriteCloser
+	marshaler   protoencoding.Marshaler
+	delimitData func(data []byte, index int) ([]byte, error)
+	index       int
+}
+
+func newDelimitedMessageWriter(
+	writeCloser io.WriteCloser,
+	marshaler protoencoding.Marshaler,
+	delimitData func(data []byte, index int) ([]byte, error),
+) *delimitedMessageWriter {
+	return &delimitedMessageWriter{
+		writeCloser: writeCloser,
+		marshaler:   marshaler,
+		delimitData: delimitData,
+	}
+}
+
+func (w *delimitedMessageWriter) Write(message proto.Message) error {
+	data, err := w.marshaler.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("message %d: %w", w.index, err)
+	}
+	data, err = w.delimitData(data, w.index)
+	if err != nil {
+		return fmt.Errorf("message %d: %w", w.index, err)
+	}
+	if _, err := w.writeCloser.Write(data); err != nil {
+		return err
+	}
+	w.index++
+	return nil
+}
+
+func (w *delimitedMessageWriter) Close() error {
+	return w.writeCloser.Close()
+}
+
+// getDelimitDataFunc returns the function that returns the data of the message at
+// the given index within the stream, with its delimiter.
+func getDelimitDataFunc(
+	messageEncoding buffetch.MessageEncoding,
+	messageDelimiter buffetch.MessageDelimiter,
+) (func([]byte, int) ([]byte, error), error) {
+	switch messageDelimiter {
+	case buffetch.MessageDelimiterVarint:
+		if messageEncoding == buffetch.MessageEncodingBinpb {
+			return delimitDataVarint, nil
+		}
+	case buffetch.MessageDelimiterEnvelope:
+		if messageEncoding == buffetch.MessageEncodingBinpb {
+			return delimitDataEnvelope, nil
+		}
+	case buffetch.MessageDelimiterNewline:
+		switch messageEncoding {
+		case buffetch.MessageEncodingJSON:
+			return delimitDataNewline, nil
+		case buffetch.MessageEncodingYAML:
+			return delimitDataYAMLDocument, nil
+		}
+	}
+	// This is a system error, as the MessageRef validates the delimiter.
+	return nil, syserror.Newf("unsupported MessageDelimiter %v for MessageEncoding %v", messageDelimiter, messageEncoding)
+}
+
+func delimitDataVarint(data []byte, _ int) ([]byte, error) {
+	delimitedData := protowire.AppendVarint(make([]byte, 0, protowire.SizeVarint(uint64(len(data)))+len(data)), uint64(len(data)))
+	return append(delimitedData, data...), nil
+}
+
+func delimitDataEnvelope(data []byte, _ int) ([]byte, error) {
+	if len(data) > maxMessageSize {
+		return nil, fmt.Errorf("message size %d exceeds the maximum of %d bytes", len(data), maxMessageSize)
+	}
+	delimitedData := make([]byte, envelopeHeaderSize, envelopeHeaderSize+len(data))
+	binary.BigEndian.PutUint32(delimitedData[1:], uint32(len(data)))
+	return append(delimitedData, data...), nil
+}
+
+func delimitDataNewline(data []byte, _ int) ([]byte, error) {
+	return append(data, '\n'), nil
+}
+
+func delimitDataYAMLDocument(data []byte, index int) ([]byte, error) {
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	if index == 0 {
+		return data, nil
+	}
+	return append([]byte("---\n"), data...), nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxRawMessageDepth is the maximum depth of nested groups and messages when parsing
+// raw messages, which matches the default recursion limit of protoc.
+const maxRawMessageDepth = 100
+
+// rawField is a field of a raw message.
+type rawField struct {
+	number   protowire.Number
+	wireType protowire.Type
+	// value is the value of varint, i32, and i64 fields.
+	value uint64
+	// data is the value of len fields.
+	data []byte
+	// fields are the fields of groups, and of len fields that are nested messages.
+	fields []*rawField
+	// isString is true for len fields that are printable strings.
+	isString bool
+}
+
+// rawParseError is an error for invalid wire format.
+type rawParseError struct {
+	offset int
+	err    error
+}
+
+func (e *rawParseError) Error() string {
+	return fmt.Sprintf("invalid wire format at offset %d: %v", e.offset, e.err)
+}
+
+func (e *rawParseError) Unwrap() error {
+	return e.err
+}
+
+func writeRawMessage(writer io.Writer, data []byte) error {
+	fields, _, parseErr := parseRawFields(data, 0, 0, 0)
+	bufferedWriter := bufio.NewWriter(writer)
+	writeRawFields(bufferedWriter, fields, 0)
+	if err := bufferedWriter.Flush(); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// parseRawFields parses the fields of the data, where offset is the offset of the data
+// within the message, for errors.
+//
+// If groupNumber is non-zero, the fields of the group with the number are parsed, up to
+// and including the end-group tag.
+//
+// Returns the fields parsed before any error and the number of bytes consumed.
+func parseRawFields(
+	data []byte,
+	offset int,
+	depth int,
+	groupNumber protowire.Number,
+) ([]*rawField, int, error) {
+	if depth > maxRawMessageDepth {
+		return nil, 0, &rawParseError{offset: offset, err: errors.New("exceeded maximum nesting depth")}
+	}
+	var fields []*rawField
+	var n int
+	for n < len(data) {
+		number, wireType, tagLen := protowire.ConsumeTag(data[n:])
+		if tagLen < 0 {
+			return fields, n, &rawParseError{offset: offset + n, err: protowire.ParseError(tagLen)}
+		}
+		field := &rawField{
+			number:   number,
+			wireType: wireType,
+		}
+		var valueLen int
+		switch wireType {
+		case protowire.VarintType:
+			field.value, valueLen = protowire.ConsumeVarint(data[n+tagLen:])
+		case protowire.Fixed32Type:
+			var value uint32
+			value, valueLen = protowire.ConsumeFixed32(data[n+tagLen:])
+			field.value = uint64(value)
+		case protowire.Fixed64Type:
+			field.value, valueLen = protowire.ConsumeFixed64(data[n+tagLen:])
+		case protowire.BytesType:
+			field.data, valueLen = protowire.ConsumeBytes(data[n+tagLen:])
+			if valueLen >= 0 {
+				field.fields, field.isString = classifyRawData(field.data, depth)
+			}
+		case protowire.StartGroupType:
+			var err error
+			field.fields, valueLen, err = parseRawFields(data[n+tagLen:], offset+n+tagLen, depth+1, number)
+			if err != nil {
+				// Keep the fields of the group that were parsed before the error.
+				return append(fields, field), n, err
+			}
+		case protowire.EndGroupType:
+			if number != groupNumber {
+				return fields, n, &rawParseError{offset: offset + n, err: fmt.Errorf("unexpected end of group %d", number)}
+			}
+			return fields, n + tagLen, nil
+		default:
+			return fields, n, &rawParseError{offset: offset + n, err: fmt.Errorf("invalid wire type %d", wireType)}
+		}
+		if valueLen < 0 {
+			return fields, n, &rawParseError{offset: offset + n + tagLen, err: protowire.ParseError(valueLen)}
+		}
+		fields = append(fields, field)
+		n += tagLen + valueLen
+	}
+	if groupNumber != 0 {
+		return fields, n, &rawParseError{offset: offset + n, err: fmt.Errorf("missing end of group %d", groupNumber)}
+	}
+	return fields, n, nil
+}
+
+// classifyRawData returns the fields of the data of a len field if it is a nested
+// message, or true if it is a string.
+//
+// Printable strings are preferred over nested messages, as short strings often happen
+// to parse as messages. Strings with whitespace such as newlines are only strings if
+// they do not parse as messages, as the tags of many fields are such characters.
+func classifyRawData(data []byte, depth int) ([]*rawField, bool) {
+	if isRawString(data, unicode.IsPrint) {
+		return nil, true
+	}
+	if fields, _, err := parseRawFields(data, 0, depth+1, 0); err == nil {
+		return fields, false
+	}
+	return nil, isRawString(data, func(r rune) bool {
+		return unicode.IsPrint(r) || unicode.IsSpace(r)
+	})
+}
+
+func isRawString(data []byte, isStringRune func(rune) bool) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !isStringRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeRawFields(writer *bufio.Writer, fields []*rawField, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, field := range fields {
+		_, _ = writer.WriteString(indent)
+		_, _ = writer.WriteString(strconv.FormatInt(int64(field.number), 10))
+		_, _ = writer.WriteString(" (")
+		_, _ = writer.WriteString(rawWireTypeString(field.wireType))
+		_, _ = writer.WriteString(")")
+		switch {
+		case field.wireType == protowire.StartGroupType || (field.wireType == protowire.BytesType && field.fields != nil):
+			_, _ = writer.WriteString(" {\n")
+			writeRawFields(writer, field.fields, depth+1)
+			_, _ = writer.WriteString(indent)
+			_, _ = writer.WriteString("}\n")
+			continue
+		case field.wireType == protowire.VarintType:
+			_, _ = fmt.Fprintf(writer, ": %d", field.value)
+			if int64(field.value) < 0 {
+				_, _ = fmt.Fprintf(writer, " (int64: %d)", int64(field.value))
+			}
+		case field.wireType == protowire.Fixed32Type:
+			_, _ = fmt.Fprintf(
+				writer,
+				": 0x%08x (float: %s, int32: %d)",
+				field.value,
+				strconv.FormatFloat(float64(math.Float32frombits(uint32(field.value))), 'g', -1, 32),
+				int32(field.value),
+			)
+		case field.wireType == protowire.Fixed64Type:
+			_, _ = fmt.Fprintf(
+				writer,
+				": 0x%016x (double: %s, int64: %d)",
+				field.value,
+				strconv.FormatFloat(math.Float64frombits(field.value), 'g', -1, 64),
+				int64(field.value),
+			)
+		case field.isString:
+			_, _ = writer.WriteString(": ")
+			_, _ = writer.WriteString(strconv.Quote(string(field.data)))
+		default:
+			_, _ = fmt.Fprintf(writer, ": %s (%d bytes)", hex.EncodeToString(field.data), len(field.data))
+		}
+		_, _ = writer.WriteString("\n")
+	}
+}
+
+func rawWireTypeString(wireType protowire.Type) string {
+	switch wireType {
+	case protowire.VarintType:
+		return "varint"
+	case protowire.Fixed32Type:
+		return "i32"
+	case protowire.Fixed64Type:
+		return "i64"
+	case protowire.BytesType:
+		return "len"
+	case protowire.StartGroupType:
+		return "group"
+	default:
+		return strconv.Itoa(int(wireType))
+	}
+}
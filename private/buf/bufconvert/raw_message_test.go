@@ -0,0 +1,110 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRawMessage(t *testing.T) {
+	t.Parallel()
+	testWriteRawMessage(
+		t,
+		"\x08\x96\x01\x10\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01",
+		`1 (varint): 150
+2 (varint): 18446744073709551615 (int64: -1)`,
+		"",
+	)
+	testWriteRawMessage(
+		t,
+		"\x0d\x00\x00\x80\x3f\x11\x00\x00\x00\x00\x00\x00\xf8\x3f",
+		`1 (i32): 0x3f800000 (float: 1, int32: 1065353216)
+2 (i64): 0x3ff8000000000000 (double: 1.5, int64: 4609434218613702656)`,
+		"",
+	)
+	testWriteRawMessage(
+		t,
+		// A printable string, a nested message, an empty value, a string with a newline
+		// that does not parse as a message, and bytes.
+		"\x0a\x05hello\x12\x05\x08\x01\x12\x01a\x1a\x00\x22\x03a\nb\x2a\x02\xff\xfe",
+		`1 (len): "hello"
+2 (len) {
+  1 (varint): 1
+  2 (len): "a"
+}
+3 (len): ""
+4 (len): "a\nb"
+5 (len): fffe (2 bytes)`,
+		"",
+	)
+	testWriteRawMessage(
+		t,
+		"\x0b\x08\x01\x13\x10\x02\x14\x0c",
+		`1 (group) {
+  1 (varint): 1
+  2 (group) {
+    2 (varint): 2
+  }
+}`,
+		"",
+	)
+	testWriteRawMessage(
+		t,
+		"\x08\x01\x12\x05ab",
+		`1 (varint): 1`,
+		"invalid wire format at offset 3: unexpected EOF",
+	)
+	testWriteRawMessage(
+		t,
+		"\x0b\x08\x01\x14",
+		`1 (group) {
+  1 (varint): 1
+}`,
+		"invalid wire format at offset 3: unexpected end of group 2",
+	)
+	testWriteRawMessage(
+		t,
+		"\x08\x01\x0f",
+		`1 (varint): 1`,
+		"invalid wire format at offset 2: invalid wire type 7",
+	)
+}
+
+func TestWriteRawMessageMaxDepth(t *testing.T) {
+	t.Parallel()
+	err := WriteRawMessage(io.Discard, []byte(strings.Repeat("\x0b", maxRawMessageDepth+2)))
+	assert.EqualError(t, err, "invalid wire format at offset 101: exceeded maximum nesting depth")
+}
+
+func testWriteRawMessage(
+	t *testing.T,
+	data string,
+	expectedOutput string,
+	expectedErrString string,
+) {
+	buffer := bytes.NewBuffer(nil)
+	err := WriteRawMessage(buffer, []byte(data))
+	if expectedErrString == "" {
+		assert.NoError(t, err)
+	} else {
+		assert.EqualError(t, err, expectedErrString)
+	}
+	assert.Equal(t, expectedOutput, strings.TrimSuffix(buffer.String(), "\n"))
+}
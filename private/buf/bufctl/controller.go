@@ -31,6 +31,7 @@ import (
 	"buf.build/go/protoyaml"
 	"buf.build/go/standard/xio"
 	"buf.build/go/standard/xslices"
+	"github.com/bufbuild/buf/private/buf/bufconvert"
 	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/buf/bufwkt/bufwktstore"
 	"github.com/bufbuild/buf/private/buf/bufworkspace"
@@ -143,6 +144,45 @@ type Controller interface {
 		defaultMessageEncoding buffetch.MessageEncoding,
 		options ...FunctionOption,
 	) error
+	// GetMessages returns a MessageReader for the messages of the message input.
+	//
+	// If the message input has a delimiter, the messages are read one at a time.
+	// Otherwise, the MessageReader reads the single message of the message input.
+	// If the message input is null, the MessageReader reads no messages.
+	//
+	// The returned MessageReader must be closed.
+	GetMessages(
+		ctx context.Context,
+		schemaImage bufimage.Image,
+		messageInput string,
+		typeName string,
+		defaultMessageEncoding buffetch.MessageEncoding,
+		options ...FunctionOption,
+	) (bufconvert.MessageReader, buffetch.MessageEncoding, error)
+	// GetMessageData returns a MessageDataReader for the data of the messages of the
+	// message input, without unmarshalling them.
+	//
+	// The delimiter of the message input is handled the same as for GetMessages.
+	//
+	// The returned MessageDataReader must be closed.
+	GetMessageData(
+		ctx context.Context,
+		messageInput string,
+		defaultMessageEncoding buffetch.MessageEncoding,
+		options ...FunctionOption,
+	) (bufconvert.MessageDataReader, buffetch.MessageEncoding, error)
+	// PutMessages returns a MessageWriter for the message output.
+	//
+	// If the message output does not have a delimiter, only a single message can be written.
+	//
+	// The returned MessageWriter must be closed.
+	PutMessages(
+		ctx context.Context,
+		schemaImage bufimage.Image,
+		messageOutput string,
+		defaultMessageEncoding buffetch.MessageEncoding,
+		options ...FunctionOption,
+	) (bufconvert.MessageWriter, error)
 	// GetCheckClientForWorkspace returns a new bufcheck Client for the given Workspace.
 	//
 	// Clients are bound to a specific Workspace to ensure that the correct
@@ -701,33 +741,9 @@ func (c *controller) GetMessage(
 	if messageRef.IsNull() {
 		return nil, messageEncoding, nil
 	}
-	var validator protoyaml.Validator
-	if functionOptions.messageValidation {
-		protovalidateValidator, err := protovalidate.New()
-		if err != nil {
-			return nil, 0, err
-		}
-		validator = yamlValidator{protovalidateValidator}
-	}
-	var unmarshaler protoencoding.Unmarshaler
-	switch messageEncoding {
-	case buffetch.MessageEncodingBinpb:
-		unmarshaler = protoencoding.NewWireUnmarshaler(schemaImage.Resolver())
-	case buffetch.MessageEncodingJSON:
-		unmarshaler = protoencoding.NewJSONUnmarshaler(schemaImage.Resolver())
-	case buffetch.MessageEncodingTxtpb:
-		unmarshaler = protoencoding.NewTxtpbUnmarshaler(schemaImage.Resolver())
-	case buffetch.MessageEncodingYAML:
-		unmarshaler = protoencoding.NewYAMLUnmarshaler(
-			schemaImage.Resolver(),
-			protoencoding.YAMLUnmarshalerWithPath(messageRef.Path()),
-			// This will pretty print validation errors.
-			protoencoding.YAMLUnmarshalerWithValidator(validator),
-		)
-		validator = nil // Validation errors are handled by the unmarshaler.
-	default:
-		// This is a system error.
-		return nil, 0, syserror.Newf("unknown MessageEncoding: %v", messageEncoding)
+	unmarshaler, err := newProtoencodingUnmarshaler(schemaImage, messageRef, functionOptions.messageValidation)
+	if err != nil {
+		return nil, 0, err
 	}
 	readCloser, err := c.buffetchReader.GetMessageFile(ctx, c.container, messageRef)
 	if err != nil {
@@ -744,11 +760,6 @@ func (c *controller) GetMessage(
 	if err := unmarshaler.Unmarshal(data, message); err != nil {
 		return nil, 0, err
 	}
-	if validator != nil {
-		if err := validator.Validate(message); err != nil {
-			return nil, 0, err
-		}
-	}
 	return message, messageEncoding, nil
 }
 
@@ -796,6 +807,144 @@ func (c *controller) PutMessage(
 	return errors.Join(err, writeCloser.Close())
 }
 
+func (c *controller) GetMessages(
+	ctx context.Context,
+	schemaImage bufimage.Image,
+	messageInput string,
+	typeName string,
+	defaultMessageEncoding buffetch.MessageEncoding,
+	options ...FunctionOption,
+) (_ bufconvert.MessageReader, _ buffetch.MessageEncoding, retErr error) {
+	defer c.handleFileAnnotationSetRetError(&retErr)
+	functionOptions := newFunctionOptions(c)
+	for _, option := range options {
+		option(functionOptions)
+	}
+	// Must be messageRefParser NOT c.buffetchRefParser as a NewMessageRefParser
+	// defaults to a defaultMessageEncoding and not dir.
+	messageRefParser := buffetch.NewMessageRefParser(
+		c.logger,
+		buffetch.MessageRefParserWithDefaultMessageEncoding(
+			defaultMessageEncoding,
+		),
+	)
+	messageRef, err := messageRefParser.GetMessageRef(ctx, messageInput)
+	if err != nil {
+		return nil, 0, err
+	}
+	if messageRef.IsNull() {
+		return nullMessageReader{}, messageRef.MessageEncoding(), nil
+	}
+	unmarshaler, err := newProtoencodingUnmarshaler(schemaImage, messageRef, functionOptions.messageValidation)
+	if err != nil {
+		return nil, 0, err
+	}
+	message, err := bufreflect.NewMessage(ctx, schemaImage, typeName)
+	if err != nil {
+		return nil, 0, err
+	}
+	readCloser, err := c.buffetchReader.GetMessageFile(ctx, c.container, messageRef)
+	if err != nil {
+		return nil, 0, err
+	}
+	messageReader, err := bufconvert.NewMessageReader(
+		readCloser,
+		message.ProtoReflect().Type(),
+		unmarshaler,
+		messageRef.MessageEncoding(),
+		messageRef.MessageDelimiter(),
+	)
+	if err != nil {
+		return nil, 0, errors.Join(err, readCloser.Close())
+	}
+	return newFileAnnotationSetMessageReader(c, messageReader), messageRef.MessageEncoding(), nil
+}
+
+func (c *controller) GetMessageData(
+	ctx context.Context,
+	messageInput string,
+	defaultMessageEncoding buffetch.MessageEncoding,
+	options ...FunctionOption,
+) (_ bufconvert.MessageDataReader, _ buffetch.MessageEncoding, retErr error) {
+	defer c.handleFileAnnotationSetRetError(&retErr)
+	functionOptions := newFunctionOptions(c)
+	for _, option := range options {
+		option(functionOptions)
+	}
+	// Must be messageRefParser NOT c.buffetchRefParser as a NewMessageRefParser
+	// defaults to a defaultMessageEncoding and not dir.
+	messageRefParser := buffetch.NewMessageRefParser(
+		c.logger,
+		buffetch.MessageRefParserWithDefaultMessageEncoding(
+			defaultMessageEncoding,
+		),
+	)
+	messageRef, err := messageRefParser.GetMessageRef(ctx, messageInput)
+	if err != nil {
+		return nil, 0, err
+	}
+	if messageRef.IsNull() {
+		return nullMessageDataReader{}, messageRef.MessageEncoding(), nil
+	}
+	readCloser, err := c.buffetchReader.GetMessageFile(ctx, c.container, messageRef)
+	if err != nil {
+		return nil, 0, err
+	}
+	messageDataReader, err := bufconvert.NewMessageDataReader(
+		readCloser,
+		messageRef.MessageEncoding(),
+		messageRef.MessageDelimiter(),
+	)
+	if err != nil {
+		return nil, 0, errors.Join(err, readCloser.Close())
+	}
+	return messageDataReader, messageRef.MessageEncoding(), nil
+}
+
+func (c *controller) PutMessages(
+	ctx context.Context,
+	schemaImage bufimage.Image,
+	messageOutput string,
+	defaultMessageEncoding buffetch.MessageEncoding,
+	options ...FunctionOption,
+) (_ bufconvert.MessageWriter, retErr error) {
+	defer c.handleFileAnnotationSetRetError(&retErr)
+	functionOptions := newFunctionOptions(c)
+	for _, option := range options {
+		option(functionOptions)
+	}
+	// Must be messageRefParser NOT c.buffetchRefParser as a NewMessageRefParser
+	// defaults to a defaultMessageEncoding and not dir.
+	messageRefParser := buffetch.NewMessageRefParser(
+		c.logger,
+		buffetch.MessageRefParserWithDefaultMessageEncoding(
+			defaultMessageEncoding,
+		),
+	)
+	messageRef, err := messageRefParser.GetMessageRef(ctx, messageOutput)
+	if err != nil {
+		return nil, err
+	}
+	marshaler, err := newProtoencodingMarshaler(schemaImage, messageRef)
+	if err != nil {
+		return nil, err
+	}
+	writeCloser, err := c.buffetchWriter.PutMessageFile(ctx, c.container, messageRef)
+	if err != nil {
+		return nil, err
+	}
+	messageWriter, err := bufconvert.NewMessageWriter(
+		writeCloser,
+		marshaler,
+		messageRef.MessageEncoding(),
+		messageRef.MessageDelimiter(),
+	)
+	if err != nil {
+		return nil, errors.Join(err, writeCloser.Close())
+	}
+	return messageWriter, nil
+}
+
 func (c *controller) GetCheckClientForWorkspace(
 	ctx context.Context,
 	workspace bufworkspace.Workspace,
@@ -1314,6 +1463,81 @@ func (v yamlValidator) Validate(msg proto.Message) error {
 	return v.protovalidateValidator.Validate(msg)
 }
 
+// nullMessageReader is a [bufconvert.MessageReader] for null message inputs, that
+// reads no messages.
+type nullMessageReader struct{}
+
+func (nullMessageReader) Read() (proto.Message, error) {
+	return nil, io.EOF
+}
+
+func (nullMessageReader) Close() error {
+	return nil
+}
+
+// nullMessageDataReader is a [bufconvert.MessageDataReader] for null message inputs,
+// that reads no messages.
+type nullMessageDataReader struct{}
+
+func (nullMessageDataReader) Read() ([]byte, error) {
+	return nil, io.EOF
+}
+
+func (nullMessageDataReader) Close() error {
+	return nil
+}
+
+// fileAnnotationSetMessageReader is a [bufconvert.MessageReader] that handles the
+// FileAnnotationSets returned when reading a message, such as validation errors, in
+// the same way as the other Controller functions.
+type fileAnnotationSetMessageReader struct {
+	controller    *controller
+	messageReader bufconvert.MessageReader
+}
+
+func newFileAnnotationSetMessageReader(
+	controller *controller,
+	messageReader bufconvert.MessageReader,
+) *fileAnnotationSetMessageReader {
+	return &fileAnnotationSetMessageReader{
+		controller:    controller,
+		messageReader: messageReader,
+	}
+}
+
+func (r *fileAnnotationSetMessageReader) Read() (_ proto.Message, retErr error) {
+	defer r.controller.handleFileAnnotationSetRetError(&retErr)
+	return r.messageReader.Read()
+}
+
+func (r *fileAnnotationSetMessageReader) Close() error {
+	return r.messageReader.Close()
+}
+
+// validatingUnmarshaler is a [protoencoding.Unmarshaler] that validates messages after
+// unmarshalling them.
+type validatingUnmarshaler struct {
+	unmarshaler protoencoding.Unmarshaler
+	validator   protoyaml.Validator
+}
+
+func newValidatingUnmarshaler(
+	unmarshaler protoencoding.Unmarshaler,
+	validator protoyaml.Validator,
+) *validatingUnmarshaler {
+	return &validatingUnmarshaler{
+		unmarshaler: unmarshaler,
+		validator:   validator,
+	}
+}
+
+func (v *validatingUnmarshaler) Unmarshal(data []byte, message proto.Message) error {
+	if err := v.unmarshaler.Unmarshal(data, message); err != nil {
+		return err
+	}
+	return v.validator.Validate(message)
+}
+
 func getImageFileInfosForModuleSet(ctx context.Context, moduleSet bufmodule.ModuleSet) ([]bufimage.ImageFileInfo, error) {
 	// Sorted.
 	fileInfos, err := bufmodule.GetFileInfos(
@@ -1402,6 +1626,48 @@ func newStorageosProvider(disableSymlinks bool) storageos.Provider {
 	return storageos.NewProvider(options...)
 }
 
+// newProtoencodingUnmarshaler returns a new Unmarshaler for the MessageRef.
+//
+// If messageValidation is true, the Unmarshaler validates the messages with protovalidate.
+func newProtoencodingUnmarshaler(
+	image bufimage.Image,
+	messageRef buffetch.MessageRef,
+	messageValidation bool,
+) (protoencoding.Unmarshaler, error) {
+	var validator protoyaml.Validator
+	if messageValidation {
+		protovalidateValidator, err := protovalidate.New()
+		if err != nil {
+			return nil, err
+		}
+		validator = yamlValidator{protovalidateValidator}
+	}
+	var unmarshaler protoencoding.Unmarshaler
+	switch messageEncoding := messageRef.MessageEncoding(); messageEncoding {
+	case buffetch.MessageEncodingBinpb:
+		unmarshaler = protoencoding.NewWireUnmarshaler(image.Resolver())
+	case buffetch.MessageEncodingJSON:
+		unmarshaler = protoencoding.NewJSONUnmarshaler(image.Resolver())
+	case buffetch.MessageEncodingTxtpb:
+		unmarshaler = protoencoding.NewTxtpbUnmarshaler(image.Resolver())
+	case buffetch.MessageEncodingYAML:
+		// Validation errors are handled by the unmarshaler, which will pretty
+		// print validation errors.
+		return protoencoding.NewYAMLUnmarshaler(
+			image.Resolver(),
+			protoencoding.YAMLUnmarshalerWithPath(messageRef.Path()),
+			protoencoding.YAMLUnmarshalerWithValidator(validator),
+		), nil
+	default:
+		// This is a system error.
+		return nil, syserror.Newf("unknown MessageEncoding: %v", messageEncoding)
+	}
+	if validator == nil {
+		return unmarshaler, nil
+	}
+	return newValidatingUnmarshaler(unmarshaler, validator), nil
+}
+
 func newProtoencodingMarshaler(
 	image bufimage.Image,
 	messageRef buffetch.MessageRef,
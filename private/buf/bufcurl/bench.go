@@ -0,0 +1,408 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"buf.build/go/app/appext"
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// BenchmarkFormatText is the text format for benchmark results.
+	BenchmarkFormatText = "text"
+	// BenchmarkFormatJSON is the JSON format for benchmark results.
+	BenchmarkFormatJSON = "json"
+
+	// DefaultBenchmarkRequests is the number of requests sent by a benchmark if
+	// neither a number of requests nor a duration is given.
+	DefaultBenchmarkRequests = 200
+	// MaxBenchmarkRPS is the maximum target number of RPCs started per second, at which
+	// an RPC is started every nanosecond.
+	MaxBenchmarkRPS = 1e9
+)
+
+// AllBenchmarkFormatStrings are all the formats for benchmark results.
+var AllBenchmarkFormatStrings = []string{
+	BenchmarkFormatText,
+	BenchmarkFormatJSON,
+}
+
+// BenchmarkConfig configures a benchmark.
+type BenchmarkConfig struct {
+	// Concurrency is the number of RPCs that are in flight at once. Must be positive.
+	Concurrency int
+	// Requests is the total number of RPCs to send. If zero, RPCs are sent until
+	// Duration has elapsed.
+	Requests int
+	// Duration is the maximum amount of time to send RPCs for. If zero, RPCs are sent
+	// until Requests have been sent.
+	Duration time.Duration
+	// RPS is the target number of RPCs started per second, across all concurrent RPCs.
+	// If zero, RPCs are sent as fast as possible. Must not be greater than MaxBenchmarkRPS.
+	RPS float64
+}
+
+// BenchmarkResult is the result of a benchmark.
+type BenchmarkResult struct {
+	// Duration is the time from the start of the first RPC to the end of the last.
+	Duration time.Duration
+	// Latencies are the latencies of all RPCs, sorted in ascending order.
+	Latencies []time.Duration
+	// ErrorCounts are the number of RPCs that failed with each code.
+	ErrorCounts map[connect.Code]int
+}
+
+// Requests returns the number of RPCs that completed, successfully or not.
+func (r *BenchmarkResult) Requests() int {
+	return len(r.Latencies)
+}
+
+// Errors returns the number of RPCs that failed.
+func (r *BenchmarkResult) Errors() int {
+	var total int
+	for _, count := range r.ErrorCounts {
+		total += count
+	}
+	return total
+}
+
+// Throughput returns the number of RPCs completed per second.
+func (r *BenchmarkResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Requests()) / r.Duration.Seconds()
+}
+
+// Mean returns the mean latency of all RPCs.
+func (r *BenchmarkResult) Mean() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range r.Latencies {
+		total += latency
+	}
+	return total / time.Duration(len(r.Latencies))
+}
+
+// Percentile returns the latency below which the given percentage of RPCs completed,
+// using the nearest-rank method.
+func (r *BenchmarkResult) Percentile(percentile float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(r.Latencies))))
+	rank = min(max(rank, 1), len(r.Latencies))
+	return r.Latencies[rank-1]
+}
+
+// RunBenchmark invokes the method repeatedly with the given request data, and returns
+// the latencies and errors of all RPCs.
+//
+// All RPCs are invoked with a single Invoker from newInvoker, so that they share a
+// transport. Responses and error responses are discarded. If data is nil, every RPC sends an empty request.
+//
+// RPCs that fail are counted by their code. Errors that are not Connect errors are
+// counted as unknown. Invalid request data stops the benchmark and is returned.
+func RunBenchmark(
+	ctx context.Context,
+	container appext.Container,
+	newInvoker NewInvokerFunc,
+	methodDescriptor protoreflect.MethodDescriptor,
+	url string,
+	data []byte,
+	headers http.Header,
+	config BenchmarkConfig,
+) (*BenchmarkResult, error) {
+	if config.Concurrency <= 0 {
+		return nil, fmt.Errorf("benchmark concurrency must be positive, got %d", config.Concurrency)
+	}
+	if config.Requests == 0 && config.Duration == 0 {
+		config.Requests = DefaultBenchmarkRequests
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	benchmark := &benchmark{
+		invoker: newInvoker(
+			&stderrContainer{Container: container, stderr: io.Discard},
+			methodDescriptor,
+			url,
+			io.Discard,
+		),
+		data:        data,
+		headers:     headers,
+		config:      config,
+		start:       time.Now(),
+		errorCounts: make(map[connect.Code]int),
+	}
+	if config.RPS > 0 {
+		benchmark.tokens = make(chan struct{})
+		go benchmark.produceTokens(ctx)
+	}
+	var waitGroup sync.WaitGroup
+	for range config.Concurrency {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			if err := benchmark.work(ctx); err != nil {
+				benchmark.setErr(err)
+				cancel()
+			}
+		}()
+	}
+	waitGroup.Wait()
+	if benchmark.err != nil {
+		return nil, benchmark.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(benchmark.latencies)
+	var duration time.Duration
+	if !benchmark.end.IsZero() {
+		duration = benchmark.end.Sub(benchmark.start)
+	}
+	return &BenchmarkResult{
+		Duration:    duration,
+		Latencies:   benchmark.latencies,
+		ErrorCounts: benchmark.errorCounts,
+	}, nil
+}
+
+// WriteBenchmarkResult writes the result of a benchmark in the given format.
+func WriteBenchmarkResult(writer io.Writer, result *BenchmarkResult, format string) error {
+	switch format {
+	case BenchmarkFormatText:
+		return writeBenchmarkResultText(writer, result)
+	case BenchmarkFormatJSON:
+		return writeBenchmarkResultJSON(writer, result)
+	default:
+		return fmt.Errorf("unknown benchmark format: %q", format)
+	}
+}
+
+type benchmark struct {
+	invoker Invoker
+	data    []byte
+	headers http.Header
+	config  BenchmarkConfig
+	start   time.Time
+	// tokens receives once for each RPC that may be started, if the rate is limited.
+	tokens chan struct{}
+
+	lock        sync.Mutex
+	started     int
+	end         time.Time
+	latencies   []time.Duration
+	errorCounts map[connect.Code]int
+	err         error
+}
+
+// work invokes RPCs one at a time until the benchmark is done.
+func (b *benchmark) work(ctx context.Context) error {
+	for ctx.Err() == nil {
+		if b.tokens != nil {
+			select {
+			case <-b.tokens:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if !b.next() {
+			return nil
+		}
+		var data io.Reader
+		if b.data != nil {
+			data = bytes.NewReader(b.data)
+		}
+		start := time.Now()
+		err := b.invoker.Invoke(ctx, "(benchmark)", data, b.headers)
+		end := time.Now()
+		if err != nil {
+			var requestDataErr *requestDataError
+			if errors.As(err, &requestDataErr) {
+				return err
+			}
+			if ctx.Err() != nil {
+				// The benchmark was stopped, and this RPC is not counted.
+				return nil
+			}
+		}
+		b.record(end, end.Sub(start), err)
+	}
+	return nil
+}
+
+// next returns whether another RPC should be started, and counts it as started.
+func (b *benchmark) next() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.err != nil {
+		return false
+	}
+	if b.config.Requests > 0 && b.started >= b.config.Requests {
+		return false
+	}
+	if b.config.Duration > 0 && time.Since(b.start) >= b.config.Duration {
+		return false
+	}
+	b.started++
+	return true
+}
+
+// record records the completion of an RPC. The error is nil if the RPC succeeded.
+func (b *benchmark) record(end time.Time, latency time.Duration, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if end.After(b.end) {
+		b.end = end
+	}
+	b.latencies = append(b.latencies, latency)
+	if err != nil {
+		code, _ := invokeErrorCode(err)
+		b.errorCounts[code]++
+	}
+}
+
+func (b *benchmark) setErr(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// produceTokens sends tokens at the target rate until the context is done.
+func (b *benchmark) produceTokens(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / b.config.RPS))
+	defer ticker.Stop()
+	for {
+		select {
+		case b.tokens <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func writeBenchmarkResultText(writer io.Writer, result *BenchmarkResult) error {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	lines := []string{
+		fmt.Sprintf("Requests:\t%d", result.Requests()),
+		fmt.Sprintf("Succeeded:\t%d", result.Requests()-result.Errors()),
+		fmt.Sprintf("Failed:\t%d", result.Errors()),
+		fmt.Sprintf("Duration:\t%s", result.Duration.Round(time.Microsecond)),
+		fmt.Sprintf("Throughput:\t%.2f requests/s", result.Throughput()),
+		"",
+		"Latency:",
+		fmt.Sprintf("  min\t%s", result.Percentile(0).Round(time.Microsecond)),
+		fmt.Sprintf("  mean\t%s", result.Mean().Round(time.Microsecond)),
+	}
+	for _, percentile := range benchmarkPercentiles {
+		lines = append(lines, fmt.Sprintf("  p%d\t%s", int(percentile), result.Percentile(percentile).Round(time.Microsecond)))
+	}
+	lines = append(lines, fmt.Sprintf("  max\t%s", result.Percentile(100).Round(time.Microsecond)))
+	if len(result.ErrorCounts) > 0 {
+		lines = append(lines, "", "Errors:")
+		for _, code := range sortedCodes(result.ErrorCounts) {
+			lines = append(lines, fmt.Sprintf("  %s\t%d", code, result.ErrorCounts[code]))
+		}
+	}
+	if _, err := io.WriteString(tabWriter, strings.Join(lines, "\n")+"\n"); err != nil {
+		return err
+	}
+	return tabWriter.Flush()
+}
+
+func writeBenchmarkResultJSON(writer io.Writer, result *BenchmarkResult) error {
+	type latencyJSON struct {
+		Min  float64 `json:"min"`
+		Mean float64 `json:"mean"`
+		P50  float64 `json:"p50"`
+		P90  float64 `json:"p90"`
+		P95  float64 `json:"p95"`
+		P99  float64 `json:"p99"`
+		Max  float64 `json:"max"`
+	}
+	type resultJSON struct {
+		Requests          int            `json:"requests"`
+		Succeeded         int            `json:"succeeded"`
+		Failed            int            `json:"failed"`
+		DurationSeconds   float64        `json:"durationSeconds"`
+		RequestsPerSecond float64        `json:"requestsPerSecond"`
+		LatencyMillis     latencyJSON    `json:"latencyMillis"`
+		Errors            map[string]int `json:"errors"`
+	}
+	errorCounts := make(map[string]int, len(result.ErrorCounts))
+	for code, count := range result.ErrorCounts {
+		errorCounts[code.String()] = count
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resultJSON{
+		Requests:          result.Requests(),
+		Succeeded:         result.Requests() - result.Errors(),
+		Failed:            result.Errors(),
+		DurationSeconds:   result.Duration.Seconds(),
+		RequestsPerSecond: result.Throughput(),
+		LatencyMillis: latencyJSON{
+			Min:  durationToMillis(result.Percentile(0)),
+			Mean: durationToMillis(result.Mean()),
+			P50:  durationToMillis(result.Percentile(50)),
+			P90:  durationToMillis(result.Percentile(90)),
+			P95:  durationToMillis(result.Percentile(95)),
+			P99:  durationToMillis(result.Percentile(99)),
+			Max:  durationToMillis(result.Percentile(100)),
+		},
+		Errors: errorCounts,
+	})
+}
+
+var benchmarkPercentiles = []float64{50, 90, 95, 99}
+
+func sortedCodes(errorCounts map[connect.Code]int) []connect.Code {
+	codes := make([]connect.Code, 0, len(errorCounts))
+	for code := range errorCounts {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+func durationToMillis(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
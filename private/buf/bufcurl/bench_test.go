@@ -0,0 +1,293 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"buf.build/go/app"
+	"buf.build/go/app/appext"
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/verbose"
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const benchTestProto = `syntax = "proto3";
+package bench.v1;
+import "google/protobuf/struct.proto";
+service BenchService {
+  rpc Echo(google.protobuf.Struct) returns (google.protobuf.Struct);
+  rpc EchoStream(stream google.protobuf.Struct) returns (stream google.protobuf.Struct);
+}
+`
+
+func TestRunBenchmark(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int64
+	mux := http.NewServeMux()
+	mux.Handle("/bench.v1.BenchService/Echo", connect.NewUnaryHandler(
+		"/bench.v1.BenchService/Echo",
+		func(_ context.Context, request *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+			// Every fourth call fails.
+			if calls.Add(1)%4 == 0 {
+				return nil, connect.NewError(connect.CodeResourceExhausted, nil)
+			}
+			return connect.NewResponse(request.Msg), nil
+		},
+	))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	service := compileBenchService(t)
+	result, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(server.Client()),
+		service.Methods().ByName("Echo"),
+		server.URL+"/bench.v1.BenchService/Echo",
+		[]byte(`{"greeting": "hello"}`),
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 4,
+			Requests:    40,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 40, result.Requests())
+	assert.Equal(t, 10, result.Errors())
+	assert.Equal(t, map[connect.Code]int{connect.CodeResourceExhausted: 10}, result.ErrorCounts)
+	assert.Positive(t, result.Duration)
+	assert.Positive(t, result.Throughput())
+	assert.LessOrEqual(t, result.Percentile(0), result.Percentile(50))
+	assert.LessOrEqual(t, result.Percentile(50), result.Percentile(99))
+	assert.LessOrEqual(t, result.Percentile(99), result.Percentile(100))
+
+	var text bytes.Buffer
+	require.NoError(t, WriteBenchmarkResult(&text, result, BenchmarkFormatText))
+	assert.Contains(t, text.String(), "Requests:    40\n")
+	assert.Contains(t, text.String(), "resource_exhausted  10\n")
+
+	var jsonResult struct {
+		Requests  int            `json:"requests"`
+		Succeeded int            `json:"succeeded"`
+		Failed    int            `json:"failed"`
+		Errors    map[string]int `json:"errors"`
+	}
+	var jsonOutput bytes.Buffer
+	require.NoError(t, WriteBenchmarkResult(&jsonOutput, result, BenchmarkFormatJSON))
+	require.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &jsonResult))
+	assert.Equal(t, 40, jsonResult.Requests)
+	assert.Equal(t, 30, jsonResult.Succeeded)
+	assert.Equal(t, 10, jsonResult.Failed)
+	assert.Equal(t, map[string]int{"resource_exhausted": 10}, jsonResult.Errors)
+}
+
+func TestRunBenchmarkStream(t *testing.T) {
+	t.Parallel()
+	var received atomic.Int64
+	mux := http.NewServeMux()
+	mux.Handle("/bench.v1.BenchService/EchoStream", connect.NewBidiStreamHandler(
+		"/bench.v1.BenchService/EchoStream",
+		func(_ context.Context, stream *connect.BidiStream[structpb.Struct, structpb.Struct]) error {
+			for {
+				message, err := stream.Receive()
+				if err != nil {
+					return nil
+				}
+				received.Add(1)
+				if err := stream.Send(message); err != nil {
+					return err
+				}
+			}
+		},
+	))
+	server := httptest.NewUnstartedServer(mux)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	service := compileBenchService(t)
+	result, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(server.Client()),
+		service.Methods().ByName("EchoStream"),
+		server.URL+"/bench.v1.BenchService/EchoStream",
+		[]byte(`{"n": 1} {"n": 2} {"n": 3}`),
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 2,
+			Requests:    10,
+			RPS:         1000,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Requests())
+	assert.Zero(t, result.Errors())
+	assert.Equal(t, int64(30), received.Load())
+}
+
+func TestRunBenchmarkDuration(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.Handle("/bench.v1.BenchService/Echo", connect.NewUnaryHandler(
+		"/bench.v1.BenchService/Echo",
+		func(_ context.Context, request *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+			return connect.NewResponse(request.Msg), nil
+		},
+	))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	service := compileBenchService(t)
+	result, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(server.Client()),
+		service.Methods().ByName("Echo"),
+		server.URL+"/bench.v1.BenchService/Echo",
+		nil,
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 1,
+			Duration:    200 * time.Millisecond,
+			RPS:         20,
+		},
+	)
+	require.NoError(t, err)
+	// At 20 RPCs per second, about 4 RPCs are sent in 200ms.
+	assert.GreaterOrEqual(t, result.Requests(), 2)
+	assert.LessOrEqual(t, result.Requests(), 6)
+}
+
+func TestRunBenchmarkTransportError(t *testing.T) {
+	t.Parallel()
+	// The server is closed before the benchmark, so that every connection is refused.
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	service := compileBenchService(t)
+	result, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(http.DefaultClient),
+		service.Methods().ByName("Echo"),
+		server.URL+"/bench.v1.BenchService/Echo",
+		nil,
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 2,
+			Requests:    10,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Requests())
+	assert.Equal(t, map[connect.Code]int{connect.CodeUnavailable: 10}, result.ErrorCounts)
+}
+
+func TestRunBenchmarkNonConnectError(t *testing.T) {
+	t.Parallel()
+	service := compileBenchService(t)
+	result, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		func(appext.Container, protoreflect.MethodDescriptor, string, io.Writer) Invoker {
+			return failingInvoker{err: errors.New("stream reset")}
+		},
+		service.Methods().ByName("Echo"),
+		"http://127.0.0.1:0/bench.v1.BenchService/Echo",
+		nil,
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 2,
+			Requests:    10,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.Requests())
+	assert.Equal(t, map[connect.Code]int{connect.CodeUnknown: 10}, result.ErrorCounts)
+}
+
+func TestRunBenchmarkInvalidData(t *testing.T) {
+	t.Parallel()
+	service := compileBenchService(t)
+	_, err := RunBenchmark(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(http.DefaultClient),
+		service.Methods().ByName("Echo"),
+		"http://127.0.0.1:0/bench.v1.BenchService/Echo",
+		[]byte(`{`),
+		http.Header{},
+		BenchmarkConfig{
+			Concurrency: 2,
+			Requests:    10,
+		},
+	)
+	require.Error(t, err)
+}
+
+func compileBenchService(t *testing.T) protoreflect.ServiceDescriptor {
+	t.Helper()
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"bench.proto": benchTestProto,
+			}),
+		}),
+	}).Compile(context.Background(), "bench.proto")
+	require.NoError(t, err)
+	return files[0].Services().ByName("BenchService")
+}
+
+func newTestContainer(t *testing.T) appext.Container {
+	t.Helper()
+	nameContainer, err := appext.NewNameContainer(
+		app.NewContainer(nil, strings.NewReader(""), io.Discard, io.Discard),
+		"buf",
+	)
+	require.NoError(t, err)
+	return appext.NewContainer(nameContainer, slog.New(slog.DiscardHandler))
+}
+
+func newTestInvokerFunc(httpClient connect.HTTPClient) NewInvokerFunc {
+	return func(container appext.Container, methodDescriptor protoreflect.MethodDescriptor, url string, output io.Writer) Invoker {
+		return NewInvoker(container, verbose.NopPrinter, methodDescriptor, protoencoding.EmptyResolver, false, httpClient, nil, url, output)
+	}
+}
+
+type failingInvoker struct {
+	err error
+}
+
+func (i failingInvoker) Invoke(context.Context, string, io.Reader, http.Header) error {
+	return i.err
+}
@@ -0,0 +1,484 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"buf.build/go/app"
+	"buf.build/go/app/appext"
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+)
+
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Collection is a collection of named requests, read from a YAML or JSON file.
+//
+// Strings in the paths, headers, and data of requests may refer to variables as
+// ${NAME}, which are replaced when the requests are run.
+type Collection struct {
+	Requests []*CollectionRequest `json:"requests,omitempty" yaml:"requests,omitempty"`
+}
+
+// CollectionRequest is a request in a Collection.
+type CollectionRequest struct {
+	// Name is the unique name of the request within the collection.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Path is the path of the method relative to the base URL, in the form
+	// "<service>/<method>".
+	Path    string            `json:"path,omitempty" yaml:"path,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Data is the request body. If it is a string, it is the request body as JSON.
+	// Otherwise, it is the request message itself, or a list of messages for a
+	// client-streaming method.
+	Data   any                    `json:"data,omitempty" yaml:"data,omitempty"`
+	Expect *CollectionExpectation `json:"expect,omitempty" yaml:"expect,omitempty"`
+}
+
+// CollectionExpectation are the assertions on the result of a CollectionRequest.
+type CollectionExpectation struct {
+	// Code is the expected code, such as "ok" or "not_found". Defaults to "ok".
+	Code string `json:"code,omitempty" yaml:"code,omitempty"`
+	// Responses are the expected response messages in order. Every field of an
+	// expected message must be equal in the actual message, but the actual message
+	// may contain other fields. If empty, responses are not checked.
+	Responses []any `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+// Environment supplies the base URL, credentials, TLS settings, and variables that are
+// used to run the requests of a Collection.
+type Environment struct {
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	// Headers are included with every request. Request headers take precedence.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// User is the credentials for basic authorization, as "username:password".
+	User      string            `json:"user,omitempty" yaml:"user,omitempty"`
+	TLS       *EnvironmentTLS   `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+// EnvironmentTLS are the TLS settings of an Environment.
+type EnvironmentTLS struct {
+	CACert     string `json:"cacert,omitempty" yaml:"cacert,omitempty"`
+	Cert       string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Key        string `json:"key,omitempty" yaml:"key,omitempty"`
+	ServerName string `json:"servername,omitempty" yaml:"servername,omitempty"`
+	Insecure   bool   `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+}
+
+// ReadCollection reads a Collection from a YAML or JSON file.
+func ReadCollection(path string) (*Collection, error) {
+	collection := &Collection{}
+	if err := readJSONOrYAMLFile(path, collection); err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(collection.Requests))
+	for i, request := range collection.Requests {
+		if request == nil || request.Name == "" {
+			return nil, fmt.Errorf("%s: request %d has no name", path, i+1)
+		}
+		if _, ok := names[request.Name]; ok {
+			return nil, fmt.Errorf("%s: duplicate request name %q", path, request.Name)
+		}
+		names[request.Name] = struct{}{}
+		if request.Path == "" {
+			return nil, fmt.Errorf("%s: request %q has no path", path, request.Name)
+		}
+		if request.Expect != nil && request.Expect.Code != "" {
+			if _, err := parseCode(request.Expect.Code); err != nil {
+				return nil, fmt.Errorf("%s: request %q: %w", path, request.Name, err)
+			}
+		}
+	}
+	return collection, nil
+}
+
+// ReadEnvironment reads an Environment from a YAML or JSON file.
+func ReadEnvironment(path string) (*Environment, error) {
+	environment := &Environment{}
+	if err := readJSONOrYAMLFile(path, environment); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+// Request returns the request with the given name.
+func (c *Collection) Request(name string) (*CollectionRequest, error) {
+	for _, request := range c.Requests {
+		if request.Name == name {
+			return request, nil
+		}
+	}
+	return nil, fmt.Errorf("collection has no request named %q", name)
+}
+
+// VariableLookup returns a function that looks up the values of variables, first in
+// the variables of the environment, and then in the environment variables of the
+// container.
+func (e *Environment) VariableLookup(envContainer app.EnvContainer) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		if value, ok := e.Variables[name]; ok {
+			return value, true
+		}
+		if value := envContainer.Env(name); value != "" {
+			return value, true
+		}
+		return "", false
+	}
+}
+
+// Interpolate replaces all references to variables in s, in the form ${NAME}, with
+// their values. It is an error to refer to a variable that lookup does not find.
+func Interpolate(s string, lookup func(name string) (string, bool)) (string, error) {
+	var missing []string
+	result := variablePattern.ReplaceAllStringFunc(s, func(reference string) string {
+		name := variablePattern.FindStringSubmatch(reference)[1]
+		value, ok := lookup(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable(s): %s", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// RunCollectionRequest invokes the request, writing the responses to the output and
+// errors to the container's stderr, and checks the result against the expectations
+// of the request.
+//
+// If the request has no expectations, the error returned by the invoker is returned.
+// Otherwise, an error is only returned if an expectation is not met.
+func RunCollectionRequest(
+	ctx context.Context,
+	container appext.Container,
+	newInvoker NewInvokerFunc,
+	res Resolver,
+	baseURL string,
+	headers http.Header,
+	request *CollectionRequest,
+	lookup func(name string) (string, bool),
+	output io.Writer,
+) error {
+	path, err := Interpolate(request.Path, lookup)
+	if err != nil {
+		return err
+	}
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return fmt.Errorf("path %q must be in the form <service>/<method>", path)
+	}
+	methodDescriptor, err := ResolveMethodDescriptor(res, service, method)
+	if err != nil {
+		return err
+	}
+	requestHeaders := headers.Clone()
+	for name, value := range request.Headers {
+		value, err := Interpolate(value, lookup)
+		if err != nil {
+			return fmt.Errorf("header %q: %w", name, err)
+		}
+		requestHeaders.Set(name, value)
+	}
+	var data io.Reader
+	if request.Data != nil {
+		requestData, err := collectionRequestData(request.Data, lookup)
+		if err != nil {
+			return err
+		}
+		data = bytes.NewReader(requestData)
+	}
+
+	var responses bytes.Buffer
+	invoker := newInvoker(
+		container,
+		methodDescriptor,
+		strings.TrimSuffix(baseURL, "/")+"/"+service+"/"+method,
+		io.MultiWriter(output, &responses),
+	)
+	invokeErr := invoker.Invoke(ctx, request.Name, data, requestHeaders)
+	if request.Expect == nil {
+		return invokeErr
+	}
+	code, ok := invokeErrorCode(invokeErr)
+	if !ok {
+		return invokeErr
+	}
+	return request.Expect.check(code, responses.Bytes())
+}
+
+// RunCollection runs all requests of the collection in order, and prints whether each
+// of them passed or failed to the container's stdout. Responses and errors are not printed.
+//
+// A request without expectations passes if the RPC succeeds. Returns an error if any
+// request failed.
+func RunCollection(
+	ctx context.Context,
+	container appext.Container,
+	newInvoker NewInvokerFunc,
+	res Resolver,
+	baseURL string,
+	headers http.Header,
+	collection *Collection,
+	lookup func(name string) (string, bool),
+) error {
+	quietContainer := &stderrContainer{Container: container, stderr: io.Discard}
+	var failed int
+	for _, request := range collection.Requests {
+		err := RunCollectionRequest(ctx, quietContainer, newInvoker, res, baseURL, headers, request, lookup, io.Discard)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			failed++
+			if code, ok := invokeErrorCode(err); ok && err.Error() == "" {
+				err = fmt.Errorf("RPC failed with code %s", codeString(code))
+			}
+			if _, err := fmt.Fprintf(container.Stdout(), "FAIL %s: %v\n", request.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(container.Stdout(), "PASS %s\n", request.Name); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d request(s) failed", failed, len(collection.Requests))
+	}
+	return nil
+}
+
+// check returns an error if the code and the JSON responses do not meet this expectation.
+func (e *CollectionExpectation) check(code connect.Code, responses []byte) error {
+	expectedCode := connect.Code(0)
+	if e.Code != "" {
+		var err error
+		if expectedCode, err = parseCode(e.Code); err != nil {
+			return err
+		}
+	}
+	if code != expectedCode {
+		return fmt.Errorf("expected code %s, got %s", codeString(expectedCode), codeString(code))
+	}
+	if len(e.Responses) == 0 {
+		return nil
+	}
+	var actualResponses []any
+	decoder := json.NewDecoder(bytes.NewReader(responses))
+	decoder.UseNumber()
+	for {
+		var response any
+		if err := decoder.Decode(&response); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("could not parse response: %w", err)
+		}
+		actualResponses = append(actualResponses, response)
+	}
+	if len(actualResponses) != len(e.Responses) {
+		return fmt.Errorf("expected %d response(s), got %d", len(e.Responses), len(actualResponses))
+	}
+	for i, expectedResponse := range e.Responses {
+		expected, err := normalizeJSON(expectedResponse)
+		if err != nil {
+			return err
+		}
+		if path, ok := matchJSON(expected, actualResponses[i], ""); !ok {
+			actual, _ := json.Marshal(actualResponses[i])
+			if path == "" {
+				return fmt.Errorf("response %d does not match: got %s", i+1, actual)
+			}
+			return fmt.Errorf("response %d does not match at %s: got %s", i+1, path, actual)
+		}
+	}
+	return nil
+}
+
+// collectionRequestData returns the JSON request body for the data of a request.
+func collectionRequestData(data any, lookup func(name string) (string, bool)) ([]byte, error) {
+	if s, ok := data.(string); ok {
+		interpolated, err := Interpolate(s, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("data: %w", err)
+		}
+		return []byte(interpolated), nil
+	}
+	interpolated, err := interpolateValue(data, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+	messages, ok := interpolated.([]any)
+	if !ok {
+		messages = []any{interpolated}
+	}
+	// Each message of a stream is a separate JSON document.
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			return nil, fmt.Errorf("data: %w", err)
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// interpolateValue interpolates all strings within a value decoded from YAML or JSON.
+func interpolateValue(value any, lookup func(name string) (string, bool)) (any, error) {
+	switch value := value.(type) {
+	case string:
+		return Interpolate(value, lookup)
+	case []any:
+		result := make([]any, len(value))
+		for i, element := range value {
+			interpolated, err := interpolateValue(element, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = interpolated
+		}
+		return result, nil
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, element := range value {
+			interpolated, err := interpolateValue(element, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = interpolated
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// normalizeJSON converts a value decoded from YAML or JSON into the same form as a value
+// decoded from JSON with json.Decoder.UseNumber.
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var normalized any
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// matchJSON returns whether the actual value contains the expected value. If not, it
+// returns the path of the first mismatch.
+//
+// Objects match if every field of the expected object matches the same field of the
+// actual object. Arrays match if they have the same length and every element matches.
+// Numbers match if they are numerically equal, so that 64-bit integers, which are
+// strings in JSON responses, may be given as numbers.
+func matchJSON(expected any, actual any, path string) (string, bool) {
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok {
+			return path, false
+		}
+		for _, key := range slices.Sorted(maps.Keys(expected)) {
+			if path, ok := matchJSON(expected[key], actual[key], path+"."+key); !ok {
+				return path, false
+			}
+		}
+		return "", true
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok || len(actual) != len(expected) {
+			return path, false
+		}
+		for i := range expected {
+			if path, ok := matchJSON(expected[i], actual[i], fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return path, false
+			}
+		}
+		return "", true
+	case json.Number:
+		switch actual := actual.(type) {
+		case json.Number:
+			return path, numbersEqual(expected.String(), actual.String())
+		case string:
+			return path, numbersEqual(expected.String(), actual)
+		}
+		return path, false
+	default:
+		return path, reflect.DeepEqual(expected, actual)
+	}
+}
+
+func numbersEqual(a string, b string) bool {
+	aNumber, bNumber := json.Number(a), json.Number(b)
+	if aInt, err := aNumber.Int64(); err == nil {
+		bInt, err := bNumber.Int64()
+		return err == nil && aInt == bInt
+	}
+	aFloat, err := aNumber.Float64()
+	if err != nil {
+		return false
+	}
+	bFloat, err := bNumber.Float64()
+	return err == nil && aFloat == bFloat
+}
+
+func parseCode(s string) (connect.Code, error) {
+	if strings.ToLower(s) == "ok" {
+		return 0, nil
+	}
+	var code connect.Code
+	if err := code.UnmarshalText([]byte(strings.ToLower(s))); err != nil {
+		return 0, fmt.Errorf("unknown code %q", s)
+	}
+	return code, nil
+}
+
+func codeString(code connect.Code) string {
+	if code == 0 {
+		return "ok"
+	}
+	return code.String()
+}
+
+func readJSONOrYAMLFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ErrorHasFilename(err, path)
+	}
+	if err := encoding.UnmarshalJSONOrYAMLStrict(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
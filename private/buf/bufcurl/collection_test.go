@@ -0,0 +1,226 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"buf.build/go/app"
+	"buf.build/go/app/appext"
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+	lookup := (&Environment{
+		Variables: map[string]string{"NAME": "world"},
+	}).VariableLookup(app.NewEnvContainer(map[string]string{"TOKEN": "secret", "NAME": "env"}))
+	interpolated, err := Interpolate("hello ${NAME}, ${TOKEN} $NAME", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world, secret $NAME", interpolated)
+	_, err = Interpolate("${MISSING}", lookup)
+	require.ErrorContains(t, err, "MISSING")
+}
+
+func TestReadCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "collection.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`requests:
+  - name: echo
+    path: bench.v1.BenchService/Echo
+    data:
+      greeting: hello
+    expect:
+      code: ok
+`), 0600))
+	collection, err := ReadCollection(path)
+	require.NoError(t, err)
+	request, err := collection.Request("echo")
+	require.NoError(t, err)
+	assert.Equal(t, "bench.v1.BenchService/Echo", request.Path)
+	_, err = collection.Request("missing")
+	require.Error(t, err)
+
+	for _, invalid := range []string{
+		"requests:\n  - path: a/b\n",
+		"requests:\n  - name: a\n    path: a/b\n  - name: a\n    path: a/b\n",
+		"requests:\n  - name: a\n",
+		"requests:\n  - name: a\n    path: a/b\n    expect:\n      code: bogus\n",
+		"requests:\n  - name: a\n    path: a/b\n    unknown: true\n",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(invalid), 0600))
+		_, err := ReadCollection(path)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMatchJSON(t *testing.T) {
+	t.Parallel()
+	testMatchJSON(t, `{"a": 1}`, `{"a": 1, "b": "x"}`, "", true)
+	testMatchJSON(t, `{"a": 1.0}`, `{"a": 1}`, "", true)
+	testMatchJSON(t, `{"a": 5}`, `{"a": "5"}`, "", true)
+	testMatchJSON(t, `{"a": {"b": [1, 2]}}`, `{"a": {"b": [1, 2]}}`, "", true)
+	testMatchJSON(t, `{"a": 1}`, `{"a": 2}`, ".a", false)
+	testMatchJSON(t, `{"a": 1}`, `{}`, ".a", false)
+	testMatchJSON(t, `{"a": {"b": [1, 2]}}`, `{"a": {"b": [1]}}`, ".a.b", false)
+	testMatchJSON(t, `{"a": [{"b": true}]}`, `{"a": [{"b": false}]}`, ".a[0].b", false)
+}
+
+func TestRunCollection(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.Handle("/bench.v1.BenchService/Echo", connect.NewUnaryHandler(
+		"/bench.v1.BenchService/Echo",
+		func(_ context.Context, request *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+			if request.Header().Get("Authorization") != "Bearer secret" {
+				return nil, connect.NewError(connect.CodeUnauthenticated, nil)
+			}
+			return connect.NewResponse(request.Msg), nil
+		},
+	))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	service := compileBenchService(t)
+	protoencodingResolver, err := protoencoding.NewResolver(
+		protodesc.ToFileDescriptorProto(structpb.File_google_protobuf_struct_proto),
+		protodesc.ToFileDescriptorProto(service.ParentFile()),
+	)
+	require.NoError(t, err)
+	res := testResolver{Resolver: protoencodingResolver}
+	lookup := (&Environment{
+		Variables: map[string]string{"GREETING": "hello"},
+	}).VariableLookup(app.NewEnvContainer(nil))
+	collection := &Collection{
+		Requests: []*CollectionRequest{
+			{
+				Name:    "echo",
+				Path:    "bench.v1.BenchService/Echo",
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+				Data:    map[string]any{"greeting": "${GREETING}", "count": 2},
+				Expect: &CollectionExpectation{
+					Responses: []any{map[string]any{"greeting": "hello"}},
+				},
+			},
+			{
+				Name: "unauthenticated",
+				Path: "bench.v1.BenchService/Echo",
+				Data: `{}`,
+				Expect: &CollectionExpectation{
+					Code: "unauthenticated",
+				},
+			},
+			{
+				Name:    "mismatch",
+				Path:    "bench.v1.BenchService/Echo",
+				Headers: map[string]string{"Authorization": "Bearer secret"},
+				Data:    map[string]any{"greeting": "goodbye"},
+				Expect: &CollectionExpectation{
+					Responses: []any{map[string]any{"greeting": "hello"}},
+				},
+			},
+			{
+				Name: "failed",
+				Path: "bench.v1.BenchService/Echo",
+			},
+		},
+	}
+
+	var stdout bytes.Buffer
+	err = RunCollection(
+		context.Background(),
+		newTestContainerWithStdout(t, &stdout),
+		newTestInvokerFunc(server.Client()),
+		res,
+		server.URL,
+		http.Header{},
+		collection,
+		lookup,
+	)
+	require.EqualError(t, err, "2 of 4 request(s) failed")
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "PASS echo", lines[0])
+	assert.Equal(t, "PASS unauthenticated", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "FAIL mismatch: response 1 does not match at .greeting"), lines[2])
+	assert.Equal(t, "FAIL failed: RPC failed with code unauthenticated", lines[3])
+
+	request, err := collection.Request("echo")
+	require.NoError(t, err)
+	var output bytes.Buffer
+	require.NoError(t, RunCollectionRequest(
+		context.Background(),
+		newTestContainer(t),
+		newTestInvokerFunc(server.Client()),
+		res,
+		server.URL,
+		http.Header{},
+		request,
+		lookup,
+		&output,
+	))
+	assert.Contains(t, output.String(), `"greeting": "hello"`)
+}
+
+type testResolver struct {
+	protoencoding.Resolver
+}
+
+func (testResolver) ListServices() ([]protoreflect.FullName, error) {
+	return nil, nil
+}
+
+func testMatchJSON(t *testing.T, expected string, actual string, expectedPath string, expectedMatch bool) {
+	t.Helper()
+	path, ok := matchJSON(decodeTestJSON(t, expected), decodeTestJSON(t, actual), "")
+	assert.Equal(t, expectedMatch, ok, "%s %s", expected, actual)
+	assert.Equal(t, expectedPath, path, "%s %s", expected, actual)
+}
+
+func decodeTestJSON(t *testing.T, data string) any {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	require.NoError(t, decoder.Decode(&value))
+	return value
+}
+
+func newTestContainerWithStdout(t *testing.T, stdout io.Writer) appext.Container {
+	t.Helper()
+	nameContainer, err := appext.NewNameContainer(
+		app.NewContainer(nil, strings.NewReader(""), stdout, io.Discard),
+		"buf",
+	)
+	require.NoError(t, err)
+	return appext.NewContainer(nameContainer, slog.New(slog.DiscardHandler))
+}
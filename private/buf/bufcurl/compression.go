@@ -0,0 +1,337 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/verbose"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// CompressionIdentity means that messages are not compressed.
+	CompressionIdentity = "identity"
+	// CompressionGzip is the gzip compression.
+	CompressionGzip = "gzip"
+	// CompressionZstd is the Zstandard compression.
+	CompressionZstd = "zstd"
+)
+
+var (
+	// AllCompressionNames are all the valid compression names.
+	AllCompressionNames = []string{
+		CompressionIdentity,
+		CompressionGzip,
+		CompressionZstd,
+	}
+	// DefaultAcceptCompressionNames are the compressions that are accepted for responses
+	// by default.
+	DefaultAcceptCompressionNames = []string{
+		CompressionGzip,
+	}
+)
+
+// CompressionOptions returns the client options to compress request messages with the
+// given compression, and to accept response messages that are compressed with any of the
+// given accepted compressions. The compression of requests is always accepted.
+//
+// The sizes of messages before and after they are compressed or decompressed are written
+// to the printer.
+func CompressionOptions(
+	printer verbose.Printer,
+	compression string,
+	acceptCompressions []string,
+) ([]connect.ClientOption, error) {
+	if !slices.Contains(AllCompressionNames, compression) {
+		return nil, fmt.Errorf("unknown compression %q, must be one of %v", compression, AllCompressionNames)
+	}
+	accepted := make(map[string]struct{})
+	for _, acceptCompression := range acceptCompressions {
+		if !slices.Contains(AllCompressionNames, acceptCompression) {
+			return nil, fmt.Errorf("unknown compression %q, must be one of %v", acceptCompression, AllCompressionNames)
+		}
+		if acceptCompression == CompressionIdentity {
+			if len(acceptCompressions) > 1 {
+				return nil, fmt.Errorf("compression %q cannot be combined with other compressions", CompressionIdentity)
+			}
+			continue
+		}
+		accepted[acceptCompression] = struct{}{}
+	}
+	if compression != CompressionIdentity {
+		accepted[compression] = struct{}{}
+	}
+	var options []connect.ClientOption
+	for _, name := range []string{CompressionGzip, CompressionZstd} {
+		if _, ok := accepted[name]; !ok {
+			// Connect clients accept gzip by default, which nil constructors unregister.
+			options = append(options, connect.WithAcceptCompression(name, nil, nil))
+			continue
+		}
+		newDecompressor, newCompressor := newGzipDecompressor, newGzipCompressor
+		if name == CompressionZstd {
+			newDecompressor, newCompressor = newZstdDecompressor, newZstdCompressor
+		}
+		options = append(
+			options,
+			connect.WithAcceptCompression(
+				name,
+				func() connect.Decompressor {
+					decompressor, err := newDecompressor()
+					if err != nil {
+						return errorDecompressor{err: fmt.Errorf("could not create %s decompressor: %w", name, err)}
+					}
+					return newTracingDecompressor(name, printer, decompressor)
+				},
+				func() connect.Compressor {
+					compressor, err := newCompressor()
+					if err != nil {
+						return errorCompressor{err: fmt.Errorf("could not create %s compressor: %w", name, err)}
+					}
+					return newTracingCompressor(name, printer, compressor)
+				},
+			),
+		)
+	}
+	if compression != CompressionIdentity {
+		options = append(options, connect.WithSendCompression(compression))
+	}
+	return options, nil
+}
+
+// DeadlineInterceptor returns an interceptor that sets a deadline of the given timeout on
+// every RPC, which is sent to the server along with the request.
+func DeadlineInterceptor(timeout time.Duration) connect.Interceptor {
+	return deadlineInterceptor{timeout: timeout}
+}
+
+type deadlineInterceptor struct {
+	timeout time.Duration
+}
+
+func (d deadlineInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, request connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, cancel := context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+		return next(ctx, request)
+	}
+}
+
+func (d deadlineInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ctx, cancel := context.WithTimeout(ctx, d.timeout)
+		return &deadlineClientConn{
+			StreamingClientConn: next(ctx, spec),
+			cancel:              cancel,
+		}
+	}
+}
+
+func (d deadlineInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// deadlineClientConn releases the resources of the deadline of a stream once the
+// response is closed.
+type deadlineClientConn struct {
+	connect.StreamingClientConn
+
+	cancel context.CancelFunc
+}
+
+func (d *deadlineClientConn) CloseResponse() error {
+	defer d.cancel()
+	return d.StreamingClientConn.CloseResponse()
+}
+
+// tracingCompressor writes the sizes of each message before and after it is compressed
+// to the printer.
+type tracingCompressor struct {
+	name         string
+	printer      verbose.Printer
+	compressor   connect.Compressor
+	output       *countingWriter
+	uncompressed int64
+}
+
+func newTracingCompressor(name string, printer verbose.Printer, compressor connect.Compressor) *tracingCompressor {
+	output := &countingWriter{writer: io.Discard}
+	compressor.Reset(output)
+	return &tracingCompressor{
+		name:       name,
+		printer:    printer,
+		compressor: compressor,
+		output:     output,
+	}
+}
+
+func (t *tracingCompressor) Write(data []byte) (int, error) {
+	n, err := t.compressor.Write(data)
+	t.uncompressed += int64(n)
+	return n, err
+}
+
+func (t *tracingCompressor) Close() error {
+	if err := t.compressor.Close(); err != nil {
+		return err
+	}
+	t.printer.Printf("* Compressed request message with %s from %d to %d bytes\n", t.name, t.uncompressed, t.output.count)
+	return nil
+}
+
+func (t *tracingCompressor) Reset(writer io.Writer) {
+	t.output.writer = writer
+	t.output.count = 0
+	t.uncompressed = 0
+	t.compressor.Reset(t.output)
+}
+
+// tracingDecompressor writes the sizes of each message before and after it is
+// decompressed to the printer.
+type tracingDecompressor struct {
+	name         string
+	printer      verbose.Printer
+	decompressor connect.Decompressor
+	input        *countingReader
+	uncompressed int64
+}
+
+func newTracingDecompressor(name string, printer verbose.Printer, decompressor connect.Decompressor) *tracingDecompressor {
+	return &tracingDecompressor{
+		name:         name,
+		printer:      printer,
+		decompressor: decompressor,
+		input:        &countingReader{},
+	}
+}
+
+func (t *tracingDecompressor) Read(data []byte) (int, error) {
+	n, err := t.decompressor.Read(data)
+	t.uncompressed += int64(n)
+	return n, err
+}
+
+func (t *tracingDecompressor) Close() error {
+	if err := t.decompressor.Close(); err != nil {
+		return err
+	}
+	t.printer.Printf("* Decompressed response message with %s from %d to %d bytes\n", t.name, t.input.count, t.uncompressed)
+	return nil
+}
+
+func (t *tracingDecompressor) Reset(reader io.Reader) error {
+	t.input.reader = reader
+	t.input.count = 0
+	t.uncompressed = 0
+	return t.decompressor.Reset(t.input)
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(data []byte) (int, error) {
+	n, err := c.writer.Write(data)
+	c.count += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(data []byte) (int, error) {
+	n, err := c.reader.Read(data)
+	c.count += int64(n)
+	return n, err
+}
+
+func newGzipCompressor() (connect.Compressor, error) {
+	return gzip.NewWriter(io.Discard), nil
+}
+
+func newGzipDecompressor() (connect.Decompressor, error) {
+	return &gzip.Reader{}, nil
+}
+
+func newZstdCompressor() (connect.Compressor, error) {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return encoder, nil
+}
+
+func newZstdDecompressor() (connect.Decompressor, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return zstdDecompressor{Decoder: decoder}, nil
+}
+
+// errorCompressor is a connect.Compressor that could not be constructed, and returns
+// the error of its construction from every call.
+type errorCompressor struct {
+	err error
+}
+
+func (e errorCompressor) Write([]byte) (int, error) {
+	return 0, e.err
+}
+
+func (e errorCompressor) Close() error {
+	return e.err
+}
+
+func (e errorCompressor) Reset(io.Writer) {}
+
+// errorDecompressor is a connect.Decompressor that could not be constructed, and returns
+// the error of its construction from every call.
+type errorDecompressor struct {
+	err error
+}
+
+func (e errorDecompressor) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+func (e errorDecompressor) Close() error {
+	return e.err
+}
+
+func (e errorDecompressor) Reset(io.Reader) error {
+	return e.err
+}
+
+// zstdDecompressor adapts a zstd.Decoder to a connect.Decompressor.
+type zstdDecompressor struct {
+	*zstd.Decoder
+}
+
+func (zstdDecompressor) Close() error {
+	// A closed Decoder cannot be reset, and Connect closes decompressors before reusing
+	// them. Without concurrency, the Decoder holds no resources that must be released.
+	return nil
+}
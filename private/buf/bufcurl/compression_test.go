@@ -0,0 +1,223 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/verbose"
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const compressionTestProto = `syntax = "proto3";
+package compression.v1;
+import "google/protobuf/struct.proto";
+service CompressionService {
+  rpc Get(google.protobuf.Struct) returns (google.protobuf.Struct) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc Post(google.protobuf.Struct) returns (google.protobuf.Struct);
+  rpc Sleep(google.protobuf.Struct) returns (google.protobuf.Struct);
+}
+`
+
+func TestCompressionOptions(t *testing.T) {
+	t.Parallel()
+	testCompression(t, CompressionGzip, []string{CompressionGzip}, "gzip", "gzip")
+	testCompression(t, CompressionZstd, []string{CompressionGzip}, "zstd", "zstd,gzip")
+	testCompression(t, CompressionIdentity, []string{CompressionZstd}, "", "zstd")
+	testCompression(t, CompressionIdentity, []string{CompressionIdentity}, "", "")
+
+	_, err := CompressionOptions(verbose.NopPrinter, "br", nil)
+	require.Error(t, err)
+	_, err = CompressionOptions(verbose.NopPrinter, CompressionIdentity, []string{"br"})
+	require.Error(t, err)
+	_, err = CompressionOptions(verbose.NopPrinter, CompressionIdentity, []string{CompressionIdentity, CompressionGzip})
+	require.Error(t, err)
+}
+
+func TestHTTPGet(t *testing.T) {
+	t.Parallel()
+	var lock sync.Mutex
+	httpMethods := make(map[string]string)
+	server := newCompressionTestServer(t, func(request connect.AnyRequest) {
+		lock.Lock()
+		defer lock.Unlock()
+		httpMethods[request.Spec().Procedure] = request.HTTPMethod()
+	})
+	service := compileCompressionService(t)
+	for _, name := range []protoreflect.Name{"Get", "Post"} {
+		var output bytes.Buffer
+		invoker := NewInvoker(
+			newTestContainer(t),
+			verbose.NopPrinter,
+			service.Methods().ByName(name),
+			protoencoding.EmptyResolver,
+			false,
+			server.Client(),
+			[]connect.ClientOption{connect.WithHTTPGet()},
+			server.URL+"/compression.v1.CompressionService/"+string(name),
+			&output,
+		)
+		require.NoError(t, invoker.Invoke(context.Background(), "", strings.NewReader(`{"a": "b"}`), http.Header{}))
+		assert.JSONEq(t, `{"a": "b"}`, output.String())
+	}
+	assert.Equal(
+		t,
+		map[string]string{
+			"/compression.v1.CompressionService/Get":  http.MethodGet,
+			"/compression.v1.CompressionService/Post": http.MethodPost,
+		},
+		httpMethods,
+	)
+}
+
+func TestDeadlineInterceptor(t *testing.T) {
+	t.Parallel()
+	timeoutHeaders := make(chan string, 1)
+	server := newCompressionTestServer(t, func(request connect.AnyRequest) {
+		timeoutHeaders <- request.Header().Get("Connect-Timeout-Ms")
+	})
+	service := compileCompressionService(t)
+	invoker := NewInvoker(
+		newTestContainer(t),
+		verbose.NopPrinter,
+		service.Methods().ByName("Sleep"),
+		protoencoding.EmptyResolver,
+		false,
+		server.Client(),
+		[]connect.ClientOption{connect.WithInterceptors(DeadlineInterceptor(50 * time.Millisecond))},
+		server.URL+"/compression.v1.CompressionService/Sleep",
+		&bytes.Buffer{},
+	)
+	err := invoker.Invoke(context.Background(), "", nil, http.Header{})
+	code, ok := invokeErrorCode(err)
+	require.True(t, ok)
+	assert.Equal(t, connect.CodeDeadlineExceeded, code)
+	assert.NotEmpty(t, <-timeoutHeaders)
+}
+
+func testCompression(
+	t *testing.T,
+	compression string,
+	acceptCompressions []string,
+	expectedContentEncoding string,
+	expectedAcceptEncoding string,
+) {
+	t.Helper()
+	var contentEncoding, acceptEncoding string
+	server := newCompressionTestServer(t, func(request connect.AnyRequest) {
+		contentEncoding = request.Header().Get("Content-Encoding")
+		acceptEncoding = request.Header().Get("Accept-Encoding")
+	})
+	service := compileCompressionService(t)
+	var trace bytes.Buffer
+	printer := verbose.NewPrinter(&trace, "buf")
+	options, err := CompressionOptions(printer, compression, acceptCompressions)
+	require.NoError(t, err)
+	var output bytes.Buffer
+	invoker := NewInvoker(
+		newTestContainer(t),
+		verbose.NopPrinter,
+		service.Methods().ByName("Post"),
+		protoencoding.EmptyResolver,
+		false,
+		// Transparent compression by the transport would hide the compression of the RPC.
+		&http.Client{Transport: &http.Transport{DisableCompression: true}},
+		options,
+		server.URL+"/compression.v1.CompressionService/Post",
+		&output,
+	)
+	data := `{"message": "` + strings.Repeat("hello ", 100) + `"}`
+	require.NoError(t, invoker.Invoke(context.Background(), "", strings.NewReader(data), http.Header{}))
+	assert.JSONEq(t, data, output.String())
+	assert.Equal(t, expectedContentEncoding, contentEncoding)
+	assert.Equal(t, expectedAcceptEncoding, acceptEncoding)
+	if expectedContentEncoding != "" {
+		assert.Contains(t, trace.String(), "Compressed request message with "+expectedContentEncoding)
+	} else {
+		assert.NotContains(t, trace.String(), "Compressed request message")
+	}
+	if expectedAcceptEncoding != "" {
+		assert.Contains(t, trace.String(), "Decompressed response message with")
+	} else {
+		assert.NotContains(t, trace.String(), "Decompressed response message")
+	}
+}
+
+func newCompressionTestServer(t *testing.T, observe func(connect.AnyRequest)) *httptest.Server {
+	t.Helper()
+	handle := func(ctx context.Context, request *connect.Request[structpb.Struct]) (*connect.Response[structpb.Struct], error) {
+		observe(request)
+		if request.Spec().Procedure == "/compression.v1.CompressionService/Sleep" {
+			<-ctx.Done()
+			return nil, connect.NewError(connect.CodeDeadlineExceeded, ctx.Err())
+		}
+		return connect.NewResponse(request.Msg), nil
+	}
+	handlerOptions := []connect.HandlerOption{
+		connect.WithCompression(
+			CompressionZstd,
+			func() connect.Decompressor {
+				decompressor, err := newZstdDecompressor()
+				require.NoError(t, err)
+				return decompressor
+			},
+			func() connect.Compressor {
+				compressor, err := newZstdCompressor()
+				require.NoError(t, err)
+				return compressor
+			},
+		),
+	}
+	mux := http.NewServeMux()
+	for _, name := range []string{"Get", "Post", "Sleep"} {
+		procedure := "/compression.v1.CompressionService/" + name
+		options := handlerOptions
+		if name == "Get" {
+			options = append(options, connect.WithIdempotency(connect.IdempotencyNoSideEffects))
+		}
+		mux.Handle(procedure, connect.NewUnaryHandler(procedure, handle, options...))
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func compileCompressionService(t *testing.T) protoreflect.ServiceDescriptor {
+	t.Helper()
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"compression.proto": compressionTestProto,
+			}),
+		}),
+	}).Compile(context.Background(), "compression.proto")
+	require.NoError(t, err)
+	return files[0].Services().ByName("CompressionService")
+}
@@ -0,0 +1,231 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufformat"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"github.com/jhump/protoreflect/v2/protoprint"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ResolveDescribeDescriptor resolves the service, method, message, or enum with the
+// given fully-qualified name.
+//
+// Methods may also be named in the form "<service>/<method>", as in URLs.
+func ResolveDescribeDescriptor(res protoencoding.Resolver, symbol string) (protoreflect.Descriptor, error) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(symbol, "."), "/", "."))
+	if !name.IsValid() {
+		return nil, fmt.Errorf("%q is not a valid fully-qualified name", symbol)
+	}
+	descriptor, err := res.FindDescriptorByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", symbol, err)
+	}
+	switch descriptor.(type) {
+	case protoreflect.ServiceDescriptor, protoreflect.MethodDescriptor,
+		protoreflect.MessageDescriptor, protoreflect.EnumDescriptor:
+		return descriptor, nil
+	default:
+		return nil, fmt.Errorf("%q is not a service, method, message, or enum", symbol)
+	}
+}
+
+// DescribeDescriptor returns the Protobuf source of the given service, method, message,
+// or enum, including comments if the schema has source code info.
+//
+// A method is described as a service that only contains the method, followed by its
+// request and response messages, unless they are well-known types. Each element is
+// preceded by the syntax and package of the file that defines it, and is formatted with
+// bufformat.
+func DescribeDescriptor(descriptor protoreflect.Descriptor) (string, error) {
+	printer := &protoprint.Printer{Compact: true}
+	var sources []string
+	switch descriptor := descriptor.(type) {
+	case protoreflect.MethodDescriptor:
+		methodSource, err := printer.PrintProtoToString(descriptor)
+		if err != nil {
+			return "", err
+		}
+		service := descriptor.Parent()
+		source, err := formatSource(
+			descriptor.ParentFile(),
+			fmt.Sprintf("service %s {\n%s}\n", service.Name(), methodSource),
+		)
+		if err != nil {
+			return "", err
+		}
+		sources = append(sources, source)
+		messages := []protoreflect.MessageDescriptor{descriptor.Input()}
+		if descriptor.Output().FullName() != descriptor.Input().FullName() {
+			messages = append(messages, descriptor.Output())
+		}
+		for _, message := range messages {
+			if isWellKnownType(message) {
+				continue
+			}
+			messageSource, err := printer.PrintProtoToString(message)
+			if err != nil {
+				return "", err
+			}
+			source, err := formatSource(message.ParentFile(), messageSource)
+			if err != nil {
+				return "", err
+			}
+			sources = append(sources, source)
+		}
+	default:
+		elementSource, err := printer.PrintProtoToString(descriptor)
+		if err != nil {
+			return "", err
+		}
+		source, err := formatSource(descriptor.ParentFile(), elementSource)
+		if err != nil {
+			return "", err
+		}
+		sources = append(sources, source)
+	}
+	return strings.Join(sources, "\n"), nil
+}
+
+// NewRequestTemplate returns a JSON request for the given method or message, with every
+// field set to its default value.
+//
+// Repeated fields and maps contain a single default element, and only the first field of
+// each oneof is set. Fields of a message type that is already being filled in, which would
+// otherwise recurse forever, are left unset.
+func NewRequestTemplate(res protoencoding.Resolver, descriptor protoreflect.Descriptor) ([]byte, error) {
+	var messageDescriptor protoreflect.MessageDescriptor
+	switch descriptor := descriptor.(type) {
+	case protoreflect.MethodDescriptor:
+		messageDescriptor = descriptor.Input()
+	case protoreflect.MessageDescriptor:
+		messageDescriptor = descriptor
+	default:
+		return nil, fmt.Errorf("a request template can only be created for a method or message, not %s", descriptor.FullName())
+	}
+	message := dynamicpb.NewMessage(messageDescriptor)
+	populateTemplate(message, make(map[protoreflect.FullName]struct{}))
+	return protoencoding.NewJSONMarshaler(
+		res,
+		protoencoding.JSONMarshalerWithIndent(),
+		protoencoding.JSONMarshalerWithEmitUnpopulated(),
+	).Marshal(message)
+}
+
+// formatSource formats the source of a single element of the given file with bufformat.
+func formatSource(file protoreflect.FileDescriptor, elementSource string) (string, error) {
+	var source strings.Builder
+	switch file.Syntax() {
+	case protoreflect.Editions:
+		edition := protodesc.ToFileDescriptorProto(file).GetEdition()
+		fmt.Fprintf(&source, "edition = %q;\n\n", strings.TrimPrefix(edition.String(), "EDITION_"))
+	default:
+		fmt.Fprintf(&source, "syntax = %q;\n\n", file.Syntax().String())
+	}
+	if file.Package() != "" {
+		fmt.Fprintf(&source, "package %s;\n\n", file.Package())
+	}
+	source.WriteString(elementSource)
+	// The source is not linked, so references to types that are not imported are fine.
+	fileNode, err := parser.Parse(file.Path(), strings.NewReader(source.String()), reporter.NewHandler(nil))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse source of %s: %w", file.Path(), err)
+	}
+	var formatted bytes.Buffer
+	if err := bufformat.FormatFileNode(&formatted, fileNode); err != nil {
+		return "", err
+	}
+	return formatted.String(), nil
+}
+
+// populateTemplate sets every field of the message to its default value.
+//
+// visiting contains the messages that are currently being populated.
+func populateTemplate(message protoreflect.Message, visiting map[protoreflect.FullName]struct{}) {
+	descriptor := message.Descriptor()
+	if isWellKnownType(descriptor) {
+		// Well-known types have custom JSON representations, of which the empty message
+		// is the default.
+		return
+	}
+	visiting[descriptor.FullName()] = struct{}{}
+	defer delete(visiting, descriptor.FullName())
+	fields := descriptor.Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && oneof.Fields().Get(0) != field {
+			continue
+		}
+		switch {
+		case field.IsMap():
+			mapValue := message.Mutable(field).Map()
+			value, ok := templateValue(field.MapValue(), mapValue.NewValue, visiting)
+			if ok {
+				mapValue.Set(field.MapKey().Default().MapKey(), value)
+			}
+		case field.IsList():
+			list := message.Mutable(field).List()
+			if value, ok := templateValue(field, list.NewElement, visiting); ok {
+				list.Append(value)
+			}
+		default:
+			newField := func() protoreflect.Value { return message.NewField(field) }
+			if value, ok := templateValue(field, newField, visiting); ok {
+				message.Set(field, value)
+			}
+		}
+	}
+}
+
+// templateValue returns the default value of a single element of the field, or false
+// if the field should be left unset. newValue returns a new element of the field.
+func templateValue(
+	field protoreflect.FieldDescriptor,
+	newValue func() protoreflect.Value,
+	visiting map[protoreflect.FullName]struct{},
+) (protoreflect.Value, bool) {
+	message := field.Message()
+	if message == nil {
+		value := newValue()
+		if enum := field.Enum(); enum != nil && enum.Values().ByNumber(value.Enum()) == nil {
+			// Closed enums may not have a zero value, so we use the first value instead.
+			return protoreflect.ValueOfEnum(enum.Values().Get(0).Number()), true
+		}
+		return value, true
+	}
+	if _, ok := visiting[message.FullName()]; ok {
+		return protoreflect.Value{}, false
+	}
+	if message.FullName() == "google.protobuf.Value" {
+		// An empty Value cannot be marshaled, and null is its default.
+		return protoreflect.Value{}, false
+	}
+	value := newValue()
+	populateTemplate(value.Message(), visiting)
+	return value, true
+}
+
+func isWellKnownType(message protoreflect.MessageDescriptor) bool {
+	return message.ParentFile().Package() == "google.protobuf"
+}
@@ -0,0 +1,232 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const describeTestProto = `syntax = "proto3";
+
+package describe.v1;
+
+import "google/protobuf/timestamp.proto";
+
+// UserService manages users.
+service UserService {
+  // GetUser returns a user.
+  rpc GetUser(GetUserRequest) returns (User);
+  // DeleteUser deletes a user.
+  rpc DeleteUser(GetUserRequest) returns (GetUserRequest);
+}
+
+// GetUserRequest is the request for GetUser.
+message GetUserRequest {
+  // The ID of the user.
+  string id = 1;
+}
+
+// User is a user.
+message User {
+  string name = 1;
+  int64 age = 2;
+  Role role = 3;
+  repeated string tags = 4;
+  map<string, User> friends = 5;
+  User manager = 6;
+  google.protobuf.Timestamp created = 7;
+  oneof contact {
+    string email = 8;
+    string phone = 9;
+  }
+  optional bool active = 10;
+  Address address = 11;
+}
+
+message Address {
+  string street = 1;
+  repeated Address previous = 2;
+}
+
+// Role is the role of a user.
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+`
+
+func TestDescribeDescriptor(t *testing.T) {
+	t.Parallel()
+	resolver := compileDescribeTestResolver(t)
+	testCases := []struct {
+		symbol   string
+		expected string
+	}{
+		{
+			symbol: "describe.v1.UserService",
+			expected: `syntax = "proto3";
+
+package describe.v1;
+
+// UserService manages users.
+service UserService {
+  // GetUser returns a user.
+  rpc GetUser(GetUserRequest) returns (User);
+  // DeleteUser deletes a user.
+  rpc DeleteUser(GetUserRequest) returns (GetUserRequest);
+}
+`,
+		},
+		{
+			symbol: "describe.v1.UserService/DeleteUser",
+			expected: `syntax = "proto3";
+
+package describe.v1;
+
+service UserService {
+  // DeleteUser deletes a user.
+  rpc DeleteUser(GetUserRequest) returns (GetUserRequest);
+}
+
+syntax = "proto3";
+
+package describe.v1;
+
+// GetUserRequest is the request for GetUser.
+message GetUserRequest {
+  // The ID of the user.
+  string id = 1;
+}
+`,
+		},
+		{
+			symbol: "describe.v1.Address",
+			expected: `syntax = "proto3";
+
+package describe.v1;
+
+message Address {
+  string street = 1;
+  repeated Address previous = 2;
+}
+`,
+		},
+		{
+			symbol: ".describe.v1.Role",
+			expected: `syntax = "proto3";
+
+package describe.v1;
+
+// Role is the role of a user.
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.symbol, func(t *testing.T) {
+			t.Parallel()
+			descriptor, err := ResolveDescribeDescriptor(resolver, testCase.symbol)
+			require.NoError(t, err)
+			source, err := DescribeDescriptor(descriptor)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, source)
+		})
+	}
+
+	t.Run("method", func(t *testing.T) {
+		t.Parallel()
+		descriptor, err := ResolveDescribeDescriptor(resolver, "describe.v1.UserService.GetUser")
+		require.NoError(t, err)
+		source, err := DescribeDescriptor(descriptor)
+		require.NoError(t, err)
+		assert.Contains(t, source, "rpc GetUser(GetUserRequest) returns (User);")
+		assert.Contains(t, source, "message GetUserRequest {")
+		assert.Contains(t, source, "// User is a user.\nmessage User {")
+	})
+	t.Run("field", func(t *testing.T) {
+		t.Parallel()
+		_, err := ResolveDescribeDescriptor(resolver, "describe.v1.User.name")
+		require.Error(t, err)
+	})
+}
+
+func TestNewRequestTemplate(t *testing.T) {
+	t.Parallel()
+	resolver := compileDescribeTestResolver(t)
+	descriptor, err := ResolveDescribeDescriptor(resolver, "describe.v1.UserService/GetUser")
+	require.NoError(t, err)
+	template, err := NewRequestTemplate(resolver, descriptor)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": ""}`, string(template))
+
+	descriptor, err = ResolveDescribeDescriptor(resolver, "describe.v1.User")
+	require.NoError(t, err)
+	template, err = NewRequestTemplate(resolver, descriptor)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"name": "",
+			"age": "0",
+			"role": "ROLE_UNSPECIFIED",
+			"tags": [""],
+			"friends": {},
+			"manager": null,
+			"created": "1970-01-01T00:00:00Z",
+			"email": "",
+			"active": false,
+			"address": {
+				"street": "",
+				"previous": []
+			}
+		}`,
+		string(template),
+	)
+
+	descriptor, err = ResolveDescribeDescriptor(resolver, "describe.v1.Role")
+	require.NoError(t, err)
+	_, err = NewRequestTemplate(resolver, descriptor)
+	require.Error(t, err)
+}
+
+func compileDescribeTestResolver(t *testing.T) protoencoding.Resolver {
+	t.Helper()
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"describe.proto": describeTestProto,
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}).Compile(context.Background(), "describe.proto")
+	require.NoError(t, err)
+	resolver, err := protoencoding.NewResolver(
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		protodesc.ToFileDescriptorProto(files[0]),
+	)
+	require.NoError(t, err)
+	return resolver
+}
@@ -24,6 +24,7 @@ import (
 	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync"
 
 	"buf.build/go/app"
@@ -33,6 +34,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/verbose"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
@@ -54,6 +56,16 @@ func (p protoCodec) Marshal(a any) ([]byte, error) {
 	return protoencoding.NewWireMarshaler().Marshal(protoMessage)
 }
 
+// MarshalStable is used by the Connect protocol to encode requests sent with HTTP
+// GET. The wire marshaler is already deterministic.
+func (p protoCodec) MarshalStable(a any) ([]byte, error) {
+	return p.Marshal(a)
+}
+
+func (p protoCodec) IsBinary() bool {
+	return true
+}
+
 func (p protoCodec) Unmarshal(bytes []byte, a any) error {
 	if deferred, ok := a.(*deferredMessage); ok {
 		// must make a copy since Connect framework will re-use the byte slice
@@ -86,10 +98,16 @@ type invoker struct {
 // extensions that appear in the input or output. Other parameters are used
 // to create a Connect client, for issuing the RPC.
 func NewInvoker(container appext.Container, verbosePrinter verbose.Printer, md protoreflect.MethodDescriptor, res protoencoding.Resolver, emitDefaults bool, httpClient connect.HTTPClient, opts []connect.ClientOption, url string, out io.Writer) Invoker {
-	opts = append(opts, connect.WithCodec(protoCodec{}))
-	// TODO: could also provide custom compressor implementations that could give us
-	//  optics into when request and response messages are compressed (which could be
-	//  useful to include in verbose output).
+	opts = append(slices.Clone(opts), connect.WithCodec(protoCodec{}))
+	if methodOptions, ok := md.Options().(*descriptorpb.MethodOptions); ok {
+		// The idempotency level determines whether the Connect protocol may use HTTP GET.
+		switch methodOptions.GetIdempotencyLevel() {
+		case descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+			opts = append(opts, connect.WithIdempotency(connect.IdempotencyNoSideEffects))
+		case descriptorpb.MethodOptions_IDEMPOTENT:
+			opts = append(opts, connect.WithIdempotency(connect.IdempotencyIdempotent))
+		}
+	}
 	return &invoker{
 		md:           md,
 		res:          res,
@@ -122,12 +140,12 @@ func (inv *invoker) handleUnary(ctx context.Context, dataSource string, data io.
 	provider := newMessageProvider(dataSource, data, inv.res)
 	msg := dynamicpb.NewMessage(inv.md.Input())
 	if err := provider.next(msg); err != nil {
-		return err
+		return &requestDataError{err: err}
 	}
 	// make sure input does not contain a second message
 	dummy := dynamicpb.NewMessage(inv.md.Input())
 	if err := provider.next(dummy); err != io.EOF {
-		return fmt.Errorf("method %s is a unary RPC, but input contained more than one request message", inv.md.Name())
+		return &requestDataError{err: fmt.Errorf("method %s is a unary RPC, but input contained more than one request message", inv.md.Name())}
 	}
 
 	req := connect.NewRequest(msg)
@@ -179,12 +197,12 @@ func (inv *invoker) handleServerStream(ctx context.Context, dataSource string, d
 	provider := newMessageProvider(dataSource, data, inv.res)
 	msg := dynamicpb.NewMessage(inv.md.Input())
 	if err := provider.next(msg); err != nil {
-		return err
+		return &requestDataError{err: err}
 	}
 	// make sure input does not contain a second message
 	dummy := dynamicpb.NewMessage(inv.md.Input())
 	if err := provider.next(dummy); err != io.EOF {
-		return fmt.Errorf("method %s is a unary RPC, but input contained more than one request message", inv.md.Name())
+		return &requestDataError{err: fmt.Errorf("method %s is a unary RPC, but input contained more than one request message", inv.md.Name())}
 	}
 
 	req := connect.NewRequest(msg)
@@ -329,7 +347,7 @@ func (inv *invoker) handleStreamRequest(provider messageProvider, msg *dynamicpb
 		if err := provider.next(msg); errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return err, false
+			return &requestDataError{err: err}, false
 		}
 		if err := stream.Send(msg); err != nil {
 			return err, true
@@ -385,7 +403,55 @@ func (inv *invoker) handleErrorResponse(connErr *connect.Error) error {
 	}
 	_, _ = inv.errOutput.Write(prettyPrinted.Bytes())
 	_, _ = inv.errOutput.Write([]byte("\n"))
-	return app.NewError(int(connErr.Code()*8), "")
+	return &errorResponseError{
+		exitErr:    app.NewError(int(connErr.Code()*8), ""),
+		connectErr: connErr,
+	}
+}
+
+// errorResponseError is returned by Invoke if the RPC failed with an error, after
+// the error has been written to the error output. It unwraps to the error that sets
+// the exit code of the command, and keeps the *connect.Error for invokeErrorCode.
+type errorResponseError struct {
+	exitErr    error
+	connectErr *connect.Error
+}
+
+func (e *errorResponseError) Error() string {
+	return e.exitErr.Error()
+}
+
+func (e *errorResponseError) Unwrap() error {
+	return e.exitErr
+}
+
+// invokeErrorCode returns the code of an error returned by Invoker.Invoke, and whether
+// the error is an RPC error. The code of a nil error is zero, and the code of an error
+// that is not an RPC error is unknown.
+func invokeErrorCode(err error) (connect.Code, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var errorResponseErr *errorResponseError
+	if errors.As(err, &errorResponseErr) {
+		err = errorResponseErr.connectErr
+	}
+	var connectErr *connect.Error
+	return connect.CodeOf(err), errors.As(err, &connectErr)
+}
+
+// requestDataError is returned by Invoke if the request data could not be read
+// or is invalid for the method.
+type requestDataError struct {
+	err error
+}
+
+func (e *requestDataError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestDataError) Unwrap() error {
+	return e.err
 }
 
 func newStreamMessageProvider(dataSource string, data io.Reader, res protoencoding.Resolver) messageProvider {
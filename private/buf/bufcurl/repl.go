@@ -0,0 +1,689 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"buf.build/go/app/appext"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	replPrompt       = "> "
+	replStreamPrompt = "stream> "
+
+	replHelp = `Enter a method to invoke it, followed by an optional JSON request:
+
+    <service>/<method> [<request>]
+
+For client-streaming and bidi-streaming methods, each following line is sent as a
+request message. Enter .end to close the request stream.
+
+Commands:
+
+    .services            List all services
+    .methods [<service>] List all methods, or the methods of a service
+    .cancel              Cancel the current RPC
+    .end                 Close the request stream of the current RPC
+    .help                Print this help
+    .exit                Exit
+
+Press tab to complete service, method, and request field names.
+`
+)
+
+var (
+	replCommands = []string{".cancel", ".end", ".exit", ".help", ".methods", ".services"}
+
+	// errREPLCallDone is used to close the request stream of an RPC that has completed.
+	errREPLCallDone = errors.New("RPC has completed")
+)
+
+// NewInvokerFunc returns an Invoker for the given method at the given URL, which writes
+// responses to the output and errors to the container's stderr.
+type NewInvokerFunc func(
+	container appext.Container,
+	methodDescriptor protoreflect.MethodDescriptor,
+	url string,
+	output io.Writer,
+) Invoker
+
+// RunREPL runs an interactive session that invokes RPCs of the server at the base URL,
+// until the input is exhausted or the user exits.
+//
+// All RPCs are invoked with Invokers from newInvoker, so that they can share a single
+// transport and keep the same connection to the server alive. Likewise, the resolver is
+// shared by all RPCs, so that reflection requests are only made once per schema element.
+//
+// If stdin is a terminal, it is put into raw mode for line editing and tab completion.
+func RunREPL(
+	ctx context.Context,
+	container appext.Container,
+	resolver Resolver,
+	baseURL string,
+	headers http.Header,
+	newInvoker NewInvokerFunc,
+) (retErr error) {
+	repl := &repl{
+		container:  container,
+		resolver:   resolver,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		headers:    headers,
+		newInvoker: newInvoker,
+		output:     container.Stdout(),
+	}
+	var reader replLineReader
+	if file, ok := container.Stdin().(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		state, err := term.MakeRaw(int(file.Fd()))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, term.Restore(int(file.Fd()), state))
+		}()
+		terminal := term.NewTerminal(
+			struct {
+				io.Reader
+				io.Writer
+			}{file, container.Stdout()},
+			replPrompt,
+		)
+		terminal.AutoCompleteCallback = repl.autoComplete
+		repl.terminal = terminal
+		repl.output = terminal
+		reader = terminal
+		if _, err := fmt.Fprintln(repl.output, "Type .help for help."); err != nil {
+			return err
+		}
+	} else {
+		reader = &scannerLineReader{scanner: bufio.NewScanner(container.Stdin())}
+	}
+	repl.lines = &asyncLineReader{
+		reader:      reader,
+		interactive: repl.terminal != nil,
+		results:     make(chan lineResult, 1),
+	}
+	return repl.run(ctx)
+}
+
+type repl struct {
+	container  appext.Container
+	resolver   Resolver
+	baseURL    string
+	headers    http.Header
+	newInvoker NewInvokerFunc
+	output     io.Writer
+	lines      *asyncLineReader
+	// terminal is nil if stdin is not a terminal.
+	terminal *term.Terminal
+
+	// streamInput is the input message type of the client stream that request
+	// messages are currently being sent to, for completion. It is guarded by lock,
+	// since completion runs on the goroutine that reads lines.
+	lock        sync.Mutex
+	streamInput protoreflect.MessageDescriptor
+}
+
+func (r *repl) run(ctx context.Context) error {
+	for {
+		if r.lines.exhausted {
+			return nil
+		}
+		var result lineResult
+		select {
+		case result = <-r.lines.next():
+			r.lines.received(result)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if errors.Is(result.err, io.EOF) {
+			return nil
+		} else if result.err != nil {
+			return result.err
+		}
+		line := strings.TrimSpace(result.line)
+		command, args, _ := strings.Cut(line, " ")
+		args = strings.TrimSpace(args)
+		var err error
+		switch command {
+		case "":
+			continue
+		case ".exit", ".quit":
+			return nil
+		case ".help":
+			_, err = fmt.Fprint(r.output, replHelp)
+		case ".services":
+			err = r.printServices()
+		case ".methods":
+			err = r.printMethods(args)
+		case ".cancel", ".end":
+			_, err = fmt.Fprintf(r.output, "%s: no RPC is in progress\n", command)
+		default:
+			err = r.invoke(ctx, command, args)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Errors are printed, but do not end the session.
+			r.printError(err)
+		}
+	}
+}
+
+// invoke invokes the method named by target, in the form "service/method", with the
+// given request data.
+func (r *repl) invoke(ctx context.Context, target string, data string) error {
+	methodDescriptor, err := r.resolveMethod(target)
+	if err != nil {
+		return err
+	}
+	url := r.baseURL + "/" + string(methodDescriptor.Parent().FullName()) + "/" + string(methodDescriptor.Name())
+	container := &stderrContainer{Container: r.container, stderr: r.output}
+	invoker := r.newInvoker(container, methodDescriptor, url, r.output)
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var dataReader io.Reader
+	var requestWriter *io.PipeWriter
+	if methodDescriptor.IsStreamingClient() {
+		// Request messages are written to the pipe as they are entered, and are read by
+		// the invoker one at a time as they arrive.
+		var pipeReader *io.PipeReader
+		pipeReader, requestWriter = io.Pipe()
+		dataReader = pipeReader
+		defer func() {
+			_ = pipeReader.CloseWithError(errREPLCallDone)
+		}()
+		r.setStreamInput(methodDescriptor.Input())
+		defer r.setStreamInput(nil)
+		r.setPrompt(replStreamPrompt)
+		defer r.setPrompt(replPrompt)
+	} else if data != "" {
+		dataReader = strings.NewReader(data)
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := invoker.Invoke(callCtx, "(interactive)", dataReader, r.headers)
+		if pipeReader, ok := dataReader.(*io.PipeReader); ok {
+			// Unblock any pending writes of request messages.
+			_ = pipeReader.CloseWithError(errREPLCallDone)
+		}
+		done <- err
+	}()
+	if requestWriter != nil && data != "" {
+		if err := r.sendRequest(requestWriter, data); err != nil {
+			cancel()
+			return errors.Join(err, <-done)
+		}
+	}
+
+	for {
+		// Unless the user can interrupt the RPC, input is only read while there is a
+		// request stream to send it to, so that following lines wait for the RPC to complete.
+		var lines <-chan lineResult
+		if requestWriter != nil || r.lines.interactive {
+			lines = r.lines.next()
+		}
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), <-done)
+		case result := <-lines:
+			r.lines.received(result)
+			line := strings.TrimSpace(result.line)
+			switch {
+			case line == ".cancel" || (errors.Is(result.err, io.EOF) && requestWriter == nil):
+				// EOF while no request stream is open cancels the RPC.
+				cancel()
+			case line == ".end" || errors.Is(result.err, io.EOF):
+				if requestWriter != nil {
+					_ = requestWriter.Close()
+					requestWriter = nil
+					r.setPrompt(replPrompt)
+				}
+			case result.err != nil:
+				cancel()
+				return errors.Join(result.err, <-done)
+			case line == "":
+			case requestWriter != nil:
+				if err := r.sendRequest(requestWriter, line); err != nil {
+					// The RPC completed before the request could be sent.
+					requestWriter = nil
+				}
+			default:
+				if _, err := fmt.Fprintln(r.output, "An RPC is in progress. Enter .cancel to cancel it."); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// sendRequest writes a request message to the request stream of an RPC.
+func (r *repl) sendRequest(requestWriter io.Writer, data string) error {
+	_, err := io.WriteString(requestWriter, data+"\n")
+	return err
+}
+
+func (r *repl) resolveMethod(target string) (protoreflect.MethodDescriptor, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(target, "/"), "/")
+	if !ok || service == "" || method == "" {
+		return nil, fmt.Errorf("%q is not a command or a method in the form <service>/<method>; type .help for help", target)
+	}
+	return ResolveMethodDescriptor(r.resolver, service, method)
+}
+
+func (r *repl) printServices() error {
+	serviceNames, err := r.serviceNames()
+	if err != nil {
+		return err
+	}
+	for _, serviceName := range serviceNames {
+		if _, err := fmt.Fprintln(r.output, serviceName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repl) printMethods(service string) error {
+	serviceNames := []string{service}
+	if service == "" {
+		var err error
+		if serviceNames, err = r.serviceNames(); err != nil {
+			return err
+		}
+	}
+	for _, serviceName := range serviceNames {
+		methodNames, err := r.methodNames(serviceName)
+		if err != nil {
+			return err
+		}
+		for _, methodName := range methodNames {
+			if _, err := fmt.Fprintf(r.output, "%s/%s\n", serviceName, methodName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *repl) printError(err error) {
+	if err.Error() == "" {
+		// The error response of the RPC has already been printed by the invoker.
+		return
+	}
+	_, _ = fmt.Fprintf(r.output, "Error: %v\n", err)
+}
+
+func (r *repl) setPrompt(prompt string) {
+	if r.terminal != nil {
+		r.terminal.SetPrompt(prompt)
+	}
+}
+
+func (r *repl) setStreamInput(streamInput protoreflect.MessageDescriptor) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.streamInput = streamInput
+}
+
+// serviceNames returns the sorted names of all services.
+func (r *repl) serviceNames() ([]string, error) {
+	fullNames, err := r.resolver.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	serviceNames := make([]string, len(fullNames))
+	for i, fullName := range fullNames {
+		serviceNames[i] = string(fullName)
+	}
+	slices.Sort(serviceNames)
+	return slices.Compact(serviceNames), nil
+}
+
+// methodNames returns the sorted names of the methods of a service.
+func (r *repl) methodNames(service string) ([]string, error) {
+	serviceDescriptor, err := ResolveServiceDescriptor(r.resolver, service)
+	if err != nil {
+		return nil, err
+	}
+	methods := serviceDescriptor.Methods()
+	methodNames := make([]string, methods.Len())
+	for i := range methods.Len() {
+		methodNames[i] = string(methods.Get(i).Name())
+	}
+	slices.Sort(methodNames)
+	return methodNames, nil
+}
+
+// autoComplete implements term.Terminal.AutoCompleteCallback.
+func (r *repl) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	prefix := line[:pos]
+	start, candidates := r.complete(prefix)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+	completion := candidates[0]
+	for _, candidate := range candidates[1:] {
+		completion = commonPrefix(completion, candidate)
+	}
+	if len(completion) <= len(prefix)-start {
+		// Nothing more can be completed, so we list the candidates instead.
+		_, _ = fmt.Fprintln(r.output, strings.Join(candidates, "  "))
+		return "", 0, false
+	}
+	newLine := prefix[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// complete returns the candidates to replace prefix[start:] with.
+func (r *repl) complete(prefix string) (int, []string) {
+	r.lock.Lock()
+	streamInput := r.streamInput
+	r.lock.Unlock()
+	if streamInput != nil {
+		return completeJSONFieldNames(streamInput, prefix)
+	}
+	command, args, hasArgs := strings.Cut(prefix, " ")
+	if !hasArgs {
+		if strings.HasPrefix(command, ".") {
+			return 0, filterPrefix(replCommands, command)
+		}
+		return 0, r.completeTarget(command)
+	}
+	switch command {
+	case ".methods":
+		serviceNames, _ := r.serviceNames()
+		return len(command) + 1, filterPrefix(serviceNames, strings.TrimLeft(args, " "))
+	case ".cancel", ".end", ".exit", ".help", ".services":
+		return 0, nil
+	}
+	methodDescriptor, err := r.resolveMethod(command)
+	if err != nil {
+		return 0, nil
+	}
+	start, candidates := completeJSONFieldNames(methodDescriptor.Input(), args)
+	return len(command) + 1 + start, candidates
+}
+
+// completeTarget completes a target in the form "service/method".
+func (r *repl) completeTarget(target string) []string {
+	service, method, hasMethod := strings.Cut(target, "/")
+	if !hasMethod {
+		serviceNames, _ := r.serviceNames()
+		candidates := filterPrefix(serviceNames, service)
+		for i := range candidates {
+			candidates[i] += "/"
+		}
+		return candidates
+	}
+	methodNames, _ := r.methodNames(service)
+	candidates := filterPrefix(methodNames, method)
+	for i := range candidates {
+		candidates[i] = service + "/" + candidates[i]
+	}
+	return candidates
+}
+
+// completeJSONFieldNames completes the name of a field in a JSON request of the given
+// message type, where prefix is the request up to the cursor.
+//
+// Returns the candidates to replace prefix[start:] with, which are quoted field names
+// followed by a colon. No candidates are returned if the cursor is not at a field name.
+func completeJSONFieldNames(root protoreflect.MessageDescriptor, prefix string) (int, []string) {
+	type frame struct {
+		// message is nil if the keys of this object are not field names, or if this
+		// is an array.
+		message protoreflect.MessageDescriptor
+		// element is the message type of the elements of an array, or the values of
+		// a map.
+		element      protoreflect.MessageDescriptor
+		isArray      bool
+		expectingKey bool
+		field        protoreflect.FieldDescriptor
+		seenKeys     map[string]struct{}
+	}
+	var stack []*frame
+	var inString, escaped, isKey, seenRoot bool
+	stringStart := 0
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if isKey {
+					top := stack[len(stack)-1]
+					key := prefix[stringStart+1 : i]
+					top.expectingKey = false
+					top.seenKeys[key] = struct{}{}
+					top.field = nil
+					if top.message != nil {
+						top.field = findJSONField(top.message, key)
+					}
+				}
+			}
+			continue
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		switch c {
+		case '"':
+			inString = true
+			stringStart = i
+			isKey = top != nil && !top.isArray && top.expectingKey
+		case '{':
+			var message, element protoreflect.MessageDescriptor
+			switch {
+			case top == nil:
+				// Only the first top-level object is the request message.
+				if !seenRoot {
+					message = root
+				}
+				seenRoot = true
+			case top.isArray:
+				message = top.element
+			case top.field != nil && top.field.IsMap():
+				element = top.field.MapValue().Message()
+			case top.field != nil:
+				message = top.field.Message()
+			case top.element != nil:
+				// A value of a map.
+				message = top.element
+			}
+			if message != nil && message.ParentFile().Package() == "google.protobuf" {
+				// Well-known types have custom JSON representations.
+				message = nil
+			}
+			stack = append(stack, &frame{
+				message:      message,
+				element:      element,
+				expectingKey: true,
+				seenKeys:     make(map[string]struct{}),
+			})
+		case '[':
+			var element protoreflect.MessageDescriptor
+			if top != nil && top.field != nil && top.field.IsList() {
+				element = top.field.Message()
+			}
+			stack = append(stack, &frame{isArray: true, element: element})
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			if top != nil && !top.isArray {
+				top.expectingKey = true
+				top.field = nil
+			}
+		}
+	}
+	if len(stack) == 0 {
+		return 0, nil
+	}
+	top := stack[len(stack)-1]
+	if top.isArray || top.message == nil {
+		return 0, nil
+	}
+	start := len(prefix)
+	partial := ""
+	switch {
+	case inString && isKey:
+		start = stringStart
+		partial = prefix[stringStart+1:]
+	case !inString && top.expectingKey:
+	default:
+		return 0, nil
+	}
+	var candidates []string
+	fields := top.message.Fields()
+	for i := range fields.Len() {
+		jsonName := fields.Get(i).JSONName()
+		if _, ok := top.seenKeys[jsonName]; ok {
+			continue
+		}
+		if strings.HasPrefix(jsonName, partial) {
+			candidates = append(candidates, `"`+jsonName+`": `)
+		}
+	}
+	slices.Sort(candidates)
+	return start, candidates
+}
+
+// findJSONField returns the field of the message with the given JSON or proto name.
+func findJSONField(message protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := message.Fields()
+	if field := fields.ByJSONName(name); field != nil {
+		return field
+	}
+	return fields.ByName(protoreflect.Name(name))
+}
+
+func filterPrefix(values []string, prefix string) []string {
+	var filtered []string
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// stderrContainer is a container that writes errors to another writer.
+type stderrContainer struct {
+	appext.Container
+
+	stderr io.Writer
+}
+
+func (c *stderrContainer) Stderr() io.Writer {
+	return c.stderr
+}
+
+// replLineReader reads lines of input.
+type replLineReader interface {
+	// ReadLine returns the next line, or io.EOF if there are no more lines.
+	ReadLine() (string, error)
+}
+
+type scannerLineReader struct {
+	scanner *bufio.Scanner
+}
+
+func (s *scannerLineReader) ReadLine() (string, error) {
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+// asyncLineReader reads lines in the background, so that the REPL can wait for both
+// input and the completion of an RPC. At most one line is read at a time.
+type asyncLineReader struct {
+	reader replLineReader
+	// interactive is true if the user can keep entering lines after io.EOF, such as by
+	// pressing Ctrl-D in a terminal.
+	interactive bool
+	results     chan lineResult
+	pending     bool
+	// exhausted is true if there are no more lines to read.
+	exhausted bool
+}
+
+// next returns a channel that receives the next line. Once a line is received from
+// the channel, it must be passed to received before next is called again.
+//
+// The channel never receives if the input is exhausted.
+func (a *asyncLineReader) next() <-chan lineResult {
+	if a.exhausted {
+		return nil
+	}
+	if !a.pending {
+		a.pending = true
+		go func() {
+			line, err := a.reader.ReadLine()
+			a.results <- lineResult{line: line, err: err}
+		}()
+	}
+	return a.results
+}
+
+// received records that a line was received from the channel returned by next.
+func (a *asyncLineReader) received(result lineResult) lineResult {
+	a.pending = false
+	if errors.Is(result.err, io.EOF) && !a.interactive {
+		a.exhausted = true
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcurl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestCompleteJSONFieldNames(t *testing.T) {
+	t.Parallel()
+	descriptors, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			ImportPaths: []string{"./testdata"},
+		},
+	}).Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	descriptor, err := descriptors.AsResolver().FindDescriptorByName("foo.bar.Message")
+	require.NoError(t, err)
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	require.True(t, ok)
+
+	testCases := []struct {
+		prefix             string
+		expectedStart      int
+		expectedCandidates []string
+	}{
+		{
+			prefix:             `{"rm`,
+			expectedStart:      1,
+			expectedCandidates: []string{`"rmsg": `},
+		},
+		{
+			prefix:             `{"msg": {"i6`,
+			expectedStart:      9,
+			expectedCandidates: []string{`"i64": `},
+		},
+		{
+			prefix:             `{"rmsg": [{}, {"fl`,
+			expectedStart:      15,
+			expectedCandidates: []string{`"fl": `},
+		},
+		{
+			prefix:             `{"mvmsg": {"key": {"b`,
+			expectedStart:      19,
+			expectedCandidates: []string{`"b": `, `"bs": `},
+		},
+		{
+			prefix:             `{"s": "{\"b`,
+			expectedCandidates: nil,
+		},
+		{
+			prefix:             `{"mks": {"`,
+			expectedCandidates: nil,
+		},
+		{
+			prefix:             `{"i32": `,
+			expectedCandidates: nil,
+		},
+		{
+			prefix:             `{} {"`,
+			expectedCandidates: nil,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.prefix, func(t *testing.T) {
+			t.Parallel()
+			start, candidates := completeJSONFieldNames(message, testCase.prefix)
+			assert.Equal(t, testCase.expectedCandidates, candidates)
+			if candidates != nil {
+				assert.Equal(t, testCase.expectedStart, start)
+			}
+		})
+	}
+
+	t.Run("seen keys", func(t *testing.T) {
+		t.Parallel()
+		prefix := `{"i32": 1, "b": true, `
+		start, candidates := completeJSONFieldNames(message, prefix)
+		assert.Equal(t, len(prefix), start)
+		assert.Contains(t, candidates, `"i64": `)
+		assert.NotContains(t, candidates, `"i32": `)
+		assert.NotContains(t, candidates, `"b": `)
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"buf.build/go/app"
 	"buf.build/go/standard/xstrings"
@@ -42,9 +43,30 @@ const (
 	MessageEncodingTxtpb
 	// MessageEncodingYAML is the YAML message encoding.
 	MessageEncodingYAML
+)
+
+const (
+	// MessageDelimiterVarint says that each message is prefixed with its size as a varint.
+	//
+	// This is only valid for MessageEncodingBinpb.
+	MessageDelimiterVarint MessageDelimiter = iota + 1
+	// MessageDelimiterEnvelope says that each message is prefixed with the 5-byte envelope
+	// of gRPC and Connect streams, that is a byte of flags followed by the size of the message
+	// as a 4-byte big-endian integer.
+	//
+	// This is only valid for MessageEncodingBinpb.
+	MessageDelimiterEnvelope
+	// MessageDelimiterNewline says that messages are separated by newlines for
+	// MessageEncodingJSON, and are separate documents for MessageEncodingYAML.
+	//
+	// This is only valid for MessageEncodingJSON and MessageEncodingYAML.
+	MessageDelimiterNewline
+)
 
+const (
 	useProtoNamesKey  = "use_proto_names"
 	useEnumNumbersKey = "use_enum_numbers"
+	delimiterKey      = "delimiter"
 )
 
 var (
@@ -89,6 +111,18 @@ var (
 // MessageEncoding is the encoding of the message.
 type MessageEncoding int
 
+// MessageDelimiter is the delimiter of the messages of a stream of messages.
+type MessageDelimiter int
+
+// String implements fmt.Stringer.
+func (m MessageDelimiter) String() string {
+	s, ok := messageDelimiterToString[m]
+	if !ok {
+		return strconv.Itoa(int(m))
+	}
+	return s
+}
+
 // Ref is an message file or source bucket reference.
 type Ref interface {
 	internalRef() internal.Ref
@@ -106,6 +140,10 @@ type MessageRef interface {
 	UseProtoNames() bool
 	// UseEnumNumbers only applies for MessageEncodingYAML at this time.
 	UseEnumNumbers() bool
+	// MessageDelimiter returns the delimiter of the messages of the file.
+	//
+	// If zero, the file holds a single message.
+	MessageDelimiter() MessageDelimiter
 	IsNull() bool
 	internalSingleRef() internal.SingleRef
 }
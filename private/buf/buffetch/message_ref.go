@@ -15,16 +15,32 @@
 package buffetch
 
 import (
+	"fmt"
+
 	"github.com/bufbuild/buf/private/buf/buffetch/internal"
 )
 
-var _ MessageRef = &messageRef{}
+var (
+	_ MessageRef = &messageRef{}
+
+	messageDelimiterToString = map[MessageDelimiter]string{
+		MessageDelimiterVarint:   "varint",
+		MessageDelimiterEnvelope: "envelope",
+		MessageDelimiterNewline:  "newline",
+	}
+	stringToMessageDelimiter = map[string]MessageDelimiter{
+		"varint":   MessageDelimiterVarint,
+		"envelope": MessageDelimiterEnvelope,
+		"newline":  MessageDelimiterNewline,
+	}
+)
 
 type messageRef struct {
-	singleRef       internal.SingleRef
-	useProtoNames   bool
-	useEnumNumbers  bool
-	messageEncoding MessageEncoding
+	singleRef        internal.SingleRef
+	useProtoNames    bool
+	useEnumNumbers   bool
+	messageEncoding  MessageEncoding
+	messageDelimiter MessageDelimiter
 }
 
 func newMessageRef(
@@ -39,11 +55,16 @@ func newMessageRef(
 	if err != nil {
 		return nil, err
 	}
+	messageDelimiter, err := getMessageDelimiterForSingleRef(singleRef, messageEncoding)
+	if err != nil {
+		return nil, err
+	}
 	return &messageRef{
-		singleRef:       singleRef,
-		useProtoNames:   useProtoNames,
-		useEnumNumbers:  useEnumNumbers,
-		messageEncoding: messageEncoding,
+		singleRef:        singleRef,
+		useProtoNames:    useProtoNames,
+		useEnumNumbers:   useEnumNumbers,
+		messageEncoding:  messageEncoding,
+		messageDelimiter: messageDelimiter,
 	}, nil
 }
 
@@ -63,6 +84,10 @@ func (r *messageRef) UseEnumNumbers() bool {
 	return r.useEnumNumbers
 }
 
+func (r *messageRef) MessageDelimiter() MessageDelimiter {
+	return r.messageDelimiter
+}
+
 func (r *messageRef) IsNull() bool {
 	return r.singleRef.FileScheme() == internal.FileSchemeNull
 }
@@ -89,3 +114,28 @@ func getTrueOrFalseForSingleRef(singleRef internal.SingleRef, key string) (bool,
 		return false, internal.NewOptionsInvalidValueForKeyError(key, value)
 	}
 }
+
+func getMessageDelimiterForSingleRef(
+	singleRef internal.SingleRef,
+	messageEncoding MessageEncoding,
+) (MessageDelimiter, error) {
+	value, ok := singleRef.CustomOptionValue(delimiterKey)
+	if !ok {
+		return 0, nil
+	}
+	messageDelimiter, ok := stringToMessageDelimiter[value]
+	if !ok {
+		return 0, internal.NewOptionsInvalidValueForKeyError(delimiterKey, value)
+	}
+	switch messageDelimiter {
+	case MessageDelimiterVarint, MessageDelimiterEnvelope:
+		if messageEncoding != MessageEncodingBinpb {
+			return 0, fmt.Errorf("%s=%s is only valid for the %s format", delimiterKey, value, formatBinpb)
+		}
+	case MessageDelimiterNewline:
+		if messageEncoding != MessageEncodingJSON && messageEncoding != MessageEncodingYAML {
+			return 0, fmt.Errorf("%s=%s is only valid for the %s and %s formats", delimiterKey, value, formatJSON, formatYAML)
+		}
+	}
+	return messageDelimiter, nil
+}
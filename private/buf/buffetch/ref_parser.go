@@ -41,30 +41,40 @@ func newRefParser(logger *slog.Logger) *refParser {
 		fetchRefParser: internal.NewRefParser(
 			logger,
 			internal.WithRawRefProcessor(processRawRef),
-			internal.WithSingleFormat(formatBin),
-			internal.WithSingleFormat(formatBinpb),
+			internal.WithSingleFormat(
+				formatBin,
+				internal.WithSingleCustomOptionKey(delimiterKey),
+			),
+			internal.WithSingleFormat(
+				formatBinpb,
+				internal.WithSingleCustomOptionKey(delimiterKey),
+			),
 			internal.WithSingleFormat(
 				formatJSON,
 				internal.WithSingleCustomOptionKey(useProtoNamesKey),
 				internal.WithSingleCustomOptionKey(useEnumNumbersKey),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(formatTxtpb),
 			internal.WithSingleFormat(
 				formatYAML,
 				internal.WithSingleCustomOptionKey(useProtoNamesKey),
 				internal.WithSingleCustomOptionKey(useEnumNumbersKey),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(
 				formatBingz,
 				internal.WithSingleDefaultCompressionType(
 					internal.CompressionTypeGzip,
 				),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(
 				formatJSONGZ,
 				internal.WithSingleDefaultCompressionType(
 					internal.CompressionTypeGzip,
 				),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithArchiveFormat(
 				formatTar,
@@ -99,30 +109,40 @@ func newMessageRefParser(logger *slog.Logger, options ...MessageRefParserOption)
 		fetchRefParser: internal.NewRefParser(
 			logger,
 			internal.WithRawRefProcessor(newProcessRawRefMessage(messageRefParserOptions.defaultMessageEncoding)),
-			internal.WithSingleFormat(formatBin),
-			internal.WithSingleFormat(formatBinpb),
+			internal.WithSingleFormat(
+				formatBin,
+				internal.WithSingleCustomOptionKey(delimiterKey),
+			),
+			internal.WithSingleFormat(
+				formatBinpb,
+				internal.WithSingleCustomOptionKey(delimiterKey),
+			),
 			internal.WithSingleFormat(
 				formatJSON,
 				internal.WithSingleCustomOptionKey(useProtoNamesKey),
 				internal.WithSingleCustomOptionKey(useEnumNumbersKey),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(formatTxtpb),
 			internal.WithSingleFormat(
 				formatYAML,
 				internal.WithSingleCustomOptionKey(useProtoNamesKey),
 				internal.WithSingleCustomOptionKey(useEnumNumbersKey),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(
 				formatBingz,
 				internal.WithSingleDefaultCompressionType(
 					internal.CompressionTypeGzip,
 				),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 			internal.WithSingleFormat(
 				formatJSONGZ,
 				internal.WithSingleDefaultCompressionType(
 					internal.CompressionTypeGzip,
 				),
+				internal.WithSingleCustomOptionKey(delimiterKey),
 			),
 		),
 	}
@@ -1285,6 +1285,26 @@ func TestGetParsedRefSuccess(t *testing.T) {
 	)
 }
 
+func TestGetMessageRefDelimiter(t *testing.T) {
+	t.Parallel()
+	testGetMessageRefDelimiter(t, 0, "path/to/file.binpb")
+	testGetMessageRefDelimiter(t, MessageDelimiterVarint, "path/to/file.binpb#delimiter=varint")
+	testGetMessageRefDelimiter(t, MessageDelimiterEnvelope, "path/to/file#format=binpb,delimiter=envelope")
+	testGetMessageRefDelimiter(t, MessageDelimiterVarint, "path/to/file.binpb.gz#delimiter=varint")
+	testGetMessageRefDelimiter(t, MessageDelimiterNewline, "path/to/file.json#delimiter=newline")
+	testGetMessageRefDelimiter(t, MessageDelimiterNewline, "path/to/file.yaml#delimiter=newline")
+
+	messageRefParser := newMessageRefParser(slogtestext.NewLogger(t))
+	_, err := messageRefParser.GetMessageRef(context.Background(), "path/to/file.binpb#delimiter=comma")
+	assert.Equal(t, internal.NewOptionsInvalidValueForKeyError(delimiterKey, "comma"), err)
+	_, err = messageRefParser.GetMessageRef(context.Background(), "path/to/file.json#delimiter=varint")
+	assert.EqualError(t, err, "delimiter=varint is only valid for the binpb format")
+	_, err = messageRefParser.GetMessageRef(context.Background(), "path/to/file.binpb#delimiter=newline")
+	assert.EqualError(t, err, "delimiter=newline is only valid for the json and yaml formats")
+	_, err = messageRefParser.GetMessageRef(context.Background(), "path/to/file.txtpb#delimiter=newline")
+	assert.Equal(t, internal.NewOptionsInvalidKeysError(delimiterKey), err)
+}
+
 func TestGetParsedRefError(t *testing.T) {
 	t.Parallel()
 	testGetParsedRefError(
@@ -1486,3 +1506,16 @@ func testNewModuleRef(
 	require.NoError(t, err)
 	return moduleRef
 }
+
+func testGetMessageRefDelimiter(
+	t *testing.T,
+	expectedMessageDelimiter MessageDelimiter,
+	value string,
+) {
+	messageRef, err := newMessageRefParser(slogtestext.NewLogger(t)).GetMessageRef(
+		context.Background(),
+		value,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expectedMessageDelimiter, messageRef.MessageDelimiter())
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"io"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
@@ -29,7 +30,7 @@ import (
 )
 
 // FormatModuleSet formats and writes the target files into a read bucket.
-func FormatModuleSet(ctx context.Context, moduleSet bufmodule.ModuleSet) (_ storage.ReadBucket, retErr error) {
+func FormatModuleSet(ctx context.Context, moduleSet bufmodule.ModuleSet, options ...FormatOption) (_ storage.ReadBucket, retErr error) {
 	return FormatBucket(
 		ctx,
 		bufmodule.ModuleReadBucketToStorageReadBucket(
@@ -37,11 +38,12 @@ func FormatModuleSet(ctx context.Context, moduleSet bufmodule.ModuleSet) (_ stor
 				bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFilesForTargetModules(moduleSet),
 			),
 		),
+		options...,
 	)
 }
 
 // FormatBucket formats the .proto files in the bucket and returns a new bucket with the formatted files.
-func FormatBucket(ctx context.Context, bucket storage.ReadBucket) (_ storage.ReadBucket, retErr error) {
+func FormatBucket(ctx context.Context, bucket storage.ReadBucket, options ...FormatOption) (_ storage.ReadBucket, retErr error) {
 	readWriteBucket := storagemem.NewReadWriteBucket()
 	paths, err := storage.AllPaths(ctx, storage.FilterReadBucket(bucket, storage.MatchPathExt(".proto")), "")
 	if err != nil {
@@ -68,7 +70,7 @@ func FormatBucket(ctx context.Context, bucket storage.ReadBucket) (_ storage.Rea
 			defer func() {
 				retErr = errors.Join(retErr, writeObjectCloser.Close())
 			}()
-			if err := FormatFileNode(writeObjectCloser, fileNode); err != nil {
+			if err := FormatFileNode(writeObjectCloser, fileNode, options...); err != nil {
 				return err
 			}
 			return writeObjectCloser.SetExternalPath(readObjectCloser.ExternalPath())
@@ -81,7 +83,37 @@ func FormatBucket(ctx context.Context, bucket storage.ReadBucket) (_ storage.Rea
 }
 
 // FormatFileNode formats the given file node and writ the result to dest.
-func FormatFileNode(dest io.Writer, fileNode *ast.FileNode) error {
-	formatter := newFormatter(dest, fileNode)
+func FormatFileNode(dest io.Writer, fileNode *ast.FileNode, options ...FormatOption) error {
+	formatOptions := newFormatOptions()
+	for _, option := range options {
+		option(formatOptions)
+	}
+	formatter := newFormatter(dest, fileNode, formatOptions.formatConfig)
 	return formatter.Run()
 }
+
+// FormatOption is an option for formatting.
+type FormatOption func(*formatOptions)
+
+// FormatWithConfig returns a new FormatOption that formats with the style of the FormatConfig.
+//
+// The default is bufconfig.DefaultFormatConfig.
+func FormatWithConfig(formatConfig bufconfig.FormatConfig) FormatOption {
+	return func(formatOptions *formatOptions) {
+		if formatConfig != nil {
+			formatOptions.formatConfig = formatConfig
+		}
+	}
+}
+
+// *** PRIVATE ***
+
+type formatOptions struct {
+	formatConfig bufconfig.FormatConfig
+}
+
+func newFormatOptions() *formatOptions {
+	return &formatOptions{
+		formatConfig: bufconfig.DefaultFormatConfig,
+	}
+}
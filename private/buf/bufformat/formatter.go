@@ -15,22 +15,26 @@
 package bufformat
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/protocompile/ast"
 )
 
 // formatter writes an *ast.FileNode as a .proto file.
 type formatter struct {
-	writer   io.Writer
-	fileNode *ast.FileNode
+	writer       io.Writer
+	fileNode     *ast.FileNode
+	formatConfig bufconfig.FormatConfig
 
 	// Used to adjust comments when we remove superfluous
 	// separators tp canonicalize message literals
@@ -40,6 +44,8 @@ type formatter struct {
 	indent int
 	// The last character written to writer.
 	lastWritten rune
+	// The number of characters written to writer since the last newline.
+	column int
 
 	// The last node written. This must be updated from all functions
 	// that write comments with a node. This flag informs how the next
@@ -65,6 +71,14 @@ type formatter struct {
 	// lines. So this flag informs the logic that makes those whitespace decisions.
 	inline bool
 
+	// The number of spaces to write after the name of a field or enum value
+	// so that its '=' is aligned with the surrounding declarations. Only
+	// populated if the FormatConfig aligns fields.
+	declToAlignmentPadding map[ast.Node]int
+	// If true, a blank line is written before the next declaration. Only
+	// set if the FormatConfig normalizes blank lines.
+	blankLineBeforeNextDecl bool
+
 	// Records all errors that occur during the formatting process. Nearly any
 	// non-nil error represents a bug in the implementation.
 	err error
@@ -74,11 +88,14 @@ type formatter struct {
 func newFormatter(
 	writer io.Writer,
 	fileNode *ast.FileNode,
+	formatConfig bufconfig.FormatConfig,
 ) *formatter {
 	return &formatter{
 		writer:                   writer,
 		fileNode:                 fileNode,
+		formatConfig:             formatConfig,
 		overrideTrailingComments: map[ast.Node]ast.Comments{},
+		declToAlignmentPadding:   map[ast.Node]int{},
 	}
 }
 
@@ -182,11 +199,17 @@ func (f *formatter) WriteString(elem string) {
 				f.err = errors.Join(f.err, err)
 				return
 			}
+			f.column++
 		}
 	}
 	if len(elem) == 0 {
 		return
 	}
+	if i := strings.LastIndexByte(elem, '\n'); i >= 0 {
+		f.column = utf8.RuneCountInString(elem[i+1:])
+	} else {
+		f.column += utf8.RuneCountInString(elem)
+	}
 	f.lastWritten, _ = utf8.DecodeLastRuneInString(elem)
 	if _, err := f.writer.Write([]byte(elem)); err != nil {
 		f.err = errors.Join(f.err, err)
@@ -216,7 +239,8 @@ func (f *formatter) writeFile() {
 
 // writeFileHeader writes the header of a .proto file. This includes the syntax,
 // package, imports, and options (in that order). The imports and options are
-// sorted. All other file elements are handled by f.writeFileTypes.
+// sorted, unless the FormatConfig says otherwise. All other file elements are
+// handled by f.writeFileTypes.
 //
 // For example,
 //
@@ -262,35 +286,37 @@ func (f *formatter) writeFileHeader() {
 	if packageNode != nil {
 		f.writePackage(packageNode)
 	}
-	sort.Slice(importNodes, func(i, j int) bool {
-		iName := importNodes[i].Name.AsString()
-		jName := importNodes[j].Name.AsString()
-		// sort by public > None > weak
-		iOrder := importSortOrder(importNodes[i])
-		jOrder := importSortOrder(importNodes[j])
+	if f.formatConfig.SortImports() {
+		sort.Slice(importNodes, func(i, j int) bool {
+			iName := importNodes[i].Name.AsString()
+			jName := importNodes[j].Name.AsString()
+			// sort by public > None > weak
+			iOrder := importSortOrder(importNodes[i])
+			jOrder := importSortOrder(importNodes[j])
 
-		if iName < jName {
-			return true
-		}
-		if iName > jName {
-			return false
-		}
-		if iOrder > jOrder {
-			return true
-		}
-		if iOrder < jOrder {
-			return false
-		}
+			if iName < jName {
+				return true
+			}
+			if iName > jName {
+				return false
+			}
+			if iOrder > jOrder {
+				return true
+			}
+			if iOrder < jOrder {
+				return false
+			}
 
-		// put commented import first
-		return !f.importHasComment(importNodes[j])
-	})
+			// put commented import first
+			return !f.importHasComment(importNodes[j])
+		})
+	}
 	for i, importNode := range importNodes {
 		if i == 0 && f.previousNode != nil && !f.leadingCommentsContainBlankLine(importNode) {
 			f.P("")
 		}
 
-		// since the imports are sorted, this will skip write imports
+		// if the imports are sorted, this will skip write imports
 		// if they have appear before and dont have comment
 		if i > 0 && importNode.Name.AsString() == importNodes[i-1].Name.AsString() &&
 			!f.importHasComment(importNode) {
@@ -299,23 +325,25 @@ func (f *formatter) writeFileHeader() {
 
 		f.writeImport(importNode, i > 0)
 	}
-	sort.Slice(optionNodes, func(i, j int) bool {
-		// The default options (e.g. cc_enable_arenas) should always
-		// be sorted above custom options (which are identified by a
-		// leading '(').
-		left := stringForOptionName(optionNodes[i].Name)
-		right := stringForOptionName(optionNodes[j].Name)
-		if strings.HasPrefix(left, "(") && !strings.HasPrefix(right, "(") {
-			// Prefer the default option on the right.
-			return false
-		}
-		if !strings.HasPrefix(left, "(") && strings.HasPrefix(right, "(") {
-			// Prefer the default option on the left.
-			return true
-		}
-		// Both options are custom, so we defer to the standard sorting.
-		return left < right
-	})
+	if f.formatConfig.SortOptions() {
+		sort.Slice(optionNodes, func(i, j int) bool {
+			// The default options (e.g. cc_enable_arenas) should always
+			// be sorted above custom options (which are identified by a
+			// leading '(').
+			left := stringForOptionName(optionNodes[i].Name)
+			right := stringForOptionName(optionNodes[j].Name)
+			if strings.HasPrefix(left, "(") && !strings.HasPrefix(right, "(") {
+				// Prefer the default option on the right.
+				return false
+			}
+			if !strings.HasPrefix(left, "(") && strings.HasPrefix(right, "(") {
+				// Prefer the default option on the left.
+				return true
+			}
+			// Both options are custom, so we defer to the standard sorting.
+			return left < right
+		})
+	}
 	for i, optionNode := range optionNodes {
 		if i == 0 && f.previousNode != nil && !f.leadingCommentsContainBlankLine(optionNode) {
 			f.P("")
@@ -333,6 +361,13 @@ func (f *formatter) writeFileTypes() {
 			// These elements have already been written by f.writeFileHeader.
 			continue
 		default:
+			if f.formatConfig.NormalizeBlankLines() {
+				// All of the types have bodies, so they are always separated by a blank line.
+				f.blankLineBeforeNextDecl = f.previousNode != nil
+				f.writeNode(node)
+				f.blankLineBeforeNextDecl = false
+				continue
+			}
 			info := f.nodeInfo(node)
 			wantNewline := f.previousNode != nil && (i == 0 || info.LeadingComments().Len() > 0)
 			if wantNewline && !f.leadingCommentsContainBlankLine(node) {
@@ -557,9 +592,7 @@ func (f *formatter) writeMessage(messageNode *ast.MessageNode) {
 	var elementWriterFunc func()
 	if len(messageNode.Decls) != 0 {
 		elementWriterFunc = func() {
-			for _, decl := range messageNode.Decls {
-				f.writeNode(decl)
-			}
+			f.writeDecls(toNodes(messageNode.Decls))
 		}
 	}
 	f.writeStart(messageNode.Keyword)
@@ -828,9 +861,11 @@ func (f *formatter) writeEnum(enumNode *ast.EnumNode) {
 	var elementWriterFunc func()
 	if len(enumNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range enumNode.Decls {
-				f.writeNode(decl)
+			decls := toNodes(enumNode.Decls)
+			if f.formatConfig.SortEnumValues() {
+				sortEnumValues(decls)
 			}
+			f.writeDecls(decls)
 		}
 	}
 	f.writeStart(enumNode.Keyword)
@@ -854,6 +889,7 @@ func (f *formatter) writeEnum(enumNode *ast.EnumNode) {
 //	];
 func (f *formatter) writeEnumValue(enumValueNode *ast.EnumValueNode) {
 	f.writeStart(enumValueNode.Name)
+	f.writeAlignmentPadding(enumValueNode)
 	f.Space()
 	f.writeInline(enumValueNode.Equals)
 	f.Space()
@@ -893,6 +929,7 @@ func (f *formatter) writeField(fieldNode *ast.FieldNode) {
 	}
 	f.Space()
 	f.writeInline(fieldNode.Name)
+	f.writeAlignmentPadding(fieldNode)
 	f.Space()
 	f.writeInline(fieldNode.Equals)
 	f.Space()
@@ -909,6 +946,7 @@ func (f *formatter) writeMapField(mapFieldNode *ast.MapFieldNode) {
 	f.writeNode(mapFieldNode.MapType)
 	f.Space()
 	f.writeInline(mapFieldNode.Name)
+	f.writeAlignmentPadding(mapFieldNode)
 	f.Space()
 	f.writeInline(mapFieldNode.Equals)
 	f.Space()
@@ -953,9 +991,7 @@ func (f *formatter) writeExtend(extendNode *ast.ExtendNode) {
 	var elementWriterFunc func()
 	if len(extendNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range extendNode.Decls {
-				f.writeNode(decl)
-			}
+			f.writeDecls(toNodes(extendNode.Decls))
 		}
 	}
 	f.writeStart(extendNode.Keyword)
@@ -981,9 +1017,11 @@ func (f *formatter) writeService(serviceNode *ast.ServiceNode) {
 	var elementWriterFunc func()
 	if len(serviceNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range serviceNode.Decls {
-				f.writeNode(decl)
+			decls := toNodes(serviceNode.Decls)
+			if f.formatConfig.SortRPCs() {
+				sortRPCs(decls)
 			}
+			f.writeDecls(decls)
 		}
 	}
 	f.writeStart(serviceNode.Keyword)
@@ -1009,9 +1047,7 @@ func (f *formatter) writeRPC(rpcNode *ast.RPCNode) {
 	var elementWriterFunc func()
 	if len(rpcNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range rpcNode.Decls {
-				f.writeNode(decl)
-			}
+			f.writeDecls(toNodes(rpcNode.Decls))
 		}
 	}
 	f.writeStart(rpcNode.Keyword)
@@ -1064,9 +1100,7 @@ func (f *formatter) writeOneOf(oneOfNode *ast.OneofNode) {
 	var elementWriterFunc func()
 	if len(oneOfNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range oneOfNode.Decls {
-				f.writeNode(decl)
-			}
+			f.writeDecls(toNodes(oneOfNode.Decls))
 		}
 	}
 	f.writeStart(oneOfNode.Keyword)
@@ -1095,9 +1129,7 @@ func (f *formatter) writeGroup(groupNode *ast.GroupNode) {
 	var elementWriterFunc func()
 	if len(groupNode.Decls) > 0 {
 		elementWriterFunc = func() {
-			for _, decl := range groupNode.Decls {
-				f.writeNode(decl)
-			}
+			f.writeDecls(toNodes(groupNode.Decls))
 		}
 	}
 	// We need to handle the comments for the group label specially since
@@ -1216,12 +1248,28 @@ func (f *formatter) writeRange(rangeNode *ast.RangeNode) {
 //	  deprecated = true,
 //	  json_name = "something"
 //	]
+//
+// If the FormatConfig has a maximum line length, compact options that fit
+// within it are written in-line instead, and compact options that do not
+// fit are always written across multiple lines.
 func (f *formatter) writeCompactOptions(compactOptionsNode *ast.CompactOptionsNode) {
 	f.inCompactOptions = true
 	defer func() {
 		f.inCompactOptions = false
 	}()
-	if len(compactOptionsNode.Options) == 1 &&
+	writeSingleOptionInline := len(compactOptionsNode.Options) == 1
+	if maxLineLength := f.formatConfig.MaxLineLength(); maxLineLength > 0 {
+		if width, ok := f.compactOptionsInlineWidth(compactOptionsNode); ok {
+			// A space is written before the '[', and the ']' is followed by
+			// the ';' that ends the declaration.
+			if f.column+1+width+1 <= maxLineLength {
+				f.writeCompactOptionsInline(compactOptionsNode)
+				return
+			}
+			writeSingleOptionInline = false
+		}
+	}
+	if writeSingleOptionInline &&
 		!f.hasInteriorComments(compactOptionsNode.OpenBracket, compactOptionsNode.Options[0].Name) {
 		// If there's only a single compact scalar option without comments, we can write it
 		// in-line. For example:
@@ -1275,6 +1323,58 @@ func (f *formatter) writeCompactOptions(compactOptionsNode *ast.CompactOptionsNo
 	)
 }
 
+// writeCompactOptionsInline writes a compact options node in-line.
+//
+// For example,
+//
+//	[deprecated = true, json_name = "something"]
+//
+// This is only used for compact options without comments and with scalar values,
+// see f.compactOptionsInlineWidth.
+func (f *formatter) writeCompactOptionsInline(compactOptionsNode *ast.CompactOptionsNode) {
+	f.writeInline(compactOptionsNode.OpenBracket)
+	for i, optionNode := range compactOptionsNode.Options {
+		if i > 0 {
+			// The length of this slice must be exactly len(Options)-1.
+			f.writeInline(compactOptionsNode.Commas[i-1])
+			f.Space()
+		}
+		f.writeInline(optionNode.Name)
+		f.Space()
+		f.writeInline(optionNode.Equals)
+		f.Space()
+		f.writeInline(optionNode.Val)
+	}
+	f.writeInline(compactOptionsNode.CloseBracket)
+}
+
+// compactOptionsInlineWidth returns the width of the compact options node when
+// written in-line, and false if the compact options cannot be written in-line
+// because they have interior comments or values that span multiple lines.
+func (f *formatter) compactOptionsInlineWidth(compactOptionsNode *ast.CompactOptionsNode) (int, bool) {
+	if f.hasInteriorComments(terminalNodes(compactOptionsNode)...) {
+		return 0, false
+	}
+	// The '[' and ']'.
+	width := 2
+	for i, optionNode := range compactOptionsNode.Options {
+		switch optionNode.Val.(type) {
+		case *ast.CompoundStringLiteralNode, *ast.MessageLiteralNode, *ast.ArrayLiteralNode:
+			return 0, false
+		}
+		if i > 0 {
+			// The ", " between options.
+			width += 2
+		}
+		// The " = " between the name and the value.
+		width += utf8.RuneCountInString(stringForOptionName(optionNode.Name)) + 3
+		for _, node := range terminalNodes(optionNode.Val) {
+			width += utf8.RuneCountInString(f.nodeInfo(node).RawText())
+		}
+	}
+	return width, true
+}
+
 func (f *formatter) hasInteriorComments(nodes ...ast.Node) bool {
 	for i, n := range nodes {
 		// interior comments mean we ignore leading comments on first
@@ -1392,6 +1492,114 @@ func (f *formatter) writeCompositeValueForArrayLiteral(
 	}
 }
 
+// writeDecls writes the declarations within the body of a composite type, e.g. the
+// fields of a message.
+//
+// If the FormatConfig aligns fields, the '=' of consecutive fields and enum values are
+// aligned. If the FormatConfig normalizes blank lines, declarations with bodies are
+// separated from the surrounding declarations by a blank line.
+func (f *formatter) writeDecls(decls []ast.Node) {
+	if f.formatConfig.AlignFields() {
+		f.setAlignmentPadding(decls)
+	}
+	var previousDecl ast.Node
+	for _, decl := range decls {
+		if _, ok := decl.(*ast.EmptyDeclNode); ok {
+			continue
+		}
+		if f.formatConfig.NormalizeBlankLines() && previousDecl != nil {
+			f.blankLineBeforeNextDecl = hasBody(previousDecl) || hasBody(decl)
+		}
+		f.writeNode(decl)
+		f.blankLineBeforeNextDecl = false
+		previousDecl = decl
+	}
+}
+
+// setAlignmentPadding sets the alignment padding of each run of consecutive fields
+// and enum values within the declarations, so that their '=' are aligned.
+//
+// A run ends at any other declaration, and at a blank line. Fields and enum values
+// with comments before their '=' are not aligned, and end the run as well.
+func (f *formatter) setAlignmentPadding(decls []ast.Node) {
+	var (
+		runDecls  []ast.Node
+		runWidths []int
+	)
+	endRun := func() {
+		if len(runWidths) > 1 {
+			maxWidth := slices.Max(runWidths)
+			for i, decl := range runDecls {
+				f.declToAlignmentPadding[decl] = maxWidth - runWidths[i]
+			}
+		}
+		runDecls = nil
+		runWidths = nil
+	}
+	for _, decl := range decls {
+		if _, ok := decl.(*ast.EmptyDeclNode); ok {
+			continue
+		}
+		width, ok := f.alignmentWidth(decl)
+		if !ok || f.leadingCommentsContainBlankLine(decl) {
+			endRun()
+		}
+		if ok {
+			runDecls = append(runDecls, decl)
+			runWidths = append(runWidths, width)
+		}
+	}
+	endRun()
+}
+
+// alignmentWidth returns the width of the declaration up to its '=', and false if
+// the declaration is not a field or enum value that can be aligned.
+func (f *formatter) alignmentWidth(decl ast.Node) (int, bool) {
+	var (
+		parts []string
+		nodes []ast.Node
+	)
+	switch decl := decl.(type) {
+	case *ast.FieldNode:
+		if decl.Label.KeywordNode != nil {
+			parts = append(parts, decl.Label.Val)
+			nodes = append(nodes, decl.Label.KeywordNode)
+		}
+		parts = append(parts, string(decl.FldType.AsIdentifier()), decl.Name.Val)
+		nodes = append(nodes, decl.FldType, decl.Name, decl.Equals)
+	case *ast.MapFieldNode:
+		mapTypeNode := decl.MapType
+		parts = append(
+			parts,
+			"map<"+mapTypeNode.KeyType.Val+", "+string(mapTypeNode.ValueType.AsIdentifier())+">",
+			decl.Name.Val,
+		)
+		nodes = append(nodes, mapTypeNode, decl.Name, decl.Equals)
+	case *ast.EnumValueNode:
+		parts = append(parts, decl.Name.Val)
+		nodes = append(nodes, decl.Name, decl.Equals)
+	default:
+		return 0, false
+	}
+	var terminals []ast.Node
+	for _, node := range nodes {
+		terminals = append(terminals, terminalNodes(node)...)
+	}
+	if f.hasInteriorComments(terminals...) {
+		return 0, false
+	}
+	return utf8.RuneCountInString(strings.Join(parts, " ")), true
+}
+
+// writeAlignmentPadding writes the space before the '=' of the field or enum value,
+// including its alignment padding.
+func (f *formatter) writeAlignmentPadding(decl ast.Node) {
+	if padding := f.declToAlignmentPadding[decl]; padding > 0 {
+		f.Space()
+		f.WriteString(strings.Repeat(" ", padding))
+	}
+}
+
 // writeCompositeTypeBody writes the body of a composite type, e.g. message, enum, extend, oneof, etc.
 func (f *formatter) writeCompositeTypeBody(
 	openBrace *ast.RuneNode,
@@ -1825,10 +2033,19 @@ func (f *formatter) writeStartMaybeCompact(node ast.Node, forceCompact bool) {
 		nodeNewlineCount = newlineCount(info.LeadingWhitespace())
 		compact          = forceCompact || isOpenBrace(f.previousNode)
 	)
+	if f.blankLineBeforeNextDecl {
+		// The blank line replaces any newlines in the source before the
+		// declaration, so the rest of this is written as if it was compact.
+		f.blankLineBeforeNextDecl = false
+		if !compact {
+			f.P("")
+			compact = true
+		}
+	}
 	if length := info.LeadingComments().Len(); length > 0 {
 		// If leading comments are defined, the whitespace we care about
 		// is attached to the first comment.
-		f.writeMultilineCommentsMaybeCompact(info.LeadingComments(), forceCompact)
+		f.writeMultilineCommentsMaybeCompact(info.LeadingComments(), compact)
 		if !forceCompact && nodeNewlineCount > 1 {
 			// At this point, we're looking at the lines between
 			// a comment and the node its attached to.
@@ -2408,6 +2625,83 @@ func stringForFieldReference(fieldReference *ast.FieldReferenceNode) string {
 	return result
 }
 
+// toNodes returns the elements as ast.Nodes.
+func toNodes[T ast.Node](elements []T) []ast.Node {
+	nodes := make([]ast.Node, len(elements))
+	for i, element := range elements {
+		nodes[i] = element
+	}
+	return nodes
+}
+
+// terminalNodes returns the terminal nodes of the given node, in order.
+func terminalNodes(node ast.Node) []ast.Node {
+	compositeNode, ok := node.(ast.CompositeNode)
+	if !ok {
+		return []ast.Node{node}
+	}
+	var nodes []ast.Node
+	for _, child := range compositeNode.Children() {
+		nodes = append(nodes, terminalNodes(child)...)
+	}
+	return nodes
+}
+
+// hasBody returns true if the given declaration has a body, such as a message.
+func hasBody(decl ast.Node) bool {
+	switch decl := decl.(type) {
+	case *ast.MessageNode, *ast.EnumNode, *ast.ExtendNode, *ast.ServiceNode, *ast.OneofNode, *ast.GroupNode:
+		return true
+	case *ast.RPCNode:
+		return decl.OpenBrace != nil
+	default:
+		return false
+	}
+}
+
+// sortEnumValues sorts the enum values within the declarations of an enum by number,
+// with the values with the number zero first. Other declarations keep their position.
+func sortEnumValues(decls []ast.Node) {
+	sortDeclsOfType(decls, func(left *ast.EnumValueNode, right *ast.EnumValueNode) int {
+		leftNumber, _ := left.Number.AsInt64()
+		rightNumber, _ := right.Number.AsInt64()
+		if (leftNumber == 0) != (rightNumber == 0) {
+			if leftNumber == 0 {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(leftNumber, rightNumber)
+	})
+}
+
+// sortRPCs sorts the RPCs within the declarations of a service by name. Other
+// declarations keep their position.
+func sortRPCs(decls []ast.Node) {
+	sortDeclsOfType(decls, func(left *ast.RPCNode, right *ast.RPCNode) int {
+		return strings.Compare(left.Name.Val, right.Name.Val)
+	})
+}
+
+// sortDeclsOfType stably sorts the declarations of type T in place, leaving all other
+// declarations where they are.
+func sortDeclsOfType[T ast.Node](decls []ast.Node, compare func(T, T) int) {
+	var (
+		indexes []int
+		sorted  []T
+	)
+	for i, decl := range decls {
+		if typedDecl, ok := decl.(T); ok {
+			indexes = append(indexes, i)
+			sorted = append(sorted, typedDecl)
+		}
+	}
+	slices.SortStableFunc(sorted, compare)
+	for i, index := range indexes {
+		decls[index] = sorted[i]
+	}
+}
+
 // isOpenBrace returns true if the given node represents one of the
 // possible open brace tokens, namely '{', '[', or '<'.
 func isOpenBrace(node ast.Node) bool {
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/pkg/diff"
 	"github.com/bufbuild/buf/private/pkg/slogtestext"
@@ -36,6 +37,35 @@ func TestFormatter(t *testing.T) {
 	testFormatProto3(t)
 }
 
+func TestFormatterWithConfig(t *testing.T) {
+	t.Parallel()
+	testFormatNoDiff(
+		t,
+		"testdata/config/align",
+		FormatWithConfig(newFormatConfig(t, true, true, true, 0, false, false, false)),
+	)
+	testFormatNoDiff(
+		t,
+		"testdata/config/maxlinelength",
+		FormatWithConfig(newFormatConfig(t, true, true, false, 80, false, false, false)),
+	)
+	testFormatNoDiff(
+		t,
+		"testdata/config/normalizeblanklines",
+		FormatWithConfig(newFormatConfig(t, true, true, false, 0, true, false, false)),
+	)
+	testFormatNoDiff(
+		t,
+		"testdata/config/sort",
+		FormatWithConfig(newFormatConfig(t, true, true, false, 0, false, true, true)),
+	)
+	testFormatNoDiff(
+		t,
+		"testdata/config/nosort",
+		FormatWithConfig(newFormatConfig(t, false, false, false, 0, false, false, false)),
+	)
+}
+
 func testFormatCustomOptions(t *testing.T) {
 	testFormatNoDiff(t, "testdata/customoptions")
 }
@@ -68,7 +98,7 @@ func testFormatProto3(t *testing.T) {
 	testFormatNoDiff(t, "testdata/proto3/service/v1")
 }
 
-func testFormatNoDiff(t *testing.T, path string) {
+func testFormatNoDiff(t *testing.T, path string, options ...FormatOption) {
 	t.Run(path, func(t *testing.T) {
 		ctx := context.Background()
 		bucket, err := storageos.NewProvider().NewReadWriteBucket(path)
@@ -77,7 +107,7 @@ func testFormatNoDiff(t *testing.T, path string) {
 		moduleSetBuilder.AddLocalModule(bucket, path, true)
 		moduleSet, err := moduleSetBuilder.Build()
 		require.NoError(t, err)
-		readBucket, err := FormatModuleSet(ctx, moduleSet)
+		readBucket, err := FormatModuleSet(ctx, moduleSet, options...)
 		require.NoError(t, err)
 		require.NoError(
 			t,
@@ -110,3 +140,26 @@ func testFormatNoDiff(t *testing.T, path string) {
 		)
 	})
 }
+
+func newFormatConfig(
+	t *testing.T,
+	sortImports bool,
+	sortOptions bool,
+	alignFields bool,
+	maxLineLength int,
+	normalizeBlankLines bool,
+	sortEnumValues bool,
+	sortRPCs bool,
+) bufconfig.FormatConfig {
+	formatConfig, err := bufconfig.NewFormatConfig(
+		sortImports,
+		sortOptions,
+		alignFields,
+		maxLineLength,
+		normalizeBlankLines,
+		sortEnumValues,
+		sortRPCs,
+	)
+	require.NoError(t, err)
+	return formatConfig
+}
@@ -26,8 +26,10 @@ import (
 	"buf.build/go/app"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginstore"
 	"github.com/bufbuild/buf/private/pkg/connectclient"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/wasm"
 )
 
 const (
@@ -84,9 +86,12 @@ type Generator interface {
 }
 
 // NewGenerator returns a new Generator.
+//
+// The Wasm runtime is used to run local plugins whose path has the .wasm extension.
 func NewGenerator(
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 	// Pass a clientConfig instead of a CodeGenerationServiceClient because the
 	// plugins' remotes/registries is not known at this time, and remotes/registries
 	// may be different for different plugins.
@@ -95,6 +100,7 @@ func NewGenerator(
 	return newGenerator(
 		logger,
 		storageosProvider,
+		wasmRuntime,
 		clientConfig,
 	)
 }
@@ -120,6 +126,46 @@ func GenerateWithDeleteOuts(deleteOuts bool) GenerateOption {
 	}
 }
 
+// GenerateWithCheck returns a new GenerateOption that checks that the generated
+// files on disk are up to date instead of writing them.
+//
+// If a generated file does not exist or differs from the file on disk, Generate
+// returns a bufanalysis.FileAnnotationSet with a FileAnnotation for each such file.
+// If the output directories would be deleted prior to generation, files in the
+// output directories that are not generated are also reported. Nothing on disk is
+// written or deleted.
+func GenerateWithCheck(check bool) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.check = check
+	}
+}
+
+// GenerateWithResponseStore returns a new GenerateOption that caches the responses of
+// plugins in the ResponseStore.
+//
+// If the response of a plugin for the same CodeGeneratorRequests is in the store, the
+// plugin is not run. Responses are cached for local plugins that are run without
+// additional arguments, keyed on the plugin binary, and for remote plugins with a
+// pinned version and revision.
+//
+// The default is to not cache responses.
+func GenerateWithResponseStore(responseStore bufprotopluginstore.ResponseStore) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.responseStore = responseStore
+	}
+}
+
+// GenerateWithPluginReportFunc returns a new GenerateOption that calls the function
+// with a PluginReport for every invocation of a plugin.
+//
+// The function is called once all of the plugins for an image have run successfully,
+// in the order of the plugins in the config.
+func GenerateWithPluginReportFunc(pluginReportFunc func(PluginReport)) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.pluginReportFunc = pluginReportFunc
+	}
+}
+
 // GenerateWithIncludeImportsOverride is a strict override on whether imports are
 // generated. This overrides IncludeImports from the GeneratePluginConfig.
 //
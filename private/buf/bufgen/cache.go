@@ -0,0 +1,111 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+
+	"github.com/bufbuild/buf/private/buf/bufprotopluginexec"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufremoteplugin/bufremotepluginref"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// cacheKeyVersion is part of every cache key, and must be changed if the
+// content of cache keys changes.
+const cacheKeyVersion = "v1"
+
+// getLocalPluginIdentity returns the identity of the local plugin, which is part of
+// the cache keys of its responses.
+//
+// Returns false if the responses of the plugin cannot be cached.
+func getLocalPluginIdentity(pluginConfig bufconfig.GeneratePluginConfig) (string, bool, error) {
+	return bufprotopluginexec.GetPluginIdentity(
+		pluginConfig.Name(),
+		bufprotopluginexec.HandlerWithPluginPath(pluginConfig.Path()...),
+		bufprotopluginexec.HandlerWithProtocPath(pluginConfig.ProtocPath()...),
+	)
+}
+
+// getLocalPluginCacheKey returns the key of the response of the local plugin with the
+// identity for the request in a ResponseStore.
+func getLocalPluginCacheKey(
+	pluginIdentity string,
+	request *pluginpb.CodeGeneratorRequest,
+) (string, error) {
+	cacheKeyHash := newCacheKeyHash("local", pluginIdentity)
+	if err := writeCacheKeyMessage(cacheKeyHash, request); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(cacheKeyHash.Sum(nil)), nil
+}
+
+// getRemotePluginCacheKey returns the key of the response of the remote plugin for the
+// request in a ResponseStore.
+//
+// The response of a remote plugin is only cached if both its version and revision
+// are pinned, as the response otherwise changes when a new version or revision is
+// published. Returns false if the response of the plugin cannot be cached.
+func getRemotePluginCacheKey(
+	pluginConfig bufconfig.GeneratePluginConfig,
+	remote string,
+	protoImageData []byte,
+	request proto.Message,
+) (string, bool, error) {
+	if pluginConfig.Revision() == 0 {
+		return "", false, nil
+	}
+	if _, err := bufremotepluginref.PluginReferenceForString(pluginConfig.Name(), pluginConfig.Revision()); err != nil {
+		// No version.
+		return "", false, nil
+	}
+	cacheKeyHash := newCacheKeyHash("remote", remote)
+	writeCacheKeyData(cacheKeyHash, protoImageData)
+	if err := writeCacheKeyMessage(cacheKeyHash, request); err != nil {
+		return "", false, err
+	}
+	return hex.EncodeToString(cacheKeyHash.Sum(nil)), true, nil
+}
+
+func newCacheKeyHash(pluginType string, pluginIdentity string) hash.Hash {
+	cacheKeyHash := sha256.New()
+	writeCacheKeyData(cacheKeyHash, []byte(cacheKeyVersion))
+	writeCacheKeyData(cacheKeyHash, []byte(pluginType))
+	writeCacheKeyData(cacheKeyHash, []byte(pluginIdentity))
+	return cacheKeyHash
+}
+
+func writeCacheKeyMessage(cacheKeyHash hash.Hash, message proto.Message) error {
+	// The wire marshaler is deterministic.
+	data, err := protoencoding.NewWireMarshaler().Marshal(message)
+	if err != nil {
+		return err
+	}
+	writeCacheKeyData(cacheKeyHash, data)
+	return nil
+}
+
+// writeCacheKeyData writes the data to the hash with a length prefix, so that the
+// boundaries between the parts of a cache key are unambiguous.
+func writeCacheKeyData(cacheKeyHash hash.Hash, data []byte) {
+	// Writes to a hash.Hash never return an error.
+	_, _ = cacheKeyHash.Write(binary.BigEndian.AppendUint64(nil, uint64(len(data))))
+	_, _ = cacheKeyHash.Write(data)
+}
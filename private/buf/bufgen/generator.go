@@ -18,27 +18,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"buf.build/go/app"
 	"buf.build/go/standard/xslices"
 	connect "connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/buf/bufprotopluginexec"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagemodify"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
 	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin"
 	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginos"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginstore"
 	"github.com/bufbuild/buf/private/bufpkg/bufremoteplugin"
 	"github.com/bufbuild/buf/private/bufpkg/bufremoteplugin/bufremotepluginref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
 	"github.com/bufbuild/buf/private/pkg/connectclient"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/thread"
+	"github.com/bufbuild/buf/private/pkg/wasm"
+	"github.com/bufbuild/protoplugin"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
@@ -52,12 +59,13 @@ type generator struct {
 func newGenerator(
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 	clientConfig *connectclient.Config,
 ) *generator {
 	return &generator{
 		logger:              logger,
 		storageosProvider:   storageosProvider,
-		pluginexecGenerator: bufprotopluginexec.NewGenerator(logger, storageosProvider),
+		pluginexecGenerator: bufprotopluginexec.NewGenerator(logger, storageosProvider, wasmRuntime),
 		clientConfig:        clientConfig,
 	}
 }
@@ -104,6 +112,20 @@ func (g *generator) Generate(
 	if generateOptions.deleteOuts != nil {
 		shouldDeleteOuts = *generateOptions.deleteOuts
 	}
+	if generateOptions.check {
+		return g.checkCode(
+			ctx,
+			container,
+			images,
+			generateOptions.baseOutDirPath,
+			config.GeneratePluginConfigs(),
+			generateOptions.includeImportsOverride,
+			generateOptions.includeWellKnownTypesOverride,
+			generateOptions.responseStore,
+			generateOptions.pluginReportFunc,
+			shouldDeleteOuts,
+		)
+	}
 	if shouldDeleteOuts {
 		if err := g.deleteOuts(
 			ctx,
@@ -114,21 +136,84 @@ func (g *generator) Generate(
 		}
 	}
 	for _, image := range images {
+		responseWriter := g.newResponseWriter()
 		if err := g.generateCode(
 			ctx,
 			container,
+			responseWriter,
 			image,
 			generateOptions.baseOutDirPath,
 			config.GeneratePluginConfigs(),
 			generateOptions.includeImportsOverride,
 			generateOptions.includeWellKnownTypesOverride,
+			generateOptions.responseStore,
+			generateOptions.pluginReportFunc,
 		); err != nil {
 			return err
 		}
+		if err := responseWriter.Close(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// checkCode generates the code for all of the images in-memory, and compares
+// it with the files on disk.
+//
+// A single ResponseWriter is used for all of the images, so that files
+// generated for one image are not reported as extra files for another.
+func (g *generator) checkCode(
+	ctx context.Context,
+	container app.EnvStdioContainer,
+	images []bufimage.Image,
+	baseOutDir string,
+	pluginConfigs []bufconfig.GeneratePluginConfig,
+	includeImportsOverride *bool,
+	includeWellKnownTypesOverride *bool,
+	responseStore bufprotopluginstore.ResponseStore,
+	pluginReportFunc func(PluginReport),
+	deleteOuts bool,
+) error {
+	responseWriter := g.newResponseWriter()
+	for _, image := range images {
+		if err := g.generateCode(
+			ctx,
+			container,
+			responseWriter,
+			image,
+			baseOutDir,
+			pluginConfigs,
+			includeImportsOverride,
+			includeWellKnownTypesOverride,
+			responseStore,
+			pluginReportFunc,
+		); err != nil {
+			return err
+		}
+	}
+	var checkOptions []bufprotopluginos.CheckOption
+	if deleteOuts {
+		checkOptions = append(checkOptions, bufprotopluginos.CheckWithExtraFiles())
+	}
+	fileAnnotations, err := responseWriter.Check(ctx, checkOptions...)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		return bufanalysis.NewFileAnnotationSet(fileAnnotations...)
+	}
+	return nil
+}
+
+func (g *generator) newResponseWriter() bufprotopluginos.ResponseWriter {
+	return bufprotopluginos.NewResponseWriter(
+		g.logger,
+		g.storageosProvider,
+		bufprotopluginos.ResponseWriterWithCreateOutDirIfNotExists(),
+	)
+}
+
 func (g *generator) deleteOuts(
 	ctx context.Context,
 	baseOutDir string,
@@ -149,14 +234,19 @@ func (g *generator) deleteOuts(
 	)
 }
 
+// generateCode executes the plugins for the image and adds their responses to the
+// ResponseWriter.
 func (g *generator) generateCode(
 	ctx context.Context,
 	container app.EnvStdioContainer,
+	responseWriter bufprotopluginos.ResponseWriter,
 	inputImage bufimage.Image,
 	baseOutDir string,
 	pluginConfigs []bufconfig.GeneratePluginConfig,
 	includeImportsOverride *bool,
 	includeWellKnownTypesOverride *bool,
+	responseStore bufprotopluginstore.ResponseStore,
+	pluginReportFunc func(PluginReport),
 ) error {
 	responses, err := g.execPlugins(
 		ctx,
@@ -165,16 +255,13 @@ func (g *generator) generateCode(
 		inputImage,
 		includeImportsOverride,
 		includeWellKnownTypesOverride,
+		responseStore,
+		pluginReportFunc,
 	)
 	if err != nil {
 		return err
 	}
 	// Apply the CodeGeneratorResponses in the order they were specified.
-	responseWriter := bufprotopluginos.NewResponseWriter(
-		g.logger,
-		g.storageosProvider,
-		bufprotopluginos.ResponseWriterWithCreateOutDirIfNotExists(),
-	)
 	for i, pluginConfig := range pluginConfigs {
 		out := pluginConfig.Out()
 		if baseOutDir != "" && baseOutDir != "." {
@@ -192,9 +279,6 @@ func (g *generator) generateCode(
 			return fmt.Errorf("plugin %s: %v", pluginConfig.Name(), err)
 		}
 	}
-	if err := responseWriter.Close(); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -205,10 +289,17 @@ func (g *generator) execPlugins(
 	image bufimage.Image,
 	includeImportsOverride *bool,
 	includeWellKnownTypesOverride *bool,
+	responseStore bufprotopluginstore.ResponseStore,
+	pluginReportFunc func(PluginReport),
 ) ([]*pluginpb.CodeGeneratorResponse, error) {
 	// Collect all of the plugin jobs so that they can be executed in parallel.
 	jobs := make([]func(context.Context) error, 0, len(pluginConfigs))
 	responses := make([]*pluginpb.CodeGeneratorResponse, len(pluginConfigs))
+	// The durations, whether the responses were cached, and the stderr of the plugins,
+	// for the PluginReports. The durations and stderr are only recorded for local plugins.
+	durations := make([]time.Duration, len(pluginConfigs))
+	cached := make([]bool, len(pluginConfigs))
+	stderrs := make([]*syncBuffer, len(pluginConfigs))
 	requiredFeatures := computeRequiredFeatures(image)
 
 	// Group the pluginConfigs by similar properties to batch image processing.
@@ -243,12 +334,16 @@ func (g *generator) execPlugins(
 					indexedPluginConfigs,
 					includeImportsOverride,
 					includeWellKnownTypesOverride,
+					responseStore,
 				)
 				if err != nil {
 					return err
 				}
+				// The plugins for the remote are run with a single request, so the
+				// duration of each plugin is not known.
 				for _, result := range results {
-					responses[result.Index] = result.Value
+					responses[result.Index] = result.Value.response
+					cached[result.Index] = result.Value.cached
 				}
 				return nil
 			})
@@ -279,18 +374,26 @@ func (g *generator) execPlugins(
 				if includeWellKnownTypesOverride != nil {
 					includeWellKnownTypes = *includeWellKnownTypesOverride
 				}
-				response, err := g.execLocalPlugin(
+				// Capture the stderr of the plugin for the PluginReport, while
+				// still writing it to the stderr of the container.
+				stderr := &syncBuffer{}
+				start := time.Now()
+				pluginResponse, err := g.execLocalPlugin(
 					ctx,
-					container,
+					newStderrTeeContainer(container, stderr),
 					images,
 					indexedPluginConfig.Value,
 					includeImports,
 					includeWellKnownTypes,
+					responseStore,
 				)
 				if err != nil {
 					return err
 				}
-				responses[indexedPluginConfig.Index] = response
+				responses[indexedPluginConfig.Index] = pluginResponse.response
+				cached[indexedPluginConfig.Index] = pluginResponse.cached
+				durations[indexedPluginConfig.Index] = time.Since(start)
+				stderrs[indexedPluginConfig.Index] = stderr
 				return nil
 			})
 		}
@@ -320,6 +423,23 @@ func (g *generator) execPlugins(
 	if err := checkRequiredFeatures(g.logger, requiredFeatures, responses, pluginConfigs); err != nil {
 		return nil, err
 	}
+	for i, pluginConfig := range pluginConfigs {
+		var stderr string
+		if stderrs[i] != nil {
+			stderr = stderrs[i].String()
+		}
+		pluginReport := newPluginReport(
+			pluginConfig,
+			responses[i],
+			durations[i],
+			cached[i],
+			stderr,
+		)
+		logPluginReport(ctx, g.logger, pluginReport)
+		if pluginReportFunc != nil {
+			pluginReportFunc(pluginReport)
+		}
+	}
 	return responses, nil
 }
 
@@ -330,7 +450,8 @@ func (g *generator) execLocalPlugin(
 	pluginConfig bufconfig.GeneratePluginConfig,
 	includeImports bool,
 	includeWellKnownTypes bool,
-) (*pluginpb.CodeGeneratorResponse, error) {
+	responseStore bufprotopluginstore.ResponseStore,
+) (pluginResponse, error) {
 	requests, err := bufimage.ImagesToCodeGeneratorRequests(
 		pluginImages,
 		pluginConfig.Opt(),
@@ -339,8 +460,72 @@ func (g *generator) execLocalPlugin(
 		includeWellKnownTypes,
 	)
 	if err != nil {
-		return nil, err
+		return pluginResponse{}, err
+	}
+	if responseStore == nil {
+		response, err := g.generateLocal(ctx, container, pluginConfig, requests)
+		return pluginResponse{response: response}, err
+	}
+	pluginIdentity, ok, err := getLocalPluginIdentity(pluginConfig)
+	if err != nil {
+		return pluginResponse{}, fmt.Errorf("plugin %s: %v", pluginConfig.Name(), err)
+	}
+	if !ok {
+		response, err := g.generateLocal(ctx, container, pluginConfig, requests)
+		return pluginResponse{response: response}, err
+	}
+	// Each request is cached separately, so that with StrategyDirectory the plugin
+	// is only run for the directories whose requests changed.
+	responses := make([]*pluginpb.CodeGeneratorResponse, len(requests))
+	var jobs []func(context.Context) error
+	for i, request := range requests {
+		cacheKey, err := getLocalPluginCacheKey(pluginIdentity, request)
+		if err != nil {
+			return pluginResponse{}, err
+		}
+		response, err := responseStore.GetResponse(ctx, cacheKey)
+		if err == nil {
+			responses[i] = response
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return pluginResponse{}, err
+		}
+		jobs = append(jobs, func(ctx context.Context) error {
+			response, err := g.generateLocal(ctx, container, pluginConfig, []*pluginpb.CodeGeneratorRequest{request})
+			if err != nil {
+				return err
+			}
+			if err := responseStore.PutResponse(ctx, cacheKey, response); err != nil {
+				return err
+			}
+			responses[i] = response
+			return nil
+		})
+	}
+	if err := thread.Parallelize(
+		ctx,
+		jobs,
+		thread.ParallelizeWithCancelOnFailure(),
+	); err != nil {
+		return pluginResponse{}, err
+	}
+	response, err := mergeLocalPluginResponses(container, responses)
+	if err != nil {
+		return pluginResponse{}, err
 	}
+	return pluginResponse{
+		response: response,
+		cached:   len(jobs) == 0,
+	}, nil
+}
+
+func (g *generator) generateLocal(
+	ctx context.Context,
+	container app.EnvStdioContainer,
+	pluginConfig bufconfig.GeneratePluginConfig,
+	requests []*pluginpb.CodeGeneratorRequest,
+) (*pluginpb.CodeGeneratorResponse, error) {
 	response, err := g.pluginexecGenerator.Generate(
 		ctx,
 		container,
@@ -363,7 +548,8 @@ func (g *generator) execRemotePluginsV2(
 	indexedPluginConfigs []xslices.Indexed[bufconfig.GeneratePluginConfig],
 	includeImportsOverride *bool,
 	includeWellKnownTypesOverride *bool,
-) ([]xslices.Indexed[*pluginpb.CodeGeneratorResponse], error) {
+	responseStore bufprotopluginstore.ResponseStore,
+) ([]xslices.Indexed[pluginResponse], error) {
 	requests := make([]*registryv1alpha1.PluginGenerationRequest, len(indexedPluginConfigs))
 	for i, indexedPluginConfig := range indexedPluginConfigs {
 		includeImports := indexedPluginConfig.Value.IncludeImports()
@@ -384,17 +570,64 @@ func (g *generator) execRemotePluginsV2(
 		}
 		requests[i] = request
 	}
-	codeGenerationService := connectclient.Make(g.clientConfig, remote, registryv1alpha1connect.NewCodeGenerationServiceClient)
 	protoImage, err := bufimage.ImageToProtoImage(image)
 	if err != nil {
 		return nil, err
 	}
+	result := make([]xslices.Indexed[pluginResponse], 0, len(requests))
+	// The indexes into requests of the requests that are sent to the remote, and
+	// their cache keys, which are empty if the response is not cached.
+	var remoteIndexes []int
+	var remoteCacheKeys []string
+	var protoImageData []byte
+	if responseStore != nil {
+		// The wire marshaler is deterministic.
+		protoImageData, err = protoencoding.NewWireMarshaler().Marshal(protoImage)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, request := range requests {
+		var cacheKey string
+		if responseStore != nil {
+			var ok bool
+			cacheKey, ok, err = getRemotePluginCacheKey(indexedPluginConfigs[i].Value, remote, protoImageData, request)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				codeGeneratorResponse, err := responseStore.GetResponse(ctx, cacheKey)
+				if err == nil {
+					result = append(result, xslices.Indexed[pluginResponse]{
+						Value: pluginResponse{
+							response: codeGeneratorResponse,
+							cached:   true,
+						},
+						Index: indexedPluginConfigs[i].Index,
+					})
+					continue
+				}
+				if !errors.Is(err, fs.ErrNotExist) {
+					return nil, err
+				}
+			}
+		}
+		remoteIndexes = append(remoteIndexes, i)
+		remoteCacheKeys = append(remoteCacheKeys, cacheKey)
+	}
+	if len(remoteIndexes) == 0 {
+		return result, nil
+	}
+	codeGenerationService := connectclient.Make(g.clientConfig, remote, registryv1alpha1connect.NewCodeGenerationServiceClient)
 	response, err := codeGenerationService.GenerateCode(
 		ctx,
 		connect.NewRequest(
 			registryv1alpha1.GenerateCodeRequest_builder{
-				Image:    protoImage,
-				Requests: requests,
+				Image: protoImage,
+				Requests: xslices.Map(
+					remoteIndexes,
+					func(i int) *registryv1alpha1.PluginGenerationRequest { return requests[i] },
+				),
 			}.Build(),
 		),
 	)
@@ -402,23 +635,61 @@ func (g *generator) execRemotePluginsV2(
 		return nil, err
 	}
 	responses := response.Msg.GetResponses()
-	if len(responses) != len(requests) {
-		return nil, fmt.Errorf("unexpected number of responses received, got %d, wanted %d", len(responses), len(requests))
+	if len(responses) != len(remoteIndexes) {
+		return nil, fmt.Errorf("unexpected number of responses received, got %d, wanted %d", len(responses), len(remoteIndexes))
 	}
-	result := make([]xslices.Indexed[*pluginpb.CodeGeneratorResponse], 0, len(responses))
-	for i := range requests {
+	for i, requestIndex := range remoteIndexes {
 		codeGeneratorResponse := responses[i].GetResponse()
 		if codeGeneratorResponse == nil {
 			return nil, errors.New("expected code generator response")
 		}
-		result = append(result, xslices.Indexed[*pluginpb.CodeGeneratorResponse]{
-			Value: codeGeneratorResponse,
-			Index: indexedPluginConfigs[i].Index,
+		if cacheKey := remoteCacheKeys[i]; cacheKey != "" {
+			if err := responseStore.PutResponse(ctx, cacheKey, codeGeneratorResponse); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, xslices.Indexed[pluginResponse]{
+			Value: pluginResponse{
+				response: codeGeneratorResponse,
+			},
+			Index: indexedPluginConfigs[requestIndex].Index,
 		})
 	}
 	return result, nil
 }
 
+// mergeLocalPluginResponses merges the responses of a local plugin for multiple
+// requests into a single response, in the order of the requests.
+//
+// The responses are merged with the same lenient validation as when the plugin is
+// run for all of the requests at once, so that duplicate files are handled the same
+// whether or not the responses were cached. All of the responses are from the same
+// plugin, so the supported features and editions of the first response are used.
+func mergeLocalPluginResponses(
+	container app.StderrContainer,
+	responses []*pluginpb.CodeGeneratorResponse,
+) (*pluginpb.CodeGeneratorResponse, error) {
+	if len(responses) == 1 {
+		return responses[0], nil
+	}
+	responseWriter := protoplugin.NewResponseWriter(
+		protoplugin.ResponseWriterWithLenientValidation(
+			func(err error) {
+				_, _ = fmt.Fprintln(container.Stderr(), err.Error())
+			},
+		),
+	)
+	for i, response := range responses {
+		if i == 0 {
+			responseWriter.SetSupportedFeatures(response.GetSupportedFeatures())
+			responseWriter.SetMinimumEdition(response.GetMinimumEdition())
+			responseWriter.SetMaximumEdition(response.GetMaximumEdition())
+		}
+		responseWriter.AddCodeGeneratorResponseFiles(response.GetFile()...)
+	}
+	return responseWriter.ToCodeGeneratorResponse()
+}
+
 func getPluginGenerationRequest(
 	pluginConfig bufconfig.GeneratePluginConfig,
 	includeImports bool,
@@ -479,9 +750,20 @@ func validateResponses(
 	return nil
 }
 
+// pluginResponse is the response of a plugin.
+type pluginResponse struct {
+	response *pluginpb.CodeGeneratorResponse
+	// cached is true if the response was read from the ResponseStore, and the
+	// plugin was not run.
+	cached bool
+}
+
 type generateOptions struct {
 	baseOutDirPath                string
 	deleteOuts                    *bool
+	check                         bool
+	responseStore                 bufprotopluginstore.ResponseStore
+	pluginReportFunc              func(PluginReport)
 	includeImportsOverride        *bool
 	includeWellKnownTypesOverride *bool
 }
@@ -0,0 +1,73 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"bytes"
+	"testing"
+
+	"buf.build/go/app"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestMergeLocalPluginResponses(t *testing.T) {
+	t.Parallel()
+	supportedFeatures := uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+	responses := []*pluginpb.CodeGeneratorResponse{
+		&pluginpb.CodeGeneratorResponse{
+			SupportedFeatures: proto.Uint64(supportedFeatures),
+			File: []*pluginpb.CodeGeneratorResponse_File{
+				&pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String("a/v1/a.txt"),
+					Content: proto.String("a"),
+				},
+				&pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String("shared.txt"),
+					Content: proto.String("first"),
+				},
+			},
+		},
+		&pluginpb.CodeGeneratorResponse{
+			SupportedFeatures: proto.Uint64(supportedFeatures),
+			File: []*pluginpb.CodeGeneratorResponse_File{
+				&pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String("b/v1/b.txt"),
+					Content: proto.String("b"),
+				},
+				// Duplicate files across directories are dropped with a warning, as when the
+				// plugin is run for all of the directories at once.
+				&pluginpb.CodeGeneratorResponse_File{
+					Name:    proto.String("shared.txt"),
+					Content: proto.String("second"),
+				},
+			},
+		},
+	}
+	stderr := &bytes.Buffer{}
+	response, err := mergeLocalPluginResponses(app.NewContainer(nil, nil, nil, stderr), responses)
+	require.NoError(t, err)
+	require.Equal(t, supportedFeatures, response.GetSupportedFeatures())
+	var fileNames []string
+	for _, file := range response.GetFile() {
+		fileNames = append(fileNames, file.GetName())
+		if file.GetName() == "shared.txt" {
+			require.Equal(t, "first", file.GetContent())
+		}
+	}
+	require.Equal(t, []string{"a/v1/a.txt", "shared.txt", "b/v1/b.txt"}, fileNames)
+	require.Contains(t, stderr.String(), "shared.txt")
+}
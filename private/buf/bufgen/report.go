@@ -0,0 +1,320 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"buf.build/go/app"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	// ReportFormatText is the text format for PluginReports.
+	ReportFormatText = "text"
+	// ReportFormatJSON is the JSON format for PluginReports.
+	ReportFormatJSON = "json"
+)
+
+// AllReportFormatStrings are all the formats for PluginReports.
+var AllReportFormatStrings = []string{
+	ReportFormatText,
+	ReportFormatJSON,
+}
+
+// PluginReport is a report of an invocation of a plugin by Generate.
+type PluginReport struct {
+	// Name is the name of the plugin.
+	Name string
+	// Remote is the remote of the plugin, or empty if the plugin is local.
+	Remote string
+	// Duration is the wall time of the invocation.
+	//
+	// The plugins on the same remote are invoked with a single request, so the wall
+	// time of each remote plugin is not known, and Duration is zero. See HasDuration.
+	Duration time.Duration
+	// Cached is true if the response of the plugin was read from the generation cache,
+	// and the plugin was not run.
+	Cached bool
+	// Files are the files generated by the plugin, in the order of the response.
+	Files []PluginReportFile
+	// Stderr is what the plugin wrote to stderr, such as warnings.
+	//
+	// Always empty for remote plugins, as their stderr is not returned.
+	Stderr string
+}
+
+// HasDuration returns true if Duration and Stderr were recorded for the plugin,
+// that is if the plugin is local.
+func (p *PluginReport) HasDuration() bool {
+	return p.Remote == ""
+}
+
+// Size returns the total size of the generated files in bytes.
+func (p *PluginReport) Size() int {
+	var size int
+	for _, file := range p.Files {
+		size += file.Size
+	}
+	return size
+}
+
+// InsertionPoints returns the sorted unique insertion points used by the plugin.
+func (p *PluginReport) InsertionPoints() []string {
+	var insertionPoints []string
+	for _, file := range p.Files {
+		if file.InsertionPoint != "" {
+			insertionPoints = append(insertionPoints, file.InsertionPoint)
+		}
+	}
+	slices.Sort(insertionPoints)
+	return slices.Compact(insertionPoints)
+}
+
+// PluginReportFile is a file generated by a plugin.
+type PluginReportFile struct {
+	// Name is the name of the file, relative to the out directory of the plugin.
+	Name string
+	// InsertionPoint is the insertion point the content of the file is inserted at,
+	// or empty if the file is not inserted into another file.
+	InsertionPoint string
+	// Size is the size of the content of the file in bytes.
+	Size int
+}
+
+// WritePluginReports writes the PluginReports to the writer in the format.
+//
+// The text format lists the plugins from slowest to fastest. The JSON format lists the
+// plugins in the order they were invoked, and includes the generated files.
+func WritePluginReports(writer io.Writer, pluginReports []PluginReport, format string) error {
+	switch format {
+	case ReportFormatText:
+		return writePluginReportsText(writer, pluginReports)
+	case ReportFormatJSON:
+		return writePluginReportsJSON(writer, pluginReports)
+	default:
+		return fmt.Errorf("unknown report format: %q", format)
+	}
+}
+
+func newPluginReport(
+	pluginConfig bufconfig.GeneratePluginConfig,
+	response *pluginpb.CodeGeneratorResponse,
+	duration time.Duration,
+	cached bool,
+	stderr string,
+) PluginReport {
+	files := make([]PluginReportFile, len(response.GetFile()))
+	for i, file := range response.GetFile() {
+		files[i] = PluginReportFile{
+			Name:           file.GetName(),
+			InsertionPoint: file.GetInsertionPoint(),
+			Size:           len(file.GetContent()),
+		}
+	}
+	return PluginReport{
+		Name:     pluginConfig.Name(),
+		Remote:   pluginConfig.RemoteHost(),
+		Duration: duration,
+		Cached:   cached,
+		Files:    files,
+		Stderr:   stderr,
+	}
+}
+
+func logPluginReport(ctx context.Context, logger *slog.Logger, pluginReport PluginReport) {
+	attrs := []any{
+		slog.String("plugin", pluginReport.Name),
+		slog.String("remote", pluginReport.Remote),
+		slog.Bool("cached", pluginReport.Cached),
+		slog.Int("files", len(pluginReport.Files)),
+		slog.Int("bytes", pluginReport.Size()),
+		slog.Any("insertion_points", pluginReport.InsertionPoints()),
+	}
+	if pluginReport.HasDuration() {
+		attrs = append(
+			attrs,
+			slog.Duration("duration", pluginReport.Duration),
+			slog.String("stderr", pluginReport.Stderr),
+		)
+	}
+	logger.DebugContext(ctx, "plugin invocation", attrs...)
+}
+
+func writePluginReportsText(writer io.Writer, pluginReports []PluginReport) error {
+	pluginReports = slices.Clone(pluginReports)
+	// Plugins without a duration are listed last.
+	slices.SortStableFunc(pluginReports, func(a PluginReport, b PluginReport) int {
+		if a.HasDuration() != b.HasDuration() {
+			if a.HasDuration() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	lines := []string{"Plugin\tLocation\tDuration\tCached\tFiles\tBytes\tInsertion points"}
+	for _, pluginReport := range pluginReports {
+		location := "local"
+		if pluginReport.Remote != "" {
+			location = pluginReport.Remote
+		}
+		duration := "n/a"
+		if pluginReport.HasDuration() {
+			duration = pluginReport.Duration.Round(time.Millisecond).String()
+		}
+		lines = append(
+			lines,
+			fmt.Sprintf(
+				"%s\t%s\t%s\t%t\t%d\t%d\t%d",
+				pluginReport.Name,
+				location,
+				duration,
+				pluginReport.Cached,
+				len(pluginReport.Files),
+				pluginReport.Size(),
+				len(pluginReport.InsertionPoints()),
+			),
+		)
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(tabWriter, line); err != nil {
+			return err
+		}
+	}
+	if err := tabWriter.Flush(); err != nil {
+		return err
+	}
+	for _, pluginReport := range pluginReports {
+		if pluginReport.Stderr == "" {
+			continue
+		}
+		if _, err := fmt.Fprintf(writer, "\nStderr of %s:\n", pluginReport.Name); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimRight(pluginReport.Stderr, "\n"), "\n") {
+			if _, err := fmt.Fprintf(writer, "  %s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writePluginReportsJSON(writer io.Writer, pluginReports []PluginReport) error {
+	type fileJSON struct {
+		Name           string `json:"name"`
+		InsertionPoint string `json:"insertionPoint,omitempty"`
+		Bytes          int    `json:"bytes"`
+	}
+	// The duration and stderr are null if they are not known, see HasDuration.
+	type pluginJSON struct {
+		Name            string     `json:"name"`
+		Remote          string     `json:"remote,omitempty"`
+		DurationSeconds *float64   `json:"durationSeconds"`
+		Cached          bool       `json:"cached"`
+		Bytes           int        `json:"bytes"`
+		InsertionPoints []string   `json:"insertionPoints"`
+		Files           []fileJSON `json:"files"`
+		Stderr          *string    `json:"stderr"`
+	}
+	plugins := make([]pluginJSON, len(pluginReports))
+	for i, pluginReport := range pluginReports {
+		files := make([]fileJSON, len(pluginReport.Files))
+		for j, file := range pluginReport.Files {
+			files[j] = fileJSON{
+				Name:           file.Name,
+				InsertionPoint: file.InsertionPoint,
+				Bytes:          file.Size,
+			}
+		}
+		insertionPoints := pluginReport.InsertionPoints()
+		if insertionPoints == nil {
+			insertionPoints = []string{}
+		}
+		plugins[i] = pluginJSON{
+			Name:            pluginReport.Name,
+			Remote:          pluginReport.Remote,
+			Cached:          pluginReport.Cached,
+			Bytes:           pluginReport.Size(),
+			InsertionPoints: insertionPoints,
+			Files:           files,
+		}
+		if pluginReport.HasDuration() {
+			durationSeconds := pluginReport.Duration.Seconds()
+			plugins[i].DurationSeconds = &durationSeconds
+			plugins[i].Stderr = &pluginReport.Stderr
+		}
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(
+		struct {
+			Plugins []pluginJSON `json:"plugins"`
+		}{
+			Plugins: plugins,
+		},
+	)
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use, as a plugin is run
+// concurrently for the images of StrategyDirectory.
+type syncBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buffer.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buffer.String()
+}
+
+// stderrTeeContainer is an app.EnvStdioContainer that also writes stderr to another writer.
+type stderrTeeContainer struct {
+	app.EnvStdioContainer
+
+	stderr io.Writer
+}
+
+func newStderrTeeContainer(container app.EnvStdioContainer, writer io.Writer) *stderrTeeContainer {
+	return &stderrTeeContainer{
+		EnvStdioContainer: container,
+		stderr:            io.MultiWriter(container.Stderr(), writer),
+	}
+}
+
+func (s *stderrTeeContainer) Stderr() io.Writer {
+	return s.stderr
+}
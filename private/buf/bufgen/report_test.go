@@ -0,0 +1,103 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePluginReports(t *testing.T) {
+	t.Parallel()
+	pluginReports := []PluginReport{
+		{
+			Name:     "protoc-gen-fast",
+			Duration: 10 * time.Millisecond,
+			Cached:   true,
+			Files: []PluginReportFile{
+				{Name: "a/v1/a.fast.go", Size: 10},
+			},
+		},
+		{
+			Name:   "buf.build/acme/slow:v1.0.0",
+			Remote: "buf.build",
+			Files: []PluginReportFile{
+				{Name: "a/v1/a.slow.go", Size: 100},
+				{Name: "a/v1/a.fast.go", InsertionPoint: "imports", Size: 20},
+				{Name: "a/v1/a.fast.go", InsertionPoint: "imports", Size: 5},
+			},
+		},
+		{
+			Name:     "protoc-gen-noisy",
+			Duration: 500 * time.Millisecond,
+			Stderr:   "warning: a\nwarning: b\n",
+		},
+	}
+
+	var buffer bytes.Buffer
+	require.NoError(t, WritePluginReports(&buffer, pluginReports, ReportFormatText))
+	require.Equal(
+		t,
+		`Plugin                      Location   Duration  Cached  Files  Bytes  Insertion points
+protoc-gen-noisy            local      500ms     false   0      0      0
+protoc-gen-fast             local      10ms      true    1      10     0
+buf.build/acme/slow:v1.0.0  buf.build  n/a       false   3      125    1
+
+Stderr of protoc-gen-noisy:
+  warning: a
+  warning: b
+`,
+		buffer.String(),
+	)
+
+	buffer.Reset()
+	require.NoError(t, WritePluginReports(&buffer, pluginReports[:2], ReportFormatJSON))
+	require.JSONEq(
+		t,
+		`{
+  "plugins": [
+    {
+      "name": "protoc-gen-fast",
+      "durationSeconds": 0.01,
+      "cached": true,
+      "bytes": 10,
+      "insertionPoints": [],
+      "files": [{"name": "a/v1/a.fast.go", "bytes": 10}],
+      "stderr": ""
+    },
+    {
+      "name": "buf.build/acme/slow:v1.0.0",
+      "remote": "buf.build",
+      "durationSeconds": null,
+      "cached": false,
+      "bytes": 125,
+      "insertionPoints": ["imports"],
+      "files": [
+        {"name": "a/v1/a.slow.go", "bytes": 100},
+        {"name": "a/v1/a.fast.go", "insertionPoint": "imports", "bytes": 20},
+        {"name": "a/v1/a.fast.go", "insertionPoint": "imports", "bytes": 5}
+      ],
+      "stderr": null
+    }
+  ]
+}`,
+		buffer.String(),
+	)
+
+	require.Error(t, WritePluginReports(&buffer, pluginReports, "yaml"))
+}
@@ -104,6 +104,10 @@ type lsp struct {
 
 	lock sync.Mutex
 
+	// The workspace folders opened in the client, sorted so that nested folders come
+	// before their parents. This is guarded by lock.
+	workspaceFolders []*workspaceFolder
+
 	// These are atomics, because they are read often and written to
 	// almost never, but potentially concurrently. Having them side-by-side
 	// is fine; they are almost never written to so false sharing is not a
@@ -121,6 +125,13 @@ func (l *lsp) init(_ context.Context, params *protocol.InitializeParams) error {
 	}
 	l.initParams.Store(params)
 
+	workspaceFolders := params.WorkspaceFolders
+	if len(workspaceFolders) == 0 && params.RootURI != "" {
+		// Clients that do not support workspace folders may still send a root.
+		workspaceFolders = []protocol.WorkspaceFolder{{URI: string(params.RootURI)}}
+	}
+	l.setWorkspaceFolders(workspaceFolders)
+
 	// TODO: set up logging. We need to forward everything from server.logger through to
 	// the client, if tracing is turned on. The right way to do this is with an extra
 	// goroutine and some channels.
@@ -131,7 +142,8 @@ func (l *lsp) init(_ context.Context, params *protocol.InitializeParams) error {
 // newHandler constructs an RPC handler that wraps the default one from jsonrpc2. This allows us
 // to inject debug logging, tracing, and timeouts to requests.
 func (l *lsp) newHandler() jsonrpc2.Handler {
-	actual := protocol.ServerHandler(newServer(l), nil)
+	server := newServer(l)
+	actual := protocol.ServerHandler(server, nil)
 	return jsonrpc2.AsyncHandler(func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
 		l.logger.Debug(
 			"handling request",
@@ -153,7 +165,11 @@ func (l *lsp) newHandler() jsonrpc2.Handler {
 			err = replier(ctx, nil, fmt.Errorf("the first call to the server must be the %q method", protocol.MethodInitialize))
 		} else {
 			l.lock.Lock()
-			err = actual(ctx, replier, req)
+			if req.Method() == protocol.MethodTextDocumentDidChange {
+				err = server.handleDidChange(ctx, replier, req)
+			} else {
+				err = actual(ctx, replier, req)
+			}
 			l.lock.Unlock()
 		}
 
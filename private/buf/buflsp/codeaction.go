@@ -0,0 +1,556 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file defines code actions, which fix the diagnostics reported by lint and breaking
+// checks, and organize the imports of a file.
+
+package buflsp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"buf.build/go/standard/xlog/xslog"
+	"github.com/bufbuild/buf/private/buf/bufrefactor"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/protocompile/ast"
+	"go.lsp.dev/protocol"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// sourceRemoveUnusedImports is the kind of the code action that removes all unused imports.
+	sourceRemoveUnusedImports protocol.CodeActionKind = "source.removeUnusedImports"
+
+	lintSource     = "buf lint"
+	breakingSource = "buf breaking"
+)
+
+// reservedOnDeleteRuleIDs are the IDs of the breaking rules that are fixed by reserving
+// the numbers and names of deleted fields or enum values.
+var reservedOnDeleteRuleIDs = map[string]struct{}{
+	"ENUM_VALUE_NO_DELETE":                        {},
+	"ENUM_VALUE_NO_DELETE_UNLESS_NAME_RESERVED":   {},
+	"ENUM_VALUE_NO_DELETE_UNLESS_NUMBER_RESERVED": {},
+	"FIELD_NO_DELETE":                             {},
+	"FIELD_NO_DELETE_UNLESS_NAME_RESERVED":        {},
+	"FIELD_NO_DELETE_UNLESS_NUMBER_RESERVED":      {},
+}
+
+// checkAnnotation is a lint or breaking annotation that was reported as a diagnostic.
+type checkAnnotation struct {
+	source     string
+	annotation bufanalysis.FileAnnotation
+}
+
+// CodeActions returns the code actions for the given diagnostics of this file, limited to
+// the given kinds. If no kinds are given, all code actions are returned.
+//
+// This operation requires RunChecks() for the actions that fix lint and breaking diagnostics.
+func (f *file) CodeActions(
+	ctx context.Context,
+	diagnostics []protocol.Diagnostic,
+	only []protocol.CodeActionKind,
+) []protocol.CodeAction {
+	if f.fileNode == nil {
+		return nil
+	}
+
+	var actions []protocol.CodeAction
+	if isCodeActionKindRequested(protocol.QuickFix, only) {
+		for _, diagnostic := range diagnostics {
+			actions = append(actions, f.quickFixes(ctx, diagnostic)...)
+		}
+	}
+	if isCodeActionKindRequested(protocol.SourceOrganizeImports, only) {
+		if textEdits := f.organizeImportsEdits(); len(textEdits) > 0 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "Organize imports",
+				Kind:  protocol.SourceOrganizeImports,
+				Edit:  f.newFileEdit(textEdits...),
+			})
+		}
+	}
+	if isCodeActionKindRequested(sourceRemoveUnusedImports, only) {
+		var textEdits []protocol.TextEdit
+		for _, node := range f.unusedImports() {
+			textEdits = append(textEdits, f.deleteLinesEdit(node))
+		}
+		if len(textEdits) > 0 {
+			actions = append(actions, protocol.CodeAction{
+				Title: "Remove unused imports",
+				Kind:  sourceRemoveUnusedImports,
+				Edit:  f.newFileEdit(textEdits...),
+			})
+		}
+	}
+	return actions
+}
+
+// quickFixes returns the code actions that fix the given diagnostic.
+func (f *file) quickFixes(ctx context.Context, diagnostic protocol.Diagnostic) []protocol.CodeAction {
+	newQuickFix := func(title string, edit *protocol.WorkspaceEdit) protocol.CodeAction {
+		return protocol.CodeAction{
+			Title:       title,
+			Kind:        protocol.QuickFix,
+			Diagnostics: []protocol.Diagnostic{diagnostic},
+			Edit:        edit,
+		}
+	}
+
+	if diagnostic.Source == serverName {
+		// The compiler warns about unused imports.
+		for _, node := range f.unusedImports() {
+			if infoToRange(f.fileNode.NodeInfo(node)).Start.Line == diagnostic.Range.Start.Line {
+				return []protocol.CodeAction{newQuickFix(
+					fmt.Sprintf("Remove unused import %q", node.Name.AsString()),
+					f.newFileEdit(f.deleteLinesEdit(node)),
+				)}
+			}
+		}
+		return nil
+	}
+
+	annotation := f.findCheckAnnotation(diagnostic)
+	if annotation == nil {
+		return nil
+	}
+	ruleID := annotation.annotation.Type()
+	var actions []protocol.CodeAction
+	switch annotation.source {
+	case lintSource:
+		if len(annotation.annotation.SuggestedEdits()) > 0 {
+			edit, err := f.lintFixEdit(ctx, annotation.annotation)
+			if err != nil {
+				f.lsp.logger.Warn(
+					"could not fix lint annotation",
+					slog.String("uri", string(f.uri)),
+					slog.String("rule", ruleID),
+					xslog.ErrorAttr(err),
+				)
+			} else if edit != nil {
+				action := newQuickFix(fmt.Sprintf("Fix %s", ruleID), edit)
+				action.IsPreferred = true
+				actions = append(actions, action)
+			}
+		}
+		actions = append(actions, newQuickFix(
+			fmt.Sprintf("Ignore %s with a buf:lint:ignore comment", ruleID),
+			f.newFileEdit(f.lintIgnoreEdit(ruleID, diagnostic.Range.Start.Line)),
+		))
+	case breakingSource:
+		if _, ok := reservedOnDeleteRuleIDs[ruleID]; ok {
+			if textEdit := f.reservedOnDeleteEdit(diagnostic.Range.Start); textEdit != nil {
+				action := newQuickFix("Reserve the deleted numbers and names", f.newFileEdit(*textEdit))
+				action.IsPreferred = true
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+// findCheckAnnotation returns the lint or breaking annotation that was reported as the
+// given diagnostic, or nil if there is none.
+func (f *file) findCheckAnnotation(diagnostic protocol.Diagnostic) *checkAnnotation {
+	for i := range f.checkAnnotations {
+		checkAnnotation := &f.checkAnnotations[i]
+		if checkAnnotation.source == diagnostic.Source &&
+			checkAnnotation.annotation.Type() == fmt.Sprint(diagnostic.Code) &&
+			annotationToRange(checkAnnotation.annotation) == diagnostic.Range {
+			return checkAnnotation
+		}
+	}
+	return nil
+}
+
+// lintFixEdit returns the edit that mechanically fixes the lint annotation across the
+// workspace, or nil if the annotation cannot be safely fixed.
+//
+// This operation requires IndexImports().
+func (f *file) lintFixEdit(ctx context.Context, annotation bufanalysis.FileAnnotation) (*protocol.WorkspaceEdit, error) {
+	if f.workspace == nil || f.module == nil {
+		return nil, nil
+	}
+	refactorer, err := f.newRefactorer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edits, err := refactorer.SuggestedEdits(ctx, annotation)
+	if err != nil {
+		return nil, err
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	if err := refactorer.Apply(ctx, edits...); err != nil {
+		if errors.Is(err, bufrefactor.ErrUnsafeEdit) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return f.newWorkspaceEdit(refactorer)
+}
+
+// lintIgnoreEdit returns the edit that adds a buf:lint:ignore comment for the rule above
+// the given line.
+func (f *file) lintIgnoreEdit(ruleID string, line uint32) protocol.TextEdit {
+	text := f.lineText(line)
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	start := protocol.Position{Line: line}
+	return protocol.TextEdit{
+		Range:   protocol.Range{Start: start, End: start},
+		NewText: indent + "// buf:lint:ignore " + ruleID + "\n",
+	}
+}
+
+// reservedOnDeleteEdit returns the edit that reserves the numbers and names of the fields or
+// enum values that were deleted from the message or enum at the given position, compared to
+// the --against image.
+//
+// This operation requires BuildImages().
+func (f *file) reservedOnDeleteEdit(position protocol.Position) *protocol.TextEdit {
+	if f.image == nil || f.againstImage == nil {
+		return nil
+	}
+	node, fullName := f.findMessageOrEnumAt(position)
+	if node == nil {
+		return nil
+	}
+	current := findDescriptorByName(f.image, fullName)
+	against := findDescriptorByName(f.againstImage, fullName)
+	if current == nil || against == nil {
+		return nil
+	}
+
+	var numbers []string
+	var names []string
+	addDeleted := func(
+		number protoreflect.FieldNumber,
+		name protoreflect.Name,
+		isNumberUsed bool,
+		isNameUsed bool,
+		reservedRanges interface {
+			Has(protoreflect.FieldNumber) bool
+		},
+		reservedNames protoreflect.Names,
+	) {
+		if isNumberUsed {
+			return
+		}
+		if !reservedRanges.Has(number) {
+			numbers = append(numbers, strconv.Itoa(int(number)))
+		}
+		if !isNameUsed && !reservedNames.Has(name) {
+			names = append(names, string(name))
+		}
+	}
+	var openBrace *ast.RuneNode
+	switch node := node.(type) {
+	case *ast.MessageNode:
+		currentMessage, ok1 := current.(protoreflect.MessageDescriptor)
+		againstMessage, ok2 := against.(protoreflect.MessageDescriptor)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		openBrace = node.OpenBrace
+		againstFields := againstMessage.Fields()
+		for i := range againstFields.Len() {
+			field := againstFields.Get(i)
+			addDeleted(
+				field.Number(),
+				field.Name(),
+				currentMessage.Fields().ByNumber(field.Number()) != nil,
+				currentMessage.Fields().ByName(field.Name()) != nil,
+				currentMessage.ReservedRanges(),
+				currentMessage.ReservedNames(),
+			)
+		}
+	case *ast.EnumNode:
+		currentEnum, ok1 := current.(protoreflect.EnumDescriptor)
+		againstEnum, ok2 := against.(protoreflect.EnumDescriptor)
+		if !ok1 || !ok2 {
+			return nil
+		}
+		openBrace = node.OpenBrace
+		againstValues := againstEnum.Values()
+		for i := range againstValues.Len() {
+			value := againstValues.Get(i)
+			addDeleted(
+				protoreflect.FieldNumber(value.Number()),
+				value.Name(),
+				currentEnum.Values().ByNumber(value.Number()) != nil,
+				currentEnum.Values().ByName(value.Name()) != nil,
+				enumReservedRanges{currentEnum.ReservedRanges()},
+				currentEnum.ReservedNames(),
+			)
+		}
+	}
+	if len(numbers) == 0 && len(names) == 0 {
+		return nil
+	}
+
+	text := f.lineText(infoToRange(f.fileNode.NodeInfo(node)).Start.Line)
+	indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))] + "  "
+	var newText strings.Builder
+	if len(numbers) > 0 {
+		newText.WriteString("\n" + indent + "reserved " + strings.Join(numbers, ", ") + ";")
+	}
+	if len(names) > 0 {
+		if f.fileNode.Edition == nil {
+			// Before editions, reserved names are string literals.
+			for i, name := range names {
+				names[i] = strconv.Quote(name)
+			}
+		}
+		newText.WriteString("\n" + indent + "reserved " + strings.Join(names, ", ") + ";")
+	}
+	end := infoToRange(f.fileNode.NodeInfo(openBrace)).End
+	return &protocol.TextEdit{
+		Range:   protocol.Range{Start: end, End: end},
+		NewText: newText.String(),
+	}
+}
+
+// findMessageOrEnumAt returns the innermost message or enum that contains the position,
+// along with its full name.
+func (f *file) findMessageOrEnumAt(position protocol.Position) (ast.Node, string) {
+	var found ast.Node
+	var foundFullName string
+	scope := strings.Join(f.Package(), ".")
+	decls := make([]ast.Node, 0, len(f.fileNode.Decls))
+	for _, decl := range f.fileNode.Decls {
+		decls = append(decls, decl)
+	}
+	for len(decls) > 0 {
+		var next []ast.Node
+		for _, decl := range decls {
+			var name string
+			switch decl := decl.(type) {
+			case *ast.MessageNode:
+				name = decl.Name.Val
+			case *ast.EnumNode:
+				name = decl.Name.Val
+			default:
+				continue
+			}
+			declRange := infoToRange(f.fileNode.NodeInfo(decl))
+			if comparePositions(declRange.Start, position) > 0 || comparePositions(position, declRange.End) >= 0 {
+				continue
+			}
+			found = decl
+			scope = joinName(scope, name)
+			foundFullName = scope
+			if message, ok := decl.(*ast.MessageNode); ok {
+				for _, child := range message.Decls {
+					next = append(next, child)
+				}
+			}
+			break
+		}
+		decls = next
+	}
+	return found, foundFullName
+}
+
+// organizeImportsEdits returns the edits that sort the imports of this file and remove
+// the unused imports.
+//
+// Imports are only sorted if they are next to each other without comments, so that no
+// comments are lost. Otherwise, unused imports are only removed.
+func (f *file) organizeImportsEdits() []protocol.TextEdit {
+	var imports []*ast.ImportNode
+	for _, decl := range f.fileNode.Decls {
+		if node, ok := decl.(*ast.ImportNode); ok {
+			imports = append(imports, node)
+		}
+	}
+	if len(imports) == 0 {
+		return nil
+	}
+	unusedImports := f.unusedImports()
+
+	canSort := true
+	for i, node := range imports {
+		info := f.fileNode.NodeInfo(node)
+		if info.LeadingComments().Len() > 0 || info.TrailingComments().Len() > 0 {
+			canSort = false
+			break
+		}
+		if i > 0 {
+			between := f.text[f.fileNode.NodeInfo(imports[i-1]).End().Offset+1 : info.Start().Offset]
+			if strings.TrimSpace(between) != "" {
+				canSort = false
+				break
+			}
+		}
+	}
+	if !canSort {
+		var textEdits []protocol.TextEdit
+		for _, node := range unusedImports {
+			textEdits = append(textEdits, f.deleteLinesEdit(node))
+		}
+		return textEdits
+	}
+
+	var kept []*ast.ImportNode
+	for _, node := range imports {
+		if !slices.Contains(unusedImports, node) {
+			kept = append(kept, node)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b *ast.ImportNode) int {
+		return cmp.Compare(a.Name.AsString(), b.Name.AsString())
+	})
+	statements := make([]string, len(kept))
+	for i, node := range kept {
+		info := f.fileNode.NodeInfo(node)
+		statements[i] = f.text[info.Start().Offset : info.End().Offset+1]
+	}
+	first := f.fileNode.NodeInfo(imports[0])
+	last := f.fileNode.NodeInfo(imports[len(imports)-1])
+	newText := strings.Join(statements, "\n")
+	if newText == f.text[first.Start().Offset:last.End().Offset+1] {
+		return nil
+	}
+	if len(kept) == 0 {
+		// Remove the lines of the imports entirely.
+		return []protocol.TextEdit{{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: uint32(first.Start().Line - 1)},
+				End:   protocol.Position{Line: uint32(last.End().Line)},
+			},
+		}}
+	}
+	return []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: infoToRange(first).Start,
+			End:   infoToRange(last).End,
+		},
+		NewText: newText,
+	}}
+}
+
+// unusedImports returns the imports of this file that the compiler reported as unused.
+//
+// This operation requires BuildImages().
+func (f *file) unusedImports() []*ast.ImportNode {
+	if f.image == nil || f.objectInfo == nil {
+		return nil
+	}
+	imageFile := f.image.GetFile(f.objectInfo.Path())
+	if imageFile == nil {
+		return nil
+	}
+	dependencies := imageFile.FileDescriptorProto().GetDependency()
+	unused := make(map[string]bool)
+	for _, index := range imageFile.UnusedDependencyIndexes() {
+		if int(index) < len(dependencies) {
+			unused[dependencies[index]] = true
+		}
+	}
+	var nodes []*ast.ImportNode
+	for _, decl := range f.fileNode.Decls {
+		if node, ok := decl.(*ast.ImportNode); ok && unused[node.Name.AsString()] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// deleteLinesEdit returns the edit that deletes the lines of the given node.
+func (f *file) deleteLinesEdit(node ast.Node) protocol.TextEdit {
+	info := f.fileNode.NodeInfo(node)
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: uint32(info.Start().Line - 1)},
+			End:   protocol.Position{Line: uint32(info.End().Line)},
+		},
+	}
+}
+
+// newFileEdit returns a WorkspaceEdit that makes the given edits to this file.
+func (f *file) newFileEdit(textEdits ...protocol.TextEdit) *protocol.WorkspaceEdit {
+	return &protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{f.uri: textEdits},
+	}
+}
+
+// lineText returns the text of the given line of this file, without its line ending.
+func (f *file) lineText(line uint32) string {
+	lines := strings.Split(f.text, "\n")
+	if int(line) >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line], "\r")
+}
+
+// enumReservedRanges adapts protoreflect.EnumRanges to check field numbers.
+type enumReservedRanges struct {
+	protoreflect.EnumRanges
+}
+
+func (r enumReservedRanges) Has(number protoreflect.FieldNumber) bool {
+	return r.EnumRanges.Has(protoreflect.EnumNumber(number))
+}
+
+// findDescriptorByName finds the descriptor with the given full name in the Image, or nil if
+// there is none.
+func findDescriptorByName(image bufimage.Image, fullName string) protoreflect.Descriptor {
+	resolver := image.Resolver()
+	if resolver == nil {
+		return nil
+	}
+	descriptor, err := resolver.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil
+	}
+	return descriptor
+}
+
+// annotationToRange returns the range of a lint or breaking annotation.
+func annotationToRange(annotation bufanalysis.FileAnnotation) protocol.Range {
+	// Annotations use 1-indexed lines and columns, like protocompile.
+	return protocol.Range{
+		Start: protocol.Position{
+			Line:      uint32(annotation.StartLine()) - 1,
+			Character: uint32(annotation.StartColumn()) - 1,
+		},
+		End: protocol.Position{
+			Line:      uint32(annotation.EndLine()) - 1,
+			Character: uint32(annotation.EndColumn()) - 1,
+		},
+	}
+}
+
+// isCodeActionKindRequested returns whether code actions of the kind were requested by
+// a client that only wants the given kinds.
+func isCodeActionKindRequested(kind protocol.CodeActionKind, only []protocol.CodeActionKind) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, requested := range only {
+		if kind == requested || strings.HasPrefix(string(kind), string(requested)+".") {
+			return true
+		}
+	}
+	return false
+}
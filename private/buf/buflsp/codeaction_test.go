@@ -0,0 +1,238 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buflsp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.lsp.dev/protocol"
+)
+
+func TestOrganizeImports(t *testing.T) {
+	t.Parallel()
+
+	file := newTestCodeActionFile(
+		t,
+		map[string]string{
+			"a.proto": `syntax = "proto3";
+
+import "c.proto";
+import "b.proto";
+import "d.proto";
+
+message A {
+  C c = 1;
+  B b = 2;
+}
+`,
+			"b.proto": `syntax = "proto3"; message B {}`,
+			"c.proto": `syntax = "proto3"; message C {}`,
+			"d.proto": `syntax = "proto3"; message D {}`,
+		},
+	)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+import "b.proto";
+import "c.proto";
+
+message A {
+  C c = 1;
+  B b = 2;
+}
+`,
+		applyTextEdits(t, file.text, file.organizeImportsEdits()),
+	)
+	unusedImports := file.unusedImports()
+	require.Len(t, unusedImports, 1)
+	assert.Equal(t, "d.proto", unusedImports[0].Name.AsString())
+}
+
+func TestLintIgnoreEdit(t *testing.T) {
+	t.Parallel()
+
+	file := newTestCodeActionFile(
+		t,
+		map[string]string{
+			"a.proto": `syntax = "proto3";
+
+message A {
+  string fooBar = 1;
+}
+`,
+		},
+	)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+message A {
+  // buf:lint:ignore FIELD_LOWER_SNAKE_CASE
+  string fooBar = 1;
+}
+`,
+		applyTextEdits(t, file.text, []protocol.TextEdit{file.lintIgnoreEdit("FIELD_LOWER_SNAKE_CASE", 3)}),
+	)
+}
+
+func TestReservedOnDeleteEdit(t *testing.T) {
+	t.Parallel()
+
+	file := newTestCodeActionFile(
+		t,
+		map[string]string{
+			"a.proto": `syntax = "proto3";
+
+package a.v1;
+
+message A {
+  message B {
+    string one = 1;
+  }
+  string one = 1;
+  reserved 3;
+}
+
+enum E {
+  E_UNSPECIFIED = 0;
+}
+`,
+		},
+	)
+	file.againstImage = buildTestImage(
+		t,
+		map[string]string{
+			"a.proto": `syntax = "proto3";
+
+package a.v1;
+
+message A {
+  message B {
+    string one = 1;
+    string two = 2;
+  }
+  string one = 1;
+  string two = 2;
+  string three = 3;
+  string four = 4;
+}
+
+enum E {
+  E_UNSPECIFIED = 0;
+  E_ONE = 1;
+}
+`,
+		},
+	)
+	testReservedOnDeleteEdit(
+		t,
+		file,
+		protocol.Position{Line: 4},
+		protocol.Position{Line: 4, Character: 11},
+		"\n  reserved 2, 4;\n  reserved \"two\", \"three\", \"four\";",
+	)
+	testReservedOnDeleteEdit(
+		t,
+		file,
+		protocol.Position{Line: 5, Character: 2},
+		protocol.Position{Line: 5, Character: 13},
+		"\n    reserved 2;\n    reserved \"two\";",
+	)
+	testReservedOnDeleteEdit(
+		t,
+		file,
+		protocol.Position{Line: 12},
+		protocol.Position{Line: 12, Character: 8},
+		"\n  reserved 1;\n  reserved \"E_ONE\";",
+	)
+	assert.Nil(t, file.reservedOnDeleteEdit(protocol.Position{Line: 2}))
+}
+
+func testReservedOnDeleteEdit(
+	t *testing.T,
+	file *file,
+	position protocol.Position,
+	expectedPosition protocol.Position,
+	expectedNewText string,
+) {
+	t.Helper()
+	textEdit := file.reservedOnDeleteEdit(position)
+	require.NotNil(t, textEdit)
+	assert.Equal(t, protocol.Range{Start: expectedPosition, End: expectedPosition}, textEdit.Range)
+	assert.Equal(t, expectedNewText, textEdit.NewText)
+}
+
+func newTestCodeActionFile(t *testing.T, pathToText map[string]string) *file {
+	file := &file{
+		lsp:  &lsp{logger: slog.New(slog.DiscardHandler)},
+		uri:  "file:///a.proto",
+		text: pathToText["a.proto"],
+	}
+	file.RefreshAST(context.Background())
+	require.NotNil(t, file.fileNode)
+	file.image = buildTestImage(t, pathToText)
+	readBucket, err := storagemem.NewReadBucket(map[string][]byte{"a.proto": []byte(file.text)})
+	require.NoError(t, err)
+	file.objectInfo, err = readBucket.Stat(context.Background(), "a.proto")
+	require.NoError(t, err)
+	return file
+}
+
+func buildTestImage(t *testing.T, pathToText map[string]string) bufimage.Image {
+	image, diagnostics := buildImage(
+		context.Background(),
+		[]string{"a.proto"},
+		slog.New(slog.DiscardHandler),
+		func(path string) (io.ReadCloser, error) {
+			text, ok := pathToText[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return io.NopCloser(strings.NewReader(text)), nil
+		},
+	)
+	require.NotNil(t, image, "%v", diagnostics)
+	return image
+}
+
+// applyTextEdits applies non-overlapping TextEdits to the text.
+func applyTextEdits(t *testing.T, text string, textEdits []protocol.TextEdit) string {
+	lineOffsets := []int{0}
+	for i := range len(text) {
+		if text[i] == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+	toOffset := func(position protocol.Position) int {
+		require.Less(t, int(position.Line), len(lineOffsets))
+		return lineOffsets[position.Line] + int(position.Character)
+	}
+	// Apply the edits from last to first, so that offsets remain valid.
+	for i := len(textEdits) - 1; i >= 0; i-- {
+		textEdit := textEdits[i]
+		text = text[:toOffset(textEdit.Range.Start)] + textEdit.NewText + text[toOffset(textEdit.Range.End):]
+	}
+	return text
+}
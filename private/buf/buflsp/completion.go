@@ -0,0 +1,575 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file defines code completion.
+//
+// A file that is being edited rarely parses, so completion does not use the symbols of a file.
+// Instead, the line under the cursor decides what to complete, and the rest of the file is
+// parsed without that line to find the declaration that contains the cursor.
+
+package buflsp
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"buf.build/go/standard/xlog/xslog"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"go.lsp.dev/protocol"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var (
+	importCompletionPattern               = regexp.MustCompile(`^\s*import\s+(?:(?:public|weak)\s+)?"([^"]*)$`)
+	optionStatementNameCompletionPattern  = regexp.MustCompile(`^\s*option\s+(\(?[\w.]*)$`)
+	optionBracketNameCompletionPattern    = regexp.MustCompile(`[\[,]\s*(\(?[\w.]*)$`)
+	optionStatementValueCompletionPattern = regexp.MustCompile(`^\s*option\s+(\(?[\w.]+\)?)\s*=\s*([\w.]*)$`)
+	optionBracketValueCompletionPattern   = regexp.MustCompile(`[\[,]\s*(\(?[\w.]+\)?)\s*=\s*([\w.]*)$`)
+	fieldNumberCompletionPattern          = regexp.MustCompile(`^\s*(?:(?:optional|required|repeated)\s+)?(?:map\s*<[^>]*>|[\w.]+)\s+\w+\s*=\s*$`)
+	fieldTypeCompletionPattern            = regexp.MustCompile(`^\s*(?:(?:optional|required|repeated)\s+)?([\w.]*)$`)
+	mapValueTypeCompletionPattern         = regexp.MustCompile(`\bmap\s*<\s*\w+\s*,\s*([\w.]*)$`)
+	methodTypeCompletionPattern           = regexp.MustCompile(`(?:\brpc\s+\w+\s*|\breturns\s*)\(\s*(?:stream\s+)?([\w.]*)$`)
+)
+
+// Completions returns the completion items for the given cursor position in this file.
+//
+// This operation requires IndexImports().
+func (f *file) Completions(ctx context.Context, position protocol.Position) []protocol.CompletionItem {
+	lines := strings.SplitAfter(f.text, "\n")
+	if int(position.Line) >= len(lines) {
+		return nil
+	}
+	line := strings.TrimRight(lines[position.Line], "\r\n")
+	prefix := line[:min(int(position.Character), len(line))]
+
+	// Blank out the line being edited, so that the rest of the file is likely to parse.
+	lines[position.Line] = lines[position.Line][len(line):]
+	completer := &completer{
+		file:     f,
+		position: position,
+		prefix:   prefix,
+		result:   parseForCompletion(f.uri.Filename(), strings.Join(lines, "")),
+	}
+	return completer.Complete(ctx)
+}
+
+// completer computes the completion items for a single position.
+type completer struct {
+	file     *file
+	position protocol.Position
+	// The text of the line before the cursor.
+	prefix string
+	// The file, parsed without the line under the cursor. May be nil.
+	result parser.Result
+	// The declarations that contain the cursor, outermost first.
+	scopes []ast.Node
+
+	// The types and extensions visible from the file, by full name.
+	typeNames  []string
+	enums      map[string]*descriptorpb.EnumDescriptorProto
+	extensions []completionExtension
+}
+
+// completionExtension is an extension that is visible from the file being completed.
+type completionExtension struct {
+	fullName string
+	// The full name of the scope the extension is declared in, for resolving its type.
+	scope string
+	field *descriptorpb.FieldDescriptorProto
+}
+
+// Complete returns the completion items for the line under the cursor.
+func (c *completer) Complete(ctx context.Context) []protocol.CompletionItem {
+	if match := importCompletionPattern.FindStringSubmatch(c.prefix); match != nil {
+		return c.completeImports(match[1])
+	}
+	if c.result == nil {
+		return nil
+	}
+	c.findScopes()
+	c.indexVisible(ctx)
+
+	inBrackets := strings.LastIndexByte(c.prefix, '[') > strings.LastIndexByte(c.prefix, ']')
+	optionValuePattern, optionNamePattern := optionStatementValueCompletionPattern, optionStatementNameCompletionPattern
+	if inBrackets {
+		optionValuePattern, optionNamePattern = optionBracketValueCompletionPattern, optionBracketNameCompletionPattern
+	}
+	options := c.optionsDescriptor(inBrackets)
+	if match := optionValuePattern.FindStringSubmatch(c.prefix); match != nil {
+		if options == nil {
+			return nil
+		}
+		return c.completeOptionValues(options, match[1], match[2])
+	}
+	if match := optionNamePattern.FindStringSubmatch(c.prefix); match != nil {
+		if options == nil {
+			return nil
+		}
+		return c.completeOptionNames(options, inBrackets, match[1])
+	}
+
+	switch c.scope().(type) {
+	case *ast.MessageNode, *ast.GroupNode, *ast.OneofNode, *ast.ExtendNode:
+		if fieldNumberCompletionPattern.MatchString(c.prefix) {
+			return c.completeFieldNumber()
+		}
+		if match := mapValueTypeCompletionPattern.FindStringSubmatch(c.prefix); match != nil {
+			return c.completeTypes(match[1], true)
+		}
+		if match := fieldTypeCompletionPattern.FindStringSubmatch(c.prefix); match != nil {
+			return c.completeTypes(match[1], true)
+		}
+	case *ast.ServiceNode:
+		if match := methodTypeCompletionPattern.FindStringSubmatch(c.prefix); match != nil {
+			return c.completeTypes(match[1], false)
+		}
+	}
+	return nil
+}
+
+// completeImports completes the paths of the files that can be imported.
+func (c *completer) completeImports(typed string) []protocol.CompletionItem {
+	imported := make(map[string]bool)
+	if c.file.objectInfo != nil {
+		imported[c.file.objectInfo.Path()] = true
+	}
+	for name := range c.file.importToFile {
+		imported[name] = true
+	}
+	var paths []string
+	for path := range c.file.importablePathToObject {
+		if !imported[path] && strings.HasPrefix(path, typed) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	items := make([]protocol.CompletionItem, len(paths))
+	for i, path := range paths {
+		items[i] = c.newItem(path, protocol.CompletionItemKindFile, "", typed)
+	}
+	return items
+}
+
+// completeTypes completes the names of the types visible from the file.
+func (c *completer) completeTypes(typed string, includeEnums bool) []protocol.CompletionItem {
+	var items []protocol.CompletionItem
+	pkg := c.result.FileDescriptorProto().GetPackage()
+	for _, fullName := range c.typeNames {
+		kind := protocol.CompletionItemKindStruct
+		if _, ok := c.enums[fullName]; ok {
+			if !includeEnums {
+				continue
+			}
+			kind = protocol.CompletionItemKindEnum
+		}
+		items = append(items, c.newItem(relativeName(fullName, pkg), kind, fullName, typed))
+	}
+	if !includeEnums {
+		// Enums and scalars are not valid method inputs or outputs.
+		return items
+	}
+	var scalars []string
+	for name := range builtinDocs {
+		if name != "default" {
+			scalars = append(scalars, name)
+		}
+	}
+	slices.Sort(scalars)
+	for _, name := range scalars {
+		item := c.newItem(name, protocol.CompletionItemKindKeyword, "", typed)
+		item.Documentation = strings.Join(builtinDocs[name], "\n")
+		items = append(items, item)
+	}
+	return items
+}
+
+// completeOptionNames completes the names of the built-in and custom options that can be
+// set in the current scope.
+func (c *completer) completeOptionNames(
+	options protoreflect.MessageDescriptor,
+	inBrackets bool,
+	typed string,
+) []protocol.CompletionItem {
+	var items []protocol.CompletionItem
+	fields := options.Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if field.Name() == "uninterpreted_option" {
+			continue
+		}
+		item := c.newItem(string(field.Name()), protocol.CompletionItemKindProperty, optionTypeName(field), typed)
+		if fieldOptions, ok := field.Options().(*descriptorpb.FieldOptions); ok {
+			item.Deprecated = fieldOptions.GetDeprecated()
+		}
+		items = append(items, item)
+	}
+	if inBrackets && options.FullName() == (&descriptorpb.FieldOptions{}).ProtoReflect().Descriptor().FullName() {
+		item := c.newItem("default", protocol.CompletionItemKindProperty, "", typed)
+		item.Documentation = strings.Join(builtinDocs["default"], "\n")
+		items = append(items, item, c.newItem("json_name", protocol.CompletionItemKindProperty, "string", typed))
+	}
+	pkg := c.result.FileDescriptorProto().GetPackage()
+	for _, extension := range c.extensions {
+		if !extendeeMatches(extension.field.GetExtendee(), string(options.FullName())) {
+			continue
+		}
+		items = append(items, c.newItem(
+			"("+relativeName(extension.fullName, pkg)+")",
+			protocol.CompletionItemKindProperty,
+			extension.fullName,
+			typed,
+		))
+	}
+	return items
+}
+
+// completeOptionValues completes the values of enum and bool options.
+func (c *completer) completeOptionValues(
+	options protoreflect.MessageDescriptor,
+	optionName string,
+	typed string,
+) []protocol.CompletionItem {
+	var values []string
+	var detail string
+	if strings.HasPrefix(optionName, "(") {
+		extension := c.findExtension(strings.TrimSuffix(strings.TrimPrefix(optionName, "("), ")"))
+		if extension == nil {
+			return nil
+		}
+		switch {
+		case extension.field.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+			values = []string{"true", "false"}
+		case extension.field.GetTypeName() != "":
+			enumName, enum := c.resolveEnum(extension.scope, extension.field.GetTypeName())
+			if enum == nil {
+				return nil
+			}
+			detail = enumName
+			for _, value := range enum.GetValue() {
+				values = append(values, value.GetName())
+			}
+		}
+	} else {
+		field := options.Fields().ByName(protoreflect.Name(optionName))
+		if field == nil {
+			return nil
+		}
+		switch {
+		case field.Kind() == protoreflect.BoolKind:
+			values = []string{"true", "false"}
+		case field.Enum() != nil:
+			detail = string(field.Enum().FullName())
+			enumValues := field.Enum().Values()
+			for i := range enumValues.Len() {
+				values = append(values, string(enumValues.Get(i).Name()))
+			}
+		}
+	}
+	items := make([]protocol.CompletionItem, len(values))
+	for i, value := range values {
+		items[i] = c.newItem(value, protocol.CompletionItemKindEnumMember, detail, typed)
+	}
+	return items
+}
+
+// completeFieldNumber completes the next free field number of the message that contains
+// the cursor.
+func (c *completer) completeFieldNumber() []protocol.CompletionItem {
+	var messageNode ast.Node
+	for _, scope := range slices.Backward(c.scopes) {
+		switch scope.(type) {
+		case *ast.OneofNode:
+			// Fields in a oneof are numbered within the enclosing message.
+			continue
+		case *ast.MessageNode, *ast.GroupNode:
+			messageNode = scope
+		}
+		break
+	}
+	if messageNode == nil {
+		return nil
+	}
+	var message *descriptorpb.DescriptorProto
+	var find func([]*descriptorpb.DescriptorProto)
+	find = func(messages []*descriptorpb.DescriptorProto) {
+		for _, candidate := range messages {
+			if ast.Node(c.result.MessageNode(candidate)) == messageNode {
+				message = candidate
+				return
+			}
+			find(candidate.GetNestedType())
+		}
+	}
+	find(c.result.FileDescriptorProto().GetMessageType())
+	if message == nil {
+		return nil
+	}
+	number := bufimageutil.NextFreeFieldNumber(message)
+	if number == 0 {
+		return nil
+	}
+	item := c.newItem(strconv.Itoa(int(number)), protocol.CompletionItemKindValue, "Next free field number", "")
+	item.Preselect = true
+	return []protocol.CompletionItem{item}
+}
+
+// newItem returns a completion item that replaces the typed text before the cursor with
+// the label.
+func (c *completer) newItem(
+	label string,
+	kind protocol.CompletionItemKind,
+	detail string,
+	typed string,
+) protocol.CompletionItem {
+	start := c.position
+	start.Character -= uint32(len(typed))
+	return protocol.CompletionItem{
+		Label:  label,
+		Kind:   kind,
+		Detail: detail,
+		TextEdit: &protocol.TextEdit{
+			Range:   protocol.Range{Start: start, End: c.position},
+			NewText: label,
+		},
+	}
+}
+
+// scope returns the innermost declaration that contains the cursor, or nil if the cursor
+// is at the top level of the file.
+func (c *completer) scope() ast.Node {
+	if len(c.scopes) == 0 {
+		return nil
+	}
+	return c.scopes[len(c.scopes)-1]
+}
+
+// findScopes finds the declarations that contain the cursor.
+func (c *completer) findScopes() {
+	fileNode := c.result.AST()
+	var node ast.Node = fileNode
+	for {
+		composite, ok := node.(ast.CompositeNode)
+		if !ok {
+			return
+		}
+		var next ast.Node
+		for _, child := range composite.Children() {
+			switch child.(type) {
+			case *ast.MessageNode, *ast.GroupNode, *ast.OneofNode, *ast.ExtendNode,
+				*ast.EnumNode, *ast.ServiceNode, *ast.RPCNode:
+				childRange := infoToRange(fileNode.NodeInfo(child))
+				if comparePositions(childRange.Start, c.position) < 0 && comparePositions(c.position, childRange.End) < 0 {
+					next = child
+				}
+			}
+		}
+		if next == nil {
+			return
+		}
+		c.scopes = append(c.scopes, next)
+		node = next
+	}
+}
+
+// optionsDescriptor returns the descriptor of the options message for options set in
+// the current scope, or nil if options cannot be set.
+func (c *completer) optionsDescriptor(inBrackets bool) protoreflect.MessageDescriptor {
+	var options interface{ ProtoReflect() protoreflect.Message }
+	switch c.scope().(type) {
+	case nil:
+		if !inBrackets {
+			options = &descriptorpb.FileOptions{}
+		}
+	case *ast.MessageNode, *ast.GroupNode:
+		if inBrackets {
+			options = &descriptorpb.FieldOptions{}
+		} else {
+			options = &descriptorpb.MessageOptions{}
+		}
+	case *ast.OneofNode:
+		if inBrackets {
+			options = &descriptorpb.FieldOptions{}
+		} else {
+			options = &descriptorpb.OneofOptions{}
+		}
+	case *ast.ExtendNode:
+		if inBrackets {
+			options = &descriptorpb.FieldOptions{}
+		}
+	case *ast.EnumNode:
+		if inBrackets {
+			options = &descriptorpb.EnumValueOptions{}
+		} else {
+			options = &descriptorpb.EnumOptions{}
+		}
+	case *ast.ServiceNode:
+		if !inBrackets {
+			options = &descriptorpb.ServiceOptions{}
+		}
+	case *ast.RPCNode:
+		if !inBrackets {
+			options = &descriptorpb.MethodOptions{}
+		}
+	}
+	if options == nil {
+		return nil
+	}
+	return options.ProtoReflect().Descriptor()
+}
+
+// indexVisible indexes the types and extensions of the file and its direct imports.
+func (c *completer) indexVisible(ctx context.Context) {
+	c.enums = make(map[string]*descriptorpb.EnumDescriptorProto)
+	visible := []*descriptorpb.FileDescriptorProto{c.result.FileDescriptorProto()}
+	if opener := c.file.newFileOpener(); opener != nil {
+		for _, importPath := range c.result.FileDescriptorProto().GetDependency() {
+			data, err := readAll(opener, importPath)
+			if err != nil {
+				c.file.lsp.logger.DebugContext(
+					ctx,
+					"could not read import for completion",
+					slog.String("import", importPath),
+					xslog.ErrorAttr(err),
+				)
+				continue
+			}
+			if result := parseForCompletion(importPath, string(data)); result != nil {
+				visible = append(visible, result.FileDescriptorProto())
+			}
+		}
+	}
+	for _, fileDescriptor := range visible {
+		pkg := fileDescriptor.GetPackage()
+		c.indexTypes(pkg, fileDescriptor.GetMessageType(), fileDescriptor.GetEnumType(), fileDescriptor.GetExtension())
+	}
+}
+
+// indexTypes indexes the given types and extensions declared in the given scope, recursively.
+func (c *completer) indexTypes(
+	scope string,
+	messages []*descriptorpb.DescriptorProto,
+	enums []*descriptorpb.EnumDescriptorProto,
+	extensions []*descriptorpb.FieldDescriptorProto,
+) {
+	for _, extension := range extensions {
+		c.extensions = append(c.extensions, completionExtension{
+			fullName: joinName(scope, extension.GetName()),
+			scope:    scope,
+			field:    extension,
+		})
+	}
+	for _, enum := range enums {
+		fullName := joinName(scope, enum.GetName())
+		c.typeNames = append(c.typeNames, fullName)
+		c.enums[fullName] = enum
+	}
+	for _, message := range messages {
+		if message.GetOptions().GetMapEntry() {
+			continue
+		}
+		fullName := joinName(scope, message.GetName())
+		c.typeNames = append(c.typeNames, fullName)
+		c.indexTypes(fullName, message.GetNestedType(), message.GetEnumType(), message.GetExtension())
+	}
+}
+
+// findExtension finds a visible extension by the name it is referred to in an option.
+func (c *completer) findExtension(name string) *completionExtension {
+	name = strings.TrimPrefix(name, ".")
+	for i := range c.extensions {
+		extension := &c.extensions[i]
+		if extension.fullName == name || strings.HasSuffix(extension.fullName, "."+name) {
+			return extension
+		}
+	}
+	return nil
+}
+
+// resolveEnum resolves the type name of a field declared in the given scope to a visible enum,
+// following the scoping rules of Protobuf.
+func (c *completer) resolveEnum(scope string, typeName string) (string, *descriptorpb.EnumDescriptorProto) {
+	if fullName, ok := strings.CutPrefix(typeName, "."); ok {
+		return fullName, c.enums[fullName]
+	}
+	for {
+		fullName := joinName(scope, typeName)
+		if enum, ok := c.enums[fullName]; ok {
+			return fullName, enum
+		}
+		if scope == "" {
+			return "", nil
+		}
+		scope = scope[:max(strings.LastIndexByte(scope, '.'), 0)]
+	}
+}
+
+// parseForCompletion parses a file for completion, without reporting any errors.
+//
+// Returns nil if the file does not parse.
+func parseForCompletion(filename string, text string) parser.Result {
+	handler := reporter.NewHandler(nil)
+	fileNode, err := parser.Parse(filename, strings.NewReader(text), handler)
+	if err != nil {
+		return nil
+	}
+	// The descriptors are usable even if they are not valid.
+	result, _ := parser.ResultFromAST(fileNode, false, handler)
+	return result
+}
+
+// extendeeMatches returns whether the extendee of an extension, as written in the file that
+// declares it, can refer to the message with the given full name.
+func extendeeMatches(extendee string, fullName string) bool {
+	if name, ok := strings.CutPrefix(extendee, "."); ok {
+		return name == fullName
+	}
+	return extendee == fullName || strings.HasSuffix(fullName, "."+extendee)
+}
+
+// optionTypeName returns the name of the type of an option, for display.
+func optionTypeName(field protoreflect.FieldDescriptor) string {
+	switch {
+	case field.Message() != nil:
+		return string(field.Message().FullName())
+	case field.Enum() != nil:
+		return string(field.Enum().FullName())
+	default:
+		return field.Kind().String()
+	}
+}
+
+// relativeName returns the name that refers to the given full name from within the given
+// package.
+func relativeName(fullName string, pkg string) string {
+	if name, ok := strings.CutPrefix(fullName, pkg+"."); ok && pkg != "" {
+		return name
+	}
+	return fullName
+}
+
+// joinName joins a scope and a name into a full name.
+func joinName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
@@ -0,0 +1,116 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buflsp
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+const testCompletionFile = `syntax = "proto3";
+
+package acme.weather.v1;
+
+import "google/protobuf/descriptor.proto";
+
+option optimize_for = $;
+
+extend google.protobuf.FieldOptions {
+  Level level = 50000;
+  bool sensitive = 50001;
+}
+
+enum Level {
+  LEVEL_UNSPECIFIED = 0;
+  LEVEL_HIGH = 1;
+}
+
+message Forecast {
+  reserved 3;
+  string name = 1;
+  Level level = 2;
+  $
+}
+
+service WeatherService {
+  rpc GetForecast(Fo$) returns (Forecast);
+}
+`
+
+func TestCompletions(t *testing.T) {
+	t.Parallel()
+
+	testCompletions(t, "field-type", "  Fo", 1, []string{"Forecast"}, []string{"GetForecast"})
+	testCompletions(t, "field-type-scalar", "  repeated str", 1, []string{"string", "Level"}, nil)
+	testCompletions(t, "map-value-type", "  map<string, Le", 1, []string{"Level", "Forecast"}, nil)
+	testCompletions(t, "field-number", "  string description = ", 1, []string{"4"}, []string{"3"})
+	testCompletions(t, "field-option-name", "  string description = 4 [dep", 1, []string{"deprecated", "json_name", "(level)", "(sensitive)"}, nil)
+	testCompletions(t, "field-option-value", "  string description = 4 [deprecated = true, (level) = ", 1, []string{"LEVEL_HIGH"}, nil)
+	testCompletions(t, "field-option-bool", "  string description = 4 [(sensitive) = ", 1, []string{"true", "false"}, nil)
+	testCompletions(t, "message-option-name", "  option ", 1, []string{"deprecated", "map_entry"}, []string{"(level)"})
+	testCompletions(t, "file-option-value", "option optimize_for = ", 0, []string{"SPEED", "CODE_SIZE", "LITE_RUNTIME"}, nil)
+	testCompletions(t, "method-type", "  rpc GetForecast(Fo", 2, []string{"Forecast"}, []string{"Level", "string"})
+}
+
+func testCompletions(
+	t *testing.T,
+	name string,
+	line string,
+	marker int,
+	expectedLabels []string,
+	unexpectedLabels []string,
+) {
+	t.Run(name, func(t *testing.T) {
+		t.Parallel()
+
+		// Replace the line of the marker with the given line, and fill in all other markers.
+		fillers := []string{"SPEED", "", "Forecast"}
+		lines := strings.Split(testCompletionFile, "\n")
+		var position protocol.Position
+		var markers int
+		for i, text := range lines {
+			if !strings.Contains(text, "$") {
+				continue
+			}
+			markers++
+			if markers-1 == marker {
+				lines[i] = line
+				position = protocol.Position{Line: uint32(i), Character: uint32(len(line))}
+			} else {
+				lines[i] = strings.ReplaceAll(text, "$", fillers[markers-1])
+			}
+		}
+		file := &file{
+			lsp:  &lsp{logger: slog.New(slog.DiscardHandler)},
+			uri:  "file:///acme/weather/v1/weather.proto",
+			text: strings.Join(lines, "\n"),
+		}
+		var labels []string
+		for _, item := range file.Completions(context.Background(), position) {
+			labels = append(labels, item.Label)
+		}
+		for _, expected := range expectedLabels {
+			assert.Contains(t, labels, expected)
+		}
+		for _, unexpected := range unexpectedLabels {
+			assert.NotContains(t, labels, unexpected)
+		}
+	})
+}
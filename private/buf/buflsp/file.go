@@ -24,9 +24,11 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
+	"unicode/utf16"
 
 	"buf.build/go/standard/xio"
 	"buf.build/go/standard/xlog/xslog"
@@ -81,6 +83,12 @@ type file struct {
 	importToFile        map[string]*file
 	symbols             []*symbol
 	image, againstImage bufimage.Image
+
+	// The lint and breaking annotations that were reported as diagnostics, for code actions.
+	checkAnnotations []checkAnnotation
+
+	// The files indexed by IndexWorkspace(), and the files it holds open.
+	workspaceFiles, indexedFiles []*file
 }
 
 // IsWKT returns whether this file corresponds to a well-known type.
@@ -120,14 +128,56 @@ func (f *file) Reset(ctx context.Context) {
 	f.fileNode = nil
 	f.packageNode = nil
 	f.diagnostics = nil
+	f.checkAnnotations = nil
 	f.importablePathToObject = nil
-	f.importToFile = nil
 	f.symbols = nil
 	f.image = nil
+	f.workspaceFiles = nil
+
+	for _, indexed := range f.indexedFiles {
+		indexed.Close(ctx)
+	}
+	f.indexedFiles = nil
 
 	for _, imported := range f.importToFile {
 		imported.Close(ctx)
 	}
+	f.importToFile = nil
+}
+
+// dependsOnPaths returns whether the file may be affected by changes to the files at the
+// given local paths, that is, whether any of them is within the module of the file, or is
+// within the import closure of the file.
+//
+// Returns true if the module or the imports of the file are not known.
+//
+// This operation requires BuildImages().
+func (f *file) dependsOnPaths(paths map[string]struct{}) bool {
+	if f.objectInfo == nil || f.image == nil || f.importablePathToObject == nil {
+		return true
+	}
+	localPath := f.objectInfo.LocalPath()
+	relativePath := filepath.FromSlash(f.objectInfo.Path())
+	if !strings.HasSuffix(localPath, relativePath) {
+		return true
+	}
+	moduleDirPath := filepath.Clean(strings.TrimSuffix(localPath, relativePath))
+	for path := range paths {
+		if isWithinDir(path, moduleDirPath) {
+			return true
+		}
+	}
+	// The image contains the import closure of the file.
+	for _, imageFile := range f.image.Files() {
+		objectInfo, ok := f.importablePathToObject[imageFile.Path()]
+		if !ok {
+			continue
+		}
+		if _, ok := paths[objectInfo.LocalPath()]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Close marks a file as closed.
@@ -181,6 +231,17 @@ func (f *file) Update(ctx context.Context, version int32, text string) {
 	f.hasText = true
 }
 
+// ApplyChanges updates the contents of this file by applying incremental changes received
+// from the LSP client.
+func (f *file) ApplyChanges(ctx context.Context, version int32, changes []textDocumentContentChangeEvent) error {
+	text, err := applyContentChanges(f.text, changes)
+	if err != nil {
+		return fmt.Errorf("could not apply changes to %q: %w", f.uri, err)
+	}
+	f.Update(ctx, version, text)
+	return nil
+}
+
 // RefreshSettings refreshes configuration settings for this file.
 //
 // This only needs to happen when the file is open or when the client signals
@@ -380,6 +441,7 @@ func (f *file) RefreshAST(ctx context.Context) bool {
 
 	f.fileNode = parsed
 	f.diagnostics = report.diagnostics
+	f.checkAnnotations = nil
 	f.lsp.logger.Debug(fmt.Sprintf("got %v diagnostic(s)", len(f.diagnostics)))
 
 	// Search for a potential package node.
@@ -426,7 +488,7 @@ func (f *file) PublishDiagnostics(ctx context.Context) {
 
 // FindModule finds the Buf module for this file.
 func (f *file) FindModule(ctx context.Context) {
-	workspace, err := f.lsp.controller.GetWorkspace(ctx, f.uri.Filename())
+	workspace, err := f.lsp.getWorkspace(ctx, f.uri)
 	if err != nil {
 		f.lsp.logger.Warn("could not load workspace", slog.String("uri", string(f.uri)), xslog.ErrorAttr(err))
 		return
@@ -477,13 +539,16 @@ func (f *file) IndexImports(ctx context.Context) {
 		return
 	}
 
-	importable, err := findImportable(ctx, f.uri, f.lsp)
-	if err != nil {
-		f.lsp.logger.Warn(fmt.Sprintf("could not compute importable files for %s: %s", f.uri, err))
-		if f.importablePathToObject == nil {
+	// The importable files may already be known, e.g. for dependencies indexed by
+	// IndexWorkspace(), in which case we avoid building the workspace again.
+	importable := f.importablePathToObject
+	if importable == nil {
+		var err error
+		importable, err = findImportable(ctx, f.uri, f.lsp)
+		if err != nil {
+			f.lsp.logger.Warn(fmt.Sprintf("could not compute importable files for %s: %s", f.uri, err))
 			return
 		}
-	} else if f.importablePathToObject == nil {
 		f.importablePathToObject = importable
 	}
 
@@ -675,7 +740,7 @@ func (f *file) BuildImages(ctx context.Context) {
 	}
 
 	if opener := f.newFileOpener(); opener != nil {
-		image, diagnostics := buildImage(ctx, f.objectInfo.Path(), f.lsp.logger, opener)
+		image, diagnostics := buildImage(ctx, []string{f.objectInfo.Path()}, f.lsp.logger, opener)
 		if len(diagnostics) > 0 {
 			f.diagnostics = diagnostics
 		}
@@ -686,7 +751,7 @@ func (f *file) BuildImages(ctx context.Context) {
 
 	if opener := f.newAgainstFileOpener(ctx); opener != nil {
 		// We explicitly throw the diagnostics away.
-		image, diagnostics := buildImage(ctx, f.objectInfo.Path(), f.lsp.logger, opener)
+		image, diagnostics := buildImage(ctx, []string{f.objectInfo.Path()}, f.lsp.logger, opener)
 
 		f.againstImage = image
 		if image == nil {
@@ -716,7 +781,7 @@ func (f *file) RunLints(ctx context.Context) bool {
 	}
 
 	f.lsp.logger.Debug(fmt.Sprintf("running lint for %q in %v", f.uri, f.module.FullName()))
-	return f.appendLintErrors("buf lint", f.checkClient.Lint(
+	return f.appendLintErrors(lintSource, f.checkClient.Lint(
 		ctx,
 		f.workspace.GetLintConfigForOpaqueID(f.module.OpaqueID()),
 		f.image,
@@ -744,7 +809,7 @@ func (f *file) RunBreaking(ctx context.Context) bool {
 	}
 
 	f.lsp.logger.Debug(fmt.Sprintf("running breaking for %q in %v", f.uri, f.module.FullName()))
-	return f.appendLintErrors("buf breaking", f.checkClient.Breaking(
+	return f.appendLintErrors(breakingSource, f.checkClient.Breaking(
 		ctx,
 		f.workspace.GetBreakingConfigForOpaqueID(f.module.OpaqueID()),
 		f.image,
@@ -772,21 +837,16 @@ func (f *file) appendLintErrors(source string, err error) bool {
 
 	for _, annotation := range annotations.FileAnnotations() {
 		f.diagnostics = append(f.diagnostics, protocol.Diagnostic{
-			Range: protocol.Range{
-				Start: protocol.Position{
-					Line:      uint32(annotation.StartLine()) - 1,
-					Character: uint32(annotation.StartColumn()) - 1,
-				},
-				End: protocol.Position{
-					Line:      uint32(annotation.EndLine()) - 1,
-					Character: uint32(annotation.EndColumn()) - 1,
-				},
-			},
+			Range:    annotationToRange(annotation),
 			Code:     annotation.Type(),
 			Severity: protocol.DiagnosticSeverityWarning,
 			Source:   source,
 			Message:  annotation.Message(),
 		})
+		f.checkAnnotations = append(f.checkAnnotations, checkAnnotation{
+			source:     source,
+			annotation: annotation,
+		})
 	}
 
 	return true
@@ -873,7 +933,7 @@ func findImportable(
 	//
 	// Doing the file walk here manually helps us retain some control over what
 	// data is discarded.
-	workspace, err := lsp.controller.GetWorkspace(ctx, uri.Filename())
+	workspace, err := lsp.getWorkspace(ctx, uri)
 	if err != nil {
 		return nil, err
 	}
@@ -912,3 +972,74 @@ func findImportable(
 type wktObjectInfo struct {
 	storage.ObjectInfo
 }
+
+// textDocumentContentChangeEvent is a change to the contents of a file received from
+// the LSP client.
+//
+// Unlike [protocol.TextDocumentContentChangeEvent], the range is optional, as a change
+// without a range replaces the full contents of the file.
+type textDocumentContentChangeEvent struct {
+	Range *protocol.Range `json:"range,omitempty"`
+	Text  string          `json:"text"`
+}
+
+// didChangeTextDocumentParams is [protocol.DidChangeTextDocumentParams] with changes
+// that have optional ranges.
+type didChangeTextDocumentParams struct {
+	TextDocument   protocol.VersionedTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []textDocumentContentChangeEvent         `json:"contentChanges"`
+}
+
+// applyContentChanges applies changes from the client to text, in order.
+//
+// Changes without a range replace the full text.
+func applyContentChanges(text string, changes []textDocumentContentChangeEvent) (string, error) {
+	for _, change := range changes {
+		if change.Range == nil {
+			text = change.Text
+			continue
+		}
+		start, err := positionToOffset(text, change.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := positionToOffset(text, change.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("invalid range %v", *change.Range)
+		}
+		text = text[:start] + change.Text + text[end:]
+	}
+	return text, nil
+}
+
+// positionToOffset converts a position into a byte offset into text.
+//
+// The character of a position is in UTF-16 code units, which is the only position
+// encoding that the LSP specification requires. Characters past the end of a line are
+// clamped to the end of the line, and characters within a surrogate pair are rounded
+// up to the end of the pair.
+func positionToOffset(text string, position protocol.Position) (int, error) {
+	offset := 0
+	for range position.Line {
+		index := strings.IndexByte(text[offset:], '\n')
+		if index < 0 {
+			return 0, fmt.Errorf("line %d is out of range", position.Line)
+		}
+		offset += index + 1
+	}
+	line := text[offset:]
+	if lineLength := strings.IndexByte(line, '\n'); lineLength >= 0 {
+		line = line[:lineLength]
+	}
+	var character uint32
+	for index, r := range line {
+		if character >= position.Character {
+			return offset + index, nil
+		}
+		character += uint32(utf16.RuneLen(r))
+	}
+	return offset + len(line), nil
+}
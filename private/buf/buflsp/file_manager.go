@@ -62,3 +62,24 @@ func (fm *fileManager) Close(ctx context.Context, uri protocol.URI) {
 		deleted.Reset(ctx)
 	}
 }
+
+// Invalidate resets and refreshes every file open in the editor for which the given
+// function returns true.
+//
+// This is used when files change on disk, which may change the workspace, the importable
+// files, or the contents of imports of open files.
+func (fm *fileManager) Invalidate(ctx context.Context, isAffected func(*file) bool) {
+	var files []*file
+	fm.uriToFile.Range(func(_ protocol.URI, file *file) bool {
+		if file.IsOpenInEditor() && isAffected(file) {
+			files = append(files, file)
+		}
+		return true
+	})
+	// Files are refreshed outside of Range, since refreshing a file may open its imports,
+	// which requires the lock held by Range.
+	for _, file := range files {
+		file.Reset(ctx)
+		file.Refresh(ctx)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buflsp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.lsp.dev/protocol"
+)
+
+func TestApplyContentChanges(t *testing.T) {
+	t.Parallel()
+
+	newChange := func(startLine, startChar, endLine, endChar uint32, text string) textDocumentContentChangeEvent {
+		return textDocumentContentChangeEvent{
+			Range: &protocol.Range{
+				Start: protocol.Position{Line: startLine, Character: startChar},
+				End:   protocol.Position{Line: endLine, Character: endChar},
+			},
+			Text: text,
+		}
+	}
+	const text = "syntax = \"proto3\";\n\nmessage Foo {}\n"
+	tests := []struct {
+		name     string
+		changes  []textDocumentContentChangeEvent
+		expected string
+	}{
+		{
+			name:     "insert",
+			changes:  []textDocumentContentChangeEvent{newChange(2, 13, 2, 13, "\n  string bar = 1;\n")},
+			expected: "syntax = \"proto3\";\n\nmessage Foo {\n  string bar = 1;\n}\n",
+		},
+		{
+			name:     "replace",
+			changes:  []textDocumentContentChangeEvent{newChange(2, 8, 2, 11, "Bar")},
+			expected: "syntax = \"proto3\";\n\nmessage Bar {}\n",
+		},
+		{
+			name:     "delete-across-lines",
+			changes:  []textDocumentContentChangeEvent{newChange(0, 18, 2, 0, "\n")},
+			expected: "syntax = \"proto3\";\nmessage Foo {}\n",
+		},
+		{
+			name: "in-order",
+			changes: []textDocumentContentChangeEvent{
+				newChange(2, 8, 2, 11, "Bar"),
+				newChange(2, 8, 2, 8, "Baz"),
+			},
+			expected: "syntax = \"proto3\";\n\nmessage BazBar {}\n",
+		},
+		{
+			name:     "clamp-to-end-of-line",
+			changes:  []textDocumentContentChangeEvent{newChange(2, 13, 2, 100, "")},
+			expected: "syntax = \"proto3\";\n\nmessage Foo {\n",
+		},
+		{
+			name:     "append-at-end",
+			changes:  []textDocumentContentChangeEvent{newChange(3, 0, 3, 0, "// EOF\n")},
+			expected: text + "// EOF\n",
+		},
+		{
+			name:     "full",
+			changes:  []textDocumentContentChangeEvent{{Text: "syntax = \"proto2\";\n"}},
+			expected: "syntax = \"proto2\";\n",
+		},
+		{
+			name: "full-then-insert",
+			changes: []textDocumentContentChangeEvent{
+				newChange(0, 0, 0, 0, "// prepended\n"),
+				{Text: "message Bar {}\n"},
+				newChange(0, 8, 0, 11, "Baz"),
+			},
+			expected: "message Baz {}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := applyContentChanges(text, test.changes)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+
+	_, err := applyContentChanges(text, []textDocumentContentChangeEvent{newChange(5, 0, 5, 0, "")})
+	assert.Error(t, err)
+}
+
+func TestApplyContentChangesNonASCII(t *testing.T) {
+	t.Parallel()
+
+	newChange := func(line, startChar, endChar uint32, text string) textDocumentContentChangeEvent {
+		return textDocumentContentChangeEvent{
+			Range: &protocol.Range{
+				Start: protocol.Position{Line: line, Character: startChar},
+				End:   protocol.Position{Line: line, Character: endChar},
+			},
+			Text: text,
+		}
+	}
+	// "é" is 2 bytes and 1 UTF-16 code unit, "日" is 3 bytes and 1 UTF-16 code unit,
+	// and "😀" is 4 bytes and 2 UTF-16 code units.
+	const text = "// é日😀\nmessage Foo {} // 😀\n"
+	tests := []struct {
+		name     string
+		changes  []textDocumentContentChangeEvent
+		expected string
+	}{
+		{
+			name:     "after-two-byte",
+			changes:  []textDocumentContentChangeEvent{newChange(0, 4, 4, "e")},
+			expected: "// ée日😀\nmessage Foo {} // 😀\n",
+		},
+		{
+			name:     "replace-three-byte",
+			changes:  []textDocumentContentChangeEvent{newChange(0, 4, 5, "x")},
+			expected: "// éx😀\nmessage Foo {} // 😀\n",
+		},
+		{
+			name:     "replace-surrogate-pair",
+			changes:  []textDocumentContentChangeEvent{newChange(0, 5, 7, "!")},
+			expected: "// é日!\nmessage Foo {} // 😀\n",
+		},
+		{
+			name:     "after-surrogate-pair",
+			changes:  []textDocumentContentChangeEvent{newChange(0, 7, 7, "!")},
+			expected: "// é日😀!\nmessage Foo {} // 😀\n",
+		},
+		{
+			name:     "next-line",
+			changes:  []textDocumentContentChangeEvent{newChange(1, 8, 11, "Bar")},
+			expected: "// é日😀\nmessage Bar {} // 😀\n",
+		},
+		{
+			name:     "clamp-to-end-of-line",
+			changes:  []textDocumentContentChangeEvent{newChange(1, 18, 100, "")},
+			expected: "// é日😀\nmessage Foo {} // \n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := applyContentChanges(text, test.changes)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDidChangeTextDocumentParamsWithoutRange(t *testing.T) {
+	t.Parallel()
+
+	var params didChangeTextDocumentParams
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"textDocument":{"uri":"file:///a.proto","version":2},"contentChanges":[{"text":"message Foo {}\n"},{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"text":"// a\n"}]}`),
+		&params,
+	))
+	actual, err := applyContentChanges("syntax = \"proto3\";\n", params.ContentChanges)
+	require.NoError(t, err)
+	assert.Equal(t, "// a\nmessage Foo {}\n", actual)
+}
+
+func TestDependsOnPaths(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	tempDirPath := t.TempDir()
+	moduleDirPath := filepath.Join(tempDirPath, "module")
+	depDirPath := filepath.Join(tempDirPath, "dep")
+	pathToText := map[string]string{
+		"a.proto": `syntax = "proto3"; import "b.proto"; message A { B b = 1; }`,
+		"b.proto": `syntax = "proto3"; import "c.proto"; message B { C c = 1; }`,
+		"c.proto": `syntax = "proto3"; message C {}`,
+		"d.proto": `syntax = "proto3"; message D {}`,
+	}
+	pathToDirPath := map[string]string{
+		"a.proto": moduleDirPath,
+		"b.proto": moduleDirPath,
+		"c.proto": depDirPath,
+		"d.proto": depDirPath,
+	}
+	importable := make(map[string]storage.ObjectInfo)
+	for path, dirPath := range pathToDirPath {
+		require.NoError(t, os.MkdirAll(dirPath, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dirPath, path), []byte(pathToText[path]), 0600))
+		readBucket, err := storageos.NewProvider().NewReadWriteBucket(dirPath)
+		require.NoError(t, err)
+		importable[path], err = readBucket.Stat(ctx, path)
+		require.NoError(t, err)
+	}
+	testFile := &file{
+		lsp:                    &lsp{logger: slog.New(slog.DiscardHandler)},
+		objectInfo:             importable["a.proto"],
+		importablePathToObject: importable,
+		image:                  buildTestImage(t, pathToText),
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "same-file", path: filepath.Join(moduleDirPath, "a.proto"), expected: true},
+		{name: "new-file-in-module", path: filepath.Join(moduleDirPath, "e.proto"), expected: true},
+		{name: "transitive-import", path: filepath.Join(depDirPath, "c.proto"), expected: true},
+		{name: "not-imported", path: filepath.Join(depDirPath, "d.proto"), expected: false},
+		{name: "outside", path: filepath.Join(tempDirPath, "other", "a.proto"), expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, test.expected, testFile.dependsOnPaths(map[string]struct{}{test.path: {}}))
+		})
+	}
+	// The module and imports of a file that has not been built are not known.
+	assert.True(t, (&file{}).dependsOnPaths(map[string]struct{}{filepath.Join(tempDirPath, "other", "a.proto"): {}}))
+}
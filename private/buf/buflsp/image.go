@@ -39,12 +39,14 @@ import (
 // image.
 type fileOpener func(string) (io.ReadCloser, error)
 
-// buildImage builds a Buf Image for the given path. This does not use the controller to build
+// buildImage builds a Buf Image for the given paths. This does not use the controller to build
 // the image, because we need delicate control over the input files: namely, for the case
 // when we depend on a file that has been opened and modified in the editor.
+//
+// All other files in the Image are imports.
 func buildImage(
 	ctx context.Context,
-	path string,
+	paths []string,
 	logger *slog.Logger,
 	opener fileOpener,
 ) (bufimage.Image, []protocol.Diagnostic) {
@@ -57,18 +59,23 @@ func buildImage(
 		Reporter:       &report,
 	}
 
-	compiled, err := compiler.Compile(ctx, path)
+	compiled, err := compiler.Compile(ctx, paths...)
 	if err != nil {
-		logger.Warn("error building image", slog.String("path", path), xslog.ErrorAttr(err))
+		logger.Warn("error building image", slog.Any("paths", paths), xslog.ErrorAttr(err))
 	}
-	if compiled[0] == nil {
-		return nil, report.diagnostics
+	isTarget := make(map[string]bool, len(paths))
+	queue := make([]protoreflect.FileDescriptor, 0, len(paths))
+	for i, path := range paths {
+		if compiled[i] == nil {
+			return nil, report.diagnostics
+		}
+		isTarget[path] = true
+		queue = append(queue, compiled[i])
 	}
 
 	var imageFiles []bufimage.ImageFile
 	seen := map[string]bool{}
 
-	queue := []protoreflect.FileDescriptor{compiled[0]}
 	for len(queue) > 0 {
 		descriptor := queue[len(queue)-1]
 		queue = queue[:len(queue)-1]
@@ -114,7 +121,7 @@ func buildImage(
 			uuid.UUID{},
 			"",
 			descriptor.Path(),
-			descriptor.Path() != path,
+			!isTarget[descriptor.Path()],
 			report.syntaxMissing[descriptor.Path()],
 			unusedIndices,
 		)
@@ -127,13 +134,13 @@ func buildImage(
 	}
 
 	if err != nil {
-		logger.Warn("could not build image", slog.String("path", path), xslog.ErrorAttr(err))
+		logger.Warn("could not build image", slog.Any("paths", paths), xslog.ErrorAttr(err))
 		return nil, report.diagnostics
 	}
 
 	image, err := bufimage.NewImage(imageFiles)
 	if err != nil {
-		logger.Warn("could not build image", slog.String("path", path), xslog.ErrorAttr(err))
+		logger.Warn("could not build image", slog.Any("paths", paths), xslog.ErrorAttr(err))
 		return nil, report.diagnostics
 	}
 
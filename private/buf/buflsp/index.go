@@ -0,0 +1,288 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file defines the cross-file symbol index, which is used for finding references
+// and searching for symbols across a workspace.
+
+package buflsp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"buf.build/go/standard/xlog/xslog"
+	"github.com/bufbuild/protocompile/ast"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// IndexWorkspace indexes the symbols of every file that can be imported by this file. This
+// includes every file in its workspace, the dependencies of the workspace from the module
+// cache, and the well-known types.
+//
+// Returns all indexed files, including this file. The index is kept until this file is reset.
+//
+// This operation requires IndexImports().
+func (f *file) IndexWorkspace(ctx context.Context) []*file {
+	if f.workspaceFiles != nil {
+		return f.workspaceFiles
+	}
+	defer xslog.DebugProfile(f.lsp.logger, slog.String("uri", string(f.uri)))()
+
+	// The same file may appear in importablePathToObject multiple times, so we sort the paths
+	// to pick the same path for a file every time.
+	paths := make([]string, 0, len(f.importablePathToObject))
+	for path := range f.importablePathToObject {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	workspaceFiles := []*file{f}
+	seen := map[protocol.URI]bool{f.uri: true}
+	for _, path := range paths {
+		objectInfo := f.importablePathToObject[path]
+		fileURI := uri.File(objectInfo.LocalPath())
+		if seen[fileURI] {
+			continue
+		}
+		seen[fileURI] = true
+
+		// We hold a reference to each indexed file until this file is reset.
+		indexed := f.Manager().Open(ctx, fileURI)
+		f.indexedFiles = append(f.indexedFiles, indexed)
+		if indexed.objectInfo == nil {
+			indexed.objectInfo = objectInfo
+		}
+		if indexed.fileNode == nil {
+			if err := indexed.ReadFromDisk(ctx); err != nil {
+				f.lsp.logger.Warn(fmt.Sprintf("could not index %q: %s", fileURI, err))
+				continue
+			}
+			indexed.RefreshAST(ctx)
+		}
+		if indexed.importToFile == nil || indexed.symbols == nil {
+			// Files that were only opened as imports have symbols, but their references were
+			// never resolved. Dependencies in the module cache have no workspace of their own,
+			// so they share the importable files of this file.
+			if indexed.importablePathToObject == nil {
+				indexed.importablePathToObject = f.importablePathToObject
+			}
+			indexed.IndexImports(ctx)
+			indexed.IndexSymbols(ctx)
+		}
+		workspaceFiles = append(workspaceFiles, indexed)
+	}
+	f.workspaceFiles = workspaceFiles
+	return workspaceFiles
+}
+
+// References returns the locations of all references to the given definition in the
+// workspace of this file.
+//
+// This operation requires IndexImports().
+func (f *file) References(ctx context.Context, def *symbol, includeDeclaration bool) []protocol.Location {
+	var locations []protocol.Location
+	if includeDeclaration {
+		locations = append(locations, protocol.Location{URI: def.file.uri, Range: def.Range()})
+	}
+	for _, file := range f.IndexWorkspace(ctx) {
+		for _, symbol := range file.symbols {
+			if symbol.IsReferenceTo(def) {
+				locations = append(locations, protocol.Location{URI: file.uri, Range: symbol.Range()})
+			}
+		}
+	}
+	return locations
+}
+
+// DocumentHighlights returns the highlights for the definition of and references to the
+// given definition in this file.
+func (f *file) DocumentHighlights(def *symbol) []protocol.DocumentHighlight {
+	var highlights []protocol.DocumentHighlight
+	for _, symbol := range f.symbols {
+		switch {
+		case symbol == def:
+			highlights = append(highlights, protocol.DocumentHighlight{
+				Range: symbol.Range(),
+				Kind:  protocol.DocumentHighlightKindWrite,
+			})
+		case symbol.IsReferenceTo(def):
+			highlights = append(highlights, protocol.DocumentHighlight{
+				Range: symbol.Range(),
+				Kind:  protocol.DocumentHighlightKindRead,
+			})
+		}
+	}
+	return highlights
+}
+
+// DocumentSymbols returns the hierarchy of definitions in this file.
+func (f *file) DocumentSymbols() []protocol.DocumentSymbol {
+	type tree struct {
+		symbol   protocol.DocumentSymbol
+		children []*tree
+	}
+	var roots []*tree
+	if f.packageNode != nil {
+		roots = append(roots, &tree{symbol: protocol.DocumentSymbol{
+			Name:           string(f.packageNode.Name.AsIdentifier()),
+			Kind:           protocol.SymbolKindPackage,
+			Range:          infoToRange(f.fileNode.NodeInfo(f.packageNode)),
+			SelectionRange: infoToRange(f.fileNode.NodeInfo(f.packageNode.Name)),
+		}})
+	}
+	// Symbols are sorted by position, so parents are always visited before their children.
+	pathToTree := make(map[string]*tree)
+	for _, symbol := range f.symbols {
+		def, ok := symbol.kind.(*definition)
+		if !ok {
+			continue
+		}
+		kind, ok := symbolKindForNode(def.node)
+		if !ok {
+			continue
+		}
+		key := strings.Join(def.path, ".")
+		if _, ok := pathToTree[key]; ok {
+			// Groups define both a message and a field with the same path.
+			continue
+		}
+		node := &tree{symbol: protocol.DocumentSymbol{
+			Name:           def.path[len(def.path)-1],
+			Detail:         symbolDetailForNode(def.node),
+			Kind:           kind,
+			Range:          infoToRange(f.fileNode.NodeInfo(def.node)),
+			SelectionRange: symbol.Range(),
+		}}
+		pathToTree[key] = node
+		if parent, ok := pathToTree[strings.Join(def.path[:len(def.path)-1], ".")]; ok && len(def.path) > 1 {
+			parent.children = append(parent.children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	var toDocumentSymbols func([]*tree) []protocol.DocumentSymbol
+	toDocumentSymbols = func(trees []*tree) []protocol.DocumentSymbol {
+		if len(trees) == 0 {
+			return nil
+		}
+		documentSymbols := make([]protocol.DocumentSymbol, len(trees))
+		for i, tree := range trees {
+			documentSymbols[i] = tree.symbol
+			documentSymbols[i].Children = toDocumentSymbols(tree.children)
+		}
+		return documentSymbols
+	}
+	return toDocumentSymbols(roots)
+}
+
+// WorkspaceSymbols returns all definitions in the given files whose full names contain
+// the query, ignoring case.
+func workspaceSymbols(files []*file, query string) []protocol.SymbolInformation {
+	query = strings.ToLower(query)
+	var symbols []protocol.SymbolInformation
+	seen := make(map[string]bool)
+	for _, file := range files {
+		pkg := file.Package()
+		for _, symbol := range file.symbols {
+			def, ok := symbol.kind.(*definition)
+			if !ok {
+				continue
+			}
+			kind, ok := symbolKindForNode(def.node)
+			if !ok {
+				continue
+			}
+			fullName := strings.Join(slices.Concat(pkg, def.path), ".")
+			if !strings.Contains(strings.ToLower(fullName), query) {
+				continue
+			}
+			// Groups define both a message and a field with the same path.
+			key := string(file.uri) + ":" + fullName
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			symbols = append(symbols, protocol.SymbolInformation{
+				Name: def.path[len(def.path)-1],
+				Kind: kind,
+				Location: protocol.Location{
+					URI:   file.uri,
+					Range: symbol.Range(),
+				},
+				ContainerName: strings.Join(slices.Concat(pkg, def.path[:len(def.path)-1]), "."),
+			})
+		}
+	}
+	return symbols
+}
+
+// IsReferenceTo returns whether this symbol is a resolved reference to the given definition.
+func (s *symbol) IsReferenceTo(def *symbol) bool {
+	ref, ok := s.kind.(*reference)
+	if !ok || ref.file == nil {
+		return false
+	}
+	defKind, ok := def.kind.(*definition)
+	if !ok {
+		return false
+	}
+	return ref.file.uri == def.file.uri && slices.Equal(ref.path, defKind.path)
+}
+
+// symbolKindForNode returns the LSP symbol kind for a definition node.
+func symbolKindForNode(node ast.Node) (protocol.SymbolKind, bool) {
+	switch node.(type) {
+	case *ast.MessageNode, *ast.GroupNode:
+		return protocol.SymbolKindStruct, true
+	case *ast.FieldNode, *ast.MapFieldNode, *ast.OneofNode:
+		return protocol.SymbolKindField, true
+	case *ast.EnumNode:
+		return protocol.SymbolKindEnum, true
+	case *ast.EnumValueNode:
+		return protocol.SymbolKindEnumMember, true
+	case *ast.ServiceNode:
+		return protocol.SymbolKindInterface, true
+	case *ast.RPCNode:
+		return protocol.SymbolKindMethod, true
+	default:
+		return 0, false
+	}
+}
+
+// symbolDetailForNode returns the detail shown next to a definition in the document
+// symbol outline, such as the type of a field.
+func symbolDetailForNode(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.FieldNode:
+		return string(node.FldType.AsIdentifier())
+	case *ast.MapFieldNode:
+		return fmt.Sprintf(
+			"map<%s, %s>",
+			node.MapType.KeyType.AsIdentifier(),
+			node.MapType.ValueType.AsIdentifier(),
+		)
+	case *ast.RPCNode:
+		return fmt.Sprintf(
+			"(%s) returns (%s)",
+			node.Input.MessageType.AsIdentifier(),
+			node.Output.MessageType.AsIdentifier(),
+		)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buflsp
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.lsp.dev/protocol"
+)
+
+const testIndexFile = `syntax = "proto3";
+
+package acme.weather.v1;
+
+message Forecast {
+  Condition condition = 1;
+  map<string, Forecast> children = 2;
+  oneof when {
+    string day = 3;
+  }
+}
+
+enum Condition {
+  CONDITION_UNSPECIFIED = 0;
+}
+
+service WeatherService {
+  rpc GetForecast(Forecast) returns (Forecast);
+}
+`
+
+func TestDocumentSymbols(t *testing.T) {
+	t.Parallel()
+
+	file := newTestIndexFile(t)
+	type outline struct {
+		name     string
+		detail   string
+		kind     protocol.SymbolKind
+		children []outline
+	}
+	var toOutline func([]protocol.DocumentSymbol) []outline
+	toOutline = func(symbols []protocol.DocumentSymbol) []outline {
+		var outlines []outline
+		for _, symbol := range symbols {
+			outlines = append(outlines, outline{
+				name:     symbol.Name,
+				detail:   symbol.Detail,
+				kind:     symbol.Kind,
+				children: toOutline(symbol.Children),
+			})
+		}
+		return outlines
+	}
+	assert.Equal(
+		t,
+		[]outline{
+			{name: "acme.weather.v1", kind: protocol.SymbolKindPackage},
+			{
+				name: "Forecast",
+				kind: protocol.SymbolKindStruct,
+				children: []outline{
+					{name: "condition", detail: "Condition", kind: protocol.SymbolKindField},
+					{name: "children", detail: "map<string, Forecast>", kind: protocol.SymbolKindField},
+					{name: "when", kind: protocol.SymbolKindField},
+					{name: "day", detail: "string", kind: protocol.SymbolKindField},
+				},
+			},
+			{
+				name: "Condition",
+				kind: protocol.SymbolKindEnum,
+				children: []outline{
+					{name: "CONDITION_UNSPECIFIED", kind: protocol.SymbolKindEnumMember},
+				},
+			},
+			{
+				name: "WeatherService",
+				kind: protocol.SymbolKindInterface,
+				children: []outline{
+					{name: "GetForecast", detail: "(Forecast) returns (Forecast)", kind: protocol.SymbolKindMethod},
+				},
+			},
+		},
+		toOutline(file.DocumentSymbols()),
+	)
+}
+
+func TestWorkspaceSymbols(t *testing.T) {
+	t.Parallel()
+
+	indexFile := newTestIndexFile(t)
+	symbols := workspaceSymbols([]*file{indexFile}, "FORECAST")
+	var names []string
+	for _, symbol := range symbols {
+		names = append(names, symbol.ContainerName+"/"+symbol.Name)
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"acme.weather.v1/Forecast",
+			"acme.weather.v1.Forecast/condition",
+			"acme.weather.v1.Forecast/children",
+			"acme.weather.v1.Forecast/when",
+			"acme.weather.v1.Forecast/day",
+			"acme.weather.v1.WeatherService/GetForecast",
+		},
+		names,
+	)
+}
+
+func newTestIndexFile(t *testing.T) *file {
+	ctx := context.Background()
+	file := &file{
+		lsp:  &lsp{logger: slog.New(slog.DiscardHandler)},
+		uri:  "file:///acme/weather/v1/weather.proto",
+		text: testIndexFile,
+	}
+	file.RefreshAST(ctx)
+	require.NotNil(t, file.fileNode)
+	file.IndexSymbols(ctx)
+	return file
+}
@@ -0,0 +1,163 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file defines rename operations, which are performed with bufrefactor.
+
+package buflsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufrefactor"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/bufbuild/protocompile/ast"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// RenameTarget returns the definition that renaming this symbol would rename, along with
+// its full name.
+//
+// Returns nil if this symbol cannot be renamed.
+func (s *symbol) RenameTarget(ctx context.Context) (*symbol, string) {
+	def, node := s.Definition(ctx)
+	if def == nil || def.isOption || !def.file.IsLocal() {
+		return nil, ""
+	}
+	switch node.(type) {
+	case *ast.MessageNode, *ast.EnumNode, *ast.EnumValueNode, *ast.FieldNode,
+		*ast.MapFieldNode, *ast.OneofNode, *ast.ServiceNode, *ast.RPCNode:
+	default:
+		// Groups and other definitions cannot be renamed.
+		return nil, ""
+	}
+	kind, ok := def.kind.(*definition)
+	if !ok {
+		return nil, ""
+	}
+	return def, strings.Join(slices.Concat(def.file.Package(), kind.path), ".")
+}
+
+// NameRange returns the range of the last component of this symbol's name, which
+// is the part that renaming the symbol replaces.
+func (s *symbol) NameRange() protocol.Range {
+	if name, ok := s.name.(*ast.CompoundIdentNode); ok && len(name.Components) > 0 {
+		return infoToRange(s.file.fileNode.NodeInfo(name.Components[len(name.Components)-1]))
+	}
+	return s.Range()
+}
+
+// Rename renames the symbol with the given full name to the new name, in all of the
+// local files of this file's workspace.
+//
+// This operation requires IndexImports().
+func (f *file) Rename(ctx context.Context, fullName string, newName string) (*protocol.WorkspaceEdit, error) {
+	refactorer, err := f.newRefactorer(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot rename: %w", err)
+	}
+	newFullName := newName
+	if index := strings.LastIndexByte(fullName, '.'); index >= 0 {
+		newFullName = fullName[:index+1] + newName
+	}
+	if err := refactorer.Apply(ctx, bufrefactor.NewRenameEdit(fullName, newFullName)); err != nil {
+		return nil, err
+	}
+	return f.newWorkspaceEdit(refactorer)
+}
+
+// newRefactorer returns a new Refactorer for all of the local files of this file's workspace.
+//
+// This operation requires IndexImports().
+func (f *file) newRefactorer(ctx context.Context) (bufrefactor.Refactorer, error) {
+	opener := f.newFileOpener()
+	if opener == nil {
+		return nil, fmt.Errorf("imports of %q have not been resolved", f.uri)
+	}
+
+	// Only local files can be edited, but all of them must be built so that all references
+	// are updated. We use the editor's view of the files, which may not be saved yet.
+	var paths []string
+	pathToData := make(map[string][]byte)
+	for path, objectInfo := range f.importablePathToObject {
+		fileInfo, ok := objectInfo.(bufmodule.FileInfo)
+		if !ok || !fileInfo.Module().IsLocal() {
+			continue
+		}
+		data, err := readAll(opener, path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		pathToData[path] = data
+	}
+	slices.Sort(paths)
+	image, diagnostics := buildImage(ctx, paths, f.lsp.logger, opener)
+	if image == nil {
+		return nil, fmt.Errorf("%d build error(s) in the workspace", len(diagnostics))
+	}
+	readBucket, err := storagemem.NewReadBucket(pathToData)
+	if err != nil {
+		return nil, err
+	}
+	return bufrefactor.NewRefactorer(ctx, image, readBucket)
+}
+
+// newWorkspaceEdit returns the edits made by the Refactorer as a WorkspaceEdit.
+//
+// This operation requires IndexImports().
+func (f *file) newWorkspaceEdit(refactorer bufrefactor.Refactorer) (*protocol.WorkspaceEdit, error) {
+	changes := make(map[protocol.DocumentURI][]protocol.TextEdit)
+	for path, textEdits := range refactorer.TextEdits() {
+		objectInfo, ok := f.importablePathToObject[path]
+		if !ok {
+			return nil, fmt.Errorf("could not find local path for %q", path)
+		}
+		documentURI := uri.File(objectInfo.LocalPath())
+		for _, textEdit := range textEdits {
+			// TextEdits are 1-indexed, but LSP positions are 0-indexed.
+			changes[documentURI] = append(changes[documentURI], protocol.TextEdit{
+				Range: protocol.Range{
+					Start: protocol.Position{
+						Line:      uint32(textEdit.StartLine - 1),
+						Character: uint32(textEdit.StartColumn - 1),
+					},
+					End: protocol.Position{
+						Line:      uint32(textEdit.EndLine - 1),
+						Character: uint32(textEdit.EndColumn - 1),
+					},
+				},
+				NewText: textEdit.NewText,
+			})
+		}
+	}
+	return &protocol.WorkspaceEdit{Changes: changes}, nil
+}
+
+func readAll(opener fileOpener, path string) (_ []byte, retErr error) {
+	readCloser, err := opener(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, readCloser.Close())
+	}()
+	return io.ReadAll(readCloser)
+}
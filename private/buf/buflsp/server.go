@@ -19,13 +19,17 @@ package buflsp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"runtime/debug"
+	"slices"
 	"strings"
 
 	"github.com/bufbuild/buf/private/buf/bufformat"
 	"github.com/bufbuild/protocompile/ast"
+	"go.lsp.dev/jsonrpc2"
 	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
 )
 
 const (
@@ -68,7 +72,7 @@ type server struct {
 }
 
 // newServer creates a protocol.Server implementation out of an lsp.
-func newServer(lsp *lsp) protocol.Server {
+func newServer(lsp *lsp) *server {
 	return &server{lsp: lsp}
 }
 
@@ -104,25 +108,51 @@ func (s *server) Initialize(
 		Full   bool                 `json:"full"`
 	}
 
+	// We are interested in file operations on the same files that we watch.
+	fileOperationOptions := &protocol.FileOperationRegistrationOptions{}
+	for _, glob := range watchedFileGlobs {
+		fileOperationOptions.Filters = append(fileOperationOptions.Filters, protocol.FileOperationFilter{
+			Scheme:  "file",
+			Pattern: protocol.FileOperationPattern{Glob: glob},
+		})
+	}
+
 	return &protocol.InitializeResult{
 		Capabilities: protocol.ServerCapabilities{
 			// These are all the things we advertise to the client we can do.
 			// For now, incomplete features are explicitly disabled here as TODOs.
 			TextDocumentSync: &protocol.TextDocumentSyncOptions{
 				OpenClose: true,
-				// Request that whole files be sent to us. Protobuf IDL files don't
-				// usually get especially huge, so this simplifies our logic without
-				// necessarily making the LSP slow.
-				Change: protocol.TextDocumentSyncKindFull,
+				// Request that only the edited ranges of files be sent to us. Each
+				// change is applied to our copy of the file in order.
+				Change: protocol.TextDocumentSyncKindIncremental,
 				Save: &protocol.SaveOptions{
 					IncludeText: false,
 				},
 			},
+			CodeActionProvider: &protocol.CodeActionOptions{
+				CodeActionKinds: []protocol.CodeActionKind{
+					protocol.QuickFix,
+					protocol.SourceOrganizeImports,
+					sourceRemoveUnusedImports,
+				},
+			},
+			CompletionProvider: &protocol.CompletionOptions{
+				TriggerCharacters: []string{".", "\"", "/", "(", "[", "="},
+			},
 			DefinitionProvider: &protocol.DefinitionOptions{
 				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{WorkDoneProgress: true},
 			},
 			DocumentFormattingProvider: true,
+			DocumentHighlightProvider:  true,
+			DocumentSymbolProvider:     true,
 			HoverProvider:              true,
+			ReferencesProvider: &protocol.ReferenceOptions{
+				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{WorkDoneProgress: true},
+			},
+			RenameProvider: &protocol.RenameOptions{
+				PrepareProvider: true,
+			},
 			SemanticTokensProvider: &SemanticTokensOptions{
 				WorkDoneProgressOptions: protocol.WorkDoneProgressOptions{WorkDoneProgress: true},
 				Legend: SemanticTokensLegend{
@@ -131,6 +161,18 @@ func (s *server) Initialize(
 				},
 				Full: true,
 			},
+			WorkspaceSymbolProvider: true,
+			Workspace: &protocol.ServerCapabilitiesWorkspace{
+				WorkspaceFolders: &protocol.ServerCapabilitiesWorkspaceFolders{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+				FileOperations: &protocol.ServerCapabilitiesWorkspaceFileOperations{
+					DidCreate: fileOperationOptions,
+					DidRename: fileOperationOptions,
+					DidDelete: fileOperationOptions,
+				},
+			},
 		},
 		ServerInfo: info,
 	}, nil
@@ -155,6 +197,25 @@ func (s *server) Initialized(
 			},
 		})
 	}
+	didChangeWatchedFiles := workspaceCapabilities.DidChangeWatchedFiles
+	if didChangeWatchedFiles != nil && didChangeWatchedFiles.DynamicRegistration {
+		watchers := make([]protocol.FileSystemWatcher, len(watchedFileGlobs))
+		for i, glob := range watchedFileGlobs {
+			watchers[i] = protocol.FileSystemWatcher{GlobPattern: glob}
+		}
+		// The error is logged for us by the client wrapper.
+		_ = s.client.RegisterCapability(ctx, &protocol.RegistrationParams{
+			Registrations: []protocol.Registration{
+				{
+					ID:     protocol.MethodWorkspaceDidChangeWatchedFiles,
+					Method: protocol.MethodWorkspaceDidChangeWatchedFiles,
+					RegisterOptions: &protocol.DidChangeWatchedFilesRegistrationOptions{
+						Watchers: watchers,
+					},
+				},
+			},
+		})
+	}
 
 	return nil
 }
@@ -201,6 +262,81 @@ func (s *server) DidChangeConfiguration(
 	return nil
 }
 
+// DidChangeWorkspaceFolders is sent whenever the client adds or removes workspace folders.
+func (s *server) DidChangeWorkspaceFolders(
+	ctx context.Context,
+	params *protocol.DidChangeWorkspaceFoldersParams,
+) error {
+	s.removeWorkspaceFolders(params.Event.Removed)
+	s.addWorkspaceFolders(params.Event.Added)
+
+	// Open files in the changed folders now belong to a different workspace.
+	var paths []string
+	for _, folder := range slices.Concat(params.Event.Removed, params.Event.Added) {
+		paths = append(paths, uri.New(folder.URI).Filename())
+	}
+	s.fileManager.Invalidate(ctx, func(file *file) bool {
+		return slices.ContainsFunc(paths, func(path string) bool {
+			return isWithinDir(file.uri.Filename(), path)
+		})
+	})
+	return nil
+}
+
+// DidChangeWatchedFiles is sent whenever files watched by the client change on disk,
+// including changes made outside of the editor, such as by `buf dep update` or by
+// switching git branches.
+func (s *server) DidChangeWatchedFiles(
+	ctx context.Context,
+	params *protocol.DidChangeWatchedFilesParams,
+) error {
+	paths := make([]string, len(params.Changes))
+	for i, change := range params.Changes {
+		paths[i] = change.URI.Filename()
+	}
+	s.invalidatePaths(ctx, paths)
+	return nil
+}
+
+// DidCreateFiles is sent whenever files are created from within the client.
+func (s *server) DidCreateFiles(
+	ctx context.Context,
+	params *protocol.CreateFilesParams,
+) error {
+	paths := make([]string, len(params.Files))
+	for i, file := range params.Files {
+		paths[i] = uri.New(file.URI).Filename()
+	}
+	s.invalidatePaths(ctx, paths)
+	return nil
+}
+
+// DidRenameFiles is sent whenever files are renamed from within the client.
+func (s *server) DidRenameFiles(
+	ctx context.Context,
+	params *protocol.RenameFilesParams,
+) error {
+	paths := make([]string, 0, 2*len(params.Files))
+	for _, file := range params.Files {
+		paths = append(paths, uri.New(file.OldURI).Filename(), uri.New(file.NewURI).Filename())
+	}
+	s.invalidatePaths(ctx, paths)
+	return nil
+}
+
+// DidDeleteFiles is sent whenever files are deleted from within the client.
+func (s *server) DidDeleteFiles(
+	ctx context.Context,
+	params *protocol.DeleteFilesParams,
+) error {
+	paths := make([]string, len(params.Files))
+	for i, file := range params.Files {
+		paths[i] = uri.New(file.URI).Filename()
+	}
+	s.invalidatePaths(ctx, paths)
+	return nil
+}
+
 // -- File synchronization methods.
 
 // DidOpen is called whenever the client opens a document. This is our signal to parse
@@ -218,9 +354,43 @@ func (s *server) DidOpen(
 
 // DidChange is called whenever the client opens a document. This is our signal to parse
 // the file.
+//
+// The handler calls didChange instead, as [protocol.DidChangeTextDocumentParams] cannot
+// represent changes without a range.
 func (s *server) DidChange(
 	ctx context.Context,
 	params *protocol.DidChangeTextDocumentParams,
+) error {
+	changes := make([]textDocumentContentChangeEvent, len(params.ContentChanges))
+	for i, change := range params.ContentChanges {
+		changes[i] = textDocumentContentChangeEvent{
+			Range: &change.Range,
+			Text:  change.Text,
+		}
+	}
+	return s.didChange(ctx, &didChangeTextDocumentParams{
+		TextDocument:   params.TextDocument,
+		ContentChanges: changes,
+	})
+}
+
+// handleDidChange handles the textDocument/didChange notification the same as
+// [protocol.ServerHandler], except that the changes are decoded with optional ranges.
+func (s *server) handleDidChange(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	req jsonrpc2.Request,
+) error {
+	var params didChangeTextDocumentParams
+	if err := json.Unmarshal(req.Params(), &params); err != nil {
+		return reply(ctx, nil, fmt.Errorf("%w: %w", jsonrpc2.ErrParse, err))
+	}
+	return reply(ctx, nil, s.didChange(ctx, &params))
+}
+
+func (s *server) didChange(
+	ctx context.Context,
+	params *didChangeTextDocumentParams,
 ) error {
 	file := s.fileManager.Get(params.TextDocument.URI)
 	if file == nil {
@@ -228,7 +398,9 @@ func (s *server) DidChange(
 		return fmt.Errorf("received update for file that was not open: %q", params.TextDocument.URI)
 	}
 
-	file.Update(ctx, params.TextDocument.Version, params.ContentChanges[0].Text)
+	if err := file.ApplyChanges(ctx, params.TextDocument.Version, params.ContentChanges); err != nil {
+		return err
+	}
 	file.Refresh(ctx)
 	return nil
 }
@@ -278,8 +450,15 @@ func (s *server) Formatting(
 		return nil, nil
 	}
 
+	var formatOptions []bufformat.FormatOption
+	if file.workspace != nil && file.module != nil {
+		formatOptions = append(
+			formatOptions,
+			bufformat.FormatWithConfig(file.workspace.GetFormatConfigForOpaqueID(file.module.OpaqueID())),
+		)
+	}
 	var out strings.Builder
-	if err := bufformat.FormatFileNode(&out, file.fileNode); err != nil {
+	if err := bufformat.FormatFileNode(&out, file.fileNode, formatOptions...); err != nil {
 		return nil, err
 	}
 
@@ -365,6 +544,37 @@ func (s *server) Hover(
 	}, nil
 }
 
+// CodeAction is called to find the fixes for the diagnostics in a range of a file, and
+// the source actions for the file.
+func (s *server) CodeAction(
+	ctx context.Context,
+	params *protocol.CodeActionParams,
+) ([]protocol.CodeAction, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	return file.CodeActions(ctx, params.Context.Diagnostics, params.Context.Only), nil
+}
+
+// Completion is the entry point for code completion.
+func (s *server) Completion(
+	ctx context.Context,
+	params *protocol.CompletionParams,
+) (*protocol.CompletionList, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	items := file.Completions(ctx, params.Position)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &protocol.CompletionList{Items: items}, nil
+}
+
 // Definition is the entry point for go-to-definition.
 func (s *server) Definition(
 	ctx context.Context,
@@ -400,6 +610,156 @@ func (s *server) Definition(
 	return nil, nil
 }
 
+// PrepareRename is called to check whether the symbol at a position can be renamed,
+// and to find the range of its name.
+func (s *server) PrepareRename(
+	ctx context.Context,
+	params *protocol.PrepareRenameParams,
+) (*protocol.Range, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	symbol := file.SymbolAt(ctx, params.Position)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	if def, _ := symbol.RenameTarget(ctx); def == nil {
+		return nil, nil
+	}
+
+	range_ := symbol.NameRange()
+	return &range_, nil
+}
+
+// Rename is the entry point for renaming a symbol across the workspace.
+func (s *server) Rename(
+	ctx context.Context,
+	params *protocol.RenameParams,
+) (*protocol.WorkspaceEdit, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	symbol := file.SymbolAt(ctx, params.Position)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	def, fullName := symbol.RenameTarget(ctx)
+	if def == nil {
+		return nil, fmt.Errorf("cannot rename symbol at %d:%d", params.Position.Line+1, params.Position.Character+1)
+	}
+
+	return def.file.Rename(ctx, fullName, params.NewName)
+}
+
+// References is the entry point for finding all references to a symbol, in every file
+// of the workspace.
+func (s *server) References(
+	ctx context.Context,
+	params *protocol.ReferenceParams,
+) ([]protocol.Location, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	progress := newProgressFromClient(s.lsp, &params.WorkDoneProgressParams)
+	progress.Begin(ctx, "Searching")
+	defer progress.Done(ctx)
+
+	symbol := file.SymbolAt(ctx, params.Position)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	def, _ := symbol.Definition(ctx)
+	if def == nil {
+		return nil, nil
+	}
+
+	return file.References(ctx, def, params.Context.IncludeDeclaration), nil
+}
+
+// DocumentHighlight is called to highlight the definition of and references to the
+// symbol under the cursor in the current file.
+func (s *server) DocumentHighlight(
+	ctx context.Context,
+	params *protocol.DocumentHighlightParams,
+) ([]protocol.DocumentHighlight, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	symbol := file.SymbolAt(ctx, params.Position)
+	if symbol == nil {
+		return nil, nil
+	}
+
+	def, _ := symbol.Definition(ctx)
+	if def == nil {
+		return nil, nil
+	}
+
+	return file.DocumentHighlights(def), nil
+}
+
+// DocumentSymbol is called to render the outline of a file on the client.
+func (s *server) DocumentSymbol(
+	ctx context.Context,
+	params *protocol.DocumentSymbolParams,
+) ([]any, error) {
+	file := s.fileManager.Get(params.TextDocument.URI)
+	if file == nil {
+		return nil, nil
+	}
+
+	// The protocol allows either DocumentSymbols or SymbolInformations to be returned,
+	// hence the untyped slice.
+	var symbols []any
+	for _, symbol := range file.DocumentSymbols() {
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// Symbols is the entry point for searching for symbols in the workspaces of all files
+// that are open in the client.
+func (s *server) Symbols(
+	ctx context.Context,
+	params *protocol.WorkspaceSymbolParams,
+) ([]protocol.SymbolInformation, error) {
+	// Indexing a workspace opens more files, which cannot be done while ranging over the
+	// open files, so we collect them first.
+	var openFiles []*file
+	s.fileManager.uriToFile.Range(func(_ protocol.URI, file *file) bool {
+		if file.IsOpenInEditor() {
+			openFiles = append(openFiles, file)
+		}
+		return true
+	})
+	slices.SortFunc(openFiles, func(a, b *file) int {
+		return strings.Compare(string(a.uri), string(b.uri))
+	})
+
+	var files []*file
+	seen := make(map[protocol.URI]bool)
+	for _, openFile := range openFiles {
+		for _, file := range openFile.IndexWorkspace(ctx) {
+			if !seen[file.uri] {
+				seen[file.uri] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return workspaceSymbols(files, params.Query), nil
+}
+
 // SemanticTokensFull is called to render semantic token information on the client.
 func (s *server) SemanticTokensFull(
 	ctx context.Context,
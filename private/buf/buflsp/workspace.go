@@ -0,0 +1,215 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file defines workspace folder tracking, and the invalidation of files when
+// the workspace changes on disk.
+
+package buflsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufworkspace"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// watchedFileGlobs are the files the client is asked to watch. Changes to any of them
+// invalidate the files of the workspace folder that contains them.
+var watchedFileGlobs = []string{
+	"**/*.proto",
+	"**/" + bufconfig.DefaultBufYAMLFileName,
+	"**/" + bufconfig.DefaultBufLockFileName,
+	"**/" + bufconfig.DefaultBufWorkYAMLFileName,
+}
+
+// workspaceFolder is a workspace folder opened in the client.
+//
+// If the folder contains a buf.yaml or buf.work.yaml at its root, a single bufworkspace is
+// built for it and shared by all files within it. Otherwise, every file finds its own
+// workspace, as if no folder were open.
+type workspaceFolder struct {
+	path string
+
+	// Whether workspace has been loaded. The workspace is loaded lazily, and may be nil
+	// after loading if the folder has no configuration or the workspace failed to build.
+	loaded     bool
+	workspace  bufworkspace.Workspace
+	localPaths map[string]struct{}
+}
+
+// setWorkspaceFolders replaces the workspace folders of the server.
+//
+// This must be called with the LSP lock held.
+func (l *lsp) setWorkspaceFolders(folders []protocol.WorkspaceFolder) {
+	l.workspaceFolders = nil
+	l.addWorkspaceFolders(folders)
+}
+
+// addWorkspaceFolders adds workspace folders to the server.
+//
+// This must be called with the LSP lock held.
+func (l *lsp) addWorkspaceFolders(folders []protocol.WorkspaceFolder) {
+	for _, folder := range folders {
+		path := uri.New(folder.URI).Filename()
+		if slices.ContainsFunc(l.workspaceFolders, func(folder *workspaceFolder) bool {
+			return folder.path == path
+		}) {
+			continue
+		}
+		l.workspaceFolders = append(l.workspaceFolders, &workspaceFolder{path: path})
+	}
+	// Nested folders must be checked before their parents, so the longest paths go first.
+	slices.SortFunc(l.workspaceFolders, func(a, b *workspaceFolder) int {
+		return len(b.path) - len(a.path)
+	})
+}
+
+// removeWorkspaceFolders removes workspace folders from the server.
+//
+// This must be called with the LSP lock held.
+func (l *lsp) removeWorkspaceFolders(folders []protocol.WorkspaceFolder) {
+	for _, folder := range folders {
+		path := uri.New(folder.URI).Filename()
+		l.workspaceFolders = slices.DeleteFunc(l.workspaceFolders, func(folder *workspaceFolder) bool {
+			return folder.path == path
+		})
+	}
+}
+
+// findWorkspaceFolder returns the innermost workspace folder containing the given path,
+// or nil if the path is not in any workspace folder.
+func (l *lsp) findWorkspaceFolder(path string) *workspaceFolder {
+	for _, folder := range l.workspaceFolders {
+		if isWithinDir(path, folder.path) {
+			return folder
+		}
+	}
+	return nil
+}
+
+// getWorkspace returns the workspace for the file at the given URI.
+//
+// If the file is within a configured workspace folder, the folder's workspace is reused.
+func (l *lsp) getWorkspace(ctx context.Context, fileURI protocol.URI) (bufworkspace.Workspace, error) {
+	path := fileURI.Filename()
+	if folder := l.findWorkspaceFolder(path); folder != nil {
+		folder.load(ctx, l)
+		if _, ok := folder.localPaths[path]; ok {
+			return folder.workspace, nil
+		}
+	}
+	return l.controller.GetWorkspace(ctx, path)
+}
+
+// load builds the workspace of this folder, if it has not been built yet.
+func (w *workspaceFolder) load(ctx context.Context, lsp *lsp) {
+	if w.loaded {
+		return
+	}
+	w.loaded = true
+	if !hasWorkspaceConfig(w.path) {
+		return
+	}
+	workspace, err := lsp.controller.GetWorkspace(ctx, w.path)
+	if err != nil {
+		lsp.logger.Warn(fmt.Sprintf("could not load workspace for folder %q: %s", w.path, err))
+		return
+	}
+	localPaths := make(map[string]struct{})
+	for _, module := range workspace.Modules() {
+		if !module.IsLocal() {
+			continue
+		}
+		err := module.WalkFileInfos(ctx, func(fileInfo bufmodule.FileInfo) error {
+			localPaths[fileInfo.LocalPath()] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			lsp.logger.Warn(fmt.Sprintf("could not walk workspace for folder %q: %s", w.path, err))
+			return
+		}
+	}
+	w.workspace = workspace
+	w.localPaths = localPaths
+}
+
+// invalidate discards the workspace of this folder, so that it is rebuilt on next use.
+func (w *workspaceFolder) invalidate() {
+	w.loaded = false
+	w.workspace = nil
+	w.localPaths = nil
+}
+
+// invalidatePaths is called when the files at the given paths have changed on disk. It
+// discards any workspaces that may depend on them, and refreshes the files open in the
+// editor that may be affected.
+//
+// This must be called with the LSP lock held.
+func (l *lsp) invalidatePaths(ctx context.Context, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	// A change to a configuration file may change the modules and dependencies of any
+	// file, so every file is refreshed. Otherwise, only the files whose module or import
+	// closure contains a changed path are refreshed.
+	invalidAll := false
+	pathSet := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if folder := l.findWorkspaceFolder(path); folder != nil {
+			// The folder's workspace is only rebuilt on next use, so this is cheap.
+			folder.invalidate()
+		}
+		if isWorkspaceConfigFile(path) {
+			invalidAll = true
+		}
+		pathSet[path] = struct{}{}
+	}
+
+	l.fileManager.Invalidate(ctx, func(file *file) bool {
+		return invalidAll || file.dependsOnPaths(pathSet)
+	})
+}
+
+// hasWorkspaceConfig returns whether the directory contains a buf.yaml or buf.work.yaml.
+func hasWorkspaceConfig(dirPath string) bool {
+	for _, name := range []string{bufconfig.DefaultBufYAMLFileName, bufconfig.DefaultBufWorkYAMLFileName} {
+		if _, err := os.Stat(filepath.Join(dirPath, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isWorkspaceConfigFile returns whether the path is a buf.yaml, buf.lock, or buf.work.yaml.
+func isWorkspaceConfigFile(path string) bool {
+	switch filepath.Base(path) {
+	case bufconfig.DefaultBufYAMLFileName, bufconfig.DefaultBufLockFileName, bufconfig.DefaultBufWorkYAMLFileName:
+		return true
+	default:
+		return false
+	}
+}
+
+// isWithinDir returns whether path is dirPath, or is contained within it.
+func isWithinDir(path string, dirPath string) bool {
+	return path == dirPath || strings.HasPrefix(path, strings.TrimSuffix(dirPath, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -205,6 +205,7 @@ func (m *migrateBuilder) addModule(ctx context.Context, moduleDirPath string) (r
 				),
 				false,
 			),
+			bufconfig.DefaultFormatConfig,
 		)
 		if err != nil {
 			return err
@@ -281,6 +282,7 @@ func (m *migrateBuilder) addModule(ctx context.Context, moduleDirPath string) (r
 				map[string][]string{".": moduleConfig.RootToExcludes()[root]},
 				lintConfigForRoot,
 				breakingConfigForRoot,
+				moduleConfig.FormatConfig(),
 			)
 			if err != nil {
 				return err
@@ -317,6 +319,7 @@ func (m *migrateBuilder) addModule(ctx context.Context, moduleDirPath string) (r
 			moduleConfig.RootToExcludes(),
 			lintConfig,
 			breakingConfig,
+			moduleConfig.FormatConfig(),
 		)
 		if err != nil {
 			return err
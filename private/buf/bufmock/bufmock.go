@@ -0,0 +1,282 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufmock serves mock implementations of the services of an image.
+package bufmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// HandlerOption is an option for a new handler.
+type HandlerOption func(*handlerOptions)
+
+// WithRules returns a new HandlerOption that responds to the requests that match the
+// given rules with the responses of the rules.
+//
+// The default is to respond to every request with a generated response.
+func WithRules(rules *Rules) HandlerOption {
+	return func(handlerOptions *handlerOptions) {
+		handlerOptions.rules = rules
+	}
+}
+
+// NewHandler returns a new handler that serves a mock implementation of every service
+// in the non-import files of the image, with the Connect, gRPC, and gRPC-Web protocols.
+//
+// Requests are answered with the response of the first rule that matches them, or with
+// a generated response that satisfies the protovalidate rules of the response message.
+// The files of the image are also served with gRPC server reflection.
+func NewHandler(
+	logger *slog.Logger,
+	image bufimage.Image,
+	options ...HandlerOption,
+) (http.Handler, error) {
+	handlerOptions := newHandlerOptions()
+	for _, option := range options {
+		option(handlerOptions)
+	}
+	resolver := image.Resolver()
+	methodToRules, err := compileRules(resolver, handlerOptions.rules)
+	if err != nil {
+		return nil, err
+	}
+	validator, err := protovalidate.New()
+	if err != nil {
+		return nil, err
+	}
+	codecOptions := newCodecOptions(resolver)
+	mux := http.NewServeMux()
+	var serviceNames []string
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		file, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		services := file.Services()
+		for i := range services.Len() {
+			service := services.Get(i)
+			serviceNames = append(serviceNames, string(service.FullName()))
+			methods := service.Methods()
+			for j := range methods.Len() {
+				method := methods.Get(j)
+				if err := validator.Validate(generateMessage(method.Output())); err != nil {
+					logger.Warn(fmt.Sprintf("generated responses of %s do not satisfy their rules: %v", method.FullName(), err))
+				}
+				methodHandler := &methodHandler{
+					logger: logger,
+					method: method,
+					rules:  methodToRules[method.FullName()],
+				}
+				procedure := "/" + string(service.FullName()) + "/" + string(method.Name())
+				mux.Handle(procedure, methodHandler.newHandler(procedure, codecOptions))
+			}
+		}
+	}
+	for methodName := range methodToRules {
+		if !slices.Contains(serviceNames, string(methodName.Parent())) {
+			return nil, fmt.Errorf("rules for method %s, which is not in a served service", methodName)
+		}
+	}
+	slices.Sort(serviceNames)
+	newReflectionServer(image, serviceNames).register(mux)
+	return mux, nil
+}
+
+// methodHandler serves the mock implementation of a method.
+type methodHandler struct {
+	logger *slog.Logger
+	method protoreflect.MethodDescriptor
+	rules  []*compiledRule
+}
+
+func (m *methodHandler) newHandler(procedure string, codecOptions []connect.HandlerOption) http.Handler {
+	options := append(
+		slices.Clone(codecOptions),
+		connect.WithSchema(m.method),
+		connect.WithRequestInitializer(initializeRequest),
+	)
+	if methodOptions, ok := m.method.Options().(*descriptorpb.MethodOptions); ok {
+		switch methodOptions.GetIdempotencyLevel() {
+		case descriptorpb.MethodOptions_NO_SIDE_EFFECTS:
+			options = append(options, connect.WithIdempotency(connect.IdempotencyNoSideEffects))
+		case descriptorpb.MethodOptions_IDEMPOTENT:
+			options = append(options, connect.WithIdempotency(connect.IdempotencyIdempotent))
+		}
+	}
+	switch {
+	case m.method.IsStreamingClient() && m.method.IsStreamingServer():
+		return connect.NewBidiStreamHandler(procedure, m.handleBidiStream, options...)
+	case m.method.IsStreamingClient():
+		return connect.NewClientStreamHandler(procedure, m.handleClientStream, options...)
+	case m.method.IsStreamingServer():
+		return connect.NewServerStreamHandler(procedure, m.handleServerStream, options...)
+	default:
+		return connect.NewUnaryHandler(procedure, m.handleUnary, options...)
+	}
+}
+
+func (m *methodHandler) handleUnary(
+	ctx context.Context,
+	request *connect.Request[dynamicpb.Message],
+) (*connect.Response[dynamicpb.Message], error) {
+	response, err := m.respond(ctx, request.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(response), nil
+}
+
+func (m *methodHandler) handleClientStream(
+	ctx context.Context,
+	stream *connect.ClientStream[dynamicpb.Message],
+) (*connect.Response[dynamicpb.Message], error) {
+	// The first request determines the response, and the rest are drained.
+	var firstRequest *dynamicpb.Message
+	for stream.Receive() {
+		if firstRequest == nil {
+			firstRequest = stream.Msg()
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	if firstRequest == nil {
+		firstRequest = dynamicpb.NewMessage(m.method.Input())
+	}
+	response, err := m.respond(ctx, firstRequest)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(response), nil
+}
+
+func (m *methodHandler) handleServerStream(
+	ctx context.Context,
+	request *connect.Request[dynamicpb.Message],
+	stream *connect.ServerStream[dynamicpb.Message],
+) error {
+	rule := m.findRule(ctx, request.Msg)
+	if rule == nil {
+		return stream.Send(generateMessage(m.method.Output()))
+	}
+	for _, response := range rule.responses {
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+	return rule.error()
+}
+
+func (m *methodHandler) handleBidiStream(
+	ctx context.Context,
+	stream *connect.BidiStream[dynamicpb.Message, dynamicpb.Message],
+) error {
+	for {
+		request, err := stream.Receive()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rule := m.findRule(ctx, request)
+		if rule == nil {
+			if err := stream.Send(generateMessage(m.method.Output())); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, response := range rule.responses {
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+		}
+		if err := rule.error(); err != nil {
+			return err
+		}
+	}
+}
+
+// respond returns the single response or error to a request.
+func (m *methodHandler) respond(ctx context.Context, request *dynamicpb.Message) (*dynamicpb.Message, error) {
+	rule := m.findRule(ctx, request)
+	if rule == nil {
+		return generateMessage(m.method.Output()), nil
+	}
+	if err := rule.error(); err != nil {
+		return nil, err
+	}
+	return rule.responses[0], nil
+}
+
+// findRule returns the first rule that matches the request, or nil if no rule matches.
+func (m *methodHandler) findRule(ctx context.Context, request *dynamicpb.Message) *compiledRule {
+	for _, rule := range m.rules {
+		if rule.matches(request) {
+			m.logger.InfoContext(
+				ctx,
+				"responding with rule",
+				slog.String("method", string(m.method.FullName())),
+				slog.Int("rule", rule.index+1),
+			)
+			return rule
+		}
+	}
+	m.logger.InfoContext(
+		ctx,
+		"responding with generated message",
+		slog.String("method", string(m.method.FullName())),
+	)
+	return nil
+}
+
+// initializeRequest initializes the dynamic request messages of a method handler with
+// the input type of the method.
+func initializeRequest(spec connect.Spec, message any) error {
+	method, ok := spec.Schema.(protoreflect.MethodDescriptor)
+	if !ok {
+		return fmt.Errorf("no schema for procedure %s", spec.Procedure)
+	}
+	dynamicMessage, ok := message.(*dynamicpb.Message)
+	if !ok {
+		return fmt.Errorf("unexpected request type %T for procedure %s", message, spec.Procedure)
+	}
+	*dynamicMessage = *dynamicpb.NewMessage(method.Input())
+	return nil
+}
+
+type handlerOptions struct {
+	rules *Rules
+}
+
+func newHandlerOptions() *handlerOptions {
+	return &handlerOptions{}
+}
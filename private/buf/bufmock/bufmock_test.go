@@ -0,0 +1,313 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"buf.build/go/protovalidate"
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	reflectionv1 "github.com/bufbuild/buf/private/gen/proto/go/grpc/reflection/v1"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/protocompile"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProto = `syntax = "proto3";
+package mock.v1;
+import "buf/validate/validate.proto";
+import "google/protobuf/timestamp.proto";
+service UserService {
+  rpc GetUser(GetUserRequest) returns (GetUserResponse) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc ListUsers(ListUsersRequest) returns (stream User);
+}
+message GetUserRequest {
+  string id = 1;
+}
+message GetUserResponse {
+  User user = 1;
+}
+message ListUsersRequest {
+  int32 page_size = 1;
+}
+message User {
+  string id = 1 [(buf.validate.field).string.uuid = true];
+  string email = 2 [(buf.validate.field).string.email = true];
+  string name = 3 [(buf.validate.field).string = {prefix: "n-", min_len: 10, max_len: 12}];
+  int32 age = 4 [(buf.validate.field).int32 = {gte: 18, lt: 130}];
+  repeated string tags = 5 [(buf.validate.field).repeated = {min_items: 2, items: {string: {in: ["a", "b"]}}}];
+  Status status = 6 [(buf.validate.field).enum = {defined_only: true, not_in: [1]}];
+  google.protobuf.Timestamp created = 7 [(buf.validate.field).timestamp.lt_now = true];
+  map<string, int64> scores = 8 [(buf.validate.field).map = {min_pairs: 2, values: {int64: {gt: 100}}}];
+  double ratio = 9 [(buf.validate.field).double = {gt: 0, lt: 1}];
+  User manager = 10;
+  oneof contact {
+    string phone = 11 [(buf.validate.field).string.len = 5];
+    string fax = 12;
+  }
+}
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_INACTIVE = 2;
+}
+`
+
+func TestGenerateMessage(t *testing.T) {
+	t.Parallel()
+	image := newTestImage(t)
+	descriptor, err := image.Resolver().FindDescriptorByName("mock.v1.User")
+	require.NoError(t, err)
+	message := generateMessage(descriptor.(protoreflect.MessageDescriptor))
+	validator, err := protovalidate.New()
+	require.NoError(t, err)
+	require.NoError(t, validator.Validate(message))
+	fields := message.Descriptor().Fields()
+	assert.Equal(t, "n-namexxxx", message.Get(fields.ByName("name")).String())
+	assert.Equal(t, int64(18), message.Get(fields.ByName("age")).Int())
+	assert.Equal(t, protoreflect.EnumNumber(2), message.Get(fields.ByName("status")).Enum())
+	assert.Equal(t, 2, message.Get(fields.ByName("tags")).List().Len())
+	assert.Equal(t, 2, message.Get(fields.ByName("scores")).Map().Len())
+	assert.True(t, message.Has(fields.ByName("phone")))
+	assert.False(t, message.Has(fields.ByName("fax")))
+	// Recursive fields are left unset.
+	assert.False(t, message.Has(fields.ByName("manager")))
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+	image := newTestImage(t)
+	rules := &Rules{
+		Rules: []*Rule{
+			{
+				Method:   "mock.v1.UserService/GetUser",
+				Request:  map[string]any{"id": "1"},
+				Response: map[string]any{"user": map[string]any{"name": "Ada"}},
+			},
+			{
+				Method: "mock.v1.UserService/GetUser",
+				Request: map[string]any{
+					"id": "2",
+				},
+				Error: &RuleError{Code: "not_found", Message: "no user 2"},
+			},
+			{
+				Method: "mock.v1.UserService/ListUsers",
+				Responses: []any{
+					map[string]any{"name": "Ada"},
+					map[string]any{"name": "Grace"},
+				},
+				Error: &RuleError{Code: "resource_exhausted"},
+			},
+		},
+	}
+	handler, err := NewHandler(slog.New(slog.DiscardHandler), image, WithRules(rules))
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	service := image.Files()[len(image.Files())-1]
+	require.Equal(t, "mock.proto", service.Path())
+	getUser := findMethod(t, image, "mock.v1.UserService.GetUser")
+	getUserClient := newTestClient(server, getUser)
+
+	response, err := getUserClient.CallUnary(context.Background(), connect.NewRequest(newTestMessage(t, getUser.Input(), `{"id": "1"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":{"name":"Ada"}}`, marshalTestJSON(t, image, response.Msg))
+
+	_, err = getUserClient.CallUnary(context.Background(), connect.NewRequest(newTestMessage(t, getUser.Input(), `{"id": "2"}`)))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "no user 2")
+
+	response, err = getUserClient.CallUnary(context.Background(), connect.NewRequest(newTestMessage(t, getUser.Input(), `{"id": "3"}`)))
+	require.NoError(t, err)
+	user := response.Msg.Get(getUser.Output().Fields().ByName("user")).Message()
+	assert.Equal(t, "n-namexxxx", user.Get(user.Descriptor().Fields().ByName("name")).String())
+
+	listUsers := findMethod(t, image, "mock.v1.UserService.ListUsers")
+	stream, err := newTestClient(server, listUsers).CallServerStream(
+		context.Background(),
+		connect.NewRequest(dynamicpb.NewMessage(listUsers.Input())),
+	)
+	require.NoError(t, err)
+	var names []string
+	for stream.Receive() {
+		names = append(names, stream.Msg().Get(listUsers.Output().Fields().ByName("name")).String())
+	}
+	assert.Equal(t, []string{"Ada", "Grace"}, names)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(stream.Err()))
+}
+
+func TestHandlerReflection(t *testing.T) {
+	t.Parallel()
+	image := newTestImage(t)
+	handler, err := NewHandler(slog.New(slog.DiscardHandler), image)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	for _, procedure := range []string{reflectionV1Procedure, reflectionV1AlphaProcedure} {
+		client := connect.NewClient[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse](
+			server.Client(),
+			server.URL+procedure,
+			connect.WithGRPC(),
+		)
+		stream := client.CallBidiStream(context.Background())
+		require.NoError(t, stream.Send(reflectionv1.ServerReflectionRequest_builder{ListServices: new(string)}.Build()))
+		response, err := stream.Receive()
+		require.NoError(t, err)
+		require.Len(t, response.GetListServicesResponse().GetService(), 1)
+		assert.Equal(t, "mock.v1.UserService", response.GetListServicesResponse().GetService()[0].GetName())
+
+		symbol := "mock.v1.User"
+		require.NoError(t, stream.Send(reflectionv1.ServerReflectionRequest_builder{FileContainingSymbol: &symbol}.Build()))
+		response, err = stream.Receive()
+		require.NoError(t, err)
+		// The file and all of its transitive dependencies.
+		assert.Len(t, response.GetFileDescriptorResponse().GetFileDescriptorProto(), len(image.Files()))
+
+		symbol = "mock.v1.Missing"
+		require.NoError(t, stream.Send(reflectionv1.ServerReflectionRequest_builder{FileContainingSymbol: &symbol}.Build()))
+		response, err = stream.Receive()
+		require.NoError(t, err)
+		assert.Equal(t, int32(connect.CodeNotFound), response.GetErrorResponse().GetErrorCode())
+
+		typeName := "google.protobuf.FieldOptions"
+		require.NoError(t, stream.Send(reflectionv1.ServerReflectionRequest_builder{AllExtensionNumbersOfType: &typeName}.Build()))
+		response, err = stream.Receive()
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1159, 1160}, response.GetAllExtensionNumbersResponse().GetExtensionNumber())
+		require.NoError(t, stream.CloseRequest())
+		require.NoError(t, stream.CloseResponse())
+	}
+}
+
+func TestNewHandlerInvalidRules(t *testing.T) {
+	t.Parallel()
+	image := newTestImage(t)
+	for _, rule := range []*Rule{
+		{Method: "mock.v1.UserService/Missing", Response: map[string]any{}},
+		{Method: "mock.v1.UserService/GetUser"},
+		{Method: "mock.v1.UserService/GetUser", Responses: []any{map[string]any{}, map[string]any{}}},
+		{Method: "mock.v1.UserService/GetUser", Response: map[string]any{"unknown": 1}},
+		{Method: "mock.v1.UserService/GetUser", Error: &RuleError{Code: "bogus"}},
+		{Method: "mock.v1.UserService/GetUser", Response: map[string]any{}, Error: &RuleError{Code: "internal"}},
+	} {
+		_, err := NewHandler(slog.New(slog.DiscardHandler), image, WithRules(&Rules{Rules: []*Rule{rule}}))
+		assert.Error(t, err, rule.Method)
+	}
+}
+
+func newTestImage(t *testing.T) bufimage.Image {
+	t.Helper()
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver{
+			&protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+					"mock.proto": testProto,
+				}),
+			},
+			protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+				if path == validate.File_buf_validate_validate_proto.Path() {
+					return protocompile.SearchResult{Desc: validate.File_buf_validate_validate_proto}, nil
+				}
+				return protocompile.SearchResult{}, errors.New("not found")
+			}),
+		}),
+	}).Compile(context.Background(), "mock.proto")
+	require.NoError(t, err)
+	// Image files must be sorted topologically.
+	var imageFiles []bufimage.ImageFile
+	seen := make(map[string]struct{})
+	var addFile func(protoreflect.FileDescriptor)
+	addFile = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		imports := file.Imports()
+		for i := range imports.Len() {
+			addFile(imports.Get(i).FileDescriptor)
+		}
+		imageFile, err := bufimage.NewImageFile(
+			protodesc.ToFileDescriptorProto(file),
+			nil,
+			uuid.Nil,
+			file.Path(),
+			file.Path(),
+			file.Path() != "mock.proto",
+			false,
+			nil,
+		)
+		require.NoError(t, err)
+		imageFiles = append(imageFiles, imageFile)
+	}
+	addFile(files[0])
+	image, err := bufimage.NewImage(imageFiles)
+	require.NoError(t, err)
+	return image
+}
+
+func findMethod(t *testing.T, image bufimage.Image, name protoreflect.FullName) protoreflect.MethodDescriptor {
+	t.Helper()
+	descriptor, err := image.Resolver().FindDescriptorByName(name)
+	require.NoError(t, err)
+	method, ok := descriptor.(protoreflect.MethodDescriptor)
+	require.True(t, ok)
+	return method
+}
+
+func newTestClient(server *httptest.Server, method protoreflect.MethodDescriptor) *connect.Client[dynamicpb.Message, dynamicpb.Message] {
+	return connect.NewClient[dynamicpb.Message, dynamicpb.Message](
+		server.Client(),
+		server.URL+"/"+string(method.Parent().FullName())+"/"+string(method.Name()),
+		connect.WithSchema(method),
+		connect.WithResponseInitializer(func(spec connect.Spec, message any) error {
+			*message.(*dynamicpb.Message) = *dynamicpb.NewMessage(spec.Schema.(protoreflect.MethodDescriptor).Output())
+			return nil
+		}),
+	)
+}
+
+func newTestMessage(t *testing.T, descriptor protoreflect.MessageDescriptor, data string) *dynamicpb.Message {
+	t.Helper()
+	message := dynamicpb.NewMessage(descriptor)
+	require.NoError(t, protoencoding.NewJSONUnmarshaler(nil).Unmarshal([]byte(data), message))
+	return message
+}
+
+func marshalTestJSON(t *testing.T, image bufimage.Image, message *dynamicpb.Message) string {
+	t.Helper()
+	data, err := protoencoding.NewJSONMarshaler(image.Resolver()).Marshal(message)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,75 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmock
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/proto"
+)
+
+// codec is a Connect codec that resolves Any messages and extensions against the
+// image, rather than the global registry that the default codecs use.
+type codec struct {
+	name        string
+	marshaler   protoencoding.Marshaler
+	unmarshaler protoencoding.Unmarshaler
+}
+
+// newCodecOptions returns the handler options that install the codecs for the
+// binary and JSON encodings.
+func newCodecOptions(resolver protoencoding.Resolver) []connect.HandlerOption {
+	jsonMarshaler := protoencoding.NewJSONMarshaler(resolver)
+	jsonUnmarshaler := protoencoding.NewJSONUnmarshaler(resolver)
+	return []connect.HandlerOption{
+		connect.WithCodec(&codec{
+			name:        "proto",
+			marshaler:   protoencoding.NewWireMarshaler(),
+			unmarshaler: protoencoding.NewWireUnmarshaler(resolver),
+		}),
+		connect.WithCodec(&codec{
+			name:        "json",
+			marshaler:   jsonMarshaler,
+			unmarshaler: jsonUnmarshaler,
+		}),
+		connect.WithCodec(&codec{
+			name:        "json; charset=utf-8",
+			marshaler:   jsonMarshaler,
+			unmarshaler: jsonUnmarshaler,
+		}),
+	}
+}
+
+func (c *codec) Name() string {
+	return c.name
+}
+
+func (c *codec) Marshal(value any) ([]byte, error) {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal: %T does not implement proto.Message", value)
+	}
+	return c.marshaler.Marshal(message)
+}
+
+func (c *codec) Unmarshal(data []byte, value any) error {
+	message, ok := value.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal: %T does not implement proto.Message", value)
+	}
+	return c.unmarshaler.Unmarshal(data, message)
+}
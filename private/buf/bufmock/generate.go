@@ -0,0 +1,470 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmock
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"buf.build/go/protovalidate"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// generatedTime is the time of generated timestamps that have no constraints relative
+// to the current time, so that generated messages are stable.
+var generatedTime = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// generateMessage returns a message of the given type with every field set.
+//
+// Values are chosen to satisfy the protovalidate rules of each field where possible.
+// The examples of a rule are used first, then its const and in values, and otherwise
+// a value is derived from the field name and constrained by the bounds, lengths,
+// prefixes, suffixes, and well-known formats of the rule. Patterns and CEL expressions
+// are not taken into account, so the result should be validated.
+//
+// Only the first field of each oneof is set. Fields of a message type that is already
+// being generated, which would otherwise recurse forever, are left unset.
+func generateMessage(descriptor protoreflect.MessageDescriptor) *dynamicpb.Message {
+	message := dynamicpb.NewMessage(descriptor)
+	generator := &generator{
+		now:      time.Now(),
+		visiting: make(map[protoreflect.FullName]struct{}),
+	}
+	generator.populate(message)
+	return message
+}
+
+type generator struct {
+	now time.Time
+	// visiting contains the messages that are currently being generated.
+	visiting map[protoreflect.FullName]struct{}
+}
+
+func (g *generator) populate(message protoreflect.Message) {
+	descriptor := message.Descriptor()
+	if descriptor.ParentFile().Package() == "google.protobuf" {
+		// Well-known types that are not generated as field values are left empty.
+		return
+	}
+	g.visiting[descriptor.FullName()] = struct{}{}
+	defer delete(g.visiting, descriptor.FullName())
+	fields := descriptor.Fields()
+	for i := range fields.Len() {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && oneof.Fields().Get(0) != field {
+			continue
+		}
+		rules, err := protovalidate.ResolveFieldRules(field)
+		if err != nil {
+			rules = nil
+		}
+		if rules.GetIgnore() == validate.Ignore_IGNORE_ALWAYS {
+			continue
+		}
+		switch {
+		case field.IsMap():
+			mapRules := rules.GetMap()
+			mapValue := message.Mutable(field).Map()
+			for index := range elementCount(mapRules.HasMinPairs(), mapRules.GetMinPairs(), mapRules.HasMaxPairs(), mapRules.GetMaxPairs()) {
+				key, ok := g.value(field.MapKey(), mapRules.GetKeys(), index, nil)
+				if !ok {
+					break
+				}
+				value, ok := g.value(field.MapValue(), mapRules.GetValues(), index, mapValue.NewValue)
+				if !ok {
+					break
+				}
+				mapValue.Set(key.MapKey(), value)
+			}
+		case field.IsList():
+			repeatedRules := rules.GetRepeated()
+			list := message.Mutable(field).List()
+			for index := range elementCount(repeatedRules.HasMinItems(), repeatedRules.GetMinItems(), repeatedRules.HasMaxItems(), repeatedRules.GetMaxItems()) {
+				value, ok := g.value(field, repeatedRules.GetItems(), index, list.NewElement)
+				if !ok {
+					break
+				}
+				list.Append(value)
+			}
+		default:
+			newField := func() protoreflect.Value { return message.NewField(field) }
+			if value, ok := g.value(field, rules, 0, newField); ok {
+				message.Set(field, value)
+			}
+		}
+	}
+}
+
+// value returns a value for a single element of the field, or false if the field should
+// be left unset. index is the index of the element in a list or map, which is used to make
+// elements distinct. newMessage returns a new message for fields of a message type.
+func (g *generator) value(
+	field protoreflect.FieldDescriptor,
+	rules *validate.FieldRules,
+	index int,
+	newMessage func() protoreflect.Value,
+) (protoreflect.Value, bool) {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageValue(field.Message(), rules, index, newMessage)
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(enumValue(field.Enum(), rules.GetEnum(), index)), true
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(stringValue(string(field.Name()), rules.GetString(), index)), true
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(bytesValue(string(field.Name()), rules.GetBytes(), index)), true
+	case protoreflect.BoolKind:
+		if boolRules := rules.GetBool(); boolRules.HasConst() {
+			return protoreflect.ValueOfBool(boolRules.GetConst()), true
+		}
+		return protoreflect.ValueOfBool(true), true
+	default:
+		var typeRules protoreflect.Message
+		if rules != nil {
+			reflectRules := rules.ProtoReflect()
+			if typeField := reflectRules.WhichOneof(reflectRules.Descriptor().Oneofs().ByName("type")); typeField != nil {
+				typeRules = reflectRules.Get(typeField).Message()
+			}
+		}
+		return numberValue(field.Kind(), typeRules, index)
+	}
+}
+
+func (g *generator) messageValue(
+	descriptor protoreflect.MessageDescriptor,
+	rules *validate.FieldRules,
+	index int,
+	newMessage func() protoreflect.Value,
+) (protoreflect.Value, bool) {
+	switch descriptor.FullName() {
+	case "google.protobuf.Timestamp":
+		return protoreflect.ValueOfMessage(g.timestampValue(rules.GetTimestamp()).ProtoReflect()), true
+	case "google.protobuf.Duration":
+		return protoreflect.ValueOfMessage(durationValue(rules.GetDuration()).ProtoReflect()), true
+	case "google.protobuf.Any", "google.protobuf.Value":
+		// An empty Any or Value cannot be marshaled to JSON.
+		return protoreflect.Value{}, false
+	case "google.protobuf.BoolValue", "google.protobuf.BytesValue", "google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue", "google.protobuf.Int32Value", "google.protobuf.Int64Value",
+		"google.protobuf.StringValue", "google.protobuf.UInt32Value", "google.protobuf.UInt64Value":
+		// The rules of wrapper fields apply to the wrapped value.
+		message := newMessage()
+		valueField := descriptor.Fields().ByName("value")
+		value, ok := g.value(valueField, rules, index, nil)
+		if !ok {
+			return protoreflect.Value{}, false
+		}
+		message.Message().Set(valueField, value)
+		return message, true
+	}
+	if _, ok := g.visiting[descriptor.FullName()]; ok {
+		return protoreflect.Value{}, false
+	}
+	message := newMessage()
+	g.populate(message.Message())
+	return message, true
+}
+
+func (g *generator) timestampValue(rules *validate.TimestampRules) *timestamppb.Timestamp {
+	switch {
+	case rules.HasConst():
+		return rules.GetConst()
+	case len(rules.GetExample()) > 0:
+		return rules.GetExample()[0]
+	case rules.HasGtNow(), rules.HasWithin():
+		return timestamppb.New(g.now.Add(time.Minute).Truncate(time.Second))
+	case rules.HasGte():
+		return rules.GetGte()
+	case rules.HasGt():
+		return timestamppb.New(rules.GetGt().AsTime().Add(time.Second))
+	case rules.HasLte():
+		return rules.GetLte()
+	case rules.HasLt():
+		return timestamppb.New(rules.GetLt().AsTime().Add(-time.Second))
+	}
+	return timestamppb.New(generatedTime)
+}
+
+func durationValue(rules *validate.DurationRules) *durationpb.Duration {
+	switch {
+	case rules.HasConst():
+		return rules.GetConst()
+	case len(rules.GetExample()) > 0:
+		return rules.GetExample()[0]
+	case len(rules.GetIn()) > 0:
+		return rules.GetIn()[0]
+	case rules.HasGte():
+		return rules.GetGte()
+	case rules.HasGt():
+		return durationpb.New(rules.GetGt().AsDuration() + time.Second)
+	case rules.HasLte() && rules.GetLte().AsDuration() < time.Second:
+		return rules.GetLte()
+	case rules.HasLt() && rules.GetLt().AsDuration() <= time.Second:
+		return durationpb.New(rules.GetLt().AsDuration() - time.Millisecond)
+	}
+	return durationpb.New(time.Second)
+}
+
+func enumValue(descriptor protoreflect.EnumDescriptor, rules *validate.EnumRules, index int) protoreflect.EnumNumber {
+	switch {
+	case rules.HasConst():
+		return protoreflect.EnumNumber(rules.GetConst())
+	case len(rules.GetExample()) > 0:
+		return protoreflect.EnumNumber(rules.GetExample()[index%len(rules.GetExample())])
+	case len(rules.GetIn()) > 0:
+		return protoreflect.EnumNumber(rules.GetIn()[index%len(rules.GetIn())])
+	}
+	// Prefer values other than the zero value, which is usually unspecified.
+	var candidates []protoreflect.EnumNumber
+	values := descriptor.Values()
+	for i := range values.Len() {
+		number := values.Get(i).Number()
+		if number != 0 && !slices.Contains(rules.GetNotIn(), int32(number)) {
+			candidates = append(candidates, number)
+		}
+	}
+	if len(candidates) == 0 {
+		return values.Get(0).Number()
+	}
+	return candidates[index%len(candidates)]
+}
+
+func stringValue(name string, rules *validate.StringRules, index int) string {
+	switch {
+	case rules.HasConst():
+		return rules.GetConst()
+	case len(rules.GetExample()) > 0:
+		return rules.GetExample()[index%len(rules.GetExample())]
+	case len(rules.GetIn()) > 0:
+		return rules.GetIn()[index%len(rules.GetIn())]
+	}
+	if value, ok := wellKnownStringValue(rules, index); ok {
+		return value
+	}
+	core := name
+	if index > 0 {
+		core += strconv.Itoa(index)
+	}
+	value := fitLength(
+		rules.GetPrefix(),
+		core,
+		rules.GetContains()+rules.GetSuffix(),
+		rules.HasLen(), rules.GetLen(),
+		rules.HasMinLen(), rules.GetMinLen(),
+		rules.HasMaxLen(), rules.GetMaxLen(),
+	)
+	value = fitLength(
+		"",
+		value,
+		"",
+		rules.HasLenBytes(), rules.GetLenBytes(),
+		rules.HasMinBytes(), rules.GetMinBytes(),
+		rules.HasMaxBytes(), rules.GetMaxBytes(),
+	)
+	for slices.Contains(rules.GetNotIn(), value) {
+		value += "x"
+	}
+	return value
+}
+
+func wellKnownStringValue(rules *validate.StringRules, index int) (string, bool) {
+	switch {
+	case rules.GetEmail():
+		return fmt.Sprintf("user%d@example.com", index), true
+	case rules.GetHostname(), rules.GetAddress():
+		return fmt.Sprintf("host%d.example.com", index), true
+	case rules.GetHostAndPort():
+		return fmt.Sprintf("host%d.example.com:8080", index), true
+	case rules.GetIp(), rules.GetIpv4():
+		return fmt.Sprintf("192.0.2.%d", index+1), true
+	case rules.GetIpv6():
+		return fmt.Sprintf("2001:db8::%d", index+1), true
+	case rules.GetIpWithPrefixlen(), rules.GetIpv4WithPrefixlen():
+		return fmt.Sprintf("192.0.2.%d/24", index+1), true
+	case rules.GetIpv6WithPrefixlen():
+		return fmt.Sprintf("2001:db8::%d/64", index+1), true
+	case rules.GetIpPrefix(), rules.GetIpv4Prefix():
+		return fmt.Sprintf("192.0.%d.0/24", index+2), true
+	case rules.GetIpv6Prefix():
+		return fmt.Sprintf("2001:db8:%d::/64", index+1), true
+	case rules.GetUri(), rules.GetUriRef():
+		return fmt.Sprintf("https://example.com/%d", index), true
+	case rules.GetUuid():
+		return fmt.Sprintf("00000000-0000-4000-8000-%012d", index+1), true
+	case rules.GetTuuid():
+		return fmt.Sprintf("0000000000004000800%013d", index+1), true
+	case rules.GetWellKnownRegex() == validate.KnownRegex_KNOWN_REGEX_HTTP_HEADER_NAME:
+		return fmt.Sprintf("x-header-%d", index), true
+	case rules.GetWellKnownRegex() == validate.KnownRegex_KNOWN_REGEX_HTTP_HEADER_VALUE:
+		return fmt.Sprintf("value-%d", index), true
+	}
+	return "", false
+}
+
+func bytesValue(name string, rules *validate.BytesRules, index int) []byte {
+	switch {
+	case rules.HasConst():
+		return rules.GetConst()
+	case len(rules.GetExample()) > 0:
+		return rules.GetExample()[index%len(rules.GetExample())]
+	case len(rules.GetIn()) > 0:
+		return rules.GetIn()[index%len(rules.GetIn())]
+	case rules.GetIp(), rules.GetIpv4():
+		return net.IPv4(192, 0, 2, byte(index+1)).To4()
+	case rules.GetIpv6():
+		return net.ParseIP(fmt.Sprintf("2001:db8::%d", index+1))
+	}
+	core := name
+	if index > 0 {
+		core += strconv.Itoa(index)
+	}
+	return []byte(fitLength(
+		string(rules.GetPrefix()),
+		core,
+		string(rules.GetContains())+string(rules.GetSuffix()),
+		rules.HasLen(), rules.GetLen(),
+		rules.HasMinLen(), rules.GetMinLen(),
+		rules.HasMaxLen(), rules.GetMaxLen(),
+	))
+}
+
+// fitLength returns prefix+core+suffix, with core padded or truncated so that the
+// result has the given exact, minimum, or maximum length in characters.
+func fitLength(
+	prefix string,
+	core string,
+	suffix string,
+	hasLen bool, length uint64,
+	hasMinLen bool, minLen uint64,
+	hasMaxLen bool, maxLen uint64,
+) string {
+	if hasLen {
+		hasMinLen, minLen, hasMaxLen, maxLen = true, length, true, length
+	}
+	fixedLen := uint64(len([]rune(prefix)) + len([]rune(suffix)))
+	coreRunes := []rune(core)
+	if hasMaxLen {
+		if fixedLen >= maxLen {
+			coreRunes = nil
+		} else if uint64(len(coreRunes)) > maxLen-fixedLen {
+			coreRunes = coreRunes[:maxLen-fixedLen]
+		}
+	}
+	if hasMinLen {
+		if totalLen := fixedLen + uint64(len(coreRunes)); totalLen < minLen {
+			coreRunes = append(coreRunes, []rune(strings.Repeat("x", int(minLen-totalLen)))...)
+		}
+	}
+	return prefix + string(coreRunes) + suffix
+}
+
+// numberValue returns a value of the given numeric kind that satisfies the given rules,
+// which are the Int32Rules, DoubleRules, or similar message for the kind, or nil.
+func numberValue(kind protoreflect.Kind, rules protoreflect.Message, index int) (protoreflect.Value, bool) {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(chooseNumber(rules, protoreflect.Value.Int, index))), true
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(chooseNumber(rules, protoreflect.Value.Int, index)), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(chooseNumber(rules, protoreflect.Value.Uint, index))), true
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(chooseNumber(rules, protoreflect.Value.Uint, index)), true
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(chooseNumber(rules, protoreflect.Value.Float, index))), true
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(chooseNumber(rules, protoreflect.Value.Float, index)), true
+	default:
+		return protoreflect.Value{}, false
+	}
+}
+
+// chooseNumber chooses a number that satisfies the const, example, in, not_in, and range
+// rules of a numeric rules message. All numeric rules messages have these fields, with
+// the type of the field that they apply to.
+func chooseNumber[T int64 | uint64 | float64](rules protoreflect.Message, get func(protoreflect.Value) T, index int) T {
+	value := T(index + 1)
+	if rules == nil {
+		return value
+	}
+	fields := rules.Descriptor().Fields()
+	scalar := func(name protoreflect.Name) (T, bool) {
+		field := fields.ByName(name)
+		if field == nil || !rules.Has(field) {
+			return 0, false
+		}
+		return get(rules.Get(field)), true
+	}
+	list := func(name protoreflect.Name) []T {
+		field := fields.ByName(name)
+		if field == nil {
+			return nil
+		}
+		listValue := rules.Get(field).List()
+		values := make([]T, listValue.Len())
+		for i := range listValue.Len() {
+			values[i] = get(listValue.Get(i))
+		}
+		return values
+	}
+	if constValue, ok := scalar("const"); ok {
+		return constValue
+	}
+	if examples := list("example"); len(examples) > 0 {
+		return examples[index%len(examples)]
+	}
+	if in := list("in"); len(in) > 0 {
+		return in[index%len(in)]
+	}
+	if gte, ok := scalar("gte"); ok {
+		value = gte + T(index)
+	} else if gt, ok := scalar("gt"); ok {
+		value = gt + T(index+1)
+	}
+	if lte, ok := scalar("lte"); ok && value > lte {
+		value = lte
+	} else if lt, ok := scalar("lt"); ok && value >= lt {
+		value = lt - 1
+		if gt, ok := scalar("gt"); ok && value <= gt {
+			// Only possible for floating point numbers between gt and lt.
+			value = gt + (lt-gt)/2
+		}
+	}
+	notIn := list("not_in")
+	for slices.Contains(notIn, value) {
+		value++
+	}
+	return value
+}
+
+// elementCount returns the number of elements to generate for a repeated or map field.
+func elementCount(hasMin bool, minCount uint64, hasMax bool, maxCount uint64) int {
+	count := uint64(1)
+	if hasMin && minCount > count {
+		count = minCount
+	}
+	if hasMax && maxCount < count {
+		count = maxCount
+	}
+	return int(count)
+}
@@ -0,0 +1,214 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	reflectionv1 "github.com/bufbuild/buf/private/gen/proto/go/grpc/reflection/v1"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	reflectionV1Procedure      = "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"
+	reflectionV1AlphaProcedure = "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+)
+
+// reflectionServer implements the gRPC server reflection protocol for the files of an image.
+//
+// The v1 and v1alpha versions of the protocol have the same messages, so both are served
+// with the v1 types.
+type reflectionServer struct {
+	image        bufimage.Image
+	resolver     protoencoding.Resolver
+	serviceNames []string
+}
+
+func newReflectionServer(image bufimage.Image, serviceNames []string) *reflectionServer {
+	return &reflectionServer{
+		image:        image,
+		resolver:     image.Resolver(),
+		serviceNames: serviceNames,
+	}
+}
+
+// register registers the handlers of both versions of the protocol on the mux.
+func (r *reflectionServer) register(mux *http.ServeMux, options ...connect.HandlerOption) {
+	for _, procedure := range []string{reflectionV1Procedure, reflectionV1AlphaProcedure} {
+		mux.Handle(procedure, connect.NewBidiStreamHandler(procedure, r.serve, options...))
+	}
+}
+
+func (r *reflectionServer) serve(
+	_ context.Context,
+	stream *connect.BidiStream[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse],
+) error {
+	for {
+		request, err := stream.Receive()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(r.respond(request)); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *reflectionServer) respond(request *reflectionv1.ServerReflectionRequest) *reflectionv1.ServerReflectionResponse {
+	response := reflectionv1.ServerReflectionResponse_builder{
+		ValidHost:       request.GetHost(),
+		OriginalRequest: request,
+	}
+	switch request.WhichMessageRequest() {
+	case reflectionv1.ServerReflectionRequest_FileByFilename_case:
+		file, err := r.resolver.FindFileByPath(request.GetFileByFilename())
+		if err != nil {
+			response.ErrorResponse = newNotFoundResponse("file %q", request.GetFileByFilename())
+			break
+		}
+		response.FileDescriptorResponse = r.fileDescriptorResponse(file)
+	case reflectionv1.ServerReflectionRequest_FileContainingSymbol_case:
+		descriptor, err := r.resolver.FindDescriptorByName(protoreflect.FullName(request.GetFileContainingSymbol()))
+		if err != nil {
+			response.ErrorResponse = newNotFoundResponse("symbol %q", request.GetFileContainingSymbol())
+			break
+		}
+		response.FileDescriptorResponse = r.fileDescriptorResponse(descriptor.ParentFile())
+	case reflectionv1.ServerReflectionRequest_FileContainingExtension_case:
+		extensionRequest := request.GetFileContainingExtension()
+		var file protoreflect.FileDescriptor
+		r.rangeExtensions(protoreflect.FullName(extensionRequest.GetContainingType()), func(extension protoreflect.ExtensionDescriptor) bool {
+			if extension.Number() == protoreflect.FieldNumber(extensionRequest.GetExtensionNumber()) {
+				file = extension.ParentFile()
+				return false
+			}
+			return true
+		})
+		if file == nil {
+			response.ErrorResponse = newNotFoundResponse(
+				"extension %d of %q",
+				extensionRequest.GetExtensionNumber(),
+				extensionRequest.GetContainingType(),
+			)
+			break
+		}
+		response.FileDescriptorResponse = r.fileDescriptorResponse(file)
+	case reflectionv1.ServerReflectionRequest_AllExtensionNumbersOfType_case:
+		typeName := request.GetAllExtensionNumbersOfType()
+		descriptor, err := r.resolver.FindDescriptorByName(protoreflect.FullName(typeName))
+		if _, ok := descriptor.(protoreflect.MessageDescriptor); err != nil || !ok {
+			response.ErrorResponse = newNotFoundResponse("message %q", typeName)
+			break
+		}
+		var numbers []int32
+		r.rangeExtensions(protoreflect.FullName(typeName), func(extension protoreflect.ExtensionDescriptor) bool {
+			numbers = append(numbers, int32(extension.Number()))
+			return true
+		})
+		response.AllExtensionNumbersResponse = reflectionv1.ExtensionNumberResponse_builder{
+			BaseTypeName:    typeName,
+			ExtensionNumber: numbers,
+		}.Build()
+	case reflectionv1.ServerReflectionRequest_ListServices_case:
+		services := make([]*reflectionv1.ServiceResponse, len(r.serviceNames))
+		for i, serviceName := range r.serviceNames {
+			services[i] = reflectionv1.ServiceResponse_builder{Name: serviceName}.Build()
+		}
+		response.ListServicesResponse = reflectionv1.ListServiceResponse_builder{Service: services}.Build()
+	default:
+		response.ErrorResponse = reflectionv1.ErrorResponse_builder{
+			ErrorCode:    int32(connect.CodeInvalidArgument),
+			ErrorMessage: "unknown message request",
+		}.Build()
+	}
+	return response.Build()
+}
+
+// fileDescriptorResponse returns the file along with its transitive dependencies, with
+// the file first.
+func (r *reflectionServer) fileDescriptorResponse(file protoreflect.FileDescriptor) *reflectionv1.FileDescriptorResponse {
+	var fileDescriptorProtos [][]byte
+	seen := make(map[string]struct{})
+	var addFile func(protoreflect.FileDescriptor)
+	addFile = func(file protoreflect.FileDescriptor) {
+		if _, ok := seen[file.Path()]; ok {
+			return
+		}
+		seen[file.Path()] = struct{}{}
+		// The files of an image are valid, so marshaling them cannot fail.
+		data, _ := proto.Marshal(r.image.GetFile(file.Path()).FileDescriptorProto())
+		fileDescriptorProtos = append(fileDescriptorProtos, data)
+		imports := file.Imports()
+		for i := range imports.Len() {
+			addFile(imports.Get(i).FileDescriptor)
+		}
+	}
+	addFile(file)
+	return reflectionv1.FileDescriptorResponse_builder{
+		FileDescriptorProto: fileDescriptorProtos,
+	}.Build()
+}
+
+// rangeExtensions calls f for every extension of the given message, until f returns false.
+func (r *reflectionServer) rangeExtensions(messageName protoreflect.FullName, f func(protoreflect.ExtensionDescriptor) bool) {
+	for _, imageFile := range r.image.Files() {
+		file, err := r.resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			continue
+		}
+		if !rangeExtensionsInScope(file.Extensions(), file.Messages(), messageName, f) {
+			return
+		}
+	}
+}
+
+func rangeExtensionsInScope(
+	extensions protoreflect.ExtensionDescriptors,
+	messages protoreflect.MessageDescriptors,
+	messageName protoreflect.FullName,
+	f func(protoreflect.ExtensionDescriptor) bool,
+) bool {
+	for i := range extensions.Len() {
+		extension := extensions.Get(i)
+		if extension.ContainingMessage().FullName() == messageName && !f(extension) {
+			return false
+		}
+	}
+	for i := range messages.Len() {
+		message := messages.Get(i)
+		if !rangeExtensionsInScope(message.Extensions(), message.Messages(), messageName, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func newNotFoundResponse(format string, args ...any) *reflectionv1.ErrorResponse {
+	return reflectionv1.ErrorResponse_builder{
+		ErrorCode:    int32(connect.CodeNotFound),
+		ErrorMessage: fmt.Sprintf(format+" not found", args...),
+	}.Build()
+}
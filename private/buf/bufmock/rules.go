@@ -0,0 +1,213 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Rules determine the responses of a mock server, read from a YAML or JSON file.
+type Rules struct {
+	Rules []*Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// Rule is a canned response to the requests of a method.
+//
+// Rules are checked in order, and the first rule that matches a request determines its
+// response. Requests that match no rule get a generated response.
+type Rule struct {
+	// Method is the fully-qualified name of the method, in the form "<service>/<method>".
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// Request is the request message in JSON. The rule only matches requests in which every
+	// field that is set in this message has the same value. If empty, every request of the
+	// method matches.
+	//
+	// The requests of client-streaming methods are matched by their first message. For
+	// bidi-streaming methods, each request is matched separately.
+	Request any `json:"request,omitempty" yaml:"request,omitempty"`
+	// Response is the response message in JSON. It is short for a Responses list with a
+	// single message.
+	Response any `json:"response,omitempty" yaml:"response,omitempty"`
+	// Responses are the response messages in JSON. Only server-streaming and bidi-streaming
+	// methods may have more than one response.
+	Responses []any `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// Error is the error to respond with, after any responses of a streaming method.
+	Error *RuleError `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RuleError is the error of a Rule.
+type RuleError struct {
+	// Code is the code of the error, such as "not_found".
+	Code    string `json:"code,omitempty" yaml:"code,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// ReadRules reads Rules from a YAML or JSON file.
+//
+// The rules are checked against a schema when they are given to NewHandler.
+func ReadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := &Rules{}
+	if err := encoding.UnmarshalJSONOrYAMLStrict(data, rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// compiledRule is a Rule with its messages parsed against the schema.
+type compiledRule struct {
+	index     int
+	request   *dynamicpb.Message
+	responses []*dynamicpb.Message
+	// errorCode is zero if the rule has no error.
+	errorCode    connect.Code
+	errorMessage string
+}
+
+// compileRules parses the messages of the rules, and returns the compiled rules by the
+// full name of their method.
+func compileRules(
+	resolver protoencoding.Resolver,
+	rules *Rules,
+) (map[protoreflect.FullName][]*compiledRule, error) {
+	methodToRules := make(map[protoreflect.FullName][]*compiledRule)
+	if rules == nil {
+		return methodToRules, nil
+	}
+	for i, rule := range rules.Rules {
+		compiledRule, methodName, err := compileRule(resolver, i, rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		methodToRules[methodName] = append(methodToRules[methodName], compiledRule)
+	}
+	return methodToRules, nil
+}
+
+func compileRule(
+	resolver protoencoding.Resolver,
+	index int,
+	rule *Rule,
+) (*compiledRule, protoreflect.FullName, error) {
+	if rule == nil || rule.Method == "" {
+		return nil, "", fmt.Errorf("no method")
+	}
+	methodName := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(rule.Method, "/"), "/", "."))
+	descriptor, err := resolver.FindDescriptorByName(methodName)
+	if err != nil {
+		return nil, "", fmt.Errorf("unknown method %q", rule.Method)
+	}
+	method, ok := descriptor.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, "", fmt.Errorf("%q is not a method", rule.Method)
+	}
+	compiledRule := &compiledRule{
+		index: index,
+	}
+	if rule.Request != nil {
+		if compiledRule.request, err = parseMessage(resolver, method.Input(), rule.Request); err != nil {
+			return nil, "", fmt.Errorf("request: %w", err)
+		}
+	}
+	responses := rule.Responses
+	if rule.Response != nil {
+		if len(responses) > 0 {
+			return nil, "", fmt.Errorf("response and responses cannot both be set")
+		}
+		responses = []any{rule.Response}
+	}
+	if len(responses) > 1 && !method.IsStreamingServer() {
+		return nil, "", fmt.Errorf("method %s is not server-streaming, so it can only have one response", methodName)
+	}
+	for i, response := range responses {
+		message, err := parseMessage(resolver, method.Output(), response)
+		if err != nil {
+			return nil, "", fmt.Errorf("response %d: %w", i+1, err)
+		}
+		compiledRule.responses = append(compiledRule.responses, message)
+	}
+	if rule.Error != nil {
+		var code connect.Code
+		if err := code.UnmarshalText([]byte(strings.ToLower(rule.Error.Code))); err != nil {
+			return nil, "", fmt.Errorf("unknown error code %q", rule.Error.Code)
+		}
+		if !method.IsStreamingServer() && len(responses) > 0 {
+			return nil, "", fmt.Errorf("method %s is not server-streaming, so it cannot have both a response and an error", methodName)
+		}
+		compiledRule.errorCode = code
+		compiledRule.errorMessage = rule.Error.Message
+	} else if len(responses) == 0 && !method.IsStreamingServer() {
+		return nil, "", fmt.Errorf("no response or error")
+	}
+	return compiledRule, methodName, nil
+}
+
+// error returns the error of the rule, or nil if the rule has no error.
+func (c *compiledRule) error() error {
+	if c.errorCode == 0 {
+		return nil
+	}
+	return connect.NewError(c.errorCode, errors.New(c.errorMessage))
+}
+
+// matches returns true if the request has every field that is set in the request of the rule.
+func (c *compiledRule) matches(request protoreflect.Message) bool {
+	return c.request == nil || messageContains(c.request, request)
+}
+
+func messageContains(expected protoreflect.Message, actual protoreflect.Message) bool {
+	matches := true
+	expected.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if !actual.Has(field) {
+			matches = false
+		} else if field.Message() != nil && !field.IsList() && !field.IsMap() {
+			matches = messageContains(value.Message(), actual.Get(field).Message())
+		} else {
+			matches = value.Equal(actual.Get(field))
+		}
+		return matches
+	})
+	return matches
+}
+
+// parseMessage parses a message that was decoded from YAML or JSON.
+func parseMessage(
+	resolver protoencoding.Resolver,
+	descriptor protoreflect.MessageDescriptor,
+	value any,
+) (*dynamicpb.Message, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	message := dynamicpb.NewMessage(descriptor)
+	if err := protoencoding.NewJSONUnmarshaler(resolver, protoencoding.JSONUnmarshalerWithDisallowUnknown()).Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -43,11 +43,18 @@ const (
 	FormatText Format = 1
 	// FormatJSON is the JSON format.
 	FormatJSON Format = 2
+	// FormatCSV is the CSV format.
+	//
+	// This is only supported by the StatsPrinter.
+	FormatCSV Format = 3
 )
 
 var (
 	// AllFormatsString is the string representation of all Formats.
 	AllFormatsString = xstrings.SliceToString([]string{FormatText.String(), FormatJSON.String()})
+	// AllStatsFormatsString is the string representation of all Formats supported
+	// by the StatsPrinter.
+	AllStatsFormatsString = xstrings.SliceToString([]string{FormatText.String(), FormatJSON.String(), FormatCSV.String()})
 )
 
 // Format is a format to print.
@@ -67,6 +74,16 @@ func ParseFormat(s string) (Format, error) {
 	}
 }
 
+// ParseStatsFormat parses the format for the StatsPrinter.
+//
+// This is the same as ParseFormat, except that FormatCSV is also accepted.
+func ParseStatsFormat(s string) (Format, error) {
+	if s == "csv" {
+		return FormatCSV, nil
+	}
+	return ParseFormat(s)
+}
+
 // String implements fmt.Stringer.
 func (f Format) String() string {
 	switch f {
@@ -74,6 +91,8 @@ func (f Format) String() string {
 		return "text"
 	case FormatJSON:
 		return "json"
+	case FormatCSV:
+		return "csv"
 	default:
 		return strconv.Itoa(int(f))
 	}
@@ -315,6 +334,11 @@ func NewTokenPrinter(writer io.Writer, format Format) (TokenPrinter, error) {
 // StatsPrinter is a printer of Stats.
 type StatsPrinter interface {
 	PrintStats(ctx context.Context, format Format, stats *protostat.Stats) error
+	// PrintGroupStats prints the Stats of each group, such as each package.
+	//
+	// The groupName is the name of the kind of group, such as "Package", and is used
+	// as the header of the column of group names in text and CSV output.
+	PrintGroupStats(ctx context.Context, format Format, groupName string, groupStats ...*protostat.GroupStats) error
 }
 
 // NewStatsPrinter returns a new StatsPrinter.
@@ -16,13 +16,65 @@ package bufprint
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/bufbuild/buf/private/pkg/protostat"
 )
 
+// statsColumns are the columns of Stats printed in CSV and grouped text output, in order.
+var statsColumns = append(slices.Clone(baseStatsColumns), extendedStatsColumns...)
+
+// baseStatsColumns are the columns of Stats that have always been printed in text output.
+var baseStatsColumns = []statsColumn{
+	{textName: "Files", csvName: "files", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Files) }},
+	{textName: "Types", csvName: "types", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Types) }},
+	{textName: "Packages", csvName: "packages", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Packages) }},
+	{textName: "Messages", csvName: "messages", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Messages) }},
+	{textName: "Fields", csvName: "fields", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Fields) }},
+	{textName: "Enums", csvName: "enums", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Enums) }},
+	{textName: "Enum Values", csvName: "evalues", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.EnumValues) }},
+	{textName: "Services", csvName: "services", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Services) }},
+	{textName: "RPCs", csvName: "rpcs", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.RPCs) }},
+	{textName: "Extensions", csvName: "extensions", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Extensions) }},
+}
+
+// extendedStatsColumns are the columns of Stats that are printed after baseStatsColumns.
+var extendedStatsColumns = []statsColumn{
+	{textName: "Unary RPCs", csvName: "unary_rpcs", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.UnaryRPCs) }},
+	{textName: "Streaming RPCs", csvName: "streaming_rpcs", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.StreamingRPCs) }},
+	{textName: "Deprecated", csvName: "deprecated", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.Deprecated) }},
+	{textName: "Max Message Depth", csvName: "max_message_depth", value: func(stats *protostat.Stats) string { return strconv.Itoa(stats.MaxMessageDepth) }},
+	{textName: "Fields Per Message", csvName: "fields_per_message", value: func(stats *protostat.Stats) string {
+		return strconv.FormatFloat(stats.FieldsPerMessage, 'f', 1, 64)
+	}},
+	{textName: "Max Fields Per Message", csvName: "max_fields_per_message", value: func(stats *protostat.Stats) string {
+		return strconv.Itoa(stats.MaxFieldsPerMessage)
+	}},
+	{textName: "Comment Coverage", csvName: "comment_coverage", value: func(stats *protostat.Stats) string {
+		return strconv.FormatFloat(stats.CommentCoverage, 'f', 1, 64) + "%"
+	}},
+	{textName: "Syntaxes", csvName: "syntaxes", value: func(stats *protostat.Stats) string {
+		syntaxes := make([]string, 0, len(stats.Syntaxes))
+		for _, syntax := range slices.Sorted(maps.Keys(stats.Syntaxes)) {
+			syntaxes = append(syntaxes, fmt.Sprintf("%s=%d", syntax, stats.Syntaxes[syntax]))
+		}
+		return strings.Join(syntaxes, ",")
+	}},
+}
+
+type statsColumn struct {
+	textName string
+	csvName  string
+	value    func(*protostat.Stats) string
+}
+
 type statsPrinter struct {
 	writer io.Writer
 }
@@ -36,7 +88,7 @@ func newStatsPrinter(writer io.Writer) *statsPrinter {
 func (p *statsPrinter) PrintStats(ctx context.Context, format Format, stats *protostat.Stats) error {
 	switch format {
 	case FormatText:
-		_, err := fmt.Fprintf(
+		if _, err := fmt.Fprintf(
 			p.writer,
 			`Files:       %d
 Types:       %d
@@ -60,11 +112,102 @@ Extensions:  %d
 			stats.Services,
 			stats.RPCs,
 			stats.Extensions,
-		)
-		return err
+		); err != nil {
+			return err
+		}
+		width := 0
+		for _, column := range extendedStatsColumns {
+			width = max(width, len(column.textName))
+		}
+		for _, column := range extendedStatsColumns {
+			if _, err := fmt.Fprintf(p.writer, "%-*s %s\n", width+1, column.textName+":", column.value(stats)); err != nil {
+				return err
+			}
+		}
+		return nil
 	case FormatJSON:
 		return json.NewEncoder(p.writer).Encode(stats)
+	case FormatCSV:
+		return p.printCSV(nil, []string{""}, []*protostat.Stats{stats})
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func (p *statsPrinter) PrintGroupStats(
+	ctx context.Context,
+	format Format,
+	groupName string,
+	groupStats ...*protostat.GroupStats,
+) error {
+	names := make([]string, len(groupStats))
+	statsSlice := make([]*protostat.Stats, len(groupStats))
+	for i, groupStat := range groupStats {
+		names[i] = groupStat.Name
+		statsSlice[i] = groupStat.Stats
+	}
+	switch format {
+	case FormatText:
+		header := []string{groupName}
+		for _, column := range statsColumns {
+			header = append(header, column.textName)
+		}
+		return WithTabWriter(
+			p.writer,
+			header,
+			func(tabWriter TabWriter) error {
+				for i, stats := range statsSlice {
+					if err := tabWriter.Write(statsRow(names[i], stats)...); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case FormatJSON:
+		for _, groupStat := range groupStats {
+			if err := json.NewEncoder(p.writer).Encode(groupStat); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCSV:
+		return p.printCSV([]string{strings.ToLower(groupName)}, names, statsSlice)
 	default:
 		return fmt.Errorf("unknown format: %v", format)
 	}
 }
+
+// printCSV prints a CSV header and a row for each Stats.
+//
+// If groupHeader is empty, the rows have no group name column, and names are ignored.
+func (p *statsPrinter) printCSV(groupHeader []string, names []string, statsSlice []*protostat.Stats) error {
+	csvWriter := csv.NewWriter(p.writer)
+	header := groupHeader
+	for _, column := range statsColumns {
+		header = append(header, column.csvName)
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for i, stats := range statsSlice {
+		row := statsRow(names[i], stats)
+		if len(groupHeader) == 0 {
+			row = row[1:]
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// statsRow returns the name followed by the value of each column of stats.
+func statsRow(name string, stats *protostat.Stats) []string {
+	row := []string{name}
+	for _, column := range statsColumns {
+		row = append(row, column.value(stats))
+	}
+	return row
+}
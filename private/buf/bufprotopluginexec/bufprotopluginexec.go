@@ -23,11 +23,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
 
 	"buf.build/go/app"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/pluginrpcutil"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/wasm"
 	"github.com/bufbuild/protoplugin"
 	"google.golang.org/protobuf/types/pluginpb"
 )
@@ -62,7 +66,7 @@ type Generator interface {
 	// Generate generates a CodeGeneratorResponse for the given pluginName. The
 	// pluginName must be available on the system's PATH or one of the plugins
 	// built-in to protoc. The plugin path can be overridden via the
-	// GenerateWithPluginPath option.
+	// GenerateWithPluginPath option, including with the path to a Wasm plugin.
 	Generate(
 		ctx context.Context,
 		container app.EnvStderrContainer,
@@ -73,11 +77,14 @@ type Generator interface {
 }
 
 // NewGenerator returns a new Generator.
+//
+// The Wasm runtime is used to run Wasm plugins.
 func NewGenerator(
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 ) Generator {
-	return newGenerator(logger, storageosProvider)
+	return newGenerator(logger, storageosProvider, wasmRuntime)
 }
 
 // GenerateOption is an option for Generate.
@@ -85,7 +92,8 @@ type GenerateOption func(*generateOptions)
 
 // GenerateWithPluginPath returns a new GenerateOption that uses the given path to the plugin.
 // If the path has more than one element, the first is the plugin binary and the others are
-// optional additional arguments to pass to the binary.
+// optional additional arguments to pass to the binary. If the plugin binary has the .wasm
+// extension, it is run as a Wasm plugin.
 func GenerateWithPluginPath(pluginPath ...string) GenerateOption {
 	return func(generateOptions *generateOptions) {
 		generateOptions.pluginPath = pluginPath
@@ -104,7 +112,9 @@ func GenerateWithProtocPath(protocPath ...string) GenerateOption {
 //
 // protocPath and pluginPath are optional.
 //
-//   - If the plugin path is set, this returns a new binary handler for that path.
+//   - If the plugin path is set to a file with the .wasm extension, this returns a new Wasm
+//     handler for that path, which runs the plugin with the Wasm runtime.
+//   - If the plugin path is set otherwise, this returns a new binary handler for that path.
 //   - If the plugin path is unset, this does exec.LookPath for a binary named protoc-gen-pluginName,
 //     and if one is found, a new binary handler is returned for this.
 //   - Else, if the name is in ProtocProxyPluginNames, this returns a new protoc proxy handler.
@@ -112,6 +122,7 @@ func GenerateWithProtocPath(protocPath ...string) GenerateOption {
 func NewHandler(
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 	pluginName string,
 	options ...HandlerOption,
 ) (protoplugin.Handler, error) {
@@ -123,6 +134,9 @@ func NewHandler(
 	// Initialize binary plugin handler when path is specified with optional args. Return
 	// on error as something is wrong with the supplied pluginPath option.
 	if len(handlerOptions.pluginPath) > 0 {
+		if filepath.Ext(handlerOptions.pluginPath[0]) == ".wasm" {
+			return NewWasmHandler(logger, wasmRuntime, handlerOptions.pluginPath[0], handlerOptions.pluginPath[1:])
+		}
 		return NewBinaryHandler(logger, handlerOptions.pluginPath[0], handlerOptions.pluginPath[1:])
 	}
 
@@ -185,6 +199,89 @@ func NewBinaryHandler(logger *slog.Logger, pluginPath string, pluginArgs []strin
 	return newBinaryHandler(logger, pluginPath, pluginArgs), nil
 }
 
+// NewWasmHandler returns a new Handler that runs the Wasm plugin specified by
+// pluginPath with the Wasm runtime. The plugin is looked up in the local directory
+// and then on the PATH, and is not required to be executable.
+//
+// Wasm plugins are run in a sandbox, without access to the environment, filesystem,
+// or network. The plugin is compiled once per Handler, and the compilation is cached
+// by the runtime.
+func NewWasmHandler(
+	logger *slog.Logger,
+	wasmRuntime wasm.Runtime,
+	pluginPath string,
+	pluginArgs []string,
+) (protoplugin.Handler, error) {
+	moduleWasm, err := pluginrpcutil.ReadWasmFileFromOS(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	return newWasmHandler(logger, wasmRuntime, pluginPath, pluginArgs, moduleWasm), nil
+}
+
+// GetPluginIdentity returns a string that identifies the plugin that NewHandler returns
+// a Handler for with the same plugin name and options.
+//
+// The identity includes the path, size, and modification time of the plugin binary or
+// Wasm file, or of protoc for plugins built in to protoc, so that it changes when the
+// plugin is updated. This is used to cache the responses of plugins.
+//
+// If the plugin path or protoc path has additional arguments, false is returned, as
+// the plugin that is run cannot be identified by the binary alone, for example for
+// a plugin path of "go run path/to/plugin.go".
+//
+// The identity does not change when a plugin binary that is a wrapper script or shim
+// runs a different plugin, or when files or environment variables that the plugin reads
+// change. Callers that cache by this identity should let users disable the cache.
+func GetPluginIdentity(pluginName string, options ...HandlerOption) (string, bool, error) {
+	handlerOptions := newHandlerOptions()
+	for _, option := range options {
+		option(handlerOptions)
+	}
+	if len(handlerOptions.pluginPath) > 1 {
+		return "", false, nil
+	}
+	if len(handlerOptions.pluginPath) == 1 {
+		pluginPath := handlerOptions.pluginPath[0]
+		if filepath.Ext(pluginPath) == ".wasm" {
+			// Same lookup as pluginrpcutil.ReadWasmFileFromOS.
+			if fileInfo, err := os.Stat(pluginPath); err != nil || fileInfo.IsDir() {
+				if pluginPath, err = unsafeLookPath(pluginPath); err != nil {
+					return "", false, err
+				}
+			}
+		} else {
+			var err error
+			if pluginPath, err = unsafeLookPath(pluginPath); err != nil {
+				return "", false, err
+			}
+		}
+		return getFileIdentity(pluginName, pluginPath)
+	}
+	if pluginPath, err := unsafeLookPath("protoc-gen-" + pluginName); err == nil {
+		return getFileIdentity(pluginName, pluginPath)
+	}
+	if _, ok := bufconfig.ProtocProxyPluginNames[pluginName]; ok {
+		if len(handlerOptions.protocPath) > 1 {
+			return "", false, nil
+		}
+		protocPath := "protoc"
+		if len(handlerOptions.protocPath) == 1 {
+			protocPath = handlerOptions.protocPath[0]
+		}
+		protocPath, err := unsafeLookPath(protocPath)
+		if err != nil {
+			return "", false, err
+		}
+		return getFileIdentity(pluginName, protocPath)
+	}
+	return "", false, fmt.Errorf(
+		"could not find protoc plugin for name %s - please make sure protoc-gen-%s is installed and present on your $PATH",
+		pluginName,
+		pluginName,
+	)
+}
+
 type handlerOptions struct {
 	pluginPath []string
 	protocPath []string
@@ -194,6 +291,28 @@ func newHandlerOptions() *handlerOptions {
 	return &handlerOptions{}
 }
 
+// getFileIdentity returns the identity of the plugin with the given name that is run
+// with the file at the path.
+func getFileIdentity(pluginName string, path string) (string, bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", false, err
+	}
+	// OK to use os.Stat instead of os.Lstat here, as we want the identity of the file
+	// that is run.
+	fileInfo, err := os.Stat(absPath)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf(
+		"%s:%s:%d:%d",
+		pluginName,
+		absPath,
+		fileInfo.Size(),
+		fileInfo.ModTime().UnixNano(),
+	), true, nil
+}
+
 // unsafeLookPath is a wrapper around exec.LookPath that restores the original
 // pre-Go 1.19 behavior of resolving queries that would use relative PATH
 // entries. We consider it acceptable for the use case of locating plugins.
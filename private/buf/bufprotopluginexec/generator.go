@@ -21,21 +21,25 @@ import (
 	"buf.build/go/app"
 	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/wasm"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
 type generator struct {
 	logger            *slog.Logger
 	storageosProvider storageos.Provider
+	wasmRuntime       wasm.Runtime
 }
 
 func newGenerator(
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 ) *generator {
 	return &generator{
 		logger:            logger,
 		storageosProvider: storageosProvider,
+		wasmRuntime:       wasmRuntime,
 	}
 }
 
@@ -57,6 +61,7 @@ func (g *generator) Generate(
 	handler, err := NewHandler(
 		g.logger,
 		g.storageosProvider,
+		g.wasmRuntime,
 		pluginName,
 		handlerOptions...,
 	)
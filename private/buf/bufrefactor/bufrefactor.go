@@ -0,0 +1,131 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufrefactor performs source-level refactorings of Protobuf files.
+//
+// Refactorings are resolved against an Image, and applied as text edits to the
+// source files. Edited files can then be printed with bufformat.
+package bufrefactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/storage"
+)
+
+// ErrUnsafeEdit is returned when an Edit cannot be applied without breaking
+// the references to the edited symbol.
+var ErrUnsafeEdit = errors.New("unsafe edit")
+
+// Edit is a source-level edit.
+type Edit interface {
+	// Stringer returns a human-readable description of the Edit.
+	fmt.Stringer
+
+	isEdit()
+}
+
+// NewRenameEdit returns a new Edit that renames the symbol or package with the given full name.
+//
+// Full names do not have a leading dot, for example "foo.v1.Bar". Enum values are named
+// within their enum, for example "foo.v1.Bar.BAR_UNSPECIFIED". A symbol can only be renamed
+// within its parent, for example from "foo.v1.Bar" to "foo.v1.Baz". A package can be renamed
+// to any other package that does not exist yet, for example from "foo.v1" to "bar.v1".
+//
+// The definition of the symbol and all references to it are updated.
+func NewRenameEdit(fullName string, newFullName string, options ...RenameEditOption) Edit {
+	return newRenameEdit(fullName, newFullName, options...)
+}
+
+// RenameEditOption is an option for a new rename Edit.
+type RenameEditOption func(*renameEdit)
+
+// RenameWithReservedName returns a new RenameEditOption that adds the old name of a field
+// or enum value to the reserved names of its message or enum.
+func RenameWithReservedName() RenameEditOption {
+	return func(renameEdit *renameEdit) {
+		renameEdit.reserveName = true
+	}
+}
+
+// RenameWithDeprecatedAlias returns a new RenameEditOption that keeps the old name of an
+// enum value as a deprecated alias of the renamed value.
+//
+// The allow_alias option is added to the enum if it is not already set.
+func RenameWithDeprecatedAlias() RenameEditOption {
+	return func(renameEdit *renameEdit) {
+		renameEdit.deprecatedAlias = true
+	}
+}
+
+// NewRemoveImportEdit returns a new Edit that removes the import of importPath from the
+// file at path.
+func NewRemoveImportEdit(path string, importPath string) Edit {
+	return newRemoveImportEdit(path, importPath)
+}
+
+// TextEdit replaces the text between two positions of a file with new text.
+//
+// Lines and columns are 1-indexed, and columns are byte offsets within the line.
+// The end position is exclusive. If the start and end positions are equal, the
+// new text is inserted at the start position.
+type TextEdit struct {
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+	NewText     string
+}
+
+// Refactorer applies Edits to the source files of an Image.
+type Refactorer interface {
+	// SuggestedEdits returns the Edits for the suggested edits attached to the FileAnnotation.
+	//
+	// Returns an empty slice if the rule that produced the FileAnnotation did not attach
+	// any suggested edits, or if they could not be safely applied.
+	SuggestedEdits(ctx context.Context, fileAnnotation bufanalysis.FileAnnotation) ([]Edit, error)
+	// Apply applies the Edits.
+	//
+	// Edits are resolved against the Image the Refactorer was created with, so any
+	// number of Edits may be applied, as long as they do not conflict with each other.
+	// If an Edit cannot be safely applied, this returns an error wrapping ErrUnsafeEdit,
+	// and no changes from that Edit are made.
+	Apply(ctx context.Context, edits ...Edit) error
+	// Bucket returns a new ReadBucket with the formatted contents of all the files that
+	// were edited.
+	//
+	// The paths and external paths of the files are the same as in the source ReadBucket.
+	Bucket(ctx context.Context) (storage.ReadBucket, error)
+	// TextEdits returns the unformatted text edits to each file that was edited, keyed
+	// by path.
+	//
+	// The TextEdits for a file are sorted by position, and do not overlap.
+	TextEdits() map[string][]TextEdit
+
+	isRefactorer()
+}
+
+// NewRefactorer returns a new Refactorer for the Image.
+//
+// The ReadBucket contains the source files that can be edited, keyed by their path within
+// the Image. The Image must have source code info, and must have been built from the files
+// in the ReadBucket. Files of the Image that are not in the ReadBucket are not edited, and
+// Edits that require changes to them are unsafe.
+func NewRefactorer(ctx context.Context, image bufimage.Image, readBucket storage.ReadBucket) (Refactorer, error) {
+	return newRefactorer(ctx, image, readBucket)
+}
@@ -0,0 +1,408 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduletesting"
+	"github.com/bufbuild/buf/private/pkg/slogtestext"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFooProto = `syntax = "proto3";
+
+package foo.v1;
+
+import "foo/v1/bar.proto";
+
+message Foo {
+  Bar bar = 1;
+  foo.v1.Bar qualified_bar = 2;
+  map<string, .foo.v1.Bar> bar_map = 3;
+  Bar.Nested nested = 4;
+  int32 someValue = 5;
+}
+`
+	testBarProto = `syntax = "proto3";
+
+package foo.v1;
+
+import "google/protobuf/empty.proto";
+
+message Bar {
+  message Nested {}
+  Status status = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  OK = 1;
+}
+`
+)
+
+func TestRename(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	require.NoError(t, refactorer.Apply(ctx, NewRenameEdit("foo.v1.Bar", "foo.v1.Baz")))
+	testRequireBucket(
+		t,
+		refactorer,
+		map[string]string{
+			"foo/v1/foo.proto": `syntax = "proto3";
+
+package foo.v1;
+
+import "foo/v1/bar.proto";
+
+message Foo {
+  Baz bar = 1;
+  foo.v1.Baz qualified_bar = 2;
+  map<string, .foo.v1.Baz> bar_map = 3;
+  Baz.Nested nested = 4;
+  int32 someValue = 5;
+}
+`,
+			"foo/v1/bar.proto": `syntax = "proto3";
+
+package foo.v1;
+
+import "google/protobuf/empty.proto";
+
+message Baz {
+  message Nested {}
+  Status status = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  OK = 1;
+}
+`,
+		},
+	)
+}
+
+func TestRenameUnsafe(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	err := refactorer.Apply(ctx, NewRenameEdit("foo.v1.Bar", "foo.v1.Foo"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("foo.v1.Status.OK", "foo.v1.Status.STATUS_UNSPECIFIED"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("google.protobuf.Empty", "google.protobuf.Nothing"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("foo.v1.Bar", "foo.v1.1Bar"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("foo.v1.Bar", "foo.v1.Baz"), NewRenameEdit("foo.v1.Bar", "foo.v1.Qux"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+}
+
+func TestRenameKeepOldName(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	require.NoError(
+		t,
+		refactorer.Apply(
+			ctx,
+			NewRenameEdit("foo.v1.Foo.someValue", "foo.v1.Foo.some_value", RenameWithReservedName()),
+			NewRenameEdit("foo.v1.Status.OK", "foo.v1.Status.STATUS_OK", RenameWithDeprecatedAlias()),
+		),
+	)
+	testRequireBucket(
+		t,
+		refactorer,
+		map[string]string{
+			"foo/v1/foo.proto": `syntax = "proto3";
+
+package foo.v1;
+
+import "foo/v1/bar.proto";
+
+message Foo {
+  Bar bar = 1;
+  foo.v1.Bar qualified_bar = 2;
+  map<string, .foo.v1.Bar> bar_map = 3;
+  Bar.Nested nested = 4;
+  int32 some_value = 5;
+  reserved "someValue";
+}
+`,
+			"foo/v1/bar.proto": `syntax = "proto3";
+
+package foo.v1;
+
+import "google/protobuf/empty.proto";
+
+message Bar {
+  message Nested {}
+  Status status = 1;
+}
+
+enum Status {
+  option allow_alias = true;
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+  OK = 1 [deprecated = true];
+}
+`,
+		},
+	)
+	err := refactorer.Apply(ctx, NewRenameEdit("foo.v1.Bar", "foo.v1.Baz", RenameWithReservedName()))
+	require.Error(t, err)
+	err = refactorer.Apply(ctx, NewRenameEdit("foo.v1.Foo.bar", "foo.v1.Foo.baz", RenameWithDeprecatedAlias()))
+	require.Error(t, err)
+	// The alias keeps the old name.
+	err = refactorer.Apply(ctx, NewRenameEdit("foo.v1.Status.STATUS_UNSPECIFIED", "foo.v1.Status.OK"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+}
+
+func TestRenamePackage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	require.NoError(t, refactorer.Apply(ctx, NewRenameEdit("foo.v1", "bar.v2")))
+	testRequireBucket(
+		t,
+		refactorer,
+		map[string]string{
+			"foo/v1/foo.proto": `syntax = "proto3";
+
+package bar.v2;
+
+import "foo/v1/bar.proto";
+
+message Foo {
+  Bar bar = 1;
+  bar.v2.Bar qualified_bar = 2;
+  map<string, .bar.v2.Bar> bar_map = 3;
+  Bar.Nested nested = 4;
+  int32 someValue = 5;
+}
+`,
+			"foo/v1/bar.proto": `syntax = "proto3";
+
+package bar.v2;
+
+import "google/protobuf/empty.proto";
+
+message Bar {
+  message Nested {}
+  Status status = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  OK = 1;
+}
+`,
+		},
+	)
+	err := refactorer.Apply(ctx, NewRenameEdit("bar.v2", "google.protobuf"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("google.protobuf", "google.protobuf2"))
+	require.ErrorIs(t, err, ErrUnsafeEdit)
+	err = refactorer.Apply(ctx, NewRenameEdit("bar.v2", "bar.2v"))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrUnsafeEdit)
+}
+
+func TestTextEdits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	require.NoError(
+		t,
+		refactorer.Apply(
+			ctx,
+			NewRenameEdit("foo.v1.Bar.Nested", "foo.v1.Bar.Inner"),
+			NewRemoveImportEdit("foo/v1/bar.proto", "google/protobuf/empty.proto"),
+		),
+	)
+	require.Equal(
+		t,
+		map[string][]TextEdit{
+			"foo/v1/foo.proto": {
+				{StartLine: 11, StartColumn: 7, EndLine: 11, EndColumn: 13, NewText: "Inner"},
+			},
+			"foo/v1/bar.proto": {
+				{StartLine: 5, StartColumn: 1, EndLine: 6, EndColumn: 1},
+				{StartLine: 8, StartColumn: 11, EndLine: 8, EndColumn: 17, NewText: "Inner"},
+			},
+		},
+		refactorer.TextEdits(),
+	)
+}
+
+func TestSuggestedEdits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refactorer := testNewRefactorer(t)
+	var allEdits []Edit
+	for _, fileAnnotation := range []bufanalysis.FileAnnotation{
+		testNewFileAnnotation(
+			"foo/v1/foo.proto", 12, 9, "FIELD_LOWER_SNAKE_CASE",
+			bufanalysis.NewRenameSuggestedEdit("foo.v1.Foo.someValue", "foo.v1.Foo.some_value"),
+		),
+		testNewFileAnnotation(
+			"foo/v1/bar.proto", 14, 3, "ENUM_VALUE_PREFIX",
+			bufanalysis.NewRenameSuggestedEdit("foo.v1.Status.OK", "foo.v1.Status.STATUS_OK"),
+		),
+		testNewFileAnnotation(
+			"foo/v1/bar.proto", 5, 1, "IMPORT_USED",
+			bufanalysis.NewRemoveImportSuggestedEdit("foo/v1/bar.proto", "google/protobuf/empty.proto"),
+		),
+	} {
+		edits, err := refactorer.SuggestedEdits(ctx, fileAnnotation)
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+		allEdits = append(allEdits, edits...)
+	}
+	// Annotations without suggested edits have no Edits.
+	edits, err := refactorer.SuggestedEdits(ctx, testNewFileAnnotation("foo/v1/foo.proto", 12, 9, "FIELD_LOWER_SNAKE_CASE"))
+	require.NoError(t, err)
+	require.Empty(t, edits)
+	// Suggested edits that cannot be safely applied are dropped.
+	edits, err = refactorer.SuggestedEdits(
+		ctx,
+		testNewFileAnnotation(
+			"foo/v1/foo.proto", 12, 9, "FIELD_LOWER_SNAKE_CASE",
+			bufanalysis.NewRenameSuggestedEdit("foo.v1.Foo.someValue", "foo.v1.Foo.bar"),
+		),
+	)
+	require.NoError(t, err)
+	require.Empty(t, edits)
+	require.NoError(t, refactorer.Apply(ctx, allEdits...))
+	testRequireBucket(
+		t,
+		refactorer,
+		map[string]string{
+			"foo/v1/foo.proto": `syntax = "proto3";
+
+package foo.v1;
+
+import "foo/v1/bar.proto";
+
+message Foo {
+  Bar bar = 1;
+  foo.v1.Bar qualified_bar = 2;
+  map<string, .foo.v1.Bar> bar_map = 3;
+  Bar.Nested nested = 4;
+  int32 some_value = 5;
+}
+`,
+			"foo/v1/bar.proto": `syntax = "proto3";
+
+package foo.v1;
+
+message Bar {
+  message Nested {}
+  Status status = 1;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OK = 1;
+}
+`,
+		},
+	)
+}
+
+func testNewRefactorer(t *testing.T) Refactorer {
+	pathToData := map[string][]byte{
+		"foo/v1/foo.proto": []byte(testFooProto),
+		"foo/v1/bar.proto": []byte(testBarProto),
+	}
+	moduleSet, err := bufmoduletesting.NewModuleSetForPathToData(pathToData)
+	require.NoError(t, err)
+	image, err := bufimage.BuildImage(
+		context.Background(),
+		slogtestext.NewLogger(t),
+		bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFiles(moduleSet),
+	)
+	require.NoError(t, err)
+	readBucket, err := storagemem.NewReadBucket(pathToData)
+	require.NoError(t, err)
+	refactorer, err := NewRefactorer(context.Background(), image, readBucket)
+	require.NoError(t, err)
+	return refactorer
+}
+
+func testRequireBucket(t *testing.T, refactorer Refactorer, expectedPathToData map[string]string) {
+	readBucket, err := refactorer.Bucket(context.Background())
+	require.NoError(t, err)
+	pathToData := make(map[string]string)
+	require.NoError(
+		t,
+		storage.WalkReadObjects(
+			context.Background(),
+			readBucket,
+			"",
+			func(readObject storage.ReadObject) error {
+				data, err := storage.ReadPath(context.Background(), readBucket, readObject.Path())
+				if err != nil {
+					return err
+				}
+				pathToData[readObject.Path()] = string(data)
+				return nil
+			},
+		),
+	)
+	require.Equal(t, expectedPathToData, pathToData)
+}
+
+func testNewFileAnnotation(
+	path string,
+	line int,
+	column int,
+	typeString string,
+	suggestedEdits ...bufanalysis.SuggestedEdit,
+) bufanalysis.FileAnnotation {
+	return bufanalysis.NewFileAnnotation(
+		testFileInfo(path),
+		line,
+		column,
+		line,
+		column,
+		typeString,
+		"",
+		"",
+		"",
+		bufanalysis.FileAnnotationWithSuggestedEdits(suggestedEdits...),
+	)
+}
+
+type testFileInfo string
+
+func (t testFileInfo) Path() string {
+	return string(t)
+}
+
+func (t testFileInfo) ExternalPath() string {
+	return string(t)
+}
@@ -0,0 +1,61 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"fmt"
+)
+
+type renameEdit struct {
+	fullName        string
+	newFullName     string
+	reserveName     bool
+	deprecatedAlias bool
+}
+
+func newRenameEdit(fullName string, newFullName string, options ...RenameEditOption) *renameEdit {
+	renameEdit := &renameEdit{
+		fullName:    fullName,
+		newFullName: newFullName,
+	}
+	for _, option := range options {
+		option(renameEdit)
+	}
+	return renameEdit
+}
+
+func (r *renameEdit) String() string {
+	return fmt.Sprintf("Rename %q to %q", r.fullName, r.newFullName)
+}
+
+func (*renameEdit) isEdit() {}
+
+type removeImportEdit struct {
+	path       string
+	importPath string
+}
+
+func newRemoveImportEdit(path string, importPath string) *removeImportEdit {
+	return &removeImportEdit{
+		path:       path,
+		importPath: importPath,
+	}
+}
+
+func (r *removeImportEdit) String() string {
+	return fmt.Sprintf("Remove import %q from %q", r.importPath, r.path)
+}
+
+func (*removeImportEdit) isEdit() {}
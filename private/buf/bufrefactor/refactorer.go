@@ -0,0 +1,489 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotosource"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/bufbuild/protocompile/ast"
+)
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type refactorer struct {
+	readBucket  storage.ReadBucket
+	symbolIndex *symbolIndex
+	// scopedFullNames are the scoped full names of all symbols, updated as symbols are renamed.
+	scopedFullNames map[string]struct{}
+	// packages are the packages of all files, updated as packages are renamed.
+	packages map[string]struct{}
+	// pathToSourceFile is populated lazily as files are edited. A nil value
+	// denotes a file that is not in the ReadBucket.
+	pathToSourceFile map[string]*sourceFile
+}
+
+func newRefactorer(ctx context.Context, image bufimage.Image, readBucket storage.ReadBucket) (*refactorer, error) {
+	files, err := bufprotosource.NewFiles(ctx, image.Files(), image.Resolver())
+	if err != nil {
+		return nil, err
+	}
+	symbolIndex, err := newSymbolIndex(files)
+	if err != nil {
+		return nil, err
+	}
+	scopedFullNames := make(map[string]struct{}, len(symbolIndex.scopedFullNames))
+	for scopedFullName := range symbolIndex.scopedFullNames {
+		scopedFullNames[scopedFullName] = struct{}{}
+	}
+	packages := make(map[string]struct{}, len(symbolIndex.packageToPaths))
+	for pkg := range symbolIndex.packageToPaths {
+		packages[pkg] = struct{}{}
+	}
+	return &refactorer{
+		readBucket:       readBucket,
+		symbolIndex:      symbolIndex,
+		scopedFullNames:  scopedFullNames,
+		packages:         packages,
+		pathToSourceFile: make(map[string]*sourceFile),
+	}, nil
+}
+
+func (r *refactorer) Apply(ctx context.Context, edits ...Edit) error {
+	for _, edit := range edits {
+		var pathToReplacements map[string][]*replacement
+		var err error
+		switch t := edit.(type) {
+		case *renameEdit:
+			pathToReplacements, err = r.planRename(ctx, t)
+		case *removeImportEdit:
+			pathToReplacements, err = r.planRemoveImport(ctx, t)
+		default:
+			return fmt.Errorf("unknown Edit type: %T", edit)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", edit.String(), err)
+		}
+		// Check for conflicts before making any changes, so that a failed Edit has no effect.
+		for path, replacements := range pathToReplacements {
+			for _, replacement := range replacements {
+				if err := r.pathToSourceFile[path].checkReplacement(replacement); err != nil {
+					return fmt.Errorf("%s: %w", edit.String(), err)
+				}
+			}
+		}
+		for path, replacements := range pathToReplacements {
+			for _, replacement := range replacements {
+				r.pathToSourceFile[path].addReplacement(replacement)
+			}
+		}
+		if renameEdit, ok := edit.(*renameEdit); ok && len(pathToReplacements) > 0 {
+			if symbol, ok := r.symbolIndex.fullNameToSymbol[renameEdit.fullName]; ok {
+				if !renameEdit.deprecatedAlias {
+					delete(r.scopedFullNames, symbol.scopedFullName)
+				}
+				r.scopedFullNames[siblingFullName(symbol.scopedFullName, lastComponent(renameEdit.newFullName))] = struct{}{}
+			} else {
+				delete(r.packages, renameEdit.fullName)
+				r.packages[renameEdit.newFullName] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *refactorer) Bucket(ctx context.Context) (storage.ReadBucket, error) {
+	readWriteBucket := storagemem.NewReadWriteBucket()
+	for path, sourceFile := range r.pathToSourceFile {
+		if sourceFile == nil || len(sourceFile.replacements) == 0 {
+			continue
+		}
+		if err := func() (retErr error) {
+			writeObjectCloser, err := readWriteBucket.Put(ctx, path)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				retErr = errors.Join(retErr, writeObjectCloser.Close())
+			}()
+			if err := sourceFile.format(writeObjectCloser); err != nil {
+				return err
+			}
+			return writeObjectCloser.SetExternalPath(sourceFile.externalPath)
+		}(); err != nil {
+			return nil, err
+		}
+	}
+	return readWriteBucket, nil
+}
+
+func (r *refactorer) TextEdits() map[string][]TextEdit {
+	pathToTextEdits := make(map[string][]TextEdit)
+	for path, sourceFile := range r.pathToSourceFile {
+		if sourceFile == nil || len(sourceFile.replacements) == 0 {
+			continue
+		}
+		pathToTextEdits[path] = sourceFile.textEdits()
+	}
+	return pathToTextEdits
+}
+
+func (*refactorer) isRefactorer() {}
+
+func (r *refactorer) planRename(ctx context.Context, renameEdit *renameEdit) (map[string][]*replacement, error) {
+	if symbol, ok := r.symbolIndex.fullNameToSymbol[renameEdit.fullName]; ok {
+		return r.planRenameSymbol(ctx, symbol, renameEdit)
+	}
+	if _, ok := r.packages[renameEdit.fullName]; ok && renameEdit.fullName != "" {
+		return r.planRenamePackage(ctx, renameEdit)
+	}
+	return nil, fmt.Errorf("symbol or package %q not found", renameEdit.fullName)
+}
+
+func (r *refactorer) planRenameSymbol(ctx context.Context, symbol *symbol, renameEdit *renameEdit) (map[string][]*replacement, error) {
+	if fullNameParent(renameEdit.newFullName) != fullNameParent(symbol.fullName) {
+		return nil, fmt.Errorf("%q can only be renamed within %q", symbol.fullName, fullNameParent(symbol.fullName))
+	}
+	newName := lastComponent(renameEdit.newFullName)
+	if !identifierRegexp.MatchString(newName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+	if renameEdit.reserveName && symbol.kind != symbolKindField && symbol.kind != symbolKindEnumValue {
+		return nil, fmt.Errorf("only the names of fields and enum values can be reserved, but %q is neither", symbol.fullName)
+	}
+	if renameEdit.deprecatedAlias && symbol.kind != symbolKindEnumValue {
+		return nil, fmt.Errorf("only enum values can have aliases, but %q is not an enum value", symbol.fullName)
+	}
+	if newName == symbol.name {
+		return nil, nil
+	}
+	newScopedFullName := siblingFullName(symbol.scopedFullName, newName)
+	if _, ok := r.scopedFullNames[newScopedFullName]; ok {
+		return nil, fmt.Errorf("%w: %q already exists", ErrUnsafeEdit, newScopedFullName)
+	}
+	if err := r.checkRenameable(symbol); err != nil {
+		return nil, err
+	}
+	pathToReplacements := make(map[string][]*replacement)
+	sourceFile, err := r.getSourceFile(ctx, symbol.path)
+	if err != nil {
+		return nil, err
+	}
+	if sourceFile == nil {
+		return nil, fmt.Errorf("%w: %q is defined in %q, which cannot be edited", ErrUnsafeEdit, symbol.fullName, symbol.path)
+	}
+	identNode, ok := sourceFile.positionToIdentValueNode[symbol.namePosition].(*ast.IdentNode)
+	if !ok || identNode.Val != symbol.name {
+		return nil, fmt.Errorf("%w: could not find the definition of %q", ErrUnsafeEdit, symbol.fullName)
+	}
+	pathToReplacements[symbol.path] = append(
+		pathToReplacements[symbol.path],
+		sourceFile.newReplacement(identNode, identNode, newName),
+	)
+	if renameEdit.reserveName || renameEdit.deprecatedAlias {
+		replacements, err := r.planKeepOldName(sourceFile, symbol, identNode, renameEdit)
+		if err != nil {
+			return nil, err
+		}
+		pathToReplacements[symbol.path] = append(pathToReplacements[symbol.path], replacements...)
+	}
+	for _, reference := range r.symbolIndex.references {
+		if !isReferenceKindForSymbolKind(reference.kind, symbol.kind) {
+			continue
+		}
+		if reference.fullName != symbol.fullName && !strings.HasPrefix(reference.fullName, symbol.fullName+".") {
+			continue
+		}
+		sourceFile, components, err := r.getReferenceComponents(ctx, reference, symbol.fullName)
+		if err != nil {
+			return nil, err
+		}
+		index, ok := referencedComponentIndex(reference.fullName, symbol.fullName, len(components))
+		if !ok {
+			// The reference is relative, and does not spell out the name of the symbol.
+			continue
+		}
+		if components[index].Val != symbol.name {
+			return nil, fmt.Errorf("%w: could not find the reference to %q in %q", ErrUnsafeEdit, symbol.fullName, reference.position.path)
+		}
+		pathToReplacements[reference.position.path] = append(
+			pathToReplacements[reference.position.path],
+			sourceFile.newReplacement(components[index], components[index], newName),
+		)
+	}
+	return pathToReplacements, nil
+}
+
+// planKeepOldName returns the replacements that keep the old name of a renamed field
+// or enum value, as a reserved name or a deprecated alias.
+func (r *refactorer) planKeepOldName(
+	sourceFile *sourceFile,
+	symbol *symbol,
+	identNode *ast.IdentNode,
+	renameEdit *renameEdit,
+) ([]*replacement, error) {
+	declNode, ok := sourceFile.nameToDeclNode[identNode]
+	if !ok {
+		return nil, fmt.Errorf("%w: could not find the declaration of %q", ErrUnsafeEdit, symbol.fullName)
+	}
+	var replacements []*replacement
+	if renameEdit.deprecatedAlias {
+		enumValueNode, ok := declNode.(*ast.EnumValueNode)
+		if !ok {
+			return nil, fmt.Errorf("%w: could not find the declaration of %q", ErrUnsafeEdit, symbol.fullName)
+		}
+		enum, ok := r.symbolIndex.fullNameToSymbol[symbol.parentFullName]
+		if !ok {
+			return nil, fmt.Errorf("%w: could not find the enum of %q", ErrUnsafeEdit, symbol.fullName)
+		}
+		if !enum.allowAlias {
+			enumIdentNode, _ := sourceFile.positionToIdentValueNode[enum.namePosition].(*ast.IdentNode)
+			enumNode, ok := sourceFile.nameToDeclNode[enumIdentNode].(*ast.EnumNode)
+			if !ok {
+				return nil, fmt.Errorf("%w: could not find the declaration of %q", ErrUnsafeEdit, enum.fullName)
+			}
+			replacements = append(
+				replacements,
+				sourceFile.newInsertionAfter(
+					enumNode.OpenBrace,
+					"\n"+sourceFile.lineIndent(declNode)+"option allow_alias = true;",
+				),
+			)
+		}
+		replacements = append(
+			replacements,
+			sourceFile.newLineInsertionAfter(
+				declNode,
+				fmt.Sprintf("%s = %s [deprecated = true];", symbol.name, sourceFile.nodeText(enumValueNode.Number)),
+			),
+		)
+	}
+	if renameEdit.reserveName {
+		reservedName := fmt.Sprintf("%q", symbol.name)
+		if sourceFile.fileNode.Edition != nil {
+			// Editions use identifiers for reserved names.
+			reservedName = symbol.name
+		}
+		replacements = append(
+			replacements,
+			sourceFile.newLineInsertionAfter(declNode, "reserved "+reservedName+";"),
+		)
+	}
+	return replacements, nil
+}
+
+func (r *refactorer) planRenamePackage(ctx context.Context, renameEdit *renameEdit) (map[string][]*replacement, error) {
+	pkg := renameEdit.fullName
+	newPackage := renameEdit.newFullName
+	if renameEdit.reserveName || renameEdit.deprecatedAlias {
+		return nil, fmt.Errorf("the old name of package %q cannot be kept", pkg)
+	}
+	for _, component := range strings.Split(newPackage, ".") {
+		if !identifierRegexp.MatchString(component) {
+			return nil, fmt.Errorf("%q is not a valid package name", newPackage)
+		}
+	}
+	if newPackage == pkg {
+		return nil, nil
+	}
+	if _, ok := r.packages[newPackage]; ok {
+		return nil, fmt.Errorf("%w: package %q already exists", ErrUnsafeEdit, newPackage)
+	}
+	if _, ok := r.scopedFullNames[newPackage]; ok {
+		return nil, fmt.Errorf("%w: %q already exists", ErrUnsafeEdit, newPackage)
+	}
+	for _, symbol := range r.symbolIndex.fullNameToSymbol {
+		if symbol.kind == symbolKindExtension && symbol.pkg == pkg {
+			return nil, fmt.Errorf("%w: package %q contains extension %q, which may be referenced by option names", ErrUnsafeEdit, pkg, symbol.fullName)
+		}
+	}
+	pathToReplacements := make(map[string][]*replacement)
+	for _, path := range r.symbolIndex.packageToPaths[pkg] {
+		sourceFile, err := r.getSourceFile(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if sourceFile == nil {
+			return nil, fmt.Errorf("%w: package %q contains %q, which cannot be edited", ErrUnsafeEdit, pkg, path)
+		}
+		if sourceFile.packageNode == nil {
+			return nil, fmt.Errorf("%w: could not find the package declaration of %q", ErrUnsafeEdit, path)
+		}
+		pathToReplacements[path] = append(
+			pathToReplacements[path],
+			sourceFile.newReplacement(sourceFile.packageNode.Name, sourceFile.packageNode.Name, newPackage),
+		)
+	}
+	numPackageComponents := strings.Count(pkg, ".") + 1
+	for _, reference := range r.symbolIndex.references {
+		if reference.kind == referenceKindEnumDefault {
+			// Enum defaults are resolved within their enum, and never spell out the package.
+			continue
+		}
+		isFromPackage := r.symbolIndex.pathToPackage[reference.position.path] == pkg
+		isToPackage := strings.HasPrefix(reference.fullName, pkg+".")
+		if !isFromPackage && !isToPackage {
+			continue
+		}
+		sourceFile, components, err := r.getReferenceComponents(ctx, reference, reference.fullName)
+		if err != nil {
+			return nil, err
+		}
+		numFullNameComponents := strings.Count(reference.fullName, ".") + 1
+		if !isToPackage {
+			// References from the renamed package to other packages are resolved relative
+			// to the renamed package, unless they are fully-qualified.
+			if len(components) != numFullNameComponents {
+				return nil, fmt.Errorf("%w: %q is not fully-qualified in %q", ErrUnsafeEdit, reference.fullName, reference.position.path)
+			}
+			continue
+		}
+		switch numPackageComponentsInReference := len(components) - (numFullNameComponents - numPackageComponents); {
+		case numPackageComponentsInReference == numPackageComponents:
+			lastPackageComponent := components[numPackageComponents-1]
+			if sourceFile.nodeTextBetween(components[0], lastPackageComponent) != pkg {
+				return nil, fmt.Errorf("%w: could not find the reference to %q in %q", ErrUnsafeEdit, reference.fullName, reference.position.path)
+			}
+			pathToReplacements[reference.position.path] = append(
+				pathToReplacements[reference.position.path],
+				sourceFile.newReplacement(components[0], lastPackageComponent, newPackage),
+			)
+		case numPackageComponentsInReference <= 0 && isFromPackage:
+			// The reference is relative to the renamed package, and moves with it.
+		default:
+			return nil, fmt.Errorf("%w: %q is partially qualified in %q", ErrUnsafeEdit, reference.fullName, reference.position.path)
+		}
+	}
+	return pathToReplacements, nil
+}
+
+// checkRenameable checks that a symbol is not referenced in any way that we cannot track.
+func (r *refactorer) checkRenameable(symbol *symbol) error {
+	if !symbol.hasNamePosition {
+		return fmt.Errorf("%w: %q has no source code info", ErrUnsafeEdit, symbol.fullName)
+	}
+	if symbol.isGroup {
+		return fmt.Errorf("%w: %q is a group", ErrUnsafeEdit, symbol.fullName)
+	}
+	switch symbol.kind {
+	case symbolKindExtension:
+		return fmt.Errorf("%w: %q is an extension, which may be referenced by option names", ErrUnsafeEdit, symbol.fullName)
+	case symbolKindField, symbolKindEnumValue:
+		if _, ok := r.symbolIndex.optionReachableFullNames[symbol.parentFullName]; ok {
+			return fmt.Errorf("%w: %q may be referenced by option values", ErrUnsafeEdit, symbol.fullName)
+		}
+	case symbolKindMessage:
+		// Extensions nested in a message are referenced in option names by the name of the message.
+		for _, other := range r.symbolIndex.fullNameToSymbol {
+			if other.kind == symbolKindExtension && strings.HasPrefix(other.fullName, symbol.fullName+".") {
+				return fmt.Errorf("%w: %q contains extension %q, which may be referenced by option names", ErrUnsafeEdit, symbol.fullName, other.fullName)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *refactorer) planRemoveImport(ctx context.Context, removeImportEdit *removeImportEdit) (map[string][]*replacement, error) {
+	sourceFile, err := r.getSourceFile(ctx, removeImportEdit.path)
+	if err != nil {
+		return nil, err
+	}
+	if sourceFile == nil {
+		return nil, fmt.Errorf("%w: %q cannot be edited", ErrUnsafeEdit, removeImportEdit.path)
+	}
+	for _, decl := range sourceFile.fileNode.Decls {
+		importNode, ok := decl.(*ast.ImportNode)
+		if !ok || importNode.Name.AsString() != removeImportEdit.importPath {
+			continue
+		}
+		return map[string][]*replacement{
+			removeImportEdit.path: {sourceFile.newLineRemoval(importNode)},
+		}, nil
+	}
+	return nil, fmt.Errorf("import %q not found in %q", removeImportEdit.importPath, removeImportEdit.path)
+}
+
+// getSourceFile returns the sourceFile for the path, or nil if the path is not in the ReadBucket.
+func (r *refactorer) getSourceFile(ctx context.Context, path string) (*sourceFile, error) {
+	if sourceFile, ok := r.pathToSourceFile[path]; ok {
+		return sourceFile, nil
+	}
+	sourceFile, err := readSourceFile(ctx, r.readBucket, path)
+	if err != nil {
+		return nil, err
+	}
+	r.pathToSourceFile[path] = sourceFile
+	return sourceFile, nil
+}
+
+// getReferenceComponents returns the sourceFile of the reference, along with the components
+// of the identifier of the reference.
+//
+// The name is what is being edited, and is only used for errors.
+func (r *refactorer) getReferenceComponents(ctx context.Context, reference *reference, name string) (*sourceFile, []*ast.IdentNode, error) {
+	sourceFile, err := r.getSourceFile(ctx, reference.position.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sourceFile == nil {
+		return nil, nil, fmt.Errorf("%w: %q is referenced in %q, which cannot be edited", ErrUnsafeEdit, name, reference.position.path)
+	}
+	components, ok := sourceFile.referenceComponents(reference)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: could not find the reference to %q in %q", ErrUnsafeEdit, name, reference.position.path)
+	}
+	return sourceFile, components, nil
+}
+
+func isReferenceKindForSymbolKind(referenceKind referenceKind, symbolKind symbolKind) bool {
+	switch symbolKind {
+	case symbolKindMessage:
+		return referenceKind == referenceKindType || referenceKind == referenceKindMapValue
+	case symbolKindEnum:
+		return true
+	case symbolKindEnumValue:
+		return referenceKind == referenceKindEnumDefault
+	default:
+		return false
+	}
+}
+
+// siblingFullName returns the full name with the last component replaced by the new name.
+func siblingFullName(fullName string, newName string) string {
+	if parent := fullNameParent(fullName); parent != "" {
+		return parent + "." + newName
+	}
+	return newName
+}
+
+// fullNameParent returns the full name without the last component.
+func fullNameParent(fullName string) string {
+	if index := strings.LastIndexByte(fullName, '.'); index >= 0 {
+		return fullName[:index]
+	}
+	return ""
+}
+
+// lastComponent returns the last component of the full name.
+func lastComponent(fullName string) string {
+	return fullName[strings.LastIndexByte(fullName, '.')+1:]
+}
@@ -0,0 +1,375 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+
+	"github.com/bufbuild/buf/private/buf/bufformat"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// replacement replaces the bytes between two offsets of a file with text.
+//
+// If start and end are equal, the text is inserted at start.
+type replacement struct {
+	start int
+	end   int
+	text  string
+}
+
+func (r *replacement) isInsertion() bool {
+	return r.start == r.end
+}
+
+// overlaps returns true if the replacements cannot both be applied.
+func (r *replacement) overlaps(other *replacement) bool {
+	switch {
+	case r.isInsertion() && other.isInsertion():
+		return false
+	case r.isInsertion():
+		return other.start < r.start && r.start < other.end
+	case other.isInsertion():
+		return r.start < other.start && other.start < r.end
+	default:
+		return r.start < other.end && other.start < r.end
+	}
+}
+
+type sourceFile struct {
+	path         string
+	externalPath string
+	data         []byte
+	fileNode     *ast.FileNode
+	packageNode  *ast.PackageNode
+	// positionToIdentValueNode contains the outermost IdentValueNode at each position.
+	positionToIdentValueNode map[position]ast.IdentValueNode
+	positionToMapTypeNode    map[position]*ast.MapTypeNode
+	positionToOptionNode     map[position]*ast.OptionNode
+	// nameToDeclNode contains the declaration of each name of a message, enum, enum
+	// value, field, oneof, service, or method.
+	nameToDeclNode map[*ast.IdentNode]ast.Node
+	replacements   []*replacement
+}
+
+func readSourceFile(ctx context.Context, readBucket storage.ReadBucket, path string) (_ *sourceFile, retErr error) {
+	readObjectCloser, err := readBucket.Get(ctx, path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, readObjectCloser.Close())
+	}()
+	data, err := io.ReadAll(readObjectCloser)
+	if err != nil {
+		return nil, err
+	}
+	fileNode, err := parser.Parse(readObjectCloser.ExternalPath(), bytes.NewReader(data), reporter.NewHandler(nil))
+	if err != nil {
+		return nil, err
+	}
+	sourceFile := &sourceFile{
+		path:                     path,
+		externalPath:             readObjectCloser.ExternalPath(),
+		data:                     data,
+		fileNode:                 fileNode,
+		positionToIdentValueNode: make(map[position]ast.IdentValueNode),
+		positionToMapTypeNode:    make(map[position]*ast.MapTypeNode),
+		positionToOptionNode:     make(map[position]*ast.OptionNode),
+		nameToDeclNode:           make(map[*ast.IdentNode]ast.Node),
+	}
+	if err := ast.Walk(
+		fileNode,
+		&ast.SimpleVisitor{},
+		ast.WithBefore(
+			func(node ast.Node) error {
+				position := sourceFile.position(node)
+				switch node := node.(type) {
+				case *ast.CompoundIdentNode:
+					sourceFile.positionToIdentValueNode[position] = node
+				case *ast.IdentNode:
+					// The first component of a CompoundIdentNode has the same position, and
+					// is visited after the CompoundIdentNode.
+					if _, ok := sourceFile.positionToIdentValueNode[position]; !ok {
+						sourceFile.positionToIdentValueNode[position] = node
+					}
+				case *ast.MapTypeNode:
+					sourceFile.positionToMapTypeNode[position] = node
+				case *ast.OptionNode:
+					sourceFile.positionToOptionNode[position] = node
+				case *ast.PackageNode:
+					sourceFile.packageNode = node
+				case *ast.MessageNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.EnumNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.EnumValueNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.FieldNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.MapFieldNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.OneofNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.ServiceNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				case *ast.RPCNode:
+					sourceFile.nameToDeclNode[node.Name] = node
+				}
+				return nil
+			},
+		),
+	); err != nil {
+		return nil, err
+	}
+	return sourceFile, nil
+}
+
+func (s *sourceFile) position(node ast.Node) position {
+	start := s.fileNode.NodeInfo(node).Start()
+	return position{
+		path:   s.path,
+		line:   start.Line,
+		column: start.Col,
+	}
+}
+
+// referenceComponents returns the components of the identifier of the reference.
+func (s *sourceFile) referenceComponents(reference *reference) ([]*ast.IdentNode, bool) {
+	var identValueNode ast.IdentValueNode
+	switch reference.kind {
+	case referenceKindType:
+		identValueNode = s.positionToIdentValueNode[reference.position]
+	case referenceKindMapValue:
+		if mapTypeNode, ok := s.positionToMapTypeNode[reference.position]; ok {
+			identValueNode = mapTypeNode.ValueType
+		}
+	case referenceKindEnumDefault:
+		if optionNode, ok := s.positionToOptionNode[reference.position]; ok {
+			identValueNode, _ = optionNode.Val.(ast.IdentValueNode)
+		}
+	}
+	switch identValueNode := identValueNode.(type) {
+	case *ast.IdentNode:
+		return []*ast.IdentNode{identValueNode}, true
+	case *ast.CompoundIdentNode:
+		return identValueNode.Components, true
+	default:
+		return nil, false
+	}
+}
+
+// newReplacement returns a new replacement of the text from the start of the start
+// node to the end of the end node.
+func (s *sourceFile) newReplacement(startNode ast.Node, endNode ast.Node, text string) *replacement {
+	return &replacement{
+		start: s.fileNode.NodeInfo(startNode).Start().Offset,
+		end:   s.endOffset(endNode),
+		text:  text,
+	}
+}
+
+// newInsertionAfter returns a new replacement that inserts text directly after the node.
+func (s *sourceFile) newInsertionAfter(node ast.Node, text string) *replacement {
+	offset := s.endOffset(node)
+	return &replacement{
+		start: offset,
+		end:   offset,
+		text:  text,
+	}
+}
+
+// newLineInsertionAfter returns a new replacement that inserts text on a new line after
+// the line that the node ends on, with the same indentation as the node.
+func (s *sourceFile) newLineInsertionAfter(node ast.Node, text string) *replacement {
+	offset := s.endOffset(node)
+	if index := bytes.IndexByte(s.data[offset:], '\n'); index >= 0 {
+		offset += index
+	} else {
+		offset = len(s.data)
+	}
+	return &replacement{
+		start: offset,
+		end:   offset,
+		text:  "\n" + s.lineIndent(node) + text,
+	}
+}
+
+// newLineRemoval returns a new replacement that removes the node and its comments,
+// along with its lines if nothing else is on them.
+func (s *sourceFile) newLineRemoval(node ast.Node) *replacement {
+	nodeInfo := s.fileNode.NodeInfo(node)
+	start := nodeInfo.Start().Offset
+	if leadingComments := nodeInfo.LeadingComments(); leadingComments.Len() > 0 {
+		start = leadingComments.Index(0).Start().Offset
+	}
+	end := s.endOffset(node)
+	if trailingComments := nodeInfo.TrailingComments(); trailingComments.Len() > 0 {
+		// The end of a Comment is its last character.
+		end = trailingComments.Index(trailingComments.Len()-1).End().Offset + 1
+	}
+	lineStart := start
+	for lineStart > 0 && isHorizontalSpace(s.data[lineStart-1]) {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(s.data) && isHorizontalSpace(s.data[lineEnd]) {
+		lineEnd++
+	}
+	if (lineStart == 0 || s.data[lineStart-1] == '\n') && (lineEnd == len(s.data) || s.data[lineEnd] == '\n') {
+		start = lineStart
+		end = min(lineEnd+1, len(s.data))
+	}
+	return &replacement{
+		start: start,
+		end:   end,
+	}
+}
+
+// endOffset returns the offset after the last character of the node.
+func (s *sourceFile) endOffset(node ast.Node) int {
+	// The offset of the end of a NodeInfo is the offset of its last character, unlike
+	// its column.
+	return s.fileNode.NodeInfo(node).End().Offset + 1
+}
+
+// nodeText returns the text of the node.
+func (s *sourceFile) nodeText(node ast.Node) string {
+	return s.nodeTextBetween(node, node)
+}
+
+// nodeTextBetween returns the text from the start of the start node to the end of
+// the end node.
+func (s *sourceFile) nodeTextBetween(startNode ast.Node, endNode ast.Node) string {
+	return string(s.data[s.fileNode.NodeInfo(startNode).Start().Offset:s.endOffset(endNode)])
+}
+
+// lineIndent returns the whitespace at the start of the line that the node starts on.
+func (s *sourceFile) lineIndent(node ast.Node) string {
+	offset := s.fileNode.NodeInfo(node).Start().Offset
+	lineStart := bytes.LastIndexByte(s.data[:offset], '\n') + 1
+	lineEnd := lineStart
+	for lineEnd < offset && isHorizontalSpace(s.data[lineEnd]) {
+		lineEnd++
+	}
+	return string(s.data[lineStart:lineEnd])
+}
+
+func (s *sourceFile) checkReplacement(newReplacement *replacement) error {
+	for _, existing := range s.replacements {
+		if existing.start == newReplacement.start && existing.end == newReplacement.end {
+			if !existing.isInsertion() && existing.text != newReplacement.text {
+				return fmt.Errorf("%w: conflicts with a previous edit", ErrUnsafeEdit)
+			}
+			continue
+		}
+		if existing.overlaps(newReplacement) {
+			return fmt.Errorf("%w: conflicts with a previous edit", ErrUnsafeEdit)
+		}
+	}
+	return nil
+}
+
+func (s *sourceFile) addReplacement(newReplacement *replacement) {
+	if slices.ContainsFunc(
+		s.replacements,
+		func(existing *replacement) bool {
+			return *existing == *newReplacement
+		},
+	) {
+		return
+	}
+	s.replacements = append(s.replacements, newReplacement)
+}
+
+// sortedReplacements returns the replacements sorted by offset.
+//
+// Insertions at the same offset are kept in the order they were added.
+func (s *sourceFile) sortedReplacements() []*replacement {
+	replacements := slices.Clone(s.replacements)
+	slices.SortStableFunc(
+		replacements,
+		func(one *replacement, two *replacement) int {
+			if one.start != two.start {
+				return one.start - two.start
+			}
+			return one.end - two.end
+		},
+	)
+	return replacements
+}
+
+// edited returns the data with all replacements applied.
+func (s *sourceFile) edited() []byte {
+	var buffer bytes.Buffer
+	offset := 0
+	for _, replacement := range s.sortedReplacements() {
+		buffer.Write(s.data[offset:replacement.start])
+		buffer.WriteString(replacement.text)
+		offset = replacement.end
+	}
+	buffer.Write(s.data[offset:])
+	return buffer.Bytes()
+}
+
+// format writes the formatted edited file to writer.
+func (s *sourceFile) format(writer io.Writer) error {
+	fileNode, err := parser.Parse(s.externalPath, bytes.NewReader(s.edited()), reporter.NewHandler(nil))
+	if err != nil {
+		return err
+	}
+	return bufformat.FormatFileNode(writer, fileNode)
+}
+
+// textEdits returns the replacements as TextEdits.
+func (s *sourceFile) textEdits() []TextEdit {
+	replacements := s.sortedReplacements()
+	textEdits := make([]TextEdit, len(replacements))
+	for i, replacement := range replacements {
+		startLine, startColumn := s.lineAndColumn(replacement.start)
+		endLine, endColumn := s.lineAndColumn(replacement.end)
+		textEdits[i] = TextEdit{
+			StartLine:   startLine,
+			StartColumn: startColumn,
+			EndLine:     endLine,
+			EndColumn:   endColumn,
+			NewText:     replacement.text,
+		}
+	}
+	return textEdits
+}
+
+// lineAndColumn returns the 1-indexed line and column of the offset.
+func (s *sourceFile) lineAndColumn(offset int) (int, int) {
+	line := bytes.Count(s.data[:offset], []byte{'\n'}) + 1
+	column := offset - (bytes.LastIndexByte(s.data[:offset], '\n') + 1) + 1
+	return line, column
+}
+
+func isHorizontalSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r'
+}
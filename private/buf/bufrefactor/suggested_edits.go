@@ -0,0 +1,54 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+)
+
+func (r *refactorer) SuggestedEdits(
+	ctx context.Context,
+	fileAnnotation bufanalysis.FileAnnotation,
+) ([]Edit, error) {
+	var edits []Edit
+	for _, suggestedEdit := range fileAnnotation.SuggestedEdits() {
+		var err error
+		// Make sure that the Edit can be applied, without applying it.
+		switch t := suggestedEdit.(type) {
+		case bufanalysis.RenameSuggestedEdit:
+			edit := newRenameEdit(t.FullName(), t.NewFullName())
+			if _, err = r.planRename(ctx, edit); err == nil {
+				edits = append(edits, edit)
+			}
+		case bufanalysis.RemoveImportSuggestedEdit:
+			edit := newRemoveImportEdit(t.Path(), t.ImportPath())
+			if _, err = r.planRemoveImport(ctx, edit); err == nil {
+				edits = append(edits, edit)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, ErrUnsafeEdit) {
+				// All the suggested edits fix the same annotation, so if one of
+				// them cannot be applied, none of them are suggested.
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+	return edits, nil
+}
@@ -0,0 +1,335 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufrefactor
+
+import (
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufprotosource"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type symbolKind int
+
+const (
+	symbolKindMessage symbolKind = iota + 1
+	symbolKindEnum
+	symbolKindEnumValue
+	symbolKindField
+	symbolKindExtension
+	symbolKindOneof
+	symbolKindService
+	symbolKindMethod
+)
+
+type referenceKind int
+
+const (
+	// referenceKindType is a reference to a message or enum by a type name, such
+	// as a field type, method input or output type, or extendee.
+	referenceKindType referenceKind = iota + 1
+	// referenceKindMapValue is a reference to a message or enum by the value type
+	// of a map field.
+	referenceKindMapValue
+	// referenceKindEnumDefault is a reference to an enum value by the default
+	// value of a field.
+	referenceKindEnumDefault
+)
+
+// position is a 1-indexed position within a file.
+type position struct {
+	path   string
+	line   int
+	column int
+}
+
+func newPositionForLocation(location bufprotosource.Location) (position, bool) {
+	if location == nil {
+		return position{}, false
+	}
+	return position{
+		path:   location.FilePath(),
+		line:   location.StartLine(),
+		column: location.StartColumn(),
+	}, true
+}
+
+type symbol struct {
+	kind     symbolKind
+	fullName string
+	name     string
+	// scopedFullName is the full name of the symbol within the Protobuf scoping rules.
+	//
+	// This is the same as fullName, except for enum values, which are scoped within the
+	// parent of their enum.
+	scopedFullName string
+	path           string
+	pkg            string
+	namePosition   position
+	// hasNamePosition is false if there was no source code info for the name.
+	hasNamePosition bool
+	// isGroup is true if the symbol is a group field or the message of a group field.
+	isGroup bool
+	// parentFullName is the full name of the message or enum that contains the symbol,
+	// for fields, oneofs, enum values, and nested types, or the service for methods.
+	parentFullName string
+	// enum-specific
+	allowAlias bool
+}
+
+type reference struct {
+	kind     referenceKind
+	fullName string
+	position position
+}
+
+type symbolIndex struct {
+	fullNameToSymbol map[string]*symbol
+	scopedFullNames  map[string]struct{}
+	references       []*reference
+	pathToPackage    map[string]string
+	packageToPaths   map[string][]string
+	// optionReachableFullNames are the full names of all the messages and enums that are
+	// reachable from the type of an extension. The fields and enum values of these types
+	// may be referenced by name within option values, which we cannot safely edit.
+	optionReachableFullNames map[string]struct{}
+}
+
+func newSymbolIndex(files []bufprotosource.File) (*symbolIndex, error) {
+	symbolIndex := &symbolIndex{
+		fullNameToSymbol:         make(map[string]*symbol),
+		scopedFullNames:          make(map[string]struct{}),
+		pathToPackage:            make(map[string]string),
+		packageToPaths:           make(map[string][]string),
+		optionReachableFullNames: make(map[string]struct{}),
+	}
+	fullNameToMessage := make(map[string]bufprotosource.Message)
+	groupTypeNames := make(map[string]struct{})
+	var extensions []bufprotosource.Field
+	for _, file := range files {
+		symbolIndex.pathToPackage[file.Path()] = file.Package()
+		symbolIndex.packageToPaths[file.Package()] = append(symbolIndex.packageToPaths[file.Package()], file.Path())
+		if err := bufprotosource.ForEachMessage(
+			func(message bufprotosource.Message) error {
+				fullNameToMessage[message.FullName()] = message
+				if message.IsMapEntry() {
+					// Map entries are synthesized, and cannot be referenced or renamed.
+					return nil
+				}
+				symbolIndex.addSymbol(message, symbolKindMessage, parentFullName(message.Parent()))
+				for _, field := range message.Fields() {
+					fieldSymbol := symbolIndex.addSymbol(field, symbolKindField, message.FullName())
+					if field.Type() == descriptorpb.FieldDescriptorProto_TYPE_GROUP {
+						fieldSymbol.isGroup = true
+						groupTypeNames[field.TypeName()] = struct{}{}
+					}
+				}
+				for _, oneof := range message.Oneofs() {
+					symbolIndex.addSymbol(oneof, symbolKindOneof, message.FullName())
+				}
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+		if err := bufprotosource.ForEachEnum(
+			func(enum bufprotosource.Enum) error {
+				enumSymbol := symbolIndex.addSymbol(enum, symbolKindEnum, parentFullName(enum.Parent()))
+				enumSymbol.allowAlias = enum.AllowAlias()
+				for _, enumValue := range enum.Values() {
+					enumValueSymbol := symbolIndex.addSymbol(enumValue, symbolKindEnumValue, enum.FullName())
+					// Enum values are scoped within the parent of their enum.
+					delete(symbolIndex.scopedFullNames, enumValueSymbol.scopedFullName)
+					enumValueSymbol.scopedFullName = joinFullName(parentFullName(enum.Parent()), file.Package(), enumValue.Name())
+					symbolIndex.scopedFullNames[enumValueSymbol.scopedFullName] = struct{}{}
+				}
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+		if err := bufprotosource.ForEachExtension(
+			func(extension bufprotosource.Field) error {
+				parent := ""
+				if extension.ParentMessage() != nil {
+					parent = extension.ParentMessage().FullName()
+				}
+				extensionSymbol := symbolIndex.addSymbol(extension, symbolKindExtension, parent)
+				if extension.Type() == descriptorpb.FieldDescriptorProto_TYPE_GROUP {
+					extensionSymbol.isGroup = true
+					groupTypeNames[extension.TypeName()] = struct{}{}
+				}
+				extensions = append(extensions, extension)
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+		for _, service := range file.Services() {
+			symbolIndex.addSymbol(service, symbolKindService, "")
+			for _, method := range service.Methods() {
+				symbolIndex.addSymbol(method, symbolKindMethod, service.FullName())
+				if position, ok := newPositionForLocation(method.InputTypeLocation()); ok {
+					symbolIndex.addReference(referenceKindType, method.InputTypeName(), position)
+				}
+				if position, ok := newPositionForLocation(method.OutputTypeLocation()); ok {
+					symbolIndex.addReference(referenceKindType, method.OutputTypeName(), position)
+				}
+			}
+		}
+	}
+	// Now that we have all messages, add the references for fields.
+	for _, message := range fullNameToMessage {
+		fields := message.Fields()
+		if message.IsMapEntry() {
+			// The references of map entry fields are added by the map field.
+			fields = nil
+		}
+		for _, field := range append(fields, message.Extensions()...) {
+			symbolIndex.addFieldReferences(field, fullNameToMessage)
+		}
+	}
+	for _, file := range files {
+		for _, extension := range file.Extensions() {
+			symbolIndex.addFieldReferences(extension, fullNameToMessage)
+		}
+	}
+	// The name of the message of a group is derived from the name of the field,
+	// so neither can be renamed on its own.
+	for groupTypeName := range groupTypeNames {
+		if message, ok := symbolIndex.fullNameToSymbol[groupTypeName]; ok {
+			message.isGroup = true
+		}
+	}
+	// Compute all types reachable from extensions.
+	for _, extension := range extensions {
+		symbolIndex.addOptionReachable(extension.TypeName(), fullNameToMessage)
+	}
+	return symbolIndex, nil
+}
+
+func (s *symbolIndex) addSymbol(namedDescriptor bufprotosource.NamedDescriptor, kind symbolKind, parentFullName string) *symbol {
+	symbol := &symbol{
+		kind:           kind,
+		fullName:       namedDescriptor.FullName(),
+		name:           namedDescriptor.Name(),
+		scopedFullName: namedDescriptor.FullName(),
+		path:           namedDescriptor.File().Path(),
+		pkg:            namedDescriptor.File().Package(),
+		parentFullName: parentFullName,
+	}
+	if position, ok := newPositionForLocation(namedDescriptor.NameLocation()); ok {
+		symbol.namePosition = position
+		symbol.hasNamePosition = true
+	}
+	s.fullNameToSymbol[symbol.fullName] = symbol
+	s.scopedFullNames[symbol.scopedFullName] = struct{}{}
+	return symbol
+}
+
+func (s *symbolIndex) addReference(kind referenceKind, fullName string, position position) {
+	s.references = append(
+		s.references,
+		&reference{
+			kind:     kind,
+			fullName: fullName,
+			position: position,
+		},
+	)
+}
+
+func (s *symbolIndex) addFieldReferences(field bufprotosource.Field, fullNameToMessage map[string]bufprotosource.Message) {
+	if field.Extendee() != "" {
+		if position, ok := newPositionForLocation(field.ExtendeeLocation()); ok {
+			s.addReference(referenceKindType, field.Extendee(), position)
+		}
+	}
+	typeName := field.TypeName()
+	if typeName == "" {
+		return
+	}
+	position, ok := newPositionForLocation(field.TypeNameLocation())
+	if !ok {
+		return
+	}
+	if mapEntry, ok := fullNameToMessage[typeName]; ok && mapEntry.IsMapEntry() {
+		for _, mapEntryField := range mapEntry.Fields() {
+			// The value field of a map entry is always field 2.
+			if mapEntryField.Number() == 2 && mapEntryField.TypeName() != "" {
+				s.addReference(referenceKindMapValue, mapEntryField.TypeName(), position)
+			}
+		}
+		return
+	}
+	s.addReference(referenceKindType, typeName, position)
+	if field.Type() == descriptorpb.FieldDescriptorProto_TYPE_ENUM && field.Default() != "" {
+		if position, ok := newPositionForLocation(field.DefaultLocation()); ok {
+			s.addReference(referenceKindEnumDefault, typeName+"."+field.Default(), position)
+		}
+	}
+}
+
+func (s *symbolIndex) addOptionReachable(typeName string, fullNameToMessage map[string]bufprotosource.Message) {
+	if typeName == "" {
+		return
+	}
+	if _, ok := s.optionReachableFullNames[typeName]; ok {
+		return
+	}
+	s.optionReachableFullNames[typeName] = struct{}{}
+	if message, ok := fullNameToMessage[typeName]; ok {
+		for _, field := range message.Fields() {
+			s.addOptionReachable(field.TypeName(), fullNameToMessage)
+		}
+	}
+}
+
+func parentFullName(message bufprotosource.Message) string {
+	if message == nil {
+		return ""
+	}
+	return message.FullName()
+}
+
+// joinFullName joins the name to the parent, or the package if there is no parent.
+func joinFullName(parent string, pkg string, name string) string {
+	if parent == "" {
+		parent = pkg
+	}
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// referencedComponentIndex returns the index of the component of a reference that
+// refers to the symbol, given the number of components in the reference.
+//
+// Returns false if the reference does not refer to the symbol or one of its children,
+// or if the reference does not spell out the name of the symbol.
+func referencedComponentIndex(referenceFullName string, symbolFullName string, numComponents int) (int, bool) {
+	if referenceFullName != symbolFullName && !strings.HasPrefix(referenceFullName, symbolFullName+".") {
+		return 0, false
+	}
+	numSuffixComponents := strings.Count(referenceFullName[len(symbolFullName):], ".")
+	index := numComponents - 1 - numSuffixComponents
+	if index < 0 {
+		return 0, false
+	}
+	return index, true
+}
@@ -74,6 +74,13 @@ type Workspace interface {
 	// in the workspace. This should result in items such as the linter or breaking change
 	// detector ignoring these configs anyways.
 	GetBreakingConfigForOpaqueID(opaqueID string) bufconfig.BreakingConfig
+	// GetFormatConfigForOpaqueID gets the FormatConfig for the OpaqueID, if the OpaqueID
+	// represents a Module within the workspace.
+	//
+	// This will be the default value for Modules that didn't have an associated config,
+	// such as Modules read from buf.lock files. These Modules will not be target Modules
+	// in the workspace, and are not formatted.
+	GetFormatConfigForOpaqueID(opaqueID string) bufconfig.FormatConfig
 	// PluginConfigs gets the configured PluginConfigs of the Workspace.
 	//
 	// These come from the buf.lock file. Only v2 supports plugins.
@@ -121,6 +128,7 @@ type workspace struct {
 
 	opaqueIDToLintConfig     map[string]bufconfig.LintConfig
 	opaqueIDToBreakingConfig map[string]bufconfig.BreakingConfig
+	opaqueIDToFormatConfig   map[string]bufconfig.FormatConfig
 	pluginConfigs            []bufconfig.PluginConfig
 	remotePluginKeys         []bufplugin.PluginKey
 	policyConfigs            []bufconfig.PolicyConfig
@@ -136,6 +144,7 @@ func newWorkspace(
 	moduleSet bufmodule.ModuleSet,
 	opaqueIDToLintConfig map[string]bufconfig.LintConfig,
 	opaqueIDToBreakingConfig map[string]bufconfig.BreakingConfig,
+	opaqueIDToFormatConfig map[string]bufconfig.FormatConfig,
 	pluginConfigs []bufconfig.PluginConfig,
 	remotePluginKeys []bufplugin.PluginKey,
 	policyConfigs []bufconfig.PolicyConfig,
@@ -146,6 +155,7 @@ func newWorkspace(
 		ModuleSet:                moduleSet,
 		opaqueIDToLintConfig:     opaqueIDToLintConfig,
 		opaqueIDToBreakingConfig: opaqueIDToBreakingConfig,
+		opaqueIDToFormatConfig:   opaqueIDToFormatConfig,
 		pluginConfigs:            pluginConfigs,
 		remotePluginKeys:         remotePluginKeys,
 		policyConfigs:            policyConfigs,
@@ -162,6 +172,10 @@ func (w *workspace) GetBreakingConfigForOpaqueID(opaqueID string) bufconfig.Brea
 	return w.opaqueIDToBreakingConfig[opaqueID]
 }
 
+func (w *workspace) GetFormatConfigForOpaqueID(opaqueID string) bufconfig.FormatConfig {
+	return w.opaqueIDToFormatConfig[opaqueID]
+}
+
 func (w *workspace) PluginConfigs() []bufconfig.PluginConfig {
 	return slices.Clone(w.pluginConfigs)
 }
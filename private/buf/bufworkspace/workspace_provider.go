@@ -229,21 +229,25 @@ func (w *workspaceProvider) GetWorkspaceForModuleKey(
 
 	opaqueIDToLintConfig := make(map[string]bufconfig.LintConfig)
 	opaqueIDToBreakingConfig := make(map[string]bufconfig.BreakingConfig)
+	opaqueIDToFormatConfig := make(map[string]bufconfig.FormatConfig)
 	for _, module := range moduleSet.Modules() {
 		if bufparse.FullNameEqual(module.FullName(), moduleKey.FullName()) {
 			// Set the lint and breaking config for the single targeted Module.
 			opaqueIDToLintConfig[module.OpaqueID()] = targetModuleConfig.LintConfig()
 			opaqueIDToBreakingConfig[module.OpaqueID()] = targetModuleConfig.BreakingConfig()
+			opaqueIDToFormatConfig[module.OpaqueID()] = targetModuleConfig.FormatConfig()
 		} else {
 			// For all non-targets, set the default lint and breaking config.
 			opaqueIDToLintConfig[module.OpaqueID()] = bufconfig.DefaultLintConfigV1
 			opaqueIDToBreakingConfig[module.OpaqueID()] = bufconfig.DefaultBreakingConfigV1
+			opaqueIDToFormatConfig[module.OpaqueID()] = bufconfig.DefaultFormatConfig
 		}
 	}
 	return newWorkspace(
 		moduleSet,
 		opaqueIDToLintConfig,
 		opaqueIDToBreakingConfig,
+		opaqueIDToFormatConfig,
 		pluginConfigs,
 		remotePluginKeys,
 		policyConfigs,
@@ -531,6 +535,7 @@ func (w *workspaceProvider) getWorkspaceForBucketModuleSet(
 ) (*workspace, error) {
 	opaqueIDToLintConfig := make(map[string]bufconfig.LintConfig)
 	opaqueIDToBreakingConfig := make(map[string]bufconfig.BreakingConfig)
+	opaqueIDToFormatConfig := make(map[string]bufconfig.FormatConfig)
 	for _, module := range moduleSet.Modules() {
 		if bucketID := module.BucketID(); bucketID != "" {
 			moduleConfig, ok := bucketIDToModuleConfig[bucketID]
@@ -540,15 +545,18 @@ func (w *workspaceProvider) getWorkspaceForBucketModuleSet(
 			}
 			opaqueIDToLintConfig[module.OpaqueID()] = moduleConfig.LintConfig()
 			opaqueIDToBreakingConfig[module.OpaqueID()] = moduleConfig.BreakingConfig()
+			opaqueIDToFormatConfig[module.OpaqueID()] = moduleConfig.FormatConfig()
 		} else {
 			opaqueIDToLintConfig[module.OpaqueID()] = bufconfig.DefaultLintConfigV1
 			opaqueIDToBreakingConfig[module.OpaqueID()] = bufconfig.DefaultBreakingConfigV1
+			opaqueIDToFormatConfig[module.OpaqueID()] = bufconfig.DefaultFormatConfig
 		}
 	}
 	return newWorkspace(
 		moduleSet,
 		opaqueIDToLintConfig,
 		opaqueIDToBreakingConfig,
+		opaqueIDToFormatConfig,
 		pluginConfigs,
 		remotePluginKeys,
 		policyConfigs,
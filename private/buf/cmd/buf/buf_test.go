@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"slices"
@@ -34,6 +35,7 @@ import (
 	"buf.build/go/standard/xslices"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/bufctl"
+	"github.com/bufbuild/buf/private/buf/bufmock"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/internal/internaltesting"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
@@ -214,6 +216,22 @@ func TestSuccess6(t *testing.T) {
 	testRunStdout(t, nil, 0, ``, "lint", filepath.Join("testdata", "success", "buf", "buf.proto"))
 }
 
+func TestSuccessSARIF(t *testing.T) {
+	t.Parallel()
+	// A SARIF log with all the configured rules is printed even if there are no violations.
+	testSARIFNoResults(t, "FIELD_LOWER_SNAKE_CASE", "lint", filepath.Join("testdata", "success"), "--error-format", "sarif")
+	testSARIFNoResults(
+		t,
+		"FIELD_NO_DELETE",
+		"breaking",
+		filepath.Join("testdata", "success"),
+		"--against",
+		filepath.Join("testdata", "success"),
+		"--error-format",
+		"sarif",
+	)
+}
+
 func TestSuccessDir(t *testing.T) {
 	t.Parallel()
 	testRunStdout(t, nil, 0, ``, "build", filepath.Join("testdata", "successnobufyaml"))
@@ -2987,6 +3005,385 @@ func TestLintWithPaths(t *testing.T) {
 	)
 }
 
+func TestLintBaseline(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	baselinePath := filepath.Join(tempDirPath, "baseline.json")
+	protoFilePath := filepath.Join(tempDirPath, "a", "v1", "a.proto")
+	require.NoError(t, os.MkdirAll(filepath.Dir(protoFilePath), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDirPath, "buf.yaml"), []byte("version: v2\n"), 0600))
+	require.NoError(
+		t,
+		os.WriteFile(
+			protoFilePath,
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Foo {
+  string Value = 1;
+}
+`),
+			0600,
+		),
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"lint",
+		tempDirPath,
+		"--write-baseline",
+		baselinePath,
+	)
+	data, err := os.ReadFile(baselinePath)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+  "version": "v1",
+  "entries": [
+    {
+      "rule": "FIELD_LOWER_SNAKE_CASE",
+      "path": "a/v1/a.proto",
+      "symbol": "a.v1.Foo.Value"
+    }
+  ]
+}`,
+		string(data),
+	)
+	// All violations are in the baseline.
+	testRunStdoutStderrNoWarn(
+		t,
+		nil,
+		0,
+		"",
+		"",
+		"lint",
+		tempDirPath,
+		"--baseline",
+		baselinePath,
+	)
+	// Moving the violation to another line does not change the baseline match, but new
+	// violations are reported.
+	require.NoError(
+		t,
+		os.WriteFile(
+			protoFilePath,
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Bar {
+  string OtherValue = 1;
+}
+
+message Foo {
+  string Value = 1;
+}
+`),
+			0600,
+		),
+	)
+	testRunStdoutStderrNoWarn(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		protoFilePath+`:6:10:Field name "OtherValue" should be lower_snake_case, such as "other_value".`,
+		"",
+		"lint",
+		tempDirPath,
+		"--baseline",
+		baselinePath,
+	)
+	// Fixed violations result in a warning for the stale baseline entry.
+	require.NoError(
+		t,
+		os.WriteFile(
+			protoFilePath,
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Foo {
+  string value = 1;
+}
+`),
+			0600,
+		),
+	)
+	appcmdtesting.Run(
+		t,
+		NewRootCommand,
+		appcmdtesting.WithEnv(internaltesting.NewEnvFunc(t)),
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout(""),
+		appcmdtesting.WithExpectedStderrPartials(
+			"no longer matches any violation: FIELD_LOWER_SNAKE_CASE in a/v1/a.proto at a.v1.Foo.Value.",
+		),
+		appcmdtesting.WithArgs(
+			"lint",
+			tempDirPath,
+			"--baseline",
+			baselinePath,
+		),
+	)
+	testRunStderrContainsNoWarn(
+		t,
+		nil,
+		1,
+		[]string{"Cannot set both --baseline and --write-baseline"},
+		"lint",
+		tempDirPath,
+		"--baseline",
+		baselinePath,
+		"--write-baseline",
+		baselinePath,
+	)
+}
+
+func TestLintFix(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	aProtoFilePath := filepath.Join(tempDirPath, "a", "v1", "a.proto")
+	bProtoFilePath := filepath.Join(tempDirPath, "a", "v1", "b.proto")
+	require.NoError(t, os.MkdirAll(filepath.Dir(aProtoFilePath), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDirPath, "buf.yaml"), []byte("version: v2\n"), 0600))
+	aProtoFileData := `syntax = "proto3";
+
+package a.v1;
+
+import "a/v1/b.proto";
+import "google/protobuf/empty.proto";
+
+service Foo {
+  rpc Get(GetFooRequest) returns (Thing) {}
+}
+`
+	bProtoFileData := `syntax = "proto3";
+
+package a.v1;
+
+message GetFooRequest {
+  string fooName = 1;
+}
+
+message Thing {
+  GetFooRequest request = 1;
+  map<string, GetFooRequest> requests = 2;
+}
+`
+	require.NoError(t, os.WriteFile(aProtoFilePath, []byte(aProtoFileData), 0600))
+	require.NoError(t, os.WriteFile(bProtoFilePath, []byte(bProtoFileData), 0600))
+	// With --diff, the fixes are printed, and no files are written.
+	stdout := bytes.NewBuffer(nil)
+	appcmdtesting.Run(
+		t,
+		NewRootCommand,
+		appcmdtesting.WithEnv(internaltesting.NewEnvFunc(t)),
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithStdout(stdout),
+		appcmdtesting.WithArgs(
+			"lint",
+			tempDirPath,
+			"--fix",
+			"--diff",
+		),
+	)
+	assert.Contains(t, stdout.String(), "\n-import \"google/protobuf/empty.proto\";\n")
+	assert.Contains(t, stdout.String(), "\n+service FooService {\n")
+	assert.Contains(t, stdout.String(), "\n+  string foo_name = 1;\n")
+	data, err := os.ReadFile(aProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, aProtoFileData, string(data))
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"lint",
+		tempDirPath,
+		"--fix",
+	)
+	data, err = os.ReadFile(aProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+package a.v1;
+
+import "a/v1/b.proto";
+
+service FooService {
+  rpc Get(GetRequest) returns (GetResponse) {}
+}
+`,
+		string(data),
+	)
+	data, err = os.ReadFile(bProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+package a.v1;
+
+message GetRequest {
+  string foo_name = 1;
+}
+
+message GetResponse {
+  GetRequest request = 1;
+  map<string, GetRequest> requests = 2;
+}
+`,
+		string(data),
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"lint",
+		tempDirPath,
+	)
+	testRunStderrContainsNoWarn(
+		t,
+		nil,
+		1,
+		[]string{"Cannot set --diff without --fix"},
+		"lint",
+		tempDirPath,
+		"--diff",
+	)
+}
+
+func TestBetaRename(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	aProtoFilePath := filepath.Join(tempDirPath, "a", "a", "v1", "a.proto")
+	bProtoFilePath := filepath.Join(tempDirPath, "b", "b", "v1", "b.proto")
+	require.NoError(t, os.MkdirAll(filepath.Dir(aProtoFilePath), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Dir(bProtoFilePath), 0700))
+	require.NoError(
+		t,
+		os.WriteFile(
+			filepath.Join(tempDirPath, "buf.yaml"),
+			[]byte("version: v2\nmodules:\n  - path: a\n  - path: b\n"),
+			0600,
+		),
+	)
+	aProtoFileData := `syntax = "proto3";
+
+package a.v1;
+
+message Thing {
+  string thingName = 1;
+}
+`
+	bProtoFileData := `syntax = "proto3";
+
+package b.v1;
+
+import "a/v1/a.proto";
+
+message Holder {
+  a.v1.Thing thing = 1;
+}
+`
+	require.NoError(t, os.WriteFile(aProtoFilePath, []byte(aProtoFileData), 0600))
+	require.NoError(t, os.WriteFile(bProtoFilePath, []byte(bProtoFileData), 0600))
+	// With --diff, the rename is printed, and no files are written.
+	stdout := bytes.NewBuffer(nil)
+	appcmdtesting.Run(
+		t,
+		NewRootCommand,
+		appcmdtesting.WithEnv(internaltesting.NewEnvFunc(t)),
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithStdout(stdout),
+		appcmdtesting.WithArgs(
+			"beta",
+			"rename",
+			"a.v1.Thing",
+			"a.v1.Widget",
+			tempDirPath,
+			"--diff",
+		),
+	)
+	assert.Contains(t, stdout.String(), "\n+message Widget {\n")
+	assert.Contains(t, stdout.String(), "\n+  a.v1.Widget thing = 1;\n")
+	data, err := os.ReadFile(bProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, bProtoFileData, string(data))
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"beta",
+		"rename",
+		"a.v1.Thing",
+		"a.v1.Widget",
+		tempDirPath,
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"beta",
+		"rename",
+		"a.v1.Widget.thingName",
+		"a.v1.Widget.thing_name",
+		tempDirPath,
+		"--reserve",
+	)
+	data, err = os.ReadFile(aProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+package a.v1;
+
+message Widget {
+  string thing_name = 1;
+  reserved "thingName";
+}
+`,
+		string(data),
+	)
+	data, err = os.ReadFile(bProtoFilePath)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+package b.v1;
+
+import "a/v1/a.proto";
+
+message Holder {
+  a.v1.Widget thing = 1;
+}
+`,
+		string(data),
+	)
+	testRunStderrContainsNoWarn(
+		t,
+		nil,
+		1,
+		[]string{"unsafe edit", "no files were changed"},
+		"beta",
+		"rename",
+		"b.v1",
+		"a.v1",
+		tempDirPath,
+	)
+}
+
 func TestLintWithPlugins(t *testing.T) {
 	t.Parallel()
 	// defaults only, comment ignores on.
@@ -3197,6 +3594,139 @@ testdata/check_plugins/current/proto/common/v1/common.proto:8:5:Field name "comm
 	)
 }
 
+func TestBreakingBaseline(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	baselinePath := filepath.Join(tempDirPath, "baseline.json")
+	currentDirPath := filepath.Join(tempDirPath, "current")
+	previousDirPath := filepath.Join(tempDirPath, "previous")
+	currentProtoFilePath := filepath.Join(currentDirPath, "a", "v1", "a.proto")
+	for _, dirPath := range []string{currentDirPath, previousDirPath} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dirPath, "a", "v1"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dirPath, "buf.yaml"), []byte("version: v2\n"), 0600))
+	}
+	require.NoError(
+		t,
+		os.WriteFile(
+			filepath.Join(previousDirPath, "a", "v1", "a.proto"),
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Foo {
+  string one = 1;
+  string two = 2;
+  string three = 3;
+}
+
+message Bar {
+  string one = 1;
+  string two = 2;
+}
+`),
+			0600,
+		),
+	)
+	require.NoError(
+		t,
+		os.WriteFile(
+			currentProtoFilePath,
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Foo {
+  string two = 2;
+  string three = 3;
+}
+
+message Bar {
+  string one = 1;
+  string two = 2;
+}
+`),
+			0600,
+		),
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		"",
+		"breaking",
+		currentDirPath,
+		"--against",
+		previousDirPath,
+		"--write-baseline",
+		baselinePath,
+	)
+	data, err := os.ReadFile(baselinePath)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+  "version": "v1",
+  "entries": [
+    {
+      "rule": "FIELD_NO_DELETE",
+      "path": "a/v1/a.proto",
+      "symbol": "a.v1.Foo"
+    }
+  ]
+}`,
+		string(data),
+	)
+	// The deleted field of Foo is in the baseline.
+	testRunStdoutStderrNoWarn(
+		t,
+		nil,
+		0,
+		"",
+		"",
+		"breaking",
+		currentDirPath,
+		"--against",
+		previousDirPath,
+		"--baseline",
+		baselinePath,
+	)
+	// The baseline only suppresses as many violations as it lists, so deleting another
+	// field of Foo and a field of Bar is reported.
+	require.NoError(
+		t,
+		os.WriteFile(
+			currentProtoFilePath,
+			[]byte(`syntax = "proto3";
+
+package a.v1;
+
+message Foo {
+  string three = 3;
+}
+
+message Bar {
+  string two = 2;
+}
+`),
+			0600,
+		),
+	)
+	testRunStdoutStderrNoWarn(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		currentProtoFilePath+`:5:1:Previously present field "2" with name "two" on message "Foo" was deleted.
+`+currentProtoFilePath+`:9:1:Previously present field "1" with name "one" on message "Bar" was deleted.`,
+		"",
+		"breaking",
+		currentDirPath,
+		"--against",
+		previousDirPath,
+		"--baseline",
+		baselinePath,
+	)
+}
+
 func TestBreakingWithPaths(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
@@ -4225,6 +4755,46 @@ func TestFormatExitCode(t *testing.T) {
 	assert.NotEmpty(t, stdout.String())
 }
 
+func TestFormatConfig(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	testRunStdout(
+		t,
+		nil,
+		0,
+		``,
+		"format",
+		filepath.Join("testdata", "format", "config"),
+		"-o",
+		tempDir,
+	)
+	// Module a aligns fields, module b uses the default format.
+	data, err := os.ReadFile(filepath.Join(tempDir, "a.proto"))
+	require.NoError(t, err)
+	assert.Contains(
+		t,
+		string(data),
+		`
+message A {
+  string key        = 1;
+  bytes value_bytes = 2;
+}
+`,
+	)
+	data, err = os.ReadFile(filepath.Join(tempDir, "b.proto"))
+	require.NoError(t, err)
+	assert.Contains(
+		t,
+		string(data),
+		`
+message B {
+  string key = 1;
+  bytes value_bytes = 2;
+}
+`,
+	)
+}
+
 // Tests if the image produced by the formatted result is
 // equivalent to the original result.
 func TestFormatEquivalence(t *testing.T) {
@@ -4552,6 +5122,115 @@ func TestLintNoSourceCodeInfoIgnores(t *testing.T) {
 
 // testBuildLsFilesFormatImport does effectively an ls-files, but via doing a build of an Image, and then
 // listing the files from the image as if --format=import was set.
+func TestMockServeCurl(t *testing.T) {
+	t.Parallel()
+	dirPath := filepath.Join("testdata", "mockserve")
+	buffer := bytes.NewBuffer(nil)
+	testRun(t, 0, nil, buffer, "build", dirPath, "-o", "-")
+	protoImage := &imagev1.Image{}
+	require.NoError(t, protoencoding.NewWireUnmarshaler(nil).Unmarshal(buffer.Bytes(), protoImage))
+	image, err := bufimage.NewImageForProto(protoImage)
+	require.NoError(t, err)
+	rules, err := bufmock.ReadRules(filepath.Join(dirPath, "rules.yaml"))
+	require.NoError(t, err)
+	handler, err := bufmock.NewHandler(slogtestext.NewLogger(t), image, bufmock.WithRules(rules))
+	require.NoError(t, err)
+	// Server reflection is bidi-streaming, which requires HTTP/2.
+	server := httptest.NewUnstartedServer(handler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+acme.user.v1.UserService/GetUser
+acme.user.v1.UserService/ListUsers
+		`,
+		"curl",
+		"--insecure",
+		"--list-methods",
+		server.URL,
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+{
+  "user": {
+    "id": "123",
+    "name": "Ada"
+  }
+}
+		`,
+		"curl",
+		"--insecure",
+		"--data",
+		`{"id": "123"}`,
+		server.URL+"/acme.user.v1.UserService/GetUser",
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+{
+  "id": "1",
+  "name": "Ada"
+}
+{
+  "id": "2",
+  "name": "Grace"
+}
+		`,
+		"curl",
+		"--insecure",
+		"--protocol",
+		"grpc",
+		server.URL+"/acme.user.v1.UserService/ListUsers",
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+{
+  "user": {
+    "id": "id",
+    "name": "name"
+  }
+}
+		`,
+		"curl",
+		"--insecure",
+		"--protocol",
+		"grpcweb",
+		"--data",
+		`{"id": "1"}`,
+		server.URL+"/acme.user.v1.UserService/GetUser",
+	)
+	// buf curl exits with eight times the code of the error.
+	testRunStderr(
+		t,
+		nil,
+		40,
+		`
+{
+   "code": "not_found",
+   "message": "no such user"
+}
+		`,
+		"curl",
+		"--insecure",
+		"--data",
+		`{"id": "404"}`,
+		server.URL+"/acme.user.v1.UserService/GetUser",
+	)
+}
+
 func testBuildLsFilesFormatImport(t *testing.T, expectedExitCode int, expectedFiles []string, buildArgs ...string) {
 	buffer := bytes.NewBuffer(nil)
 	testRun(t, expectedExitCode, nil, buffer, append([]string{"build", "-o", "-"}, buildArgs...)...)
@@ -4658,6 +5337,37 @@ func testRunStdoutFile(t *testing.T, stdin io.Reader, expectedExitCode int, want
 	)
 }
 
+// testSARIFNoResults runs the command, and checks that it prints a SARIF log with a
+// single run for buf without results, and with the rule among its rules.
+func testSARIFNoResults(t *testing.T, expectedRuleID string, args ...string) {
+	stdout := bytes.NewBuffer(nil)
+	testRun(t, 0, nil, stdout, args...)
+	var sarifLog struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []json.RawMessage `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &sarifLog), stdout.String())
+	require.Len(t, sarifLog.Runs, 1)
+	run := sarifLog.Runs[0]
+	assert.Equal(t, "buf", run.Tool.Driver.Name)
+	require.NotNil(t, run.Results)
+	assert.Empty(t, run.Results)
+	ruleIDs := make([]string, len(run.Tool.Driver.Rules))
+	for i, rule := range run.Tool.Driver.Rules {
+		ruleIDs[i] = rule.ID
+	}
+	assert.Contains(t, ruleIDs, expectedRuleID)
+}
+
 func testRun(
 	t *testing.T,
 	expectedExitCode int,
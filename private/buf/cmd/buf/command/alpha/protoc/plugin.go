@@ -24,6 +24,7 @@ import (
 	"github.com/bufbuild/buf/private/buf/bufprotopluginexec"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/wasm"
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
@@ -44,6 +45,7 @@ func executePlugin(
 	ctx context.Context,
 	logger *slog.Logger,
 	storageosProvider storageos.Provider,
+	wasmRuntime wasm.Runtime,
 	container app.EnvStderrContainer,
 	images []bufimage.Image,
 	pluginName string,
@@ -52,6 +54,7 @@ func executePlugin(
 	generator := bufprotopluginexec.NewGenerator(
 		logger,
 		storageosProvider,
+		wasmRuntime,
 	)
 	requests, err := bufimage.ImagesToCodeGeneratorRequests(
 		images,
@@ -179,6 +179,13 @@ func run(
 				return err
 			}
 		}
+		wasmRuntime, err := bufcli.NewWasmRuntime(ctx, container)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, wasmRuntime.Close(ctx))
+		}()
 		pluginResponses := make([]*bufprotoplugin.PluginResponse, 0, len(env.PluginNamesSortedByOutIndex))
 		for _, pluginName := range env.PluginNamesSortedByOutIndex {
 			pluginInfo, ok := env.PluginNameToPluginInfo[pluginName]
@@ -189,6 +196,7 @@ func run(
 				ctx,
 				logger,
 				storageosProvider,
+				wasmRuntime,
 				container,
 				images,
 				pluginName,
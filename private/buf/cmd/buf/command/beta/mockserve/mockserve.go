@@ -0,0 +1,198 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mockserve
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"buf.build/go/standard/xstrings"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufctl"
+	"github.com/bufbuild/buf/private/buf/bufmock"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/pkg/transport/http/httpserver"
+	"github.com/spf13/pflag"
+)
+
+const (
+	bindFlagName            = "bind"
+	portFlagName            = "port"
+	rulesFlagName           = "rules"
+	pathsFlagName           = "path"
+	excludePathsFlagName    = "exclude-path"
+	configFlagName          = "config"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appext.SubCommandBuilder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Run a mock server for the services of an input",
+		Long: `Serve a mock implementation of every service in the input, with the Connect, gRPC, and gRPC-Web protocols.
+
+The server also supports gRPC server reflection (v1 and v1alpha), so clients such as buf curl
+can be used without a schema.
+
+Responses are taken from the rules file given with --rules, if any. Each rule matches the
+requests of a method whose fields contain the fields of the rule's request, and responds with
+the rule's response(s) and/or error. Rules are checked in order, and the first match wins.
+For example:
+
+    rules:
+      - method: acme.user.v1.UserService/GetUser
+        request:
+          id: "123"
+        response:
+          user:
+            id: "123"
+            name: Ada
+      - method: acme.user.v1.UserService/GetUser
+        request:
+          id: "404"
+        error:
+          code: not_found
+          message: no such user
+
+Requests that match no rule get a generated response, with every field set to a value that
+satisfies its protovalidate rules where possible.
+
+` + bufcli.GetInputLong(`the source, module, or image to serve`),
+		Args: appcmd.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	BindAddress     string
+	Port            string
+	Rules           string
+	Paths           []string
+	ExcludePaths    []string
+	Config          string
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.BindAddress,
+		bindFlagName,
+		"127.0.0.1",
+		"The address to be exposed to accept HTTP requests",
+	)
+	flagSet.StringVar(
+		&f.Port,
+		portFlagName,
+		"8080",
+		"The port to be exposed to accept HTTP requests",
+	)
+	flagSet.StringVar(
+		&f.Rules,
+		rulesFlagName,
+		"",
+		"The YAML or JSON file of rules that determine the responses to requests",
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			xstrings.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appext.Container,
+	flags *flags,
+) error {
+	var handlerOptions []bufmock.HandlerOption
+	if flags.Rules != "" {
+		rules, err := bufmock.ReadRules(flags.Rules)
+		if err != nil {
+			return err
+		}
+		handlerOptions = append(handlerOptions, bufmock.WithRules(rules))
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	controller, err := bufcli.NewController(
+		container,
+		bufctl.WithDisableSymlinks(flags.DisableSymlinks),
+		bufctl.WithFileAnnotationErrorFormat(flags.ErrorFormat),
+	)
+	if err != nil {
+		return err
+	}
+	image, err := controller.GetImage(
+		ctx,
+		input,
+		bufctl.WithTargetPaths(flags.Paths, flags.ExcludePaths),
+		bufctl.WithConfigOverride(flags.Config),
+	)
+	if err != nil {
+		return err
+	}
+	handler, err := bufmock.NewHandler(container.Logger(), image, handlerOptions...)
+	if err != nil {
+		return err
+	}
+	var httpListenConfig net.ListenConfig
+	httpListener, err := httpListenConfig.Listen(ctx, "tcp", fmt.Sprintf("%s:%s", flags.BindAddress, flags.Port))
+	if err != nil {
+		return err
+	}
+	return httpserver.Run(
+		ctx,
+		container.Logger(),
+		httpListener,
+		handler,
+	)
+}
@@ -0,0 +1,166 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rename
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"buf.build/go/app/appcmd"
+	"buf.build/go/app/appext"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufctl"
+	"github.com/bufbuild/buf/private/buf/bufrefactor"
+	"github.com/spf13/pflag"
+)
+
+const (
+	reserveFlagName         = "reserve"
+	deprecatedAliasFlagName = "deprecated-alias"
+	diffFlagName            = "diff"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appext.SubCommandBuilder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <old-full-name> <new-full-name> <source>",
+		Short: "Rename a Protobuf symbol or package across a workspace",
+		Long: `The first argument is the full name of the message, enum, enum value, field, oneof, service,
+method, or package to rename, without a leading dot, for example "acme.weather.v1.Forecast".
+Enum values are named within their enum, for example "acme.weather.v1.Condition.CONDITION_SUNNY".
+
+The second argument is the new full name. A symbol can only be renamed within its parent, for
+example from "acme.weather.v1.Forecast" to "acme.weather.v1.WeatherForecast". A package can be
+renamed to any package that does not exist yet.
+
+The definition and all references to it are updated in all modules of the workspace, and the
+edited files are formatted. If the rename cannot be made safely, for example because the symbol
+is referenced from outside of the workspace or by an option, no files are changed.
+
+The third argument is the source to rename within, which must be a directory or .proto file.
+This defaults to "." if no argument is specified.`,
+		Args: appcmd.RangeArgs(2, 3),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appext.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Reserve         bool
+	DeprecatedAlias bool
+	Diff            bool
+	Config          string
+	DisableSymlinks bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.BoolVar(
+		&f.Reserve,
+		reserveFlagName,
+		false,
+		"Add the old name of a renamed field or enum value to the reserved names of its message or enum",
+	)
+	flagSet.BoolVar(
+		&f.DeprecatedAlias,
+		deprecatedAliasFlagName,
+		false,
+		`Keep the old name of a renamed enum value as a deprecated alias
+The allow_alias option is added to the enum if it is not already set`,
+	)
+	flagSet.BoolVar(
+		&f.Diff,
+		diffFlagName,
+		false,
+		"Display a diff of the rename instead of rewriting files",
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appext.Container,
+	flags *flags,
+) error {
+	fullName := strings.TrimPrefix(container.Arg(0), ".")
+	newFullName := strings.TrimPrefix(container.Arg(1), ".")
+	if fullName == "" || newFullName == "" {
+		return appcmd.NewInvalidArgumentError("full names must not be empty")
+	}
+	input := "."
+	if container.NumArgs() > 2 {
+		input = container.Arg(2)
+	}
+	var renameEditOptions []bufrefactor.RenameEditOption
+	if flags.Reserve {
+		renameEditOptions = append(renameEditOptions, bufrefactor.RenameWithReservedName())
+	}
+	if flags.DeprecatedAlias {
+		renameEditOptions = append(renameEditOptions, bufrefactor.RenameWithDeprecatedAlias())
+	}
+	controller, err := bufcli.NewController(
+		container,
+		bufctl.WithDisableSymlinks(flags.DisableSymlinks),
+	)
+	if err != nil {
+		return err
+	}
+	refactorer, originalReadBucket, err := bufcli.NewRefactorerForInput(
+		ctx,
+		container,
+		controller,
+		input,
+		"buf beta rename",
+		bufctl.WithConfigOverride(flags.Config),
+	)
+	if err != nil {
+		return err
+	}
+	if err := refactorer.Apply(
+		ctx,
+		bufrefactor.NewRenameEdit(fullName, newFullName, renameEditOptions...),
+	); err != nil {
+		if errors.Is(err, bufrefactor.ErrUnsafeEdit) {
+			return fmt.Errorf("%w; no files were changed", err)
+		}
+		return appcmd.WrapInvalidArgumentError(err)
+	}
+	refactoredReadBucket, err := refactorer.Bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return bufcli.WriteRefactoredBucket(ctx, container, originalReadBucket, refactoredReadBucket, flags.Diff)
+}
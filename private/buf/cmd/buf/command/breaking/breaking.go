@@ -23,6 +23,7 @@ import (
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"buf.build/go/bufplugin/check"
 	"buf.build/go/standard/xslices"
 	"buf.build/go/standard/xstrings"
 	"github.com/bufbuild/buf/private/buf/bufcli"
@@ -48,6 +49,8 @@ const (
 	againstRegistryFlagName   = "against-registry"
 	excludePathsFlagName      = "exclude-path"
 	disableSymlinksFlagName   = "disable-symlinks"
+	baselineFlagName          = "baseline"
+	writeBaselineFlagName     = "write-baseline"
 )
 
 // NewCommand returns a new Command.
@@ -84,6 +87,8 @@ type flags struct {
 	AgainstRegistry   bool
 	ExcludePaths      []string
 	DisableSymlinks   bool
+	Baseline          string
+	WriteBaseline     string
 	// special
 	InputHashtag string
 }
@@ -97,6 +102,8 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindBaseline(flagSet, &f.Baseline, baselineFlagName)
+	bufcli.BindWriteBaseline(flagSet, &f.WriteBaseline, writeBaselineFlagName)
 	flagSet.StringVar(
 		&f.ErrorFormat,
 		errorFormatFlagName,
@@ -296,6 +303,11 @@ func run(
 		allCheckConfigs = append(allCheckConfigs, imageWithConfig.BreakingConfig())
 	}
 	var allFileAnnotations []bufanalysis.FileAnnotation
+	// BaselineEntries are only computed if we are reading or writing a baseline, and are
+	// parallel to allFileAnnotations.
+	var allBaselineEntries []bufcheck.BaselineEntry
+	// Rules are only needed to describe the FileAnnotations for formats that support rule metadata.
+	var allRules []bufcheck.Rule
 	for i, imageWithConfig := range imageWithConfigs {
 		breakingOptions := []bufcheck.BreakingOption{
 			bufcheck.WithPluginConfigs(imageWithConfig.PluginConfigs()...),
@@ -315,22 +327,65 @@ func run(
 			var fileAnnotationSet bufanalysis.FileAnnotationSet
 			if errors.As(err, &fileAnnotationSet) {
 				allFileAnnotations = append(allFileAnnotations, fileAnnotationSet.FileAnnotations()...)
+				if flags.Baseline != "" || flags.WriteBaseline != "" {
+					baselineEntries, err := bufcheck.NewBaselineEntries(ctx, imageWithConfig, fileAnnotationSet.FileAnnotations())
+					if err != nil {
+						return err
+					}
+					allBaselineEntries = append(allBaselineEntries, baselineEntries...)
+				}
 			} else {
 				return err
 			}
 		}
+		if flags.ErrorFormat == bufanalysis.FormatSARIF.String() {
+			rules, err := checkClient.ConfiguredRules(
+				ctx,
+				check.RuleTypeBreaking,
+				imageWithConfig.BreakingConfig(),
+				bufcheck.WithPluginConfigs(imageWithConfig.PluginConfigs()...),
+				bufcheck.WithPolicyConfigs(imageWithConfig.PolicyConfigs()...),
+				bufcheck.WithRelatedCheckConfigs(allCheckConfigs...),
+			)
+			if err != nil {
+				return err
+			}
+			allRules = append(allRules, rules...)
+		}
 	}
+	if flags.WriteBaseline != "" {
+		return bufcli.WriteBaselineFile(flags.WriteBaseline, bufcheck.NewBaseline(allBaselineEntries))
+	}
+	if flags.Baseline != "" {
+		baseline, err := bufcli.ReadBaselineFile(flags.Baseline)
+		if err != nil {
+			return err
+		}
+		var staleBaselineEntries []bufcheck.BaselineEntry
+		allFileAnnotations, staleBaselineEntries, err = baseline.Filter(allFileAnnotations, allBaselineEntries)
+		if err != nil {
+			return err
+		}
+		bufcli.WarnStaleBaselineEntries(container.Logger(), flags.Baseline, staleBaselineEntries)
+	}
+	printWithRules := bufanalysis.PrintWithRules(xslices.Map(allRules, func(rule bufcheck.Rule) bufanalysis.Rule { return rule })...)
 	if len(allFileAnnotations) > 0 {
 		allFileAnnotationSet := bufanalysis.NewFileAnnotationSet(allFileAnnotations...)
 		if err := bufanalysis.PrintFileAnnotationSet(
 			container.Stdout(),
 			allFileAnnotationSet,
 			flags.ErrorFormat,
+			printWithRules,
 		); err != nil {
 			return err
 		}
 		return bufctl.ErrFileAnnotation
 	}
+	if flags.ErrorFormat == bufanalysis.FormatSARIF.String() {
+		// A SARIF log is printed even if there are no FileAnnotations, so that code
+		// scanning can close the alerts for violations that were fixed.
+		return bufanalysis.PrintFileAnnotationSet(container.Stdout(), nil, flags.ErrorFormat, printWithRules)
+	}
 	return nil
 }
 
@@ -351,6 +406,9 @@ func validateFlags(flags *flags) error {
 	if flags.Against != "" && flags.AgainstRegistry {
 		return fmt.Errorf("Cannot set both --%s and --%s", againstFlagName, againstRegistryFlagName)
 	}
+	if flags.Baseline != "" && flags.WriteBaseline != "" {
+		return fmt.Errorf("Cannot set both --%s and --%s", baselineFlagName, writeBaselineFlagName)
+	}
 	return nil
 }
 
@@ -164,6 +164,7 @@ func run(
 			),
 			false,
 		),
+		bufconfig.DefaultFormatConfig,
 	)
 	if err != nil {
 		return err
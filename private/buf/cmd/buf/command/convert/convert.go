@@ -18,7 +18,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"strconv"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
@@ -27,12 +29,15 @@ import (
 	"github.com/bufbuild/buf/private/buf/bufconvert"
 	"github.com/bufbuild/buf/private/buf/bufctl"
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufprint"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/gen/data/datawkt"
 	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 const (
@@ -42,6 +47,14 @@ const (
 	toFlagName              = "to"
 	validateFlagName        = "validate"
 	disableSymlinksFlagName = "disable-symlinks"
+	fieldMaskFlagName       = "field-mask"
+	excludeFieldsFlagName   = "exclude-fields"
+	redactOptionFlagName    = "redact-option"
+	decodeRawFlagName       = "decode-raw"
+	guessTypeFlagName       = "guess-type"
+
+	// maxGuessTypeMatches is the maximum number of message types printed for --guess-type.
+	maxGuessTypeMatches = 10
 )
 
 // NewCommand returns a new Command.
@@ -83,6 +96,36 @@ Redirect from stdin to <input>:
 Use a module on the bsr:
 
     $ buf convert <buf.build/owner/repository> --type buf.Foo --from=payload.json
+
+Both "--from" and "--to" accept a stream of messages with the delimiter option, and the
+messages are converted one at a time. The binpb format accepts "delimiter=varint" for
+messages prefixed with their size as a varint, and "delimiter=envelope" for messages
+prefixed with the 5-byte envelope of gRPC and Connect. The json format accepts
+"delimiter=newline" for one message per line, and the yaml format accepts
+"delimiter=newline" for one message per document:
+
+    $ buf convert example.proto --type=buf.Foo --from=payload.binpb#delimiter=varint --to=payload.json#delimiter=newline
+
+Fields can be removed from the messages before they are written, such as to remove personal
+information. Keep only some fields with "--field-mask", clear fields with "--exclude-fields",
+and clear every field that has a given field option set with "--redact-option". Field paths
+are resolved against the schema, and can go through nested, repeated, and map fields. Messages
+packed in google.protobuf.Any are unpacked and filtered as well, and "--redact-option" also
+clears unknown fields, as they cannot be checked for the option:
+
+    $ buf convert example.proto --type=acme.v1.User --from=user.json --field-mask=id,addresses.city
+    $ buf convert example.proto --type=acme.v1.User --from=user.json --redact-option='(acme.v1.sensitive)'
+
+To inspect a binpb payload of unknown type, "--decode-raw" prints its wire structure without
+a schema: the number and wire type of every field, with nested groups, and with the values of
+length-delimited fields as strings, nested messages, or bytes:
+
+    $ buf convert --decode-raw --from=payload.binpb
+
+"--guess-type" instead tries every message type of <input> against the payload, and prints the
+message types that match best, by the number of bytes of the payload that they do not recognize:
+
+    $ buf convert example.proto --guess-type --from=payload.binpb
 `,
 		Args: appcmd.MaximumNArgs(1),
 		Run: builder.NewRunFunc(
@@ -101,6 +144,11 @@ type flags struct {
 	To              string
 	Validate        bool
 	DisableSymlinks bool
+	FieldMask       []string
+	ExcludeFields   []string
+	RedactOptions   []string
+	DecodeRaw       bool
+	GuessType       bool
 
 	// special
 	InputHashtag string
@@ -151,7 +199,43 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		validateFlagName,
 		false,
 		fmt.Sprintf(
-			`Validate the message specified with --%s by applying protovalidate rules to it. See https://github.com/bufbuild/protovalidate for more details.`,
+			`Validate the message specified with --%s by applying protovalidate rules to it. For a stream of messages, every message is validated. See https://github.com/bufbuild/protovalidate for more details.`,
+			fromFlagName,
+		),
+	)
+	flagSet.StringSliceVar(
+		&f.FieldMask,
+		fieldMaskFlagName,
+		nil,
+		`The paths of the fields to keep, such as a.b, with all other fields cleared. May be provided multiple times or as a comma-separated list`,
+	)
+	flagSet.StringSliceVar(
+		&f.ExcludeFields,
+		excludeFieldsFlagName,
+		nil,
+		`The paths of the fields to clear, such as a.b. May be provided multiple times or as a comma-separated list`,
+	)
+	flagSet.StringSliceVar(
+		&f.RedactOptions,
+		redactOptionFlagName,
+		nil,
+		`Clear every field that has the given field option set, such as debug_redact or (acme.sensitive). Options of type bool must be true. May be provided multiple times`,
+	)
+	flagSet.BoolVar(
+		&f.DecodeRaw,
+		decodeRawFlagName,
+		false,
+		fmt.Sprintf(
+			`Print the wire structure of the binpb message specified with --%s to stdout, without a schema`,
+			fromFlagName,
+		),
+	)
+	flagSet.BoolVar(
+		&f.GuessType,
+		guessTypeFlagName,
+		false,
+		fmt.Sprintf(
+			`Print the message types within the input that best match the binpb message specified with --%s to stdout`,
 			fromFlagName,
 		),
 	)
@@ -162,6 +246,9 @@ func run(
 	container appext.Container,
 	flags *flags,
 ) error {
+	if err := validateFlags(container, flags); err != nil {
+		return err
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
 	if err != nil {
 		return err
@@ -174,6 +261,22 @@ func run(
 	if err != nil {
 		return err
 	}
+	if flags.DecodeRaw {
+		return decodeRaw(ctx, container, controller, flags)
+	}
+	if flags.GuessType {
+		schemaImage, err := controller.GetImage(ctx, input)
+		if err != nil {
+			return err
+		}
+		return guessType(
+			ctx,
+			container,
+			controller,
+			bufconvert.ImageWithoutMessageSetWireFormatResolution(schemaImage),
+			flags,
+		)
+	}
 	schemaImage, schemaImageErr := controller.GetImage(
 		ctx,
 		input,
@@ -219,6 +322,17 @@ func run(
 	if flags.Validate {
 		fromFunctionOptions = append(fromFunctionOptions, bufctl.WithMessageValidation())
 	}
+	fieldFilter, err := newFieldFilter(schemaImage, flags)
+	if err != nil {
+		return err
+	}
+	isMessageStream, err := isMessageStream(ctx, container.Logger(), flags)
+	if err != nil {
+		return err
+	}
+	if isMessageStream {
+		return convertMessageStream(ctx, controller, schemaImage, fieldFilter, flags, fromFunctionOptions)
+	}
 	fromMessage, fromMessageEncoding, err := controller.GetMessage(
 		ctx,
 		schemaImage,
@@ -230,6 +344,11 @@ func run(
 	if err != nil {
 		return fmt.Errorf("--%s: %w", fromFlagName, err)
 	}
+	if fromMessage != nil && fieldFilter != nil {
+		if err := fieldFilter.Filter(fromMessage); err != nil {
+			return err
+		}
+	}
 	defaultToMessageEncoding, err := inverseEncoding(fromMessageEncoding)
 	if err != nil {
 		return err
@@ -246,6 +365,272 @@ func run(
 	return nil
 }
 
+// convertMessageStream converts a stream of delimited messages.
+//
+// Messages are converted one at a time, so that streams are converted with constant memory.
+// Errors for a message are prefixed with the index of the message within the stream.
+func convertMessageStream(
+	ctx context.Context,
+	controller bufctl.Controller,
+	schemaImage bufimage.Image,
+	fieldFilter bufconvert.FieldFilter,
+	flags *flags,
+	fromFunctionOptions []bufctl.FunctionOption,
+) (retErr error) {
+	fromMessageReader, fromMessageEncoding, err := controller.GetMessages(
+		ctx,
+		schemaImage,
+		flags.From,
+		flags.Type,
+		buffetch.MessageEncodingBinpb,
+		fromFunctionOptions...,
+	)
+	if err != nil {
+		return fmt.Errorf("--%s: %w", fromFlagName, err)
+	}
+	defer func() {
+		retErr = errors.Join(retErr, fromMessageReader.Close())
+	}()
+	// Read the first message before the output is opened, so that nothing
+	// is written if the first message is invalid.
+	fromMessage, err := readMessage(fromMessageReader)
+	if err != nil {
+		return fmt.Errorf("--%s: %w", fromFlagName, err)
+	}
+	defaultToMessageEncoding, err := inverseEncoding(fromMessageEncoding)
+	if err != nil {
+		return err
+	}
+	toMessageWriter, err := controller.PutMessages(
+		ctx,
+		schemaImage,
+		flags.To,
+		defaultToMessageEncoding,
+	)
+	if err != nil {
+		return fmt.Errorf("--%s: %w", toFlagName, err)
+	}
+	defer func() {
+		retErr = errors.Join(retErr, toMessageWriter.Close())
+	}()
+	for fromMessage != nil {
+		if fieldFilter != nil {
+			if err := fieldFilter.Filter(fromMessage); err != nil {
+				return err
+			}
+		}
+		if err := toMessageWriter.Write(fromMessage); err != nil {
+			return fmt.Errorf("--%s: %w", toFlagName, err)
+		}
+		fromMessage, err = readMessage(fromMessageReader)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", fromFlagName, err)
+		}
+	}
+	return nil
+}
+
+func validateFlags(container appext.Container, flags *flags) error {
+	if flags.DecodeRaw && flags.GuessType {
+		return appcmd.NewInvalidArgumentErrorf("--%s cannot be specified if --%s is specified", decodeRawFlagName, guessTypeFlagName)
+	}
+	var modeFlagName string
+	switch {
+	case flags.DecodeRaw:
+		modeFlagName = decodeRawFlagName
+		if container.NumArgs() > 0 {
+			return appcmd.NewInvalidArgumentErrorf("<input> cannot be specified if --%s is specified", decodeRawFlagName)
+		}
+	case flags.GuessType:
+		modeFlagName = guessTypeFlagName
+	default:
+		return nil
+	}
+	for _, conflictingFlag := range []struct {
+		name  string
+		isSet bool
+	}{
+		{name: typeFlagName, isSet: flags.Type != ""},
+		{name: toFlagName, isSet: flags.To != "-"},
+		{name: validateFlagName, isSet: flags.Validate},
+		{name: fieldMaskFlagName, isSet: len(flags.FieldMask) > 0},
+		{name: excludeFieldsFlagName, isSet: len(flags.ExcludeFields) > 0},
+		{name: redactOptionFlagName, isSet: len(flags.RedactOptions) > 0},
+	} {
+		if conflictingFlag.isSet {
+			return appcmd.NewInvalidArgumentErrorf("--%s cannot be specified if --%s is specified", conflictingFlag.name, modeFlagName)
+		}
+	}
+	return nil
+}
+
+// decodeRaw prints the wire structure of the messages of --from. Messages of a stream
+// are separated by "---" lines.
+func decodeRaw(
+	ctx context.Context,
+	container appext.Container,
+	controller bufctl.Controller,
+	flags *flags,
+) (retErr error) {
+	messageDataReader, err := getBinpbMessageData(ctx, controller, flags.From, decodeRawFlagName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, messageDataReader.Close())
+	}()
+	for index := 0; ; index++ {
+		data, err := messageDataReader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("--%s: %w", fromFlagName, err)
+		}
+		if index > 0 {
+			if _, err := io.WriteString(container.Stdout(), "---\n"); err != nil {
+				return err
+			}
+		}
+		if err := bufconvert.WriteRawMessage(container.Stdout(), data); err != nil {
+			return fmt.Errorf("--%s: %w", fromFlagName, err)
+		}
+	}
+}
+
+// guessType prints the message types of the image that best match the messages of --from.
+func guessType(
+	ctx context.Context,
+	container appext.Container,
+	controller bufctl.Controller,
+	schemaImage bufimage.Image,
+	flags *flags,
+) (retErr error) {
+	messageTypeGuesser, err := bufconvert.NewMessageTypeGuesser(schemaImage)
+	if err != nil {
+		return err
+	}
+	messageDataReader, err := getBinpbMessageData(ctx, controller, flags.From, guessTypeFlagName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, messageDataReader.Close())
+	}()
+	for {
+		data, err := messageDataReader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("--%s: %w", fromFlagName, err)
+		}
+		messageTypeGuesser.Add(data)
+	}
+	messageTypeMatches := messageTypeGuesser.Matches()
+	if len(messageTypeMatches) == 0 {
+		return errors.New("no message type within the input matches the payload")
+	}
+	if len(messageTypeMatches) > maxGuessTypeMatches {
+		messageTypeMatches = messageTypeMatches[:maxGuessTypeMatches]
+	}
+	return bufprint.WithTabWriter(
+		container.Stdout(),
+		[]string{
+			"Type",
+			"Unrecognized Bytes",
+			"Recognized Fields",
+		},
+		func(tabWriter bufprint.TabWriter) error {
+			for _, messageTypeMatch := range messageTypeMatches {
+				if err := tabWriter.Write(
+					messageTypeMatch.TypeName(),
+					strconv.Itoa(messageTypeMatch.UnrecognizedBytes()),
+					strconv.Itoa(messageTypeMatch.RecognizedFields()),
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// getBinpbMessageData returns a MessageDataReader for the messages of --from, which must
+// be in the binpb format for the given mode flag.
+func getBinpbMessageData(
+	ctx context.Context,
+	controller bufctl.Controller,
+	from string,
+	modeFlagName string,
+) (bufconvert.MessageDataReader, error) {
+	messageDataReader, messageEncoding, err := controller.GetMessageData(
+		ctx,
+		from,
+		buffetch.MessageEncodingBinpb,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", fromFlagName, err)
+	}
+	if messageEncoding != buffetch.MessageEncodingBinpb {
+		return nil, errors.Join(
+			appcmd.NewInvalidArgumentErrorf("--%s must be in the binpb format if --%s is specified", fromFlagName, modeFlagName),
+			messageDataReader.Close(),
+		)
+	}
+	return messageDataReader, nil
+}
+
+// readMessage reads the next message, or returns nil if there are no more messages.
+// isMessageStream returns true if --from or --to is a stream of delimited messages.
+func isMessageStream(ctx context.Context, logger *slog.Logger, flags *flags) (bool, error) {
+	messageRefParser := buffetch.NewMessageRefParser(logger)
+	fromMessageRef, err := messageRefParser.GetMessageRef(ctx, flags.From)
+	if err != nil {
+		return false, fmt.Errorf("--%s: %w", fromFlagName, err)
+	}
+	toMessageRef, err := messageRefParser.GetMessageRef(ctx, flags.To)
+	if err != nil {
+		return false, fmt.Errorf("--%s: %w", toFlagName, err)
+	}
+	// A zero MessageDelimiter means that the file holds a single message.
+	return fromMessageRef.MessageDelimiter() != 0 || toMessageRef.MessageDelimiter() != 0, nil
+}
+
+func readMessage(messageReader bufconvert.MessageReader) (proto.Message, error) {
+	message, err := messageReader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return message, nil
+}
+
+// newFieldFilter returns a new FieldFilter for the type of the messages, or nil if
+// no fields are filtered.
+func newFieldFilter(schemaImage bufimage.Image, flags *flags) (bufconvert.FieldFilter, error) {
+	var fieldFilterOptions []bufconvert.FieldFilterOption
+	if len(flags.FieldMask) > 0 {
+		fieldFilterOptions = append(fieldFilterOptions, bufconvert.FieldFilterWithFieldMask(flags.FieldMask...))
+	}
+	if len(flags.ExcludeFields) > 0 {
+		fieldFilterOptions = append(fieldFilterOptions, bufconvert.FieldFilterWithExcludeFields(flags.ExcludeFields...))
+	}
+	for _, redactOption := range flags.RedactOptions {
+		fieldFilterOptions = append(fieldFilterOptions, bufconvert.FieldFilterWithRedactOption(redactOption))
+	}
+	if len(fieldFilterOptions) == 0 {
+		return nil, nil
+	}
+	messageType, err := schemaImage.Resolver().FindMessageByName(protoreflect.FullName(flags.Type))
+	if err != nil {
+		return nil, err
+	}
+	return bufconvert.NewFieldFilter(schemaImage.Resolver(), messageType.Descriptor(), fieldFilterOptions...)
+}
+
 // inverseEncoding returns the opposite encoding of the provided encoding,
 // which will be the default output encoding for a given payload encoding.
 func inverseEncoding(encoding buffetch.MessageEncoding) (buffetch.MessageEncoding, error) {
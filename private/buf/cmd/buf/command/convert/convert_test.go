@@ -423,6 +423,312 @@ func TestConvertWKTImport(t *testing.T) {
 	)
 }
 
+func TestConvertVarintDelimitedToNewlineDelimited(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout("{\"one\":\"55\"}\n{}\n{\"one\":\"1\"}"),
+		appcmdtesting.WithStdin(strings.NewReader("\x02\x08\x37\x00\x02\x08\x01")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/bin_json/buf.proto",
+			"--type",
+			"buf.Foo",
+			"--from",
+			"-#format=binpb,delimiter=varint",
+			"--to",
+			"-#format=json,delimiter=newline",
+		),
+	)
+}
+
+func TestConvertNewlineDelimitedToEnvelopeDelimited(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout("\x00\x00\x00\x00\x02\x08\x37\x00\x00\x00\x00\x02\x08\x01"),
+		appcmdtesting.WithStdin(strings.NewReader("{\"one\":\"55\"}\n\n{\"one\":1}\n")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/bin_json/buf.proto",
+			"--type",
+			"buf.Foo",
+			"--from",
+			"-#format=json,delimiter=newline",
+			"--to",
+			"-#format=binpb,delimiter=envelope",
+		),
+	)
+}
+
+func TestConvertYAMLDocumentsToVarintDelimited(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout("\x02\x08\x37\x02\x08\x01"),
+		appcmdtesting.WithStdin(strings.NewReader("one: 55\n---\none: 1\n")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/bin_json/buf.proto",
+			"--type",
+			"buf.Foo",
+			"--from",
+			"-#format=yaml,delimiter=newline",
+			"--to",
+			"-#format=binpb,delimiter=varint",
+		),
+	)
+}
+
+func TestConvertNewlineDelimitedInvalidMessage(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("--from: message 1: json unmarshal:"),
+		appcmdtesting.WithStdin(strings.NewReader("{\"one\":\"55\"}\n{\"one\":\"abc\"}\n")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/bin_json/buf.proto",
+			"--type",
+			"buf.Foo",
+			"--from",
+			"-#format=json,delimiter=newline",
+			"--to",
+			"-#format=json,delimiter=newline",
+		),
+	)
+}
+
+func TestConvertMultipleMessagesWithoutDelimiter(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("--to: cannot write more than one message without a delimiter"),
+		appcmdtesting.WithStdin(strings.NewReader("{\"one\":\"55\"}\n{\"one\":\"1\"}\n")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/bin_json/buf.proto",
+			"--type",
+			"buf.Foo",
+			"--from",
+			"-#format=json,delimiter=newline",
+			"--to",
+			"-#format=json",
+		),
+	)
+}
+
+func TestConvertFieldMask(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout(`{"id":"1","addresses":[{"city":"Toronto"}]}`),
+		appcmdtesting.WithStdin(strings.NewReader(`{"id":"1","email":"a@example.com","addresses":[{"street":"1 Main St","city":"Toronto"}]}`)),
+		appcmdtesting.WithArgs(
+			"testdata/convert/filter/user.proto",
+			"--type",
+			"acme.v1.User",
+			"--from",
+			"-#format=json",
+			"--to",
+			"-#format=json",
+			"--field-mask",
+			"id,addresses.city",
+		),
+	)
+}
+
+func TestConvertExcludeFieldsAndRedactOption(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout(`{"addresses":[{"city":"Toronto"}]}`),
+		appcmdtesting.WithStdin(strings.NewReader(`{"id":"1","email":"a@example.com","addresses":[{"street":"1 Main St","city":"Toronto"}],"password":"secret"}`)),
+		appcmdtesting.WithArgs(
+			"testdata/convert/filter/user.proto",
+			"--type",
+			"acme.v1.User",
+			"--from",
+			"-#format=json",
+			"--to",
+			"-#format=json",
+			"--exclude-fields",
+			"id",
+			"--redact-option",
+			"(acme.v1.sensitive)",
+			"--redact-option",
+			"debug_redact",
+		),
+	)
+}
+
+func TestConvertFieldMaskInvalidPath(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials(`invalid field path "addresses.zip": message "acme.v1.Address" has no field "zip"`),
+		appcmdtesting.WithStdin(strings.NewReader(`{"id":"1"}`)),
+		appcmdtesting.WithArgs(
+			"testdata/convert/filter/user.proto",
+			"--type",
+			"acme.v1.User",
+			"--from",
+			"-#format=json",
+			"--field-mask",
+			"addresses.zip",
+		),
+	)
+}
+
+func TestConvertDecodeRaw(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout(`1 (len): "1"
+2 (len) {
+  2 (len): "Oslo"
+}`),
+		appcmdtesting.WithStdin(strings.NewReader("\x0a\x011\x12\x06\x12\x04Oslo")),
+		appcmdtesting.WithArgs(
+			"--decode-raw",
+			"--from",
+			"-#format=binpb",
+		),
+	)
+}
+
+func TestConvertDecodeRawVarintDelimited(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout("1 (varint): 55\n---\n---\n1 (varint): 1"),
+		appcmdtesting.WithStdin(strings.NewReader("\x02\x08\x37\x00\x02\x08\x01")),
+		appcmdtesting.WithArgs(
+			"--decode-raw",
+			"--from",
+			"-#format=binpb,delimiter=varint",
+		),
+	)
+}
+
+func TestConvertDecodeRawInvalid(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStdout(`1 (varint): 55`),
+		appcmdtesting.WithExpectedStderrPartials("--from: invalid wire format at offset 3: unexpected EOF"),
+		appcmdtesting.WithStdin(strings.NewReader("\x08\x37\x12\x05")),
+		appcmdtesting.WithArgs(
+			"--decode-raw",
+			"--from",
+			"-#format=binpb",
+		),
+	)
+}
+
+func TestConvertDecodeRawNotBinpb(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("--from must be in the binpb format if --decode-raw is specified"),
+		appcmdtesting.WithStdin(strings.NewReader(`{"one":"55"}`)),
+		appcmdtesting.WithArgs(
+			"--decode-raw",
+			"--from",
+			"-#format=json",
+		),
+	)
+}
+
+func TestConvertDecodeRawWithType(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("--type cannot be specified if --decode-raw is specified"),
+		appcmdtesting.WithArgs(
+			"--decode-raw",
+			"--type",
+			"buf.Foo",
+		),
+	)
+}
+
+func TestConvertGuessType(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		appcmdtesting.WithExpectedStdout(`Type              Unrecognized Bytes  Recognized Fields
+guess.v1.User     0                   3
+guess.v1.Address  0                   2`),
+		appcmdtesting.WithStdin(strings.NewReader("\x0a\x011\x12\x06\x12\x04Oslo")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/guess/user.proto",
+			"--guess-type",
+			"--from",
+			"-#format=binpb",
+		),
+	)
+}
+
+func TestConvertGuessTypeVarintDelimited(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(0),
+		// The second message has an int64 id, which is an unknown field of guess.v1.User.
+		appcmdtesting.WithExpectedStdout(`Type            Unrecognized Bytes  Recognized Fields
+guess.v1.Event  0                   2`),
+		appcmdtesting.WithStdin(strings.NewReader("\x02\x08\x37\x02\x08\x01")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/guess/user.proto",
+			"--guess-type",
+			"--from",
+			"-#format=binpb,delimiter=varint",
+		),
+	)
+}
+
+func TestConvertGuessTypeNoMatch(t *testing.T) {
+	t.Parallel()
+	appcmdtesting.Run(
+		t,
+		testNewCommand,
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("no message type within the input matches the payload"),
+		appcmdtesting.WithStdin(strings.NewReader("\x18\x01")),
+		appcmdtesting.WithArgs(
+			"testdata/convert/guess/user.proto",
+			"--guess-type",
+			"--from",
+			"-#format=binpb",
+		),
+	)
+}
+
 func testNewCommand(use string) *appcmd.Command {
 	return NewCommand("convert", appext.NewBuilder("convert"))
 }
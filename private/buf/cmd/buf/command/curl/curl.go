@@ -22,6 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
@@ -62,6 +64,9 @@ const (
 	unixSocketFlagName          = "unix-socket"
 	http2PriorKnowledgeFlagName = "http2-prior-knowledge"
 	http3FlagName               = "http3"
+	compressionFlagName         = "compression"
+	acceptCompressionFlagName   = "accept-compression"
+	httpGetFlagName             = "http-get"
 
 	// TLS flags
 	keyFlagName           = "key"
@@ -73,13 +78,30 @@ const (
 	insecureFlagShortName = "k"
 
 	// Action flags
-	listServicesFlagName = "list-services"
-	listMethodsFlagName  = "list-methods"
+	listServicesFlagName     = "list-services"
+	listMethodsFlagName      = "list-methods"
+	interactiveFlagName      = "interactive"
+	describeFlagName         = "describe"
+	describeTemplateFlagName = "describe-template"
+
+	// Collection flags
+	collectionFlagName  = "collection"
+	runFlagName         = "run"
+	environmentFlagName = "env"
+
+	// Benchmark flags
+	benchFlagName            = "bench"
+	benchConcurrencyFlagName = "bench-concurrency"
+	benchRequestsFlagName    = "bench-requests"
+	benchDurationFlagName    = "bench-duration"
+	benchRPSFlagName         = "bench-rps"
+	benchFormatFlagName      = "bench-format"
 
 	// Timeout flags
 	noKeepAliveFlagName    = "no-keepalive"
 	keepAliveFlagName      = "keepalive-time"
 	connectTimeoutFlagName = "connect-timeout"
+	deadlineFlagName       = "deadline"
 
 	// Header and request body flags
 	userAgentFlagName      = "user-agent"
@@ -146,6 +168,38 @@ then the headers are read from stdin.
 If headers and the request body are both to be read from the same file (or both read from stdin),
 the file must include headers first, then a blank line, and then the request body.
 
+With the --interactive flag, the URL must be a base URL, and the command starts an interactive
+session instead of invoking a single RPC. Each line entered names a method to invoke, in the form
+<service>/<method>, followed by an optional JSON request. For client-streaming and bidi-streaming
+methods, each following line is sent as a request message, until .end is entered. Service, method,
+and request field names are completed with the tab key. All RPCs in the session share a single
+connection and server reflection stream.
+
+With the --bench flag, the RPC is invoked repeatedly with the same request, and a summary of
+latency percentiles, throughput, and error counts by code is printed instead of the responses.
+The number of concurrent RPCs, the total number of RPCs or the duration, and the target rate are
+set with the --bench-* flags. All RPCs share the transport configured by the other flags.
+
+With the --collection flag, the command runs the named requests of a YAML or JSON collection
+file against a base URL, and prints whether each of them passed. Each request has a path in the
+form <service>/<method>, and optional headers, data, and expectations on the response code and
+messages. An environment file given with --env supplies the base URL, headers, credentials, TLS
+settings, and variables, which are referenced in the collection and environment as ${NAME}, and
+fall back to environment variables. With the --run flag, only the named request is run, and its
+responses are printed. A collection looks like this:
+
+    requests:
+      - name: say-hello
+        path: connectrpc.eliza.v1.ElizaService/Say
+        headers:
+          Authorization: Bearer ${TOKEN}
+        data:
+          sentence: Hello, ${NAME}.
+        expect:
+          code: ok
+          responses:
+            - sentence: Hello...I'm glad you could drop by today.
+
 Examples:
 
 Issue a unary RPC to a plain-text (i.e. "h2c") gRPC server, where the schema for the service is
@@ -180,6 +234,30 @@ headers and request data are both in a heredoc:
     {"sentence": "If you were a fish, what of fish would you be?."}
     EOM
 
+Print the source of a method and its request and response messages, and then a JSON request
+for it with every field set to its default value, where the schema is in the current directory:
+
+    $ buf curl --schema . --describe foo.bar.v1.FooService/DoSomething
+    $ buf curl --schema . --describe foo.bar.v1.FooService/DoSomething --describe-template
+
+Start an interactive session with a server that supports reflection:
+
+    $ buf curl --interactive https://demo.connectrpc.com
+    > connectrpc.eliza.v1.ElizaService/Say {"sentence": "Hello."}
+
+Benchmark a unary RPC with 50 concurrent RPCs for 30 seconds, at a target rate of 1000 RPCs per
+second, printing the summary as JSON:
+
+    $ buf curl --bench --bench-concurrency 50 --bench-duration 30s --bench-rps 1000  \
+         --bench-format json --data '{"sentence": "Hello."}'                          \
+         https://demo.connectrpc.com/connectrpc.eliza.v1.ElizaService/Say
+
+Run all requests of a collection against the base URL of an environment, and then run a single
+request of it:
+
+    $ buf curl --collection requests.yaml --env staging.yaml
+    $ buf curl --collection requests.yaml --env staging.yaml --run say-hello
+
 Note that server reflection (i.e. use of the --reflect flag) does not work with HTTP 1.1 since the
 protocol relies on bidirectional streaming. If server reflection is used, the assumed URL for the
 reflection service is the same as the given URL, but with the last two elements removed and
@@ -213,6 +291,9 @@ type flags struct {
 	UnixSocket          string
 	HTTP2PriorKnowledge bool
 	HTTP3               bool
+	Compression         string
+	AcceptCompression   []string
+	HTTPGet             bool
 
 	// TLS
 	Key, Cert, CACert, ServerName string
@@ -221,11 +302,28 @@ type flags struct {
 
 	// Actions
 	ListServices, ListMethods bool
+	Interactive               bool
+	Describe                  string
+	DescribeTemplate          bool
+
+	// Collections
+	Collection  string
+	Run         string
+	Environment string
+
+	// Benchmark
+	Bench            bool
+	BenchConcurrency int
+	BenchRequests    int
+	BenchDuration    time.Duration
+	BenchRPS         float64
+	BenchFormat      string
 
 	// Timeouts
 	NoKeepAlive           bool
 	KeepAliveTimeSeconds  float64
 	ConnectTimeoutSeconds float64
+	DeadlineSeconds       float64
 
 	// Handling request and response data and metadata
 	UserAgent string
@@ -341,6 +439,39 @@ will be used with URLs with an http scheme, and protocol negotiation will be use
 choose either HTTP 1.1 or HTTP/2 for URLs with an https scheme. With this flag set,
 HTTP/3 is always used.`,
 	)
+	flagSet.StringVar(
+		&f.Compression,
+		compressionFlagName,
+		bufcurl.CompressionIdentity,
+		fmt.Sprintf(
+			`The compression to use for request messages. This can be one of %s. Responses compressed
+with this compression are always accepted`,
+			xstrings.SliceToHumanStringOrQuoted(bufcurl.AllCompressionNames),
+		),
+	)
+	flagSet.StringSliceVar(
+		&f.AcceptCompression,
+		acceptCompressionFlagName,
+		bufcurl.DefaultAcceptCompressionNames,
+		fmt.Sprintf(
+			`The compressions to accept for response messages. This flag may be specified more than once,
+or with a comma-separated list. Each value can be one of %s. If %q is given, it must be the only
+value, and responses are only accepted without compression`,
+			xstrings.SliceToHumanStringOrQuoted(bufcurl.AllCompressionNames),
+			bufcurl.CompressionIdentity,
+		),
+	)
+	flagSet.BoolVar(
+		&f.HTTPGet,
+		httpGetFlagName,
+		false,
+		fmt.Sprintf(
+			`If set, unary RPCs to methods with an idempotency_level of NO_SIDE_EFFECTS are sent as HTTP
+GET requests, with the request message in the query string. This flag may only be used with
+the %q protocol. Other RPCs are still sent as HTTP POST requests`,
+			connect.ProtocolConnect,
+		),
+	)
 
 	flagSet.BoolVar(
 		&f.NoKeepAlive,
@@ -362,6 +493,14 @@ will be disabled`,
 		`The time limit, in seconds, for a connection to be established with the server. There is
 no limit if this flag is not present`,
 	)
+	flagSet.Float64Var(
+		&f.DeadlineSeconds,
+		deadlineFlagName,
+		0,
+		`The time limit, in seconds, for each RPC to complete, including all of its streamed messages.
+The deadline is sent to the server, in the grpc-timeout header or the connect-timeout-ms header
+of the Connect protocol. There is no limit if this flag is not present`,
+	)
 
 	flagSet.StringVar(
 		&f.Key,
@@ -432,6 +571,110 @@ to provide the RPC schema, then the given URL must be a base URL, not including
 or method name. If the schema source is not server reflection, the URL is not used and
 may be omitted.`,
 	)
+	flagSet.StringVar(
+		&f.Describe,
+		describeFlagName,
+		"",
+		`When set, the command prints the Protobuf source of the given fully-qualified service,
+method, message, or enum, including comments when the schema has them, and then exits.
+Methods may be given as <service>/<method>. If server reflection is used to provide the RPC
+schema, then the given URL must be a base URL, not including a service or method name. If the
+schema source is not server reflection, the URL is not used and may be omitted.`,
+	)
+	flagSet.BoolVar(
+		&f.DescribeTemplate,
+		describeTemplateFlagName,
+		false,
+		fmt.Sprintf(`When set with --%s, the command prints a JSON request for the given method or
+message instead of its source, with every field set to its default value.`,
+			describeFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.Collection,
+		collectionFlagName,
+		"",
+		fmt.Sprintf(`Path to a YAML or JSON collection file of named requests to run instead of a single RPC.
+By default, all requests are run, and whether each one passed its expectations is printed.
+The URL, if given, must be a base URL, and takes precedence over the base URL of the
+environment given with --%s`,
+			environmentFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.Run,
+		runFlagName,
+		"",
+		fmt.Sprintf(`The name of the request in the --%s file to run. Its responses are printed as if the
+RPC was invoked directly`,
+			collectionFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.Environment,
+		environmentFlagName,
+		"",
+		fmt.Sprintf(`Path to a YAML or JSON environment file for the --%s file, which supplies the base URL,
+headers, credentials, TLS settings, and variables for its requests. Flags take precedence
+over the settings of the environment`,
+			collectionFlagName,
+		),
+	)
+	flagSet.BoolVar(
+		&f.Interactive,
+		interactiveFlagName,
+		false,
+		`When set, the command starts an interactive session that reads methods to invoke and their
+requests from stdin. The given URL must be a base URL, not including a service or method name.`,
+	)
+
+	flagSet.BoolVar(
+		&f.Bench,
+		benchFlagName,
+		false,
+		fmt.Sprintf(`When set, the command invokes the RPC repeatedly to benchmark the server, and prints
+a summary of latencies, throughput, and errors instead of the responses. Unless --%s
+or --%s is set, %d RPCs are sent.`,
+			benchRequestsFlagName, benchDurationFlagName, bufcurl.DefaultBenchmarkRequests,
+		),
+	)
+	flagSet.IntVar(
+		&f.BenchConcurrency,
+		benchConcurrencyFlagName,
+		10,
+		`The number of RPCs that are in flight at once when benchmarking.`,
+	)
+	flagSet.IntVar(
+		&f.BenchRequests,
+		benchRequestsFlagName,
+		0,
+		fmt.Sprintf(`The total number of RPCs to send when benchmarking. If --%s is also set, the
+benchmark stops at whichever limit is reached first.`,
+			benchDurationFlagName,
+		),
+	)
+	flagSet.DurationVar(
+		&f.BenchDuration,
+		benchDurationFlagName,
+		0,
+		`The amount of time to send RPCs for when benchmarking, such as "30s".`,
+	)
+	flagSet.Float64Var(
+		&f.BenchRPS,
+		benchRPSFlagName,
+		0,
+		`The target number of RPCs per second when benchmarking, at most one billion. If zero,
+RPCs are sent as fast as possible.`,
+	)
+	flagSet.StringVar(
+		&f.BenchFormat,
+		benchFormatFlagName,
+		bufcurl.BenchmarkFormatText,
+		fmt.Sprintf(
+			`The format of the benchmark summary. This can be one of %s`,
+			xstrings.SliceToHumanStringOrQuoted(bufcurl.AllBenchmarkFormatStrings),
+		),
+	)
 
 	flagSet.StringVarP(
 		&f.UserAgent,
@@ -542,7 +785,13 @@ func (f *flags) validate(hasURL, isSecure bool) error {
 		return fmt.Errorf("must specify --%s if --%s is false", schemaFlagName, reflectFlagName)
 	}
 
-	if !hasURL && ((!f.ListServices && !f.ListMethods) || f.Reflect) {
+	if f.Collection == "" && (f.Run != "" || f.Environment != "") {
+		return fmt.Errorf("flags --%s and --%s require --%s", runFlagName, environmentFlagName, collectionFlagName)
+	}
+	if !hasURL && f.Collection != "" {
+		return appcmd.NewInvalidArgumentError("URL positional argument is missing and the --env file has no base_url")
+	}
+	if !hasURL && ((!f.ListServices && !f.ListMethods && f.Describe == "") || f.Reflect) {
 		// If we are trying to use reflection for anything or if we are invoking an RPC (which
 		// means we aren't listing services, listing methods, or describing an element), then
 		// a URL is required.
@@ -552,6 +801,78 @@ func (f *flags) validate(hasURL, isSecure bool) error {
 	if f.ListServices && f.ListMethods {
 		return fmt.Errorf("flags --%s and --%s are mutually exclusive", listServicesFlagName, listMethodsFlagName)
 	}
+	if f.Describe != "" && (f.ListServices || f.ListMethods) {
+		return fmt.Errorf("flag --%s cannot be used with --%s or --%s", describeFlagName, listServicesFlagName, listMethodsFlagName)
+	}
+	if f.DescribeTemplate && f.Describe == "" {
+		return fmt.Errorf("flag --%s requires --%s", describeTemplateFlagName, describeFlagName)
+	}
+	if f.Collection != "" {
+		if f.ListServices || f.ListMethods || f.Describe != "" || f.Interactive || f.Bench {
+			return fmt.Errorf(
+				"flag --%s cannot be used with --%s, --%s, --%s, --%s, or --%s",
+				collectionFlagName, listServicesFlagName, listMethodsFlagName, describeFlagName, interactiveFlagName, benchFlagName,
+			)
+		}
+		if f.Data != "" {
+			return fmt.Errorf("flag --%s cannot be used with --%s", collectionFlagName, dataFlagName)
+		}
+	}
+	if f.Interactive {
+		if !hasURL {
+			return appcmd.NewInvalidArgumentError("URL positional argument is missing")
+		}
+		if f.ListServices || f.ListMethods || f.Describe != "" {
+			return fmt.Errorf(
+				"flag --%s cannot be used with --%s, --%s, or --%s",
+				interactiveFlagName, listServicesFlagName, listMethodsFlagName, describeFlagName,
+			)
+		}
+		if f.Data != "" {
+			return fmt.Errorf("flag --%s cannot be used with --%s", interactiveFlagName, dataFlagName)
+		}
+	}
+	if f.Bench {
+		if f.ListServices || f.ListMethods || f.Describe != "" || f.Interactive {
+			return fmt.Errorf(
+				"flag --%s cannot be used with --%s, --%s, --%s, or --%s",
+				benchFlagName, listServicesFlagName, listMethodsFlagName, describeFlagName, interactiveFlagName,
+			)
+		}
+		if f.Verbose {
+			return fmt.Errorf("flag --%s cannot be used with --%s", benchFlagName, verboseFlagName)
+		}
+		if f.BenchConcurrency <= 0 {
+			return fmt.Errorf("--%s value must be positive", benchConcurrencyFlagName)
+		}
+		if f.BenchRequests < 0 {
+			return fmt.Errorf("--%s value must not be negative", benchRequestsFlagName)
+		}
+		if f.BenchDuration < 0 {
+			return fmt.Errorf("--%s value must not be negative", benchDurationFlagName)
+		}
+		if f.BenchRPS < 0 {
+			return fmt.Errorf("--%s value must not be negative", benchRPSFlagName)
+		}
+		if math.IsNaN(f.BenchRPS) || f.BenchRPS > bufcurl.MaxBenchmarkRPS {
+			return fmt.Errorf("--%s value must not be greater than %d", benchRPSFlagName, int64(bufcurl.MaxBenchmarkRPS))
+		}
+		if !slices.Contains(bufcurl.AllBenchmarkFormatStrings, f.BenchFormat) {
+			return fmt.Errorf(
+				"--%s value must be one of %s",
+				benchFormatFlagName,
+				xstrings.SliceToHumanStringOrQuoted(bufcurl.AllBenchmarkFormatStrings),
+			)
+		}
+	} else if f.flagSet.Changed(benchConcurrencyFlagName) || f.flagSet.Changed(benchRequestsFlagName) ||
+		f.flagSet.Changed(benchDurationFlagName) || f.flagSet.Changed(benchRPSFlagName) ||
+		f.flagSet.Changed(benchFormatFlagName) {
+		return fmt.Errorf(
+			"benchmark flags (--%s, --%s, --%s, --%s, --%s) should not be used unless --%s is set",
+			benchConcurrencyFlagName, benchRequestsFlagName, benchDurationFlagName, benchRPSFlagName,
+			benchFormatFlagName, benchFlagName,
+		)
+	}
 
 	if (f.Key != "" || f.Cert != "" || f.CACert != "" || f.ServerName != "" || f.flagSet.Changed(insecureFlagName)) &&
 		!isSecure {
@@ -629,6 +950,12 @@ func (f *flags) validate(hasURL, isSecure bool) error {
 	if f.ConnectTimeoutSeconds < 0 || (f.ConnectTimeoutSeconds == 0 && f.flagSet.Changed(connectTimeoutFlagName)) {
 		return fmt.Errorf("--%s value must be positive", connectTimeoutFlagName)
 	}
+	if f.DeadlineSeconds < 0 || (f.DeadlineSeconds == 0 && f.flagSet.Changed(deadlineFlagName)) {
+		return fmt.Errorf("--%s value must be positive", deadlineFlagName)
+	}
+	if f.HTTPGet && f.Protocol != connect.ProtocolConnect {
+		return fmt.Errorf("--%s can only be used with --%s=%s", httpGetFlagName, protocolFlagName, connect.ProtocolConnect)
+	}
 
 	var dataFile string
 	if strings.HasPrefix(f.Data, "@") {
@@ -654,10 +981,54 @@ func (f *flags) validate(hasURL, isSecure bool) error {
 			return fmt.Errorf("--%s and --%s flags cannot indicate the same source", dataFlagName, reflectHeaderFlagName)
 		}
 	}
+	if f.Interactive {
+		_, headersAreStdin := headerFiles["-"]
+		_, reflectHeadersAreStdin := reflectHeaderFiles["-"]
+		if schemaIsStdin || headersAreStdin || reflectHeadersAreStdin {
+			return fmt.Errorf("flag --%s reads from stdin, so no other flags can indicate reading from stdin", interactiveFlagName)
+		}
+	}
 
 	return nil
 }
 
+// applyEnvironment sets the credential and TLS flags that were not set on the command
+// line from the environment of a collection.
+func (f *flags) applyEnvironment(environment *bufcurl.Environment, lookup func(string) (string, bool)) error {
+	setFlag := func(flagName string, target *string, value string) error {
+		if value == "" || f.flagSet.Changed(flagName) {
+			return nil
+		}
+		interpolated, err := bufcurl.Interpolate(value, lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %w", flagName, err)
+		}
+		*target = interpolated
+		return nil
+	}
+	if err := setFlag(userFlagName, &f.User, environment.User); err != nil {
+		return err
+	}
+	if tls := environment.TLS; tls != nil {
+		if err := setFlag(caCertFlagName, &f.CACert, tls.CACert); err != nil {
+			return err
+		}
+		if err := setFlag(certFlagName, &f.Cert, tls.Cert); err != nil {
+			return err
+		}
+		if err := setFlag(keyFlagName, &f.Key, tls.Key); err != nil {
+			return err
+		}
+		if err := setFlag(serverNameFlagName, &f.ServerName, tls.ServerName); err != nil {
+			return err
+		}
+		if tls.Insecure && !f.flagSet.Changed(insecureFlagName) {
+			f.Insecure = true
+		}
+	}
+	return nil
+}
+
 func (f *flags) determineCredentials(
 	ctx context.Context,
 	container app.Container,
@@ -854,10 +1225,34 @@ func parseEndpointURL(urlArg string) (service, method, baseURL string, err error
 }
 
 func run(ctx context.Context, container appext.Container, f *flags) (err error) {
+	var collection *bufcurl.Collection
+	var environment *bufcurl.Environment
+	var lookup func(string) (string, bool)
+	if f.Collection != "" {
+		if collection, err = bufcurl.ReadCollection(f.Collection); err != nil {
+			return err
+		}
+		environment = &bufcurl.Environment{}
+		if f.Environment != "" {
+			if environment, err = bufcurl.ReadEnvironment(f.Environment); err != nil {
+				return err
+			}
+		}
+		lookup = environment.VariableLookup(container)
+		if err := f.applyEnvironment(environment, lookup); err != nil {
+			return err
+		}
+	}
 	var urlArg, host string
 	var isSecure bool
 	if container.NumArgs() != 0 {
 		urlArg = container.Arg(0)
+	} else if environment != nil && environment.BaseURL != "" {
+		if urlArg, err = bufcurl.Interpolate(environment.BaseURL, lookup); err != nil {
+			return fmt.Errorf("base_url: %w", err)
+		}
+	}
+	if urlArg != "" {
 		var err error
 		host, isSecure, err = verifyEndpointURL(urlArg)
 		if err != nil {
@@ -869,7 +1264,7 @@ func run(ctx context.Context, container appext.Container, f *flags) (err error)
 	}
 	var service, method, baseURL string
 	switch {
-	case f.ListServices || f.ListMethods:
+	case f.ListServices || f.ListMethods || f.Describe != "" || f.Interactive || f.Collection != "":
 		baseURL = urlArg
 	default:
 		service, method, baseURL, err = parseEndpointURL(urlArg)
@@ -898,6 +1293,18 @@ func run(ctx context.Context, container appext.Container, f *flags) (err error)
 		// is drained.
 		clientOptions = append(clientOptions, connect.WithInterceptors(bufcurl.TraceTrailersInterceptor(verbosePrinter)))
 	}
+	// The options for invoking RPCs are not used for server reflection.
+	compressionOptions, err := bufcurl.CompressionOptions(verbosePrinter, f.Compression, f.AcceptCompression)
+	if err != nil {
+		return err
+	}
+	invokeOptions := append(slices.Clone(clientOptions), compressionOptions...)
+	if f.HTTPGet {
+		invokeOptions = append(invokeOptions, connect.WithHTTPGet())
+	}
+	if f.DeadlineSeconds != 0 {
+		invokeOptions = append(invokeOptions, connect.WithInterceptors(bufcurl.DeadlineInterceptor(secondsToDuration(f.DeadlineSeconds))))
+	}
 
 	dataSource := "(argument)"
 	var dataFileReference string
@@ -923,6 +1330,18 @@ func run(ctx context.Context, container appext.Container, f *flags) (err error)
 	if len(requestHeaders.Values("user-agent")) == 0 {
 		requestHeaders.Set("user-agent", userAgent)
 	}
+	if environment != nil {
+		for _, name := range slices.Sorted(maps.Keys(environment.Headers)) {
+			if len(requestHeaders.Values(name)) > 0 {
+				continue
+			}
+			value, err := bufcurl.Interpolate(environment.Headers[name], lookup)
+			if err != nil {
+				return fmt.Errorf("header %q: %w", name, err)
+			}
+			requestHeaders.Set(name, value)
+		}
+	}
 	var basicCreds *string
 	if len(requestHeaders.Values("authorization")) == 0 {
 		creds, err := f.determineCredentials(ctx, container, verbosePrinter, host)
@@ -1065,6 +1484,50 @@ func run(ctx context.Context, container appext.Container, f *flags) (err error)
 			}
 		}
 		return nil
+	case f.Collection != "":
+		transport, err := makeTransportOnce()
+		if err != nil {
+			return err
+		}
+		newInvoker := func(container appext.Container, methodDescriptor protoreflect.MethodDescriptor, url string, output io.Writer) bufcurl.Invoker {
+			return bufcurl.NewInvoker(container, verbosePrinter, methodDescriptor, res, f.EmitDefaults, transport, invokeOptions, url, output)
+		}
+		if f.Run == "" {
+			return bufcurl.RunCollection(ctx, container, newInvoker, res, baseURL, requestHeaders, collection, lookup)
+		}
+		request, err := collection.Request(f.Run)
+		if err != nil {
+			return err
+		}
+		return bufcurl.RunCollectionRequest(ctx, container, newInvoker, res, baseURL, requestHeaders, request, lookup, output)
+	case f.Describe != "":
+		descriptor, err := bufcurl.ResolveDescribeDescriptor(res, f.Describe)
+		if err != nil {
+			return err
+		}
+		if f.DescribeTemplate {
+			template, err := bufcurl.NewRequestTemplate(res, descriptor)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(container.Stdout(), "%s\n", template)
+			return err
+		}
+		source, err := bufcurl.DescribeDescriptor(descriptor)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(container.Stdout(), source)
+		return err
+	case f.Interactive:
+		transport, err := makeTransportOnce()
+		if err != nil {
+			return err
+		}
+		newInvoker := func(container appext.Container, methodDescriptor protoreflect.MethodDescriptor, url string, output io.Writer) bufcurl.Invoker {
+			return bufcurl.NewInvoker(container, verbosePrinter, methodDescriptor, res, f.EmitDefaults, transport, invokeOptions, url, output)
+		}
+		return bufcurl.RunREPL(ctx, container, res, baseURL, requestHeaders, newInvoker)
 	default:
 		// Invoke RPC
 		methodDescriptor, err := bufcurl.ResolveMethodDescriptor(res, service, method)
@@ -1075,7 +1538,38 @@ func run(ctx context.Context, container appext.Container, f *flags) (err error)
 		if err != nil {
 			return err
 		}
-		invoker := bufcurl.NewInvoker(container, verbosePrinter, methodDescriptor, res, f.EmitDefaults, transport, clientOptions, urlArg, output)
+		if f.Bench {
+			var data []byte
+			if dataReader != nil {
+				// The request data is read once, and sent in every RPC.
+				if data, err = io.ReadAll(dataReader); err != nil {
+					return err
+				}
+			}
+			newInvoker := func(container appext.Container, methodDescriptor protoreflect.MethodDescriptor, url string, output io.Writer) bufcurl.Invoker {
+				return bufcurl.NewInvoker(container, verbosePrinter, methodDescriptor, res, f.EmitDefaults, transport, invokeOptions, url, output)
+			}
+			result, err := bufcurl.RunBenchmark(
+				ctx,
+				container,
+				newInvoker,
+				methodDescriptor,
+				urlArg,
+				data,
+				requestHeaders,
+				bufcurl.BenchmarkConfig{
+					Concurrency: f.BenchConcurrency,
+					Requests:    f.BenchRequests,
+					Duration:    f.BenchDuration,
+					RPS:         f.BenchRPS,
+				},
+			)
+			if err != nil {
+				return err
+			}
+			return bufcurl.WriteBenchmarkResult(output, result, f.BenchFormat)
+		}
+		invoker := bufcurl.NewInvoker(container, verbosePrinter, methodDescriptor, res, f.EmitDefaults, transport, invokeOptions, urlArg, output)
 		return invoker.Invoke(ctx, dataSource, dataReader, requestHeaders)
 	}
 }
@@ -1139,6 +1633,8 @@ func makeHTTPRoundTripper(f *flags, isSecure bool, authority string, printer ver
 			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
 				return dialTLSFunc(ctx, network, addr)
 			},
+			// Compression is negotiated by the RPC protocol, per --accept-compression.
+			DisableCompression: true,
 		}
 	case f.HTTP2PriorKnowledge:
 		transport = &http2.Transport{
@@ -1146,14 +1642,23 @@ func makeHTTPRoundTripper(f *flags, isSecure bool, authority string, printer ver
 			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
 				return dialFunc(ctx, network, addr)
 			},
+			DisableCompression: true,
 		}
 	default:
+		maxIdleConns := 1
+		if f.Bench {
+			// Keep a connection alive for every concurrent RPC, so that HTTP/1.1
+			// connections are reused by the benchmark.
+			maxIdleConns = f.BenchConcurrency
+		}
 		transport = &http.Transport{
-			Proxy:             http.ProxyFromEnvironment,
-			DialContext:       dialFunc,
-			DialTLSContext:    dialTLSFunc,
-			ForceAttemptHTTP2: true,
-			MaxIdleConns:      1,
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialFunc,
+			DialTLSContext:      dialTLSFunc,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        maxIdleConns,
+			MaxIdleConnsPerHost: maxIdleConns,
+			DisableCompression:  true,
 		}
 	}
 	return transport, nil
@@ -1200,7 +1705,7 @@ func makeHTTP3RoundTripper(f *flags, authority string, printer verbose.Printer)
 		EnableDatagrams:        false,
 		AdditionalSettings:     map[uint64]uint64{},
 		MaxResponseHeaderBytes: 0,
-		DisableCompression:     false,
+		DisableCompression:     true,
 	}
 	return roundTripper, nil
 }
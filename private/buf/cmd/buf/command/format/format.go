@@ -65,6 +65,8 @@ func NewCommand(
 		Long: `
 By default, the source is the current directory and the formatted content is written to stdout.
 
+The style can be configured for each module with the format section of a v2 buf.yaml file.
+
 Examples:
 
 Write the current directory's formatted content to stdout:
@@ -315,10 +317,24 @@ func run(
 		bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFilesForTargetModules(workspace),
 	)
 	originalReadBucket := bufmodule.ModuleReadBucketToStorageReadBucket(moduleReadBucket)
-	formattedReadBucket, err := bufformat.FormatBucket(ctx, originalReadBucket)
-	if err != nil {
-		return err
+	// Each target Module is formatted with its own FormatConfig.
+	var formattedReadBuckets []storage.ReadBucket
+	for _, module := range bufmodule.ModuleSetTargetModules(workspace) {
+		moduleFormattedReadBucket, err := bufformat.FormatBucket(
+			ctx,
+			bufmodule.ModuleReadBucketToStorageReadBucket(
+				bufmodule.ModuleReadBucketWithOnlyTargetFiles(
+					bufmodule.ModuleReadBucketWithOnlyProtoFiles(module),
+				),
+			),
+			bufformat.FormatWithConfig(workspace.GetFormatConfigForOpaqueID(module.OpaqueID())),
+		)
+		if err != nil {
+			return err
+		}
+		formattedReadBuckets = append(formattedReadBuckets, moduleFormattedReadBucket)
 	}
+	formattedReadBucket := storage.MultiReadBucket(formattedReadBuckets...)
 
 	diffBuffer := bytes.NewBuffer(nil)
 	changedPaths, err := storage.DiffWithFilenames(
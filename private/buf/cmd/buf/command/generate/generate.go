@@ -16,12 +16,15 @@ package generate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
@@ -32,6 +35,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginstore"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/spf13/pflag"
 )
@@ -51,6 +55,14 @@ const (
 	typeFlagName                = "type"
 	typeDeprecatedFlagName      = "include-types"
 	excludeTypeFlagName         = "exclude-type"
+	checkFlagName               = "check"
+	disableCacheFlagName        = "disable-cache"
+	cacheStatsFlagName          = "cache-stats"
+	pruneCacheFlagName          = "prune-cache"
+	watchFlagName               = "watch"
+	watchDebounceFlagName       = "watch-debounce"
+	reportFlagName              = "report"
+	reportFormatFlagName        = "report-format"
 )
 
 // NewCommand returns a new Command.
@@ -144,6 +156,12 @@ func NewCommand(
         # Optional.
         strategy: directory
 
+        # A local path with the ".wasm" extension is a WebAssembly plugin. It is run in a sandbox
+        # without access to the environment, file system, or network, and gives the same output on
+        # every platform. Compiled plugins are cached.
+      - local: plugins/protoc-gen-example.wasm
+        out: gen/example
+
         # "protoc_builtin" specifies a plugin that comes with protoc, without the "protoc-gen-" prefix.
       - protoc_builtin: java
         out: gen/java
@@ -404,6 +422,14 @@ type flags struct {
 	Types           []string
 	TypesDeprecated []string
 	ExcludeTypes    []string
+	Check           bool
+	DisableCache    bool
+	CacheStats      bool
+	PruneCache      time.Duration
+	Watch           bool
+	WatchDebounce   time.Duration
+	Report          string
+	ReportFormat    string
 	// special
 	InputHashtag string
 }
@@ -456,10 +482,72 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		errorFormatFlagName,
 		"text",
 		fmt.Sprintf(
-			"The format for build errors, printed to stderr. Must be one of %s",
+			"The format for build errors printed to stderr, and for out-of-date files printed to stdout with --%s. Must be one of %s",
+			checkFlagName,
 			xstrings.SliceToString(bufanalysis.AllFormatStrings),
 		),
 	)
+	flagSet.BoolVar(
+		&f.Check,
+		checkFlagName,
+		false,
+		fmt.Sprintf(
+			`Check that the generated files on disk are up to date without writing or deleting any files. Prints the generated files that are out of date or missing and exits with a non-zero exit code if there are any. If the outputs are cleaned with --%s or clean in the generation template, files in the outputs that are not generated are also printed`,
+			deleteOutsFlagName,
+		),
+	)
+	flagSet.BoolVar(
+		&f.DisableCache,
+		disableCacheFlagName,
+		false,
+		`Do not read or write the generation cache. By default, the responses of local plugins and of remote plugins pinned to a version and revision are cached, and plugins are not run if their requests have not changed since they were last cached. Local plugins are identified by the path, size, and modification time of their executable only, so set this flag if a wrapper script or shim, or the files or environment variables it uses, have changed`,
+	)
+	flagSet.BoolVar(
+		&f.CacheStats,
+		cacheStatsFlagName,
+		false,
+		`Print the number of plugin invocations that were served from the generation cache and the number that were not to stderr`,
+	)
+	flagSet.DurationVar(
+		&f.PruneCache,
+		pruneCacheFlagName,
+		0,
+		`Prior to generation, delete the entries of the generation cache that have not been used within the given duration, such as "168h"`,
+	)
+	flagSet.BoolVar(
+		&f.Watch,
+		watchFlagName,
+		false,
+		fmt.Sprintf(
+			`Keep running and generate again whenever the generation template, or the .proto files or configuration of local inputs change. Errors are printed without exiting. Only the images of the inputs with changed files are built again, and inputs that are not watched, such as modules on the BSR, are only built again when the generation template changes. Unless --%s is set, plugins are only run for the files whose CodeGeneratorRequests changed`,
+			disableCacheFlagName,
+		),
+	)
+	flagSet.DurationVar(
+		&f.WatchDebounce,
+		watchDebounceFlagName,
+		500*time.Millisecond,
+		fmt.Sprintf(
+			`With --%s, the duration to wait for changes to stop before generating again`,
+			watchFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.Report,
+		reportFlagName,
+		"",
+		`Write a report of every plugin invocation to the given file, or to stdout if "-". The report includes whether each plugin is local or remote, whether it was cached, the generated files and their sizes, and the insertion points used. For local plugins, it also includes the wall time and what the plugin wrote to stderr. Remote plugins are invoked with a single request per remote, so their wall times are not known`,
+	)
+	flagSet.StringVar(
+		&f.ReportFormat,
+		reportFormatFlagName,
+		bufgen.ReportFormatText,
+		fmt.Sprintf(
+			`The format of the report written with --%s. The text format lists the plugins from slowest to fastest, while the json format also includes the generated files. Must be one of %s`,
+			reportFlagName,
+			xstrings.SliceToString(bufgen.AllReportFormatStrings),
+		),
+	)
 	flagSet.StringVar(
 		&f.Config,
 		configFlagName,
@@ -503,6 +591,25 @@ func run(
 		// only makes sense in the context of including imports.
 		return appcmd.NewInvalidArgumentErrorf("Cannot set --%s to true without setting --%s to true", includeWKTFlagName, includeImportsFlagName)
 	}
+	if flags.DisableCache && (flags.CacheStats || flags.PruneCache != 0) {
+		return appcmd.NewInvalidArgumentErrorf("Cannot set --%s or --%s with --%s", cacheStatsFlagName, pruneCacheFlagName, disableCacheFlagName)
+	}
+	if flags.PruneCache < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", pruneCacheFlagName)
+	}
+	if flags.Watch && flags.Check {
+		return appcmd.NewInvalidArgumentErrorf("Cannot set --%s with --%s", watchFlagName, checkFlagName)
+	}
+	if !slices.Contains(bufgen.AllReportFormatStrings, flags.ReportFormat) {
+		return appcmd.NewInvalidArgumentErrorf(
+			"--%s must be one of %s",
+			reportFormatFlagName,
+			xstrings.SliceToString(bufgen.AllReportFormatStrings),
+		)
+	}
+	if flags.WatchDebounce < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be positive", watchDebounceFlagName)
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, "")
 	if err != nil {
 		return err
@@ -525,65 +632,322 @@ func run(
 	if err != nil {
 		return err
 	}
-	bufGenYAMLFile, err := readBufGenYAMLFile(ctx, storageosProvider, flags.Template)
+	var responseStore bufprotopluginstore.ResponseStore
+	var statsResponseStore *statsResponseStore
+	if !flags.DisableCache {
+		responseStore, err = bufcli.NewGenerateResponseStore(container)
+		if err != nil {
+			return err
+		}
+		if flags.PruneCache != 0 {
+			count, err := responseStore.Prune(ctx, time.Now().Add(-flags.PruneCache))
+			if err != nil {
+				return err
+			}
+			logger.DebugContext(ctx, "pruned generation cache", slog.Int("count", count))
+		}
+		if flags.CacheStats {
+			statsResponseStore = newStatsResponseStore(responseStore)
+			responseStore = statsResponseStore
+		}
+	}
+	wasmRuntime, err := bufcli.NewWasmRuntime(ctx, container)
 	if err != nil {
 		return err
 	}
-	images, err := getInputImages(
-		ctx,
+	defer func() {
+		retErr = errors.Join(retErr, wasmRuntime.Close(ctx))
+	}()
+	// The same generator is used for every generation in watch mode.
+	generator := bufgen.NewGenerator(
 		logger,
-		controller,
-		input,
-		bufGenYAMLFile,
-		flags.Config,
-		flags.Paths,
-		flags.ExcludePaths,
-		append(flags.Types, flags.TypesDeprecated...),
-		flags.ExcludeTypes,
+		storageosProvider,
+		wasmRuntime,
+		clientConfig,
 	)
-	if err != nil {
-		return err
-	}
-	generateOptions := []bufgen.GenerateOption{
-		bufgen.GenerateWithBaseOutDirPath(flags.BaseOutDirPath),
+	// The paths to watch are updated by every generation, as they depend on the
+	// generation template.
+	var currentWatchPaths *watchPaths
+	// The images of the inputs are kept between generations with --watch, and only
+	// the images of the inputs with changed files are built again.
+	var imageCache *watchImageCache
+	if flags.Watch {
+		imageCache = newWatchImageCache()
 	}
-	if flags.DeleteOuts != nil {
-		generateOptions = append(
-			generateOptions,
-			bufgen.GenerateWithDeleteOuts(*flags.DeleteOuts),
+	// changedPaths are the paths of the files that changed since the last generation,
+	// and are nil for the first generation.
+	runGenerate := func(ctx context.Context, changedPaths []string) error {
+		if imageCache != nil {
+			// The changed paths are within the paths that were watched when they changed.
+			imageCache.invalidate(changedPaths, currentWatchPaths)
+		}
+		bufGenYAMLFile, err := readBufGenYAMLFile(ctx, storageosProvider, flags.Template)
+		if err != nil {
+			if flags.Watch && currentWatchPaths == nil {
+				// Watch the generation template at least, so that fixing it triggers a generation.
+				currentWatchPaths, _ = getWatchPaths(flags, input, nil)
+			}
+			return err
+		}
+		if flags.Watch {
+			currentWatchPaths, err = getWatchPaths(flags, input, bufGenYAMLFile)
+			if err != nil {
+				return err
+			}
+		}
+		images, err := getInputImages(
+			ctx,
+			logger,
+			controller,
+			input,
+			bufGenYAMLFile,
+			flags.Config,
+			flags.Paths,
+			flags.ExcludePaths,
+			append(flags.Types, flags.TypesDeprecated...),
+			flags.ExcludeTypes,
+			imageCache,
 		)
-	}
-	if flags.IncludeImportsOverride != nil {
-		generateOptions = append(
-			generateOptions,
-			bufgen.GenerateWithIncludeImportsOverride(*flags.IncludeImportsOverride),
+		if err != nil {
+			return err
+		}
+		generateOptions := []bufgen.GenerateOption{
+			bufgen.GenerateWithBaseOutDirPath(flags.BaseOutDirPath),
+			bufgen.GenerateWithCheck(flags.Check),
+		}
+		if flags.DeleteOuts != nil {
+			generateOptions = append(
+				generateOptions,
+				bufgen.GenerateWithDeleteOuts(*flags.DeleteOuts),
+			)
+		}
+		if flags.IncludeImportsOverride != nil {
+			generateOptions = append(
+				generateOptions,
+				bufgen.GenerateWithIncludeImportsOverride(*flags.IncludeImportsOverride),
+			)
+		}
+		if flags.IncludeWKTOverride != nil {
+			generateOptions = append(
+				generateOptions,
+				bufgen.GenerateWithIncludeWellKnownTypesOverride(*flags.IncludeWKTOverride),
+			)
+		}
+		if responseStore != nil {
+			generateOptions = append(
+				generateOptions,
+				bufgen.GenerateWithResponseStore(responseStore),
+			)
+		}
+		var pluginReports []bufgen.PluginReport
+		if flags.Report != "" {
+			generateOptions = append(
+				generateOptions,
+				bufgen.GenerateWithPluginReportFunc(
+					func(pluginReport bufgen.PluginReport) {
+						pluginReports = append(pluginReports, pluginReport)
+					},
+				),
+			)
+		}
+		err = generator.Generate(
+			ctx,
+			container,
+			bufGenYAMLFile.GenerateConfig(),
+			images,
+			generateOptions...,
 		)
+		// Write the reports of the plugins that ran even if generation failed for a
+		// later image, or the generated files are out of date with --check.
+		if flags.Report != "" && (err == nil || len(pluginReports) > 0) {
+			if err := writePluginReports(container, flags.Report, pluginReports, flags.ReportFormat); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			if statsResponseStore != nil {
+				// Print the stats even if the generated files are out of date with --check.
+				if err := statsResponseStore.printStats(container.Stderr()); err != nil {
+					return err
+				}
+			}
+			var fileAnnotationSet bufanalysis.FileAnnotationSet
+			if flags.Check && errors.As(err, &fileAnnotationSet) {
+				if err := bufanalysis.PrintFileAnnotationSet(
+					container.Stdout(),
+					fileAnnotationSet,
+					flags.ErrorFormat,
+				); err != nil {
+					return err
+				}
+				return bufctl.ErrFileAnnotation
+			}
+			return err
+		}
+		if statsResponseStore != nil {
+			return statsResponseStore.printStats(container.Stderr())
+		}
+		return nil
 	}
-	if flags.IncludeWKTOverride != nil {
-		generateOptions = append(
-			generateOptions,
-			bufgen.GenerateWithIncludeWellKnownTypesOverride(*flags.IncludeWKTOverride),
-		)
+	if !flags.Watch {
+		return runGenerate(ctx, nil)
 	}
-	return bufgen.NewGenerator(
-		logger,
-		storageosProvider,
-		clientConfig,
-	).Generate(
+	generateAndPrintError := func(ctx context.Context, changedPaths []string) {
+		err := runGenerate(ctx, changedPaths)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		// Build errors were already printed by the controller with the error format.
+		if errors.Is(err, bufctl.ErrFileAnnotation) {
+			return
+		}
+		_, _ = fmt.Fprintf(container.Stderr(), "Failure: %v\n", err)
+	}
+	generateAndPrintError(ctx, nil)
+	if currentWatchPaths == nil || len(currentWatchPaths.paths()) == 0 {
+		return errors.New("no local inputs or configuration files to watch")
+	}
+	logger.InfoContext(ctx, "watching for changes", slog.Any("paths", currentWatchPaths.paths()))
+	return newWatcher(defaultWatchPollInterval, flags.WatchDebounce).watch(
 		ctx,
-		container,
-		bufGenYAMLFile.GenerateConfig(),
-		images,
-		generateOptions...,
+		func() ([]string, []string) {
+			return currentWatchPaths.paths(), currentWatchPaths.skipDirPaths
+		},
+		generateAndPrintError,
 	)
 }
 
+// writePluginReports writes the PluginReports to the file at the path, or to stdout
+// if the path is "-".
+func writePluginReports(
+	container appext.Container,
+	path string,
+	pluginReports []bufgen.PluginReport,
+	format string,
+) (retErr error) {
+	if path == "-" {
+		return bufgen.WritePluginReports(container.Stdout(), pluginReports, format)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, file.Close())
+	}()
+	return bufgen.WritePluginReports(file, pluginReports, format)
+}
+
+// getWatchPaths returns the paths to watch with --watch.
+//
+// The generation template and the module configuration are watched if they are
+// files. The workspace roots of local directory and proto file inputs are watched,
+// while other inputs, such as modules on the BSR and git repositories, are not. If
+// bufGenYAMLFile is nil, only the input from the command line is considered.
+//
+// The out directories of the plugins are skipped, as they are written to by every
+// generation.
+func getWatchPaths(flags *flags, input string, bufGenYAMLFile bufconfig.BufGenYAMLFile) (*watchPaths, error) {
+	var configFilePaths []string
+	// The workspace roots of inputs are searched for up to the directory of the
+	// generation template.
+	templateDirPath := "."
+	switch {
+	case flags.Template == "":
+		configFilePaths = append(configFilePaths, defaultTemplatePath)
+	case isConfigFilePath(flags.Template):
+		configFilePaths = append(configFilePaths, flags.Template)
+		templateDirPath = filepath.Dir(flags.Template)
+	}
+	if isConfigFilePath(flags.Config) {
+		configFilePaths = append(configFilePaths, flags.Config)
+	}
+	for i, configFilePath := range configFilePaths {
+		absConfigFilePath, err := filepath.Abs(configFilePath)
+		if err != nil {
+			return nil, err
+		}
+		configFilePaths[i] = absConfigFilePath
+	}
+	// The input paths are in the order of the images of getInputImages, and are
+	// empty for inputs that are not local.
+	var inputPaths []string
+	if input != "" || bufGenYAMLFile == nil || len(bufGenYAMLFile.InputConfigs()) == 0 {
+		if input == "" {
+			input = "."
+		}
+		inputPaths = append(inputPaths, input)
+	} else {
+		for _, inputConfig := range bufGenYAMLFile.InputConfigs() {
+			switch inputConfig.Type() {
+			case bufconfig.InputConfigTypeDirectory, bufconfig.InputConfigTypeProtoFile:
+				inputPaths = append(inputPaths, inputConfig.Location())
+			default:
+				inputPaths = append(inputPaths, "")
+			}
+		}
+	}
+	inputRootDirPaths := make([]string, len(inputPaths))
+	for i, inputPath := range inputPaths {
+		if inputPath == "" {
+			continue
+		}
+		fileInfo, err := os.Stat(inputPath)
+		if err != nil {
+			// Not a local directory or file, such as a module on the BSR.
+			continue
+		}
+		dirPath := inputPath
+		if !fileInfo.IsDir() {
+			if filepath.Ext(inputPath) != ".proto" {
+				// Images and archives are not rebuilt from sources.
+				continue
+			}
+			dirPath = filepath.Dir(inputPath)
+		}
+		rootDirPath, err := getWatchRootDirPath(dirPath, templateDirPath)
+		if err != nil {
+			return nil, err
+		}
+		inputRootDirPaths[i] = rootDirPath
+	}
+	var skipDirPaths []string
+	if bufGenYAMLFile != nil {
+		for _, pluginConfig := range bufGenYAMLFile.GenerateConfig().GeneratePluginConfigs() {
+			out := pluginConfig.Out()
+			if flags.BaseOutDirPath != "" && flags.BaseOutDirPath != "." {
+				out = filepath.Join(flags.BaseOutDirPath, out)
+			}
+			absOut, err := filepath.Abs(out)
+			if err != nil {
+				return nil, err
+			}
+			skipDirPaths = append(skipDirPaths, absOut)
+		}
+	}
+	return &watchPaths{
+		configFilePaths:   configFilePaths,
+		inputRootDirPaths: inputRootDirPaths,
+		skipDirPaths:      skipDirPaths,
+	}, nil
+}
+
+// isConfigFilePath returns true if the value of --template or --config is a path
+// to a file rather than the content of a file.
+func isConfigFilePath(value string) bool {
+	switch filepath.Ext(value) {
+	case ".yaml", ".yml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
 func readBufGenYAMLFile(
 	ctx context.Context,
 	storageosProvider storageos.Provider,
 	templatePath string,
 ) (bufconfig.BufGenYAMLFile, error) {
-	templatePathExtension := filepath.Ext(templatePath)
 	switch {
 	case templatePath == "":
 		bucket, err := storageosProvider.NewReadWriteBucket(".", storageos.ReadWriteBucketWithSymlinksIfSupported())
@@ -591,7 +955,7 @@ func readBufGenYAMLFile(
 			return nil, err
 		}
 		return bufconfig.GetBufGenYAMLFileForPrefix(ctx, bucket, ".")
-	case templatePathExtension == ".yaml" || templatePathExtension == ".yml" || templatePathExtension == ".json":
+	case isConfigFilePath(templatePath):
 		// We should not read from a bucket at "." because this path can jump context.
 		configFile, err := os.Open(templatePath)
 		if err != nil {
@@ -615,7 +979,15 @@ func getInputImages(
 	excludePathsOverride []string,
 	includeTypesOverride []string,
 	excludeTypesOverride []string,
+	imageCache *watchImageCache,
 ) ([]bufimage.Image, error) {
+	// The images are only cached with --watch.
+	getImage := func(index int, buildImage func() (bufimage.Image, error)) (bufimage.Image, error) {
+		if imageCache == nil {
+			return buildImage()
+		}
+		return imageCache.getImage(index, buildImage)
+	}
 	// If input is specified on the command line, we use that. If input is not
 	// specified on the command line, use the default input.
 	if inputSpecified != "" || len(bufGenYAMLFile.InputConfigs()) == 0 {
@@ -634,21 +1006,23 @@ func getInputImages(
 		if len(excludeTypesOverride) > 0 {
 			excludeTypes = excludeTypesOverride
 		}
-		inputImage, err := controller.GetImage(
-			ctx,
-			input,
-			bufctl.WithConfigOverride(moduleConfigOverride),
-			bufctl.WithTargetPaths(targetPathsOverride, excludePathsOverride),
-			bufctl.WithImageIncludeTypes(includeTypes),
-			bufctl.WithImageExcludeTypes(excludeTypes),
-		)
+		inputImage, err := getImage(0, func() (bufimage.Image, error) {
+			return controller.GetImage(
+				ctx,
+				input,
+				bufctl.WithConfigOverride(moduleConfigOverride),
+				bufctl.WithTargetPaths(targetPathsOverride, excludePathsOverride),
+				bufctl.WithImageIncludeTypes(includeTypes),
+				bufctl.WithImageExcludeTypes(excludeTypes),
+			)
+		})
 		if err != nil {
 			return nil, err
 		}
 		return []bufimage.Image{inputImage}, nil
 	}
 	var inputImages []bufimage.Image
-	for _, inputConfig := range bufGenYAMLFile.InputConfigs() {
+	for i, inputConfig := range bufGenYAMLFile.InputConfigs() {
 		targetPaths := inputConfig.TargetPaths()
 		if len(targetPathsOverride) > 0 {
 			targetPaths = targetPathsOverride
@@ -665,14 +1039,16 @@ func getInputImages(
 		if len(excludeTypesOverride) > 0 {
 			excludeTypes = excludeTypesOverride
 		}
-		inputImage, err := controller.GetImageForInputConfig(
-			ctx,
-			inputConfig,
-			bufctl.WithConfigOverride(moduleConfigOverride),
-			bufctl.WithTargetPaths(targetPaths, excludePaths),
-			bufctl.WithImageIncludeTypes(includeTypes),
-			bufctl.WithImageExcludeTypes(excludeTypes),
-		)
+		inputImage, err := getImage(i, func() (bufimage.Image, error) {
+			return controller.GetImageForInputConfig(
+				ctx,
+				inputConfig,
+				bufctl.WithConfigOverride(moduleConfigOverride),
+				bufctl.WithTargetPaths(targetPaths, excludePaths),
+				bufctl.WithImageIncludeTypes(includeTypes),
+				bufctl.WithImageExcludeTypes(excludeTypes),
+			)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -31,6 +31,7 @@ import (
 	"buf.build/go/app/appext"
 	"buf.build/go/standard/xslices"
 	"buf.build/go/standard/xtesting"
+	"github.com/bufbuild/buf/private/buf/bufctl"
 	"github.com/bufbuild/buf/private/buf/buftesting"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/internal/internaltesting"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
@@ -170,6 +171,304 @@ func TestGenerateV2LocalPluginBasic(t *testing.T) {
 	require.Empty(t, string(diff))
 }
 
+func TestGenerateV2LocalWasmPlugin(t *testing.T) {
+	t.Parallel()
+
+	tempDirPath := t.TempDir()
+	input := filepath.Join("testdata", "v2", "local_plugin")
+	template := filepath.Join("testdata", "v2", "local_plugin", "buf.wasm.gen.yaml")
+
+	testRunSuccess(
+		t,
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+
+	expected, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			filepath.Join("gen", "files_to_generate.txt"): []byte(`parameter: hello
+a/v1/a.proto
+b/v1/b.proto
+`),
+		},
+	)
+	require.NoError(t, err)
+	actual, err := storageos.NewProvider().NewReadWriteBucket(tempDirPath)
+	require.NoError(t, err)
+
+	diff, err := storage.DiffBytes(context.Background(), expected, actual)
+	require.NoError(t, err)
+	require.Empty(t, string(diff))
+}
+
+func TestGenerateV2Cache(t *testing.T) {
+	t.Parallel()
+
+	env := internaltesting.NewEnvFunc(t)
+	tempDirPath := t.TempDir()
+	input := filepath.Join("testdata", "v2", "local_plugin")
+	template := filepath.Join("testdata", "v2", "local_plugin", "buf.basic.gen.yaml")
+	aFilePath := filepath.Join(tempDirPath, "gen", "a", "v1", "a.top-level-type-names.yaml")
+	args := []string{
+		"--cache-stats",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	}
+
+	testRunStdoutStderrWithEnv(t, env, nil, 0, "", "generation cache: 0 hits, 2 misses", args...)
+	expectedData, err := os.ReadFile(aFilePath)
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(filepath.Join(tempDirPath, "gen")))
+	testRunStdoutStderrWithEnv(t, env, nil, 0, "", "generation cache: 2 hits, 0 misses", args...)
+	actualData, err := os.ReadFile(aFilePath)
+	require.NoError(t, err)
+	require.Equal(t, string(expectedData), string(actualData))
+
+	// With the directory strategy, each directory is cached separately.
+	testRunStdoutStderrWithEnv(
+		t,
+		env,
+		nil,
+		0,
+		"",
+		"generation cache: 1 hits, 0 misses",
+		append([]string{"--path", filepath.Join(input, "a")}, args...)...,
+	)
+	// Pruning with a tiny duration deletes every entry.
+	testRunStdoutStderrWithEnv(
+		t,
+		env,
+		nil,
+		0,
+		"",
+		"generation cache: 0 hits, 2 misses",
+		append([]string{"--prune-cache", "1ns"}, args...)...,
+	)
+	// The cache is not used with --disable-cache.
+	appcmdtesting.Run(
+		t,
+		func(name string) *appcmd.Command {
+			return NewCommand(
+				name,
+				appext.NewBuilder(name),
+			)
+		},
+		appcmdtesting.WithEnv(env),
+		appcmdtesting.WithArgs(append([]string{"--disable-cache"}, args...)...),
+		appcmdtesting.WithExpectedExitCode(1),
+		appcmdtesting.WithExpectedStderrPartials("Cannot set --cache-stats or --prune-cache with --disable-cache"),
+	)
+}
+
+func TestGenerateV2Report(t *testing.T) {
+	t.Parallel()
+
+	env := internaltesting.NewEnvFunc(t)
+	tempDirPath := t.TempDir()
+	reportFilePath := filepath.Join(tempDirPath, "report.json")
+	args := []string{
+		"--report",
+		reportFilePath,
+		"--report-format",
+		"json",
+		"--output",
+		tempDirPath,
+		"--template",
+		filepath.Join("testdata", "v2", "local_plugin", "buf.basic.gen.yaml"),
+		filepath.Join("testdata", "v2", "local_plugin"),
+	}
+	type report struct {
+		Plugins []struct {
+			Name   string `json:"name"`
+			Remote string `json:"remote"`
+			Cached bool   `json:"cached"`
+			Files  []struct {
+				Name  string `json:"name"`
+				Bytes int    `json:"bytes"`
+			} `json:"files"`
+		} `json:"plugins"`
+	}
+	readReport := func() report {
+		data, err := os.ReadFile(reportFilePath)
+		require.NoError(t, err)
+		var report report
+		require.NoError(t, json.Unmarshal(data, &report))
+		return report
+	}
+
+	testRunStdoutStderrWithEnv(t, env, nil, 0, "", "", args...)
+	actualReport := readReport()
+	require.Len(t, actualReport.Plugins, 1)
+	plugin := actualReport.Plugins[0]
+	require.Equal(t, "protoc-gen-top-level-type-names-yaml", plugin.Name)
+	require.Empty(t, plugin.Remote)
+	require.False(t, plugin.Cached)
+	require.Len(t, plugin.Files, 2)
+	for _, file := range plugin.Files {
+		fileInfo, err := os.Stat(filepath.Join(tempDirPath, "gen", file.Name))
+		require.NoError(t, err)
+		require.Equal(t, int(fileInfo.Size()), file.Bytes)
+	}
+
+	testRunStdoutStderrWithEnv(t, env, nil, 0, "", "", args...)
+	actualReport = readReport()
+	require.Len(t, actualReport.Plugins, 1)
+	require.True(t, actualReport.Plugins[0].Cached)
+
+	// With --disable-cache, plugins are always run.
+	testRunStdoutStderrWithEnv(t, env, nil, 0, "", "", append([]string{"--disable-cache"}, args...)...)
+	actualReport = readReport()
+	require.Len(t, actualReport.Plugins, 1)
+	require.False(t, actualReport.Plugins[0].Cached)
+}
+
+func TestGenerateV2Check(t *testing.T) {
+	t.Parallel()
+
+	tempDirPath := t.TempDir()
+	input := filepath.Join("testdata", "v2", "local_plugin")
+	template := filepath.Join("testdata", "v2", "local_plugin", "buf.basic.gen.yaml")
+	aFilePath := filepath.Join(tempDirPath, "gen", "a", "v1", "a.top-level-type-names.yaml")
+	bFilePath := filepath.Join(tempDirPath, "gen", "b", "v1", "b.top-level-type-names.yaml")
+	extraFilePath := filepath.Join(tempDirPath, "gen", "extra.txt")
+
+	testRunStdoutStderr(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		aFilePath+":1:1:Generated file does not exist.\n"+
+			bFilePath+":1:1:Generated file does not exist.",
+		// The test interceptor prefixes the empty error.
+		"Failure:",
+		"--check",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	_, err := os.Stat(filepath.Join(tempDirPath, "gen"))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	testRunSuccess(
+		t,
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	testRunSuccess(
+		t,
+		"--check",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+
+	require.NoError(t, os.WriteFile(aFilePath, []byte("stale"), 0600))
+	require.NoError(t, os.Remove(bFilePath))
+	require.NoError(t, os.WriteFile(extraFilePath, []byte("extra"), 0600))
+	testRunStdoutStderr(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		aFilePath+":1:1:Generated file is out of date.\n"+
+			bFilePath+":1:1:Generated file does not exist.",
+		"Failure:",
+		"--check",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	testRunStdoutStderr(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		fmt.Sprintf(
+			`{"path":%q,"start_line":1,"start_column":1,"end_line":1,"end_column":1,"type":"STALE_FILE","message":"Generated file is out of date."}
+{"path":%q,"start_line":1,"start_column":1,"end_line":1,"end_column":1,"type":"MISSING_FILE","message":"Generated file does not exist."}
+{"path":%q,"start_line":1,"start_column":1,"end_line":1,"end_column":1,"type":"EXTRA_FILE","message":"File was not generated and would be deleted."}`,
+			aFilePath,
+			bFilePath,
+			extraFilePath,
+		),
+		"Failure:",
+		"--check",
+		"--clean",
+		"--error-format",
+		"json",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	data, err := os.ReadFile(aFilePath)
+	require.NoError(t, err)
+	require.Equal(t, "stale", string(data))
+	_, err = os.Stat(bFilePath)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	data, err = os.ReadFile(extraFilePath)
+	require.NoError(t, err)
+	require.Equal(t, "extra", string(data))
+}
+
+func TestGenerateV2CheckNestedOuts(t *testing.T) {
+	t.Parallel()
+
+	tempDirPath := t.TempDir()
+	input := filepath.Join("testdata", "v2", "local_plugin")
+	template := filepath.Join("testdata", "v2", "local_plugin", "buf.nested.gen.yaml")
+	extraFilePath := filepath.Join(tempDirPath, "gen", "nested", "extra.txt")
+
+	testRunSuccess(
+		t,
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	// The files generated to gen/nested are not extra files of gen.
+	testRunSuccess(
+		t,
+		"--check",
+		"--clean",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+	// Extra files in gen/nested are reported once.
+	require.NoError(t, os.WriteFile(extraFilePath, []byte("extra"), 0600))
+	testRunStdoutStderr(
+		t,
+		nil,
+		bufctl.ExitCodeFileAnnotation,
+		extraFilePath+":1:1:File was not generated and would be deleted.",
+		"Failure:",
+		"--check",
+		"--clean",
+		"--output",
+		tempDirPath,
+		"--template",
+		template,
+		input,
+	)
+}
+
 func TestGenerateV2LocalPluginTypes(t *testing.T) {
 	t.Parallel()
 	testRunTypeArgs := func(t *testing.T, expect map[string][]byte, args ...string) {
@@ -1032,6 +1331,12 @@ plugins:
 }
 
 func testRunStdoutStderr(t *testing.T, stdin io.Reader, expectedExitCode int, expectedStdout string, expectedStderr string, args ...string) {
+	testRunStdoutStderrWithEnv(t, internaltesting.NewEnvFunc(t), stdin, expectedExitCode, expectedStdout, expectedStderr, args...)
+}
+
+// testRunStdoutStderrWithEnv is testRunStdoutStderr with the given environment, so
+// that multiple runs can share a cache directory.
+func testRunStdoutStderrWithEnv(t *testing.T, env func(string) map[string]string, stdin io.Reader, expectedExitCode int, expectedStdout string, expectedStderr string, args ...string) {
 	appcmdtesting.Run(
 		t,
 		func(name string) *appcmd.Command {
@@ -1059,7 +1364,7 @@ func testRunStdoutStderr(t *testing.T, stdin io.Reader, expectedExitCode int, ex
 		appcmdtesting.WithExpectedExitCode(expectedExitCode),
 		appcmdtesting.WithExpectedStdout(expectedStdout),
 		appcmdtesting.WithExpectedStderr(expectedStderr),
-		appcmdtesting.WithEnv(internaltesting.NewEnvFunc(t)),
+		appcmdtesting.WithEnv(env),
 		appcmdtesting.WithStdin(stdin),
 		appcmdtesting.WithArgs(args...),
 	)
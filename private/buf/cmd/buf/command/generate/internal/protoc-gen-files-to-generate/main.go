@@ -0,0 +1,74 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main implements a protoc plugin that writes the files to generate and the
+// parameter of each request to files_to_generate.txt.
+//
+// The plugin only depends on the standard library and the protobuf runtime, so that
+// it can be built as a Wasm plugin with GOOS=wasip1 GOARCH=wasm.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const fileName = "files_to_generate.txt"
+
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	request := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(data, request); err != nil {
+		return err
+	}
+	response := &pluginpb.CodeGeneratorResponse{}
+	if request.GetParameter() == "fail" {
+		response.Error = proto.String("failed as requested")
+	} else {
+		var content strings.Builder
+		if request.GetParameter() != "" {
+			_, _ = fmt.Fprintf(&content, "parameter: %s\n", request.GetParameter())
+		}
+		for _, fileToGenerate := range request.GetFileToGenerate() {
+			_, _ = fmt.Fprintln(&content, fileToGenerate)
+		}
+		response.File = []*pluginpb.CodeGeneratorResponse_File{
+			{
+				Name:    proto.String(fileName),
+				Content: proto.String(content.String()),
+			},
+		}
+	}
+	data, err = proto.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,64 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync/atomic"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin/bufprotopluginstore"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// statsResponseStore counts the hits and misses of a ResponseStore for --cache-stats.
+//
+// Plugins are run in parallel, so the counts are atomic.
+type statsResponseStore struct {
+	bufprotopluginstore.ResponseStore
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newStatsResponseStore(delegate bufprotopluginstore.ResponseStore) *statsResponseStore {
+	return &statsResponseStore{
+		ResponseStore: delegate,
+	}
+}
+
+func (s *statsResponseStore) GetResponse(
+	ctx context.Context,
+	key string,
+) (*pluginpb.CodeGeneratorResponse, error) {
+	response, err := s.ResponseStore.GetResponse(ctx, key)
+	switch {
+	case err == nil:
+		s.hits.Add(1)
+	case errors.Is(err, fs.ErrNotExist):
+		s.misses.Add(1)
+	}
+	return response, err
+}
+
+// printStats prints the hits and misses, and resets them, so that every generation
+// with --watch prints its own stats.
+func (s *statsResponseStore) printStats(writer io.Writer) error {
+	_, err := fmt.Fprintf(writer, "generation cache: %d hits, %d misses\n", s.hits.Swap(0), s.misses.Swap(0))
+	return err
+}
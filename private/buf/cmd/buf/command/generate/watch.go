@@ -0,0 +1,484 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"buf.build/go/standard/xslices"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+)
+
+const (
+	// defaultWatchPollInterval is the interval at which the watched files are polled.
+	defaultWatchPollInterval = 250 * time.Millisecond
+	// defaultTemplatePath is the path of the generation template that is read if
+	// --template is not set.
+	defaultTemplatePath = "buf.gen.yaml"
+	// watchRacyDuration is how recently a directory must have been modified when it was
+	// read for it to be read again on the next poll. File systems with a coarse
+	// modification time granularity, such as FAT with two seconds, may not change the
+	// modification time of a directory for an entry that is added right after the
+	// directory was read.
+	watchRacyDuration = 2 * time.Second
+)
+
+// watcher polls files for changes.
+//
+// Polling is used rather than file system notifications so that watching works the
+// same way on every platform and file system, including network file systems.
+type watcher struct {
+	pollInterval time.Duration
+	debounce     time.Duration
+}
+
+func newWatcher(pollInterval time.Duration, debounce time.Duration) *watcher {
+	return &watcher{
+		pollInterval: pollInterval,
+		debounce:     debounce,
+	}
+}
+
+// watch calls f with the paths of the changed files every time the watched files
+// change, once they have not changed for the debounce duration, until the context
+// is done.
+//
+// getPaths returns the files and directories to watch, and the directories within
+// them to skip. It is called after every call to f, as the paths to watch can change
+// when f is called. Within directories, only .proto files and buf configuration files
+// are watched. The paths passed to f are absolute.
+func (w *watcher) watch(
+	ctx context.Context,
+	getPaths func() (paths []string, skipDirPaths []string),
+	f func(ctx context.Context, changedPaths []string),
+) error {
+	snapshot, err := newWatchSnapshot(getPaths())
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	// The changed paths that f has not been called for, and the time of the last
+	// change.
+	changedPathSet := make(map[string]struct{})
+	var lastChangeTime time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		changedPaths, err := snapshot.update()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if len(changedPaths) > 0 {
+			for _, changedPath := range changedPaths {
+				changedPathSet[changedPath] = struct{}{}
+			}
+			lastChangeTime = now
+			continue
+		}
+		if len(changedPathSet) == 0 || now.Sub(lastChangeTime) < w.debounce {
+			continue
+		}
+		f(ctx, xslices.MapKeysToSortedSlice(changedPathSet))
+		changedPathSet = make(map[string]struct{})
+		// Do not treat files written by f as changes.
+		paths, skipDirPaths := getPaths()
+		if snapshot.hasPaths(paths, skipDirPaths) {
+			_, err = snapshot.update()
+		} else {
+			snapshot, err = newWatchSnapshot(paths, skipDirPaths)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// watchSnapshot is the state of the watched files, which is updated by polling.
+//
+// A directory is only read again if its modification time changed, which is the case
+// when entries are added to or removed from it. Polling files in which nothing
+// changed only stats the known directories and files, rather than walking them.
+type watchSnapshot struct {
+	paths          []string
+	skipDirPaths   []string
+	skipDirPathMap map[string]struct{}
+	// dirPathToModTime are the modification times of the directories when they were
+	// last read, or zero if they are to be read again.
+	dirPathToModTime map[string]time.Time
+	filePathToInfo   map[string]watchFileInfo
+}
+
+// watchFileInfo is the state of a watched file that is compared between polls.
+type watchFileInfo struct {
+	size    int64
+	modTime time.Time
+}
+
+// newWatchSnapshot returns a new watchSnapshot of the files at the given paths.
+//
+// Paths that do not exist are ignored until they are created. Directories are walked
+// by their absolute paths, and the directories within them at the absolute paths in
+// skipDirPaths are not walked.
+func newWatchSnapshot(paths []string, skipDirPaths []string) (*watchSnapshot, error) {
+	absPaths := make([]string, len(paths))
+	for i, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		absPaths[i] = absPath
+	}
+	skipDirPathMap := make(map[string]struct{}, len(skipDirPaths))
+	for _, skipDirPath := range skipDirPaths {
+		skipDirPathMap[skipDirPath] = struct{}{}
+	}
+	snapshot := &watchSnapshot{
+		paths:            absPaths,
+		skipDirPaths:     skipDirPaths,
+		skipDirPathMap:   skipDirPathMap,
+		dirPathToModTime: make(map[string]time.Time),
+		filePathToInfo:   make(map[string]watchFileInfo),
+	}
+	if _, err := snapshot.update(); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// hasPaths returns true if the snapshot is of the given paths.
+func (s *watchSnapshot) hasPaths(paths []string, skipDirPaths []string) bool {
+	if len(paths) != len(s.paths) || !slices.Equal(skipDirPaths, s.skipDirPaths) {
+		return false
+	}
+	for i, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil || absPath != s.paths[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// update polls the watched files, and returns the sorted paths of the files that were
+// added, changed, or removed since the last update.
+func (s *watchSnapshot) update() ([]string, error) {
+	changedPathSet := make(map[string]struct{})
+	for _, path := range s.paths {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			s.remove(path, changedPathSet)
+			continue
+		}
+		if !fileInfo.IsDir() {
+			s.updateFile(path, fileInfo, changedPathSet)
+			continue
+		}
+		if _, ok := s.dirPathToModTime[path]; !ok {
+			if err := s.readDir(path, fileInfo.ModTime(), changedPathSet); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, dirPath := range xslices.MapKeysToSortedSlice(s.dirPathToModTime) {
+		modTime, ok := s.dirPathToModTime[dirPath]
+		if !ok {
+			// Removed as a directory within another directory.
+			continue
+		}
+		fileInfo, err := os.Stat(dirPath)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			s.remove(dirPath, changedPathSet)
+			continue
+		}
+		if !fileInfo.ModTime().Equal(modTime) {
+			if err := s.readDir(dirPath, fileInfo.ModTime(), changedPathSet); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, filePath := range xslices.MapKeysToSortedSlice(s.filePathToInfo) {
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			s.remove(filePath, changedPathSet)
+			continue
+		}
+		s.updateFile(filePath, fileInfo, changedPathSet)
+	}
+	return xslices.MapKeysToSortedSlice(changedPathSet), nil
+}
+
+// readDir reads the entries of the directory, and reads the directories within it
+// that were not read yet.
+func (s *watchSnapshot) readDir(dirPath string, modTime time.Time, changedPathSet map[string]struct{}) error {
+	dirEntries, err := os.ReadDir(dirPath)
+	if err != nil {
+		// Directories may be deleted while reading.
+		if errors.Is(err, fs.ErrNotExist) {
+			s.remove(dirPath, changedPathSet)
+			return nil
+		}
+		return err
+	}
+	if time.Since(modTime) < watchRacyDuration {
+		// Read the directory again on the next poll, as entries may be added
+		// without changing its modification time.
+		modTime = time.Time{}
+	}
+	s.dirPathToModTime[dirPath] = modTime
+	entryPathSet := make(map[string]struct{}, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		entryPath := filepath.Join(dirPath, dirEntry.Name())
+		if dirEntry.IsDir() {
+			// Skip hidden directories such as .git.
+			if strings.HasPrefix(dirEntry.Name(), ".") {
+				continue
+			}
+			if _, ok := s.skipDirPathMap[entryPath]; ok {
+				continue
+			}
+			entryPathSet[entryPath] = struct{}{}
+			if _, ok := s.dirPathToModTime[entryPath]; ok {
+				continue
+			}
+			fileInfo, err := dirEntry.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			if err := s.readDir(entryPath, fileInfo.ModTime(), changedPathSet); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isWatchedFileName(dirEntry.Name()) {
+			continue
+		}
+		fileInfo, err := dirEntry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		entryPathSet[entryPath] = struct{}{}
+		s.updateFile(entryPath, fileInfo, changedPathSet)
+	}
+	// Remove the files and directories that are no longer in the directory. The
+	// watched paths themselves are updated separately, as they may be files that
+	// are not watched within directories, such as the generation template.
+	for _, path := range slices.Concat(
+		xslices.MapKeysToSlice(s.filePathToInfo),
+		xslices.MapKeysToSlice(s.dirPathToModTime),
+	) {
+		if _, ok := entryPathSet[path]; ok || filepath.Dir(path) != dirPath || slices.Contains(s.paths, path) {
+			continue
+		}
+		s.remove(path, changedPathSet)
+	}
+	return nil
+}
+
+func (s *watchSnapshot) updateFile(filePath string, fileInfo fs.FileInfo, changedPathSet map[string]struct{}) {
+	watchFileInfo := watchFileInfo{
+		size:    fileInfo.Size(),
+		modTime: fileInfo.ModTime(),
+	}
+	if existingWatchFileInfo, ok := s.filePathToInfo[filePath]; !ok || existingWatchFileInfo != watchFileInfo {
+		s.filePathToInfo[filePath] = watchFileInfo
+		changedPathSet[filePath] = struct{}{}
+	}
+}
+
+// remove removes the file or directory at the path, and everything within it.
+func (s *watchSnapshot) remove(path string, changedPathSet map[string]struct{}) {
+	for filePath := range s.filePathToInfo {
+		if filePath == path || isWithinDirPath(filePath, path) {
+			delete(s.filePathToInfo, filePath)
+			changedPathSet[filePath] = struct{}{}
+		}
+	}
+	for dirPath := range s.dirPathToModTime {
+		if dirPath == path || isWithinDirPath(dirPath, path) {
+			delete(s.dirPathToModTime, dirPath)
+		}
+	}
+}
+
+// watchImageCache keeps the images of the inputs between generations with --watch,
+// so that only the images of the inputs with changed files are built again.
+type watchImageCache struct {
+	indexToImage map[int]bufimage.Image
+}
+
+func newWatchImageCache() *watchImageCache {
+	return &watchImageCache{
+		indexToImage: make(map[int]bufimage.Image),
+	}
+}
+
+// invalidate removes the images that the changed paths may be part of.
+//
+// The image of an input is removed if a changed path is within the root directory of
+// the input. All images are removed if watchPaths is nil, or if a changed path is a
+// configuration file, as the inputs and their configuration may have changed. The
+// images of inputs that are not watched, such as modules on the BSR, are only built
+// again in the latter case.
+func (c *watchImageCache) invalidate(changedPaths []string, watchPaths *watchPaths) {
+	if watchPaths == nil {
+		clear(c.indexToImage)
+		return
+	}
+	for _, changedPath := range changedPaths {
+		if slices.Contains(watchPaths.configFilePaths, changedPath) {
+			clear(c.indexToImage)
+			return
+		}
+		for index, inputRootDirPath := range watchPaths.inputRootDirPaths {
+			if inputRootDirPath != "" && isWithinDirPath(changedPath, inputRootDirPath) {
+				delete(c.indexToImage, index)
+			}
+		}
+	}
+}
+
+// getImage returns the image of the input at the index, which is built with
+// buildImage if it is not cached.
+func (c *watchImageCache) getImage(index int, buildImage func() (bufimage.Image, error)) (bufimage.Image, error) {
+	if image, ok := c.indexToImage[index]; ok {
+		return image, nil
+	}
+	image, err := buildImage()
+	if err != nil {
+		return nil, err
+	}
+	c.indexToImage[index] = image
+	return image, nil
+}
+
+// watchPaths are the paths watched with --watch.
+type watchPaths struct {
+	// configFilePaths are the absolute paths of the generation template and the
+	// module configuration, if they are files.
+	configFilePaths []string
+	// inputRootDirPaths are the absolute paths of the directories watched for the
+	// inputs, in the order of the images of getInputImages. The path is empty for
+	// inputs that are not watched, such as modules on the BSR.
+	inputRootDirPaths []string
+	// skipDirPaths are the absolute paths of the directories within the input root
+	// directories that are not watched, such as the out directories of plugins.
+	skipDirPaths []string
+}
+
+// paths returns the files and directories to watch.
+func (w *watchPaths) paths() []string {
+	paths := slices.Clone(w.configFilePaths)
+	for _, inputRootDirPath := range w.inputRootDirPaths {
+		if inputRootDirPath != "" && !slices.Contains(paths, inputRootDirPath) {
+			paths = append(paths, inputRootDirPath)
+		}
+	}
+	return paths
+}
+
+// isWithinDirPath returns true if the path is within the directory. Both paths
+// must be absolute.
+func isWithinDirPath(path string, dirPath string) bool {
+	return strings.HasPrefix(path, dirPath+string(filepath.Separator))
+}
+
+func isWatchedFileName(name string) bool {
+	switch name {
+	case bufconfig.DefaultBufYAMLFileName, bufconfig.DefaultBufWorkYAMLFileName, bufconfig.DefaultBufLockFileName:
+		return true
+	default:
+		return filepath.Ext(name) == ".proto"
+	}
+}
+
+// getWatchRootDirPath returns the directory to watch for a local directory or
+// proto file input.
+//
+// An input directory can be a module within a workspace, in which case the other
+// modules of the workspace are part of the image as well, so the root of the
+// workspace is watched. This is the nearest enclosing directory with a
+// buf.work.yaml file, or otherwise the nearest enclosing directory with a buf.yaml
+// file, or otherwise the directory itself.
+//
+// The search does not go above stopDirPath, which is the directory of the generation
+// template, or above the root of a VCS repository, which is a directory with a .git
+// directory or file.
+func getWatchRootDirPath(dirPath string, stopDirPath string) (string, error) {
+	absDirPath, err := filepath.Abs(dirPath)
+	if err != nil {
+		return "", err
+	}
+	absStopDirPath, err := filepath.Abs(stopDirPath)
+	if err != nil {
+		return "", err
+	}
+	var bufYAMLDirPath string
+	for curDirPath := absDirPath; ; curDirPath = filepath.Dir(curDirPath) {
+		if fileExists(filepath.Join(curDirPath, bufconfig.DefaultBufWorkYAMLFileName)) {
+			return curDirPath, nil
+		}
+		if bufYAMLDirPath == "" && fileExists(filepath.Join(curDirPath, bufconfig.DefaultBufYAMLFileName)) {
+			bufYAMLDirPath = curDirPath
+		}
+		if curDirPath == absStopDirPath || pathExists(filepath.Join(curDirPath, ".git")) {
+			break
+		}
+		if filepath.Dir(curDirPath) == curDirPath {
+			break
+		}
+	}
+	if bufYAMLDirPath != "" {
+		return bufYAMLDirPath, nil
+	}
+	return absDirPath, nil
+}
+
+func fileExists(filePath string) bool {
+	fileInfo, err := os.Stat(filePath)
+	return err == nil && !fileInfo.IsDir()
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
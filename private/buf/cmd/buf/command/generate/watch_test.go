@@ -0,0 +1,202 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dirPath := t.TempDir()
+	protoFilePath := filepath.Join(dirPath, "a", "a.proto")
+	require.NoError(t, os.Mkdir(filepath.Dir(protoFilePath), 0755))
+	require.NoError(t, os.WriteFile(protoFilePath, nil, 0600))
+	genDirPath := filepath.Join(dirPath, "gen")
+	require.NoError(t, os.Mkdir(genDirPath, 0755))
+
+	calls := make(chan []string, 10)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- newWatcher(10*time.Millisecond, 100*time.Millisecond).watch(
+			ctx,
+			func() ([]string, []string) {
+				return []string{dirPath}, []string{genDirPath}
+			},
+			func(_ context.Context, changedPaths []string) {
+				calls <- changedPaths
+			},
+		)
+	}()
+	// Wait for the initial poll.
+	time.Sleep(50 * time.Millisecond)
+
+	// A burst of changes results in a single call.
+	for i := range 3 {
+		require.NoError(t, os.WriteFile(protoFilePath, []byte(strings.Repeat("a", i+1)), 0600))
+		time.Sleep(20 * time.Millisecond)
+	}
+	select {
+	case changedPaths := <-calls:
+		require.Equal(t, []string{protoFilePath}, changedPaths)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "expected a call after the proto file changed")
+	}
+	// Files that are not .proto files or configuration files are not watched.
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath, "a", "a.txt"), nil, 0600))
+	// Hidden directories are not watched.
+	require.NoError(t, os.Mkdir(filepath.Join(dirPath, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath, ".git", "b.proto"), nil, 0600))
+	// Skipped directories such as plugin out directories are not watched.
+	require.NoError(t, os.WriteFile(filepath.Join(genDirPath, "c.proto"), nil, 0600))
+	time.Sleep(300 * time.Millisecond)
+	require.Empty(t, calls)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dirPath, "buf.yaml"), []byte("version: v2\n"), 0600))
+	select {
+	case changedPaths := <-calls:
+		require.Equal(t, []string{filepath.Join(dirPath, "buf.yaml")}, changedPaths)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "expected a call after buf.yaml was created")
+	}
+
+	cancel()
+	require.NoError(t, <-errC)
+	require.Empty(t, calls)
+}
+
+func TestWatchSnapshot(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	aFilePath := filepath.Join(dirPath, "a", "a.proto")
+	require.NoError(t, os.Mkdir(filepath.Dir(aFilePath), 0755))
+	require.NoError(t, os.WriteFile(aFilePath, nil, 0600))
+	templateFilePath := filepath.Join(dirPath, "buf.gen.yaml")
+	require.NoError(t, os.WriteFile(templateFilePath, nil, 0600))
+
+	snapshot, err := newWatchSnapshot([]string{templateFilePath, dirPath}, nil)
+	require.NoError(t, err)
+	changedPaths, err := snapshot.update()
+	require.NoError(t, err)
+	require.Empty(t, changedPaths)
+
+	// Files are found in new directories.
+	bFilePath := filepath.Join(dirPath, "b", "c", "b.proto")
+	require.NoError(t, os.MkdirAll(filepath.Dir(bFilePath), 0755))
+	require.NoError(t, os.WriteFile(bFilePath, nil, 0600))
+	changedPaths, err = snapshot.update()
+	require.NoError(t, err)
+	require.Equal(t, []string{bFilePath}, changedPaths)
+
+	// Changed files and files in removed directories are changed.
+	require.NoError(t, os.WriteFile(aFilePath, []byte("syntax = \"proto3\";\n"), 0600))
+	require.NoError(t, os.RemoveAll(filepath.Join(dirPath, "b")))
+	changedPaths, err = snapshot.update()
+	require.NoError(t, err)
+	require.Equal(t, []string{aFilePath, bFilePath}, changedPaths)
+
+	// The generation template is watched although it is not watched within directories.
+	require.NoError(t, os.WriteFile(templateFilePath, []byte("version: v2\n"), 0600))
+	changedPaths, err = snapshot.update()
+	require.NoError(t, err)
+	require.Equal(t, []string{templateFilePath}, changedPaths)
+	changedPaths, err = snapshot.update()
+	require.NoError(t, err)
+	require.Empty(t, changedPaths)
+}
+
+func TestWatchImageCache(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	watchPaths := &watchPaths{
+		configFilePaths:   []string{filepath.Join(dirPath, "buf.gen.yaml")},
+		inputRootDirPaths: []string{filepath.Join(dirPath, "a"), filepath.Join(dirPath, "b"), ""},
+	}
+	imageCache := newWatchImageCache()
+	var builds int
+	getImages := func() {
+		for i := range watchPaths.inputRootDirPaths {
+			_, err := imageCache.getImage(i, func() (bufimage.Image, error) {
+				builds++
+				return nil, nil
+			})
+			require.NoError(t, err)
+		}
+	}
+	getImages()
+	require.Equal(t, 3, builds)
+	getImages()
+	require.Equal(t, 3, builds)
+
+	// Only the image of the input with a changed file is built again.
+	imageCache.invalidate([]string{filepath.Join(dirPath, "a", "a.proto")}, watchPaths)
+	getImages()
+	require.Equal(t, 4, builds)
+	// A file next to an input directory is not within it.
+	imageCache.invalidate([]string{filepath.Join(dirPath, "ab.proto")}, watchPaths)
+	getImages()
+	require.Equal(t, 4, builds)
+	// All images are built again if the generation template changed.
+	imageCache.invalidate([]string{filepath.Join(dirPath, "buf.gen.yaml")}, watchPaths)
+	getImages()
+	require.Equal(t, 7, builds)
+}
+
+func TestGetWatchRootDirPath(t *testing.T) {
+	t.Parallel()
+	dirPath := t.TempDir()
+	// A v1 workspace with a module in proto, and a v2 workspace in v2 with a module
+	// in v2/proto, which has no buf.yaml.
+	for _, path := range []string{
+		"buf.work.yaml",
+		filepath.Join("proto", "buf.yaml"),
+		filepath.Join("v2", "buf.yaml"),
+		filepath.Join("v2", "proto", "a", "a.proto"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dirPath, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dirPath, path), nil, 0600))
+	}
+	rootDirPath, err := getWatchRootDirPath(filepath.Join(dirPath, "proto"), dirPath)
+	require.NoError(t, err)
+	require.Equal(t, dirPath, rootDirPath)
+	// The buf.work.yaml file takes precedence over the buf.yaml file.
+	rootDirPath, err = getWatchRootDirPath(filepath.Join(dirPath, "v2", "proto", "a"), dirPath)
+	require.NoError(t, err)
+	require.Equal(t, dirPath, rootDirPath)
+	// The search stops at the directory of the generation template.
+	rootDirPath, err = getWatchRootDirPath(filepath.Join(dirPath, "v2", "proto", "a"), filepath.Join(dirPath, "v2"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dirPath, "v2"), rootDirPath)
+	// The search stops at the root of a VCS repository.
+	require.NoError(t, os.Mkdir(filepath.Join(dirPath, "v2", ".git"), 0755))
+	rootDirPath, err = getWatchRootDirPath(filepath.Join(dirPath, "v2", "proto", "a"), dirPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dirPath, "v2"), rootDirPath)
+	require.NoError(t, os.Remove(filepath.Join(dirPath, "v2", ".git")))
+	require.NoError(t, os.Remove(filepath.Join(dirPath, "buf.work.yaml")))
+	rootDirPath, err = getWatchRootDirPath(filepath.Join(dirPath, "v2", "proto", "a"), dirPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dirPath, "v2"), rootDirPath)
+}
@@ -21,12 +21,16 @@ import (
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/standard/xslices"
 	"buf.build/go/standard/xstrings"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/bufctl"
+	"github.com/bufbuild/buf/private/buf/bufrefactor"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/spf13/pflag"
 )
 
@@ -36,6 +40,10 @@ const (
 	pathsFlagName           = "path"
 	excludePathsFlagName    = "exclude-path"
 	disableSymlinksFlagName = "disable-symlinks"
+	baselineFlagName        = "baseline"
+	writeBaselineFlagName   = "write-baseline"
+	fixFlagName             = "fix"
+	diffFlagName            = "diff"
 )
 
 // NewCommand returns a new Command.
@@ -64,6 +72,10 @@ type flags struct {
 	Paths           []string
 	ExcludePaths    []string
 	DisableSymlinks bool
+	Baseline        string
+	WriteBaseline   string
+	Fix             bool
+	Diff            bool
 	// special
 	InputHashtag string
 }
@@ -77,6 +89,24 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindBaseline(flagSet, &f.Baseline, baselineFlagName)
+	bufcli.BindWriteBaseline(flagSet, &f.WriteBaseline, writeBaselineFlagName)
+	flagSet.BoolVar(
+		&f.Fix,
+		fixFlagName,
+		false,
+		`Fix violations that have a mechanical fix by rewriting the source files in-place
+Renames update all references within the workspace, and are skipped if they cannot be made safely. Only the remaining violations are reported`,
+	)
+	flagSet.BoolVar(
+		&f.Diff,
+		diffFlagName,
+		false,
+		fmt.Sprintf(
+			"Display a diff of the fixes instead of rewriting files. Must be used with --%s",
+			fixFlagName,
+		),
+	)
 	flagSet.StringVar(
 		&f.ErrorFormat,
 		errorFormatFlagName,
@@ -102,6 +132,12 @@ func run(
 	if err := bufcli.ValidateErrorFormatFlagLint(flags.ErrorFormat, errorFormatFlagName); err != nil {
 		return err
 	}
+	if flags.Baseline != "" && flags.WriteBaseline != "" {
+		return appcmd.NewInvalidArgumentErrorf("Cannot set both --%s and --%s", baselineFlagName, writeBaselineFlagName)
+	}
+	if flags.Diff && !flags.Fix {
+		return appcmd.NewInvalidArgumentErrorf("Cannot set --%s without --%s", diffFlagName, fixFlagName)
+	}
 	// Parse out if this is config-ignore-yaml.
 	// This is messed.
 	controllerErrorFormat := flags.ErrorFormat
@@ -138,7 +174,28 @@ func run(
 	if err != nil {
 		return err
 	}
+	// The Refactorer and the original source files are only needed if we are fixing violations.
+	var refactorer bufrefactor.Refactorer
+	var originalReadBucket storage.ReadBucket
+	if flags.Fix {
+		refactorer, originalReadBucket, err = bufcli.NewRefactorerForInput(
+			ctx,
+			container,
+			controller,
+			input,
+			"--"+fixFlagName,
+			bufctl.WithConfigOverride(flags.Config),
+		)
+		if err != nil {
+			return err
+		}
+	}
 	var allFileAnnotations []bufanalysis.FileAnnotation
+	// BaselineEntries are only computed if we are reading or writing a baseline, and are
+	// parallel to allFileAnnotations.
+	var allBaselineEntries []bufcheck.BaselineEntry
+	// Rules are only needed to describe the FileAnnotations for formats that support rule metadata.
+	var allRules []bufcheck.Rule
 	// We add all check configs (both lint and breaking) as related configs to check if plugins
 	// have rules configured.
 	// We allocated twice the size of imageWithConfigs for both lint and breaking configs.
@@ -161,12 +218,65 @@ func run(
 		); err != nil {
 			var fileAnnotationSet bufanalysis.FileAnnotationSet
 			if errors.As(err, &fileAnnotationSet) {
-				allFileAnnotations = append(allFileAnnotations, fileAnnotationSet.FileAnnotations()...)
+				fileAnnotations := fileAnnotationSet.FileAnnotations()
+				if refactorer != nil {
+					fileAnnotations, err = fixFileAnnotations(ctx, refactorer, fileAnnotations)
+					if err != nil {
+						return err
+					}
+				}
+				allFileAnnotations = append(allFileAnnotations, fileAnnotations...)
+				if flags.Baseline != "" || flags.WriteBaseline != "" {
+					baselineEntries, err := bufcheck.NewBaselineEntries(ctx, imageWithConfig, fileAnnotations)
+					if err != nil {
+						return err
+					}
+					allBaselineEntries = append(allBaselineEntries, baselineEntries...)
+				}
 			} else {
 				return err
 			}
 		}
+		if flags.ErrorFormat == bufanalysis.FormatSARIF.String() {
+			rules, err := checkClient.ConfiguredRules(
+				ctx,
+				check.RuleTypeLint,
+				imageWithConfig.LintConfig(),
+				bufcheck.WithPluginConfigs(imageWithConfig.PluginConfigs()...),
+				bufcheck.WithPolicyConfigs(imageWithConfig.PolicyConfigs()...),
+				bufcheck.WithRelatedCheckConfigs(allCheckConfigs...),
+			)
+			if err != nil {
+				return err
+			}
+			allRules = append(allRules, rules...)
+		}
+	}
+	if refactorer != nil {
+		refactoredReadBucket, err := refactorer.Bucket(ctx)
+		if err != nil {
+			return err
+		}
+		if err := bufcli.WriteRefactoredBucket(ctx, container, originalReadBucket, refactoredReadBucket, flags.Diff); err != nil {
+			return err
+		}
+	}
+	if flags.WriteBaseline != "" {
+		return bufcli.WriteBaselineFile(flags.WriteBaseline, bufcheck.NewBaseline(allBaselineEntries))
 	}
+	if flags.Baseline != "" {
+		baseline, err := bufcli.ReadBaselineFile(flags.Baseline)
+		if err != nil {
+			return err
+		}
+		var staleBaselineEntries []bufcheck.BaselineEntry
+		allFileAnnotations, staleBaselineEntries, err = baseline.Filter(allFileAnnotations, allBaselineEntries)
+		if err != nil {
+			return err
+		}
+		bufcli.WarnStaleBaselineEntries(container.Logger(), flags.Baseline, staleBaselineEntries)
+	}
+	printWithRules := bufanalysis.PrintWithRules(xslices.Map(allRules, func(rule bufcheck.Rule) bufanalysis.Rule { return rule })...)
 	if len(allFileAnnotations) > 0 {
 		allFileAnnotationSet := bufanalysis.NewFileAnnotationSet(allFileAnnotations...)
 		if flags.ErrorFormat == "config-ignore-yaml" {
@@ -181,11 +291,45 @@ func run(
 				container.Stdout(),
 				allFileAnnotationSet,
 				flags.ErrorFormat,
+				printWithRules,
 			); err != nil {
 				return err
 			}
 		}
 		return bufctl.ErrFileAnnotation
 	}
+	if flags.ErrorFormat == bufanalysis.FormatSARIF.String() {
+		// A SARIF log is printed even if there are no FileAnnotations, so that code
+		// scanning can close the alerts for violations that were fixed.
+		return bufanalysis.PrintFileAnnotationSet(container.Stdout(), nil, flags.ErrorFormat, printWithRules)
+	}
 	return nil
 }
+
+// fixFileAnnotations applies the suggested Edits for the FileAnnotations, and returns
+// the FileAnnotations that were not fixed.
+func fixFileAnnotations(
+	ctx context.Context,
+	refactorer bufrefactor.Refactorer,
+	fileAnnotations []bufanalysis.FileAnnotation,
+) ([]bufanalysis.FileAnnotation, error) {
+	var unfixedFileAnnotations []bufanalysis.FileAnnotation
+	for _, fileAnnotation := range fileAnnotations {
+		edits, err := refactorer.SuggestedEdits(ctx, fileAnnotation)
+		if err != nil {
+			return nil, err
+		}
+		if len(edits) == 0 {
+			unfixedFileAnnotations = append(unfixedFileAnnotations, fileAnnotation)
+			continue
+		}
+		if err := refactorer.Apply(ctx, edits...); err != nil {
+			if !errors.Is(err, bufrefactor.ErrUnsafeEdit) {
+				return nil, err
+			}
+			// This conflicts with a previous fix, and may be fixable on the next run.
+			unfixedFileAnnotations = append(unfixedFileAnnotations, fileAnnotation)
+		}
+	}
+	return unfixedFileAnnotations, nil
+}
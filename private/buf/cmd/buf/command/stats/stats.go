@@ -20,9 +20,11 @@ import (
 
 	"buf.build/go/app/appcmd"
 	"buf.build/go/app/appext"
+	"buf.build/go/standard/xstrings"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/bufctl"
 	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/buf/bufworkspace"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/pkg/protostat"
 	"github.com/bufbuild/buf/private/pkg/protostat/protostatstorage"
@@ -31,9 +33,16 @@ import (
 
 const (
 	formatFlagName          = "format"
+	byFlagName              = "by"
 	disableSymlinksFlagName = "disable-symlinks"
+
+	byPackage = "package"
+	byFile    = "file"
+	byModule  = "module"
 )
 
+var allByString = xstrings.SliceToString([]string{byPackage, byFile, byModule})
+
 // NewCommand returns a new Command.
 func NewCommand(
 	name string,
@@ -56,6 +65,7 @@ func NewCommand(
 
 type flags struct {
 	Format          string
+	By              string
 	DisableSymlinks bool
 
 	// special
@@ -71,7 +81,16 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		&f.Format,
 		formatFlagName,
 		bufprint.FormatText.String(),
-		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllStatsFormatsString),
+	)
+	flagSet.StringVar(
+		&f.By,
+		byFlagName,
+		"",
+		fmt.Sprintf(
+			`Print statistics for each group of files instead of in total. Must be one of %s`,
+			allByString,
+		),
 	)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
 	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
@@ -82,10 +101,15 @@ func run(
 	container appext.Container,
 	flags *flags,
 ) error {
-	format, err := bufprint.ParseFormat(flags.Format)
+	format, err := bufprint.ParseStatsFormat(flags.Format)
 	if err != nil {
 		return appcmd.WrapInvalidArgumentError(err)
 	}
+	switch flags.By {
+	case "", byPackage, byFile, byModule:
+	default:
+		return appcmd.NewInvalidArgumentErrorf("--%s must be one of %s", byFlagName, allByString)
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
 	if err != nil {
 		return err
@@ -104,22 +128,54 @@ func run(
 	if err != nil {
 		return err
 	}
-	stats, err := protostat.GetStats(
-		ctx,
-		protostatstorage.NewFileWalker(
-			bufmodule.ModuleReadBucketToStorageReadBucket(
-				bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFilesForTargetModules(
-					workspace,
-				),
+	statsPrinter := bufprint.NewStatsPrinter(container.Stdout())
+	fileWalker := protostatstorage.NewFileWalker(
+		bufmodule.ModuleReadBucketToStorageReadBucket(
+			bufmodule.ModuleSetToModuleReadBucketWithOnlyProtoFilesForTargetModules(
+				workspace,
 			),
 		),
 	)
+	var groupStats []*protostat.GroupStats
+	switch flags.By {
+	case "":
+		stats, err := protostat.GetStats(ctx, fileWalker)
+		if err != nil {
+			return err
+		}
+		return statsPrinter.PrintStats(ctx, format, stats)
+	case byPackage:
+		groupStats, err = protostat.GetStatsByPackage(ctx, fileWalker)
+	case byFile:
+		groupStats, err = protostat.GetStatsByFile(ctx, fileWalker)
+	case byModule:
+		groupStats, err = getStatsByModule(ctx, workspace)
+	}
 	if err != nil {
 		return err
 	}
-	return bufprint.NewStatsPrinter(container.Stdout()).PrintStats(
-		ctx,
-		format,
-		stats,
-	)
+	return statsPrinter.PrintGroupStats(ctx, format, xstrings.ToPascalCase(flags.By), groupStats...)
+}
+
+// getStatsByModule gathers statistics for each target module of the workspace.
+func getStatsByModule(ctx context.Context, workspace bufworkspace.Workspace) ([]*protostat.GroupStats, error) {
+	var groupStats []*protostat.GroupStats
+	for _, module := range bufmodule.ModuleSetTargetModules(workspace) {
+		stats, err := protostat.GetStats(
+			ctx,
+			protostatstorage.NewFileWalker(
+				bufmodule.ModuleReadBucketToStorageReadBucket(
+					bufmodule.ModuleReadBucketWithOnlyProtoFiles(module),
+				),
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+		groupStats = append(groupStats, &protostat.GroupStats{
+			Name:  module.OpaqueID(),
+			Stats: stats,
+		})
+	}
+	return groupStats, nil
 }
@@ -34,6 +34,16 @@ const (
 	//
 	// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-error-message.
 	FormatGithubActions
+	// FormatSARIF is the SARIF 2.1.0 format for FileAnnotations.
+	//
+	// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+	FormatSARIF
+	// FormatGitLabCodeQuality is the GitLab Code Quality format for FileAnnotations.
+	//
+	// See https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool.
+	FormatGitLabCodeQuality
+	// FormatCheckstyle is the Checkstyle XML format for FileAnnotations.
+	FormatCheckstyle
 )
 
 var (
@@ -46,6 +56,9 @@ var (
 		"msvs",
 		"junit",
 		"github-actions",
+		"sarif",
+		"gitlab-code-quality",
+		"checkstyle",
 	}
 	// AllFormatStringsWithAliases is all format strings with aliases.
 	//
@@ -57,23 +70,32 @@ var (
 		"msvs",
 		"junit",
 		"github-actions",
+		"sarif",
+		"gitlab-code-quality",
+		"checkstyle",
 	}
 
 	stringToFormat = map[string]Format{
 		"text": FormatText,
 		// alias for text
-		"gcc":            FormatText,
-		"json":           FormatJSON,
-		"msvs":           FormatMSVS,
-		"junit":          FormatJUnit,
-		"github-actions": FormatGithubActions,
+		"gcc":                 FormatText,
+		"json":                FormatJSON,
+		"msvs":                FormatMSVS,
+		"junit":               FormatJUnit,
+		"github-actions":      FormatGithubActions,
+		"sarif":               FormatSARIF,
+		"gitlab-code-quality": FormatGitLabCodeQuality,
+		"checkstyle":          FormatCheckstyle,
 	}
 	formatToString = map[Format]string{
-		FormatText:          "text",
-		FormatJSON:          "json",
-		FormatMSVS:          "msvs",
-		FormatJUnit:         "junit",
-		FormatGithubActions: "github-actions",
+		FormatText:              "text",
+		FormatJSON:              "json",
+		FormatMSVS:              "msvs",
+		FormatJUnit:             "junit",
+		FormatGithubActions:     "github-actions",
+		FormatSARIF:             "sarif",
+		FormatGitLabCodeQuality: "gitlab-code-quality",
+		FormatCheckstyle:        "checkstyle",
 	}
 )
 
@@ -154,6 +176,11 @@ type FileAnnotation interface {
 	// May be empty if this annotation did not originate from a policy.
 	// This may be added to the printed message field for certain printers.
 	PolicyName() string
+	// SuggestedEdits are the edits that the rule that produced the annotation suggests
+	// to resolve it.
+	//
+	// May be empty if the rule does not suggest any edits.
+	SuggestedEdits() []SuggestedEdit
 
 	isFileAnnotation()
 }
@@ -169,6 +196,7 @@ func NewFileAnnotation(
 	message string,
 	pluginName string,
 	policyName string,
+	options ...FileAnnotationOption,
 ) FileAnnotation {
 	return newFileAnnotation(
 		fileInfo,
@@ -180,9 +208,68 @@ func NewFileAnnotation(
 		message,
 		pluginName,
 		policyName,
+		options...,
 	)
 }
 
+// FileAnnotationOption is an option for a new FileAnnotation.
+type FileAnnotationOption func(*fileAnnotation)
+
+// FileAnnotationWithSuggestedEdits returns a new FileAnnotationOption that sets the
+// edits that the rule suggests to resolve the FileAnnotation.
+func FileAnnotationWithSuggestedEdits(suggestedEdits ...SuggestedEdit) FileAnnotationOption {
+	return func(fileAnnotation *fileAnnotation) {
+		fileAnnotation.suggestedEdits = append(fileAnnotation.suggestedEdits, suggestedEdits...)
+	}
+}
+
+// SuggestedEdit is a source-level edit that a rule suggests to resolve a FileAnnotation.
+//
+// A SuggestedEdit is either a RenameSuggestedEdit or a RemoveImportSuggestedEdit.
+type SuggestedEdit interface {
+	// Stringer returns a human-readable description of the SuggestedEdit.
+	fmt.Stringer
+
+	isSuggestedEdit()
+}
+
+// RenameSuggestedEdit is a SuggestedEdit that renames a declaration and all references to it.
+type RenameSuggestedEdit interface {
+	SuggestedEdit
+
+	// FullName is the full name of the declaration to rename, without a leading dot.
+	//
+	// Enum values are named within their enum, for example "foo.v1.Bar.BAR_UNSPECIFIED".
+	FullName() string
+	// NewFullName is the new full name of the declaration, which has the same parent
+	// as FullName.
+	NewFullName() string
+
+	isRenameSuggestedEdit()
+}
+
+// NewRenameSuggestedEdit returns a new RenameSuggestedEdit.
+func NewRenameSuggestedEdit(fullName string, newFullName string) RenameSuggestedEdit {
+	return newRenameSuggestedEdit(fullName, newFullName)
+}
+
+// RemoveImportSuggestedEdit is a SuggestedEdit that removes an import from a file.
+type RemoveImportSuggestedEdit interface {
+	SuggestedEdit
+
+	// Path is the path of the file to remove the import from.
+	Path() string
+	// ImportPath is the path of the import to remove.
+	ImportPath() string
+
+	isRemoveImportSuggestedEdit()
+}
+
+// NewRemoveImportSuggestedEdit returns a new RemoveImportSuggestedEdit.
+func NewRemoveImportSuggestedEdit(path string, importPath string) RemoveImportSuggestedEdit {
+	return newRemoveImportSuggestedEdit(path, importPath)
+}
+
 // FileAnnotationSet is a set of FileAnnotations.
 type FileAnnotationSet interface {
 	// Stringer returns the string representation for this FileAnnotationSet.
@@ -207,25 +294,87 @@ func NewFileAnnotationSet(fileAnnotations ...FileAnnotation) FileAnnotationSet {
 	return newFileAnnotationSet(fileAnnotations)
 }
 
+// Rule is a minimal Rule interface.
+//
+// This is used to describe the rules that FileAnnotations were produced by for
+// formats that support rule metadata, such as FormatSARIF.
+type Rule interface {
+	// ID is the ID of the Rule, which matches FileAnnotation.Type.
+	ID() string
+	// Purpose is the purpose of the Rule.
+	Purpose() string
+	// PluginName is the name of the plugin that the Rule originated from.
+	//
+	// May be empty if this Rule did not originate from a plugin.
+	PluginName() string
+}
+
 // PrintFileAnnotationSet prints the file annotations separated by newlines.
-func PrintFileAnnotationSet(writer io.Writer, fileAnnotationSet FileAnnotationSet, formatString string) error {
+//
+// The FileAnnotationSet may be nil if there are no FileAnnotations. This is used to
+// print the output of a run without FileAnnotations for formats that describe the
+// run itself, such as FormatSARIF.
+func PrintFileAnnotationSet(
+	writer io.Writer,
+	fileAnnotationSet FileAnnotationSet,
+	formatString string,
+	options ...PrintFileAnnotationSetOption,
+) error {
 	format, err := ParseFormat(formatString)
 	if err != nil {
 		return err
 	}
+	printFileAnnotationSetOptions := newPrintFileAnnotationSetOptions()
+	for _, option := range options {
+		option(printFileAnnotationSetOptions)
+	}
+	var fileAnnotations []FileAnnotation
+	if fileAnnotationSet != nil {
+		fileAnnotations = fileAnnotationSet.FileAnnotations()
+	}
 
 	switch format {
 	case FormatText:
-		return printAsText(writer, fileAnnotationSet.FileAnnotations())
+		return printAsText(writer, fileAnnotations)
 	case FormatJSON:
-		return printAsJSON(writer, fileAnnotationSet.FileAnnotations())
+		return printAsJSON(writer, fileAnnotations)
 	case FormatMSVS:
-		return printAsMSVS(writer, fileAnnotationSet.FileAnnotations())
+		return printAsMSVS(writer, fileAnnotations)
 	case FormatJUnit:
-		return printAsJUnit(writer, fileAnnotationSet.FileAnnotations())
+		return printAsJUnit(writer, fileAnnotations)
 	case FormatGithubActions:
-		return printAsGithubActions(writer, fileAnnotationSet.FileAnnotations())
+		return printAsGithubActions(writer, fileAnnotations)
+	case FormatSARIF:
+		return printAsSARIF(writer, fileAnnotations, printFileAnnotationSetOptions.rules)
+	case FormatGitLabCodeQuality:
+		return printAsGitLabCodeQuality(writer, fileAnnotations)
+	case FormatCheckstyle:
+		return printAsCheckstyle(writer, fileAnnotations)
 	default:
 		return fmt.Errorf("unknown FileAnnotation Format: %v", format)
 	}
 }
+
+// PrintFileAnnotationSetOption is an option for PrintFileAnnotationSet.
+type PrintFileAnnotationSetOption func(*printFileAnnotationSetOptions)
+
+// PrintWithRules returns a new PrintFileAnnotationSetOption that provides the Rules
+// that the FileAnnotations may have been produced by.
+//
+// Rules are used to add rule descriptions to formats that support them, such as
+// FormatSARIF. Formats that do not support rule metadata ignore this option.
+func PrintWithRules(rules ...Rule) PrintFileAnnotationSetOption {
+	return func(printFileAnnotationSetOptions *printFileAnnotationSetOptions) {
+		printFileAnnotationSetOptions.rules = append(printFileAnnotationSetOptions.rules, rules...)
+	}
+}
+
+// *** PRIVATE ***
+
+type printFileAnnotationSetOptions struct {
+	rules []Rule
+}
+
+func newPrintFileAnnotationSetOptions() *printFileAnnotationSetOptions {
+	return &printFileAnnotationSetOptions{}
+}
@@ -15,6 +15,7 @@
 package bufanalysistesting
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -135,3 +136,388 @@ path/to/file.proto(2,1) : error FOO : Hello. (buf-plugin-foo)
 		sb.String(),
 	)
 }
+
+func TestSARIF(t *testing.T) {
+	t.Parallel()
+	fileAnnotations := []bufanalysis.FileAnnotation{
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			1,
+			2,
+			1,
+			5,
+			"FOO",
+			"Hello.",
+		),
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			0,
+			0,
+			0,
+			0,
+			"BAR",
+			"Goodbye.",
+			WithPluginName("buf-plugin-foo"),
+		),
+	}
+	sb := &strings.Builder{}
+	err := bufanalysis.PrintFileAnnotationSet(
+		sb,
+		bufanalysis.NewFileAnnotationSet(fileAnnotations...),
+		"sarif",
+		bufanalysis.PrintWithRules(
+			newRule("FOO", "Checks foo.", ""),
+			newRule("BAZ", "Checks baz.", ""),
+			newRule("BAR", "Checks bar.", "buf-plugin-foo"),
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "buf",
+          "informationUri": "https://buf.build",
+          "rules": [
+            {
+              "id": "FOO",
+              "shortDescription": {
+                "text": "Checks foo."
+              }
+            },
+            {
+              "id": "BAZ",
+              "shortDescription": {
+                "text": "Checks baz."
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "FOO",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {
+            "text": "Hello."
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "path/to/file.proto"
+                },
+                "region": {
+                  "startLine": 1,
+                  "startColumn": 2,
+                  "endLine": 1,
+                  "endColumn": 5
+                }
+              }
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "tool": {
+        "driver": {
+          "name": "buf-plugin-foo",
+          "informationUri": "https://buf.build",
+          "rules": [
+            {
+              "id": "BAR",
+              "shortDescription": {
+                "text": "Checks bar."
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "BAR",
+          "ruleIndex": 0,
+          "level": "error",
+          "message": {
+            "text": "Goodbye."
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "path/to/file.proto"
+                }
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`,
+		sb.String(),
+	)
+}
+
+func TestSARIFNoFileAnnotations(t *testing.T) {
+	t.Parallel()
+	sb := &strings.Builder{}
+	err := bufanalysis.PrintFileAnnotationSet(
+		sb,
+		nil,
+		"sarif",
+		bufanalysis.PrintWithRules(
+			newRule("FOO", "Checks foo.", ""),
+			newRule("BAR", "Checks bar.", "buf-plugin-foo"),
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "buf",
+          "informationUri": "https://buf.build",
+          "rules": [
+            {
+              "id": "FOO",
+              "shortDescription": {
+                "text": "Checks foo."
+              }
+            }
+          ]
+        }
+      },
+      "results": []
+    },
+    {
+      "tool": {
+        "driver": {
+          "name": "buf-plugin-foo",
+          "informationUri": "https://buf.build",
+          "rules": [
+            {
+              "id": "BAR",
+              "shortDescription": {
+                "text": "Checks bar."
+              }
+            }
+          ]
+        }
+      },
+      "results": []
+    }
+  ]
+}
+`,
+		sb.String(),
+	)
+
+	// Without rules, the run of buf is still printed.
+	sb.Reset()
+	require.NoError(t, bufanalysis.PrintFileAnnotationSet(sb, nil, "sarif"))
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Name string `json:"name"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []json.RawMessage `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &log))
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "buf", log.Runs[0].Tool.Driver.Name)
+	assert.NotNil(t, log.Runs[0].Results)
+	assert.Empty(t, log.Runs[0].Results)
+}
+
+func TestGitLabCodeQuality(t *testing.T) {
+	t.Parallel()
+	fileAnnotations := []bufanalysis.FileAnnotation{
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			1,
+			2,
+			3,
+			5,
+			"FOO",
+			"Hello.",
+		),
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			4,
+			2,
+			4,
+			5,
+			"FOO",
+			"Hello.",
+		),
+		newFileAnnotation(
+			t,
+			"",
+			0,
+			0,
+			0,
+			0,
+			"BAR",
+			"Goodbye.",
+			WithPluginName("buf-plugin-foo"),
+		),
+	}
+	sb := &strings.Builder{}
+	err := bufanalysis.PrintFileAnnotationSet(sb, bufanalysis.NewFileAnnotationSet(fileAnnotations...), "gitlab-code-quality")
+	require.NoError(t, err)
+	var issues []struct {
+		Description string `json:"description"`
+		CheckName   string `json:"check_name"`
+		Fingerprint string `json:"fingerprint"`
+		Severity    string `json:"severity"`
+		Location    struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+				End   int `json:"end"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &issues))
+	require.Len(t, issues, 3)
+	assert.Equal(t, "Goodbye. (buf-plugin-foo)", issues[0].Description)
+	assert.Equal(t, "BAR", issues[0].CheckName)
+	assert.Equal(t, "<input>", issues[0].Location.Path)
+	assert.Equal(t, 1, issues[0].Location.Lines.Begin)
+	assert.Equal(t, "Hello.", issues[1].Description)
+	assert.Equal(t, "FOO", issues[1].CheckName)
+	assert.Equal(t, "major", issues[1].Severity)
+	assert.Equal(t, "path/to/file.proto", issues[1].Location.Path)
+	assert.Equal(t, 1, issues[1].Location.Lines.Begin)
+	assert.Equal(t, 3, issues[1].Location.Lines.End)
+	assert.Equal(t, 4, issues[2].Location.Lines.Begin)
+	assert.Equal(t, 0, issues[2].Location.Lines.End)
+	// Fingerprints do not depend on line information, but are unique.
+	assert.NotEqual(t, issues[1].Fingerprint, issues[2].Fingerprint)
+	sb.Reset()
+	err = bufanalysis.PrintFileAnnotationSet(
+		sb,
+		bufanalysis.NewFileAnnotationSet(
+			newFileAnnotation(
+				t,
+				"path/to/file.proto",
+				10,
+				2,
+				10,
+				5,
+				"FOO",
+				"Hello.",
+			),
+		),
+		"gitlab-code-quality",
+	)
+	require.NoError(t, err)
+	var movedIssues []struct {
+		Fingerprint string `json:"fingerprint"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(sb.String()), &movedIssues))
+	require.Len(t, movedIssues, 1)
+	assert.Equal(t, issues[1].Fingerprint, movedIssues[0].Fingerprint)
+}
+
+func TestCheckstyle(t *testing.T) {
+	t.Parallel()
+	fileAnnotations := []bufanalysis.FileAnnotation{
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			1,
+			2,
+			1,
+			5,
+			"FOO",
+			"Hello <world>.",
+		),
+		newFileAnnotation(
+			t,
+			"path/to/file.proto",
+			0,
+			0,
+			0,
+			0,
+			"BAR",
+			"Goodbye.",
+			WithPluginName("buf-plugin-foo"),
+		),
+		newFileAnnotation(
+			t,
+			"path/to/other.proto",
+			3,
+			0,
+			3,
+			0,
+			"FOO",
+			"Hello.",
+		),
+	}
+	sb := &strings.Builder{}
+	err := bufanalysis.PrintFileAnnotationSet(sb, bufanalysis.NewFileAnnotationSet(fileAnnotations...), "checkstyle")
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="path/to/file.proto">
+    <error line="1" severity="error" message="Goodbye." source="buf-plugin-foo.BAR"></error>
+    <error line="1" column="2" severity="error" message="Hello &lt;world&gt;." source="FOO"></error>
+  </file>
+  <file name="path/to/other.proto">
+    <error line="3" severity="error" message="Hello." source="FOO"></error>
+  </file>
+</checkstyle>
+`,
+		sb.String(),
+	)
+}
+
+type testRule struct {
+	id         string
+	purpose    string
+	pluginName string
+}
+
+func newRule(id string, purpose string, pluginName string) *testRule {
+	return &testRule{
+		id:         id,
+		purpose:    purpose,
+		pluginName: pluginName,
+	}
+}
+
+func (r *testRule) ID() string {
+	return r.id
+}
+
+func (r *testRule) Purpose() string {
+	return r.purpose
+}
+
+func (r *testRule) PluginName() string {
+	return r.pluginName
+}
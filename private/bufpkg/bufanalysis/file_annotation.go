@@ -29,6 +29,8 @@ type fileAnnotation struct {
 	message     string
 	pluginName  string
 	policyName  string
+	// suggestedEdits are not part of the string representation.
+	suggestedEdits []SuggestedEdit
 }
 
 func newFileAnnotation(
@@ -41,8 +43,9 @@ func newFileAnnotation(
 	message string,
 	pluginName string,
 	policyName string,
+	options ...FileAnnotationOption,
 ) *fileAnnotation {
-	return &fileAnnotation{
+	fileAnnotation := &fileAnnotation{
 		fileInfo:    fileInfo,
 		startLine:   startLine,
 		startColumn: startColumn,
@@ -53,6 +56,10 @@ func newFileAnnotation(
 		pluginName:  pluginName,
 		policyName:  policyName,
 	}
+	for _, option := range options {
+		option(fileAnnotation)
+	}
+	return fileAnnotation
 }
 
 func (f *fileAnnotation) FileInfo() FileInfo {
@@ -91,6 +98,10 @@ func (f *fileAnnotation) PolicyName() string {
 	return f.policyName
 }
 
+func (f *fileAnnotation) SuggestedEdits() []SuggestedEdit {
+	return f.suggestedEdits
+}
+
 func (f *fileAnnotation) String() string {
 	if f == nil {
 		return ""
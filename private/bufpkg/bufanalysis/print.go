@@ -16,6 +16,8 @@ package bufanalysis
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -104,6 +106,157 @@ func printAsJUnit(writer io.Writer, fileAnnotations []FileAnnotation) error {
 	return nil
 }
 
+func printAsSARIF(writer io.Writer, fileAnnotations []FileAnnotation, rules []Rule) error {
+	// We emit one run per plugin, with the builtin rules being their own run. Every
+	// rule is listed in the run of its plugin whether or not it produced any results,
+	// so that a run without results still describes the rules that were checked. The
+	// order of runs is the order in which the plugins are first seen in the rules, and
+	// then in the fileAnnotations, which is deterministic as fileAnnotations are sorted.
+	pluginNameToRun := make(map[string]*externalSARIFRun)
+	runs := make([]*externalSARIFRun, 0)
+	getRun := func(pluginName string) *externalSARIFRun {
+		run, ok := pluginNameToRun[pluginName]
+		if !ok {
+			run = newExternalSARIFRun(pluginName)
+			pluginNameToRun[pluginName] = run
+			runs = append(runs, run)
+		}
+		return run
+	}
+	for _, rule := range rules {
+		getRun(rule.PluginName()).addRule(rule.ID(), rule.Purpose())
+	}
+	for _, fileAnnotation := range fileAnnotations {
+		getRun(fileAnnotation.PluginName()).addFileAnnotation(fileAnnotation)
+	}
+	if len(runs) == 0 {
+		// A log without runs does not say which tool was run, so we always emit
+		// at least the run of buf itself.
+		getRun("")
+	}
+	data, err := json.MarshalIndent(
+		externalSARIFLog{
+			Schema:  sarifSchemaURI,
+			Version: sarifVersion,
+			Runs:    runs,
+		},
+		"",
+		"  ",
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printAsGitLabCodeQuality(writer io.Writer, fileAnnotations []FileAnnotation) error {
+	externalIssues := make([]externalGitLabCodeQualityIssue, 0, len(fileAnnotations))
+	// GitLab uses fingerprints to track issues across pipelines, so we do not want
+	// to include line information, as that changes with unrelated edits. We instead
+	// disambiguate otherwise-identical FileAnnotations by their order of occurrence.
+	fingerprintToCount := make(map[string]int)
+	for _, fileAnnotation := range fileAnnotations {
+		externalIssue := newExternalGitLabCodeQualityIssue(fileAnnotation)
+		count := fingerprintToCount[externalIssue.Fingerprint]
+		fingerprintToCount[externalIssue.Fingerprint] = count + 1
+		if count > 0 {
+			externalIssue.Fingerprint = gitLabCodeQualityFingerprint(externalIssue.Fingerprint, strconv.Itoa(count))
+		}
+		externalIssues = append(externalIssues, externalIssue)
+	}
+	data, err := json.MarshalIndent(externalIssues, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printAsCheckstyle(writer io.Writer, fileAnnotations []FileAnnotation) error {
+	if _, err := writer.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	checkstyle := xml.StartElement{
+		Name: xml.Name{Local: "checkstyle"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: "4.3"},
+		},
+	}
+	if err := encoder.EncodeToken(checkstyle); err != nil {
+		return err
+	}
+	for _, annotations := range groupAnnotationsByPath(fileAnnotations) {
+		path := "<input>"
+		if fileInfo := annotations[0].FileInfo(); fileInfo != nil {
+			path = fileInfo.ExternalPath()
+		}
+		file := xml.StartElement{
+			Name: xml.Name{Local: "file"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Local: "name"}, Value: path},
+			},
+		}
+		if err := encoder.EncodeToken(file); err != nil {
+			return err
+		}
+		for _, annotation := range annotations {
+			if err := printFileAnnotationAsCheckstyle(encoder, annotation); err != nil {
+				return err
+			}
+		}
+		if err := encoder.EncodeToken(xml.EndElement{Name: file.Name}); err != nil {
+			return err
+		}
+	}
+	if err := encoder.EncodeToken(xml.EndElement{Name: checkstyle.Name}); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte("\n")); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printFileAnnotationAsCheckstyle(encoder *xml.Encoder, annotation FileAnnotation) error {
+	errorElement := xml.StartElement{
+		Name: xml.Name{Local: "error"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "line"}, Value: strconv.Itoa(atLeast1(annotation.StartLine()))},
+		},
+	}
+	// We only print column information if we have line information.
+	if annotation.StartLine() > 0 && annotation.StartColumn() > 0 {
+		errorElement.Attr = append(
+			errorElement.Attr,
+			xml.Attr{Name: xml.Name{Local: "column"}, Value: strconv.Itoa(annotation.StartColumn())},
+		)
+	}
+	source := annotation.Type()
+	if pluginName := annotation.PluginName(); pluginName != "" {
+		source = pluginName + "." + source
+	}
+	errorElement.Attr = append(
+		errorElement.Attr,
+		xml.Attr{Name: xml.Name{Local: "severity"}, Value: "error"},
+		xml.Attr{Name: xml.Name{Local: "message"}, Value: annotation.Message()},
+		xml.Attr{Name: xml.Name{Local: "source"}, Value: source},
+	)
+	if err := encoder.EncodeToken(errorElement); err != nil {
+		return err
+	}
+	return encoder.EncodeToken(xml.EndElement{Name: errorElement.Name})
+}
+
 func printFileAnnotationAsJUnit(encoder *xml.Encoder, annotation FileAnnotation) error {
 	testcase := xml.StartElement{Name: xml.Name{Local: "testcase"}}
 	name := annotation.Type()
@@ -305,6 +458,226 @@ func newExternalFileAnnotation(f FileAnnotation) externalFileAnnotation {
 	}
 }
 
+const (
+	sarifVersion            = "2.1.0"
+	sarifSchemaURI          = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName           = "buf"
+	sarifToolInformationURI = "https://buf.build"
+)
+
+type externalSARIFLog struct {
+	Schema  string              `json:"$schema"`
+	Version string              `json:"version"`
+	Runs    []*externalSARIFRun `json:"runs"`
+}
+
+type externalSARIFRun struct {
+	Tool    externalSARIFTool     `json:"tool"`
+	Results []externalSARIFResult `json:"results"`
+
+	ruleIDToIndex map[string]int
+}
+
+func newExternalSARIFRun(pluginName string) *externalSARIFRun {
+	name := sarifToolName
+	if pluginName != "" {
+		name = pluginName
+	}
+	return &externalSARIFRun{
+		Tool: externalSARIFTool{
+			Driver: externalSARIFDriver{
+				Name:           name,
+				InformationURI: sarifToolInformationURI,
+				Rules:          make([]externalSARIFRule, 0),
+			},
+		},
+		Results:       make([]externalSARIFResult, 0),
+		ruleIDToIndex: make(map[string]int),
+	}
+}
+
+// addRule adds the rule to the run if it has not been added yet, and returns its index.
+func (r *externalSARIFRun) addRule(ruleID string, purpose string) int {
+	if ruleIndex, ok := r.ruleIDToIndex[ruleID]; ok {
+		return ruleIndex
+	}
+	ruleIndex := len(r.Tool.Driver.Rules)
+	r.ruleIDToIndex[ruleID] = ruleIndex
+	externalRule := externalSARIFRule{
+		ID: ruleID,
+	}
+	if purpose != "" {
+		externalRule.ShortDescription = &externalSARIFMessage{
+			Text: purpose,
+		}
+	}
+	r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, externalRule)
+	return ruleIndex
+}
+
+func (r *externalSARIFRun) addFileAnnotation(f FileAnnotation) {
+	ruleID := f.Type()
+	if ruleID == "" {
+		// should never happen but just in case
+		ruleID = "FAILURE"
+	}
+	ruleIndex := r.addRule(ruleID, "")
+	message := f.Message()
+	if message == "" {
+		message = ruleID
+	}
+	path := "<input>"
+	if f.FileInfo() != nil {
+		path = f.FileInfo().ExternalPath()
+	}
+	physicalLocation := externalSARIFPhysicalLocation{
+		ArtifactLocation: externalSARIFArtifactLocation{
+			URI: path,
+		},
+	}
+	// We only print region information if we have line information.
+	if startLine := f.StartLine(); startLine > 0 {
+		physicalLocation.Region = &externalSARIFRegion{
+			StartLine:   startLine,
+			StartColumn: f.StartColumn(),
+			EndLine:     f.EndLine(),
+			EndColumn:   f.EndColumn(),
+		}
+	}
+	result := externalSARIFResult{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     "error",
+		Message: externalSARIFMessage{
+			Text: message,
+		},
+		Locations: []externalSARIFLocation{
+			{
+				PhysicalLocation: physicalLocation,
+			},
+		},
+	}
+	if policyName := f.PolicyName(); policyName != "" {
+		result.Properties = &externalSARIFProperties{
+			Policy: policyName,
+		}
+	}
+	r.Results = append(r.Results, result)
+}
+
+type externalSARIFTool struct {
+	Driver externalSARIFDriver `json:"driver"`
+}
+
+type externalSARIFDriver struct {
+	Name           string              `json:"name"`
+	InformationURI string              `json:"informationUri,omitempty"`
+	Rules          []externalSARIFRule `json:"rules"`
+}
+
+type externalSARIFRule struct {
+	ID               string                `json:"id"`
+	ShortDescription *externalSARIFMessage `json:"shortDescription,omitempty"`
+}
+
+type externalSARIFResult struct {
+	RuleID     string                   `json:"ruleId"`
+	RuleIndex  int                      `json:"ruleIndex"`
+	Level      string                   `json:"level"`
+	Message    externalSARIFMessage     `json:"message"`
+	Locations  []externalSARIFLocation  `json:"locations"`
+	Properties *externalSARIFProperties `json:"properties,omitempty"`
+}
+
+type externalSARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type externalSARIFLocation struct {
+	PhysicalLocation externalSARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type externalSARIFPhysicalLocation struct {
+	ArtifactLocation externalSARIFArtifactLocation `json:"artifactLocation"`
+	Region           *externalSARIFRegion          `json:"region,omitempty"`
+}
+
+type externalSARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type externalSARIFRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type externalSARIFProperties struct {
+	Policy string `json:"policy,omitempty"`
+}
+
+type externalGitLabCodeQualityIssue struct {
+	Description string                            `json:"description"`
+	CheckName   string                            `json:"check_name"`
+	Fingerprint string                            `json:"fingerprint"`
+	Severity    string                            `json:"severity"`
+	Location    externalGitLabCodeQualityLocation `json:"location"`
+}
+
+type externalGitLabCodeQualityLocation struct {
+	Path  string                         `json:"path"`
+	Lines externalGitLabCodeQualityLines `json:"lines"`
+}
+
+type externalGitLabCodeQualityLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end,omitempty"`
+}
+
+func newExternalGitLabCodeQualityIssue(f FileAnnotation) externalGitLabCodeQualityIssue {
+	path := "<input>"
+	if f.FileInfo() != nil {
+		path = f.FileInfo().ExternalPath()
+	}
+	description := f.Message()
+	if pluginName, policyName := f.PluginName(), f.PolicyName(); pluginName != "" || policyName != "" {
+		description += " (" + strings.Join(nonEmptyStrings(pluginName, policyName), ", ") + ")"
+	}
+	lines := externalGitLabCodeQualityLines{
+		Begin: atLeast1(f.StartLine()),
+	}
+	if endLine := f.EndLine(); endLine > lines.Begin {
+		lines.End = endLine
+	}
+	return externalGitLabCodeQualityIssue{
+		Description: description,
+		CheckName:   f.Type(),
+		Fingerprint: gitLabCodeQualityFingerprint(
+			path,
+			f.Type(),
+			f.Message(),
+			f.PluginName(),
+			f.PolicyName(),
+		),
+		Severity: "major",
+		Location: externalGitLabCodeQualityLocation{
+			Path:  path,
+			Lines: lines,
+		},
+	}
+}
+
+func gitLabCodeQualityFingerprint(values ...string) string {
+	hash := sha256.New()
+	for _, value := range values {
+		_, _ = hash.Write([]byte(value))
+		// Separate values so that ("ab", "c") and ("a", "bc") do not collide.
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 func printEachAnnotationOnNewLine(
 	writer io.Writer,
 	fileAnnotations []FileAnnotation,
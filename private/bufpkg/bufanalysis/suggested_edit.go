@@ -0,0 +1,75 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufanalysis
+
+import (
+	"fmt"
+)
+
+type renameSuggestedEdit struct {
+	fullName    string
+	newFullName string
+}
+
+func newRenameSuggestedEdit(fullName string, newFullName string) *renameSuggestedEdit {
+	return &renameSuggestedEdit{
+		fullName:    fullName,
+		newFullName: newFullName,
+	}
+}
+
+func (r *renameSuggestedEdit) FullName() string {
+	return r.fullName
+}
+
+func (r *renameSuggestedEdit) NewFullName() string {
+	return r.newFullName
+}
+
+func (r *renameSuggestedEdit) String() string {
+	return fmt.Sprintf("Rename %q to %q", r.fullName, r.newFullName)
+}
+
+func (*renameSuggestedEdit) isSuggestedEdit() {}
+
+func (*renameSuggestedEdit) isRenameSuggestedEdit() {}
+
+type removeImportSuggestedEdit struct {
+	path       string
+	importPath string
+}
+
+func newRemoveImportSuggestedEdit(path string, importPath string) *removeImportSuggestedEdit {
+	return &removeImportSuggestedEdit{
+		path:       path,
+		importPath: importPath,
+	}
+}
+
+func (r *removeImportSuggestedEdit) Path() string {
+	return r.path
+}
+
+func (r *removeImportSuggestedEdit) ImportPath() string {
+	return r.importPath
+}
+
+func (r *removeImportSuggestedEdit) String() string {
+	return fmt.Sprintf("Remove import %q from %q", r.importPath, r.path)
+}
+
+func (*removeImportSuggestedEdit) isSuggestedEdit() {}
+
+func (*removeImportSuggestedEdit) isRemoveImportSuggestedEdit() {}
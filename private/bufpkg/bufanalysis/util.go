@@ -20,3 +20,13 @@ func atLeast1(i int) int {
 	}
 	return i
 }
+
+func nonEmptyStrings(values ...string) []string {
+	nonEmpty := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+	return nonEmpty
+}
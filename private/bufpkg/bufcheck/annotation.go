@@ -25,6 +25,8 @@ type annotation struct {
 
 	pluginName string
 	policyName string
+	// suggestedEdits are only set for the annotations of builtin rules.
+	suggestedEdits []bufanalysis.SuggestedEdit
 }
 
 func newAnnotation(checkAnnotation check.Annotation, pluginName string, policyName string) *annotation {
@@ -92,5 +94,6 @@ func annotationToFileAnnotation(
 		annotation.Message(),
 		annotation.PluginName(),
 		annotation.PolicyName(),
+		bufanalysis.FileAnnotationWithSuggestedEdits(annotation.suggestedEdits...),
 	)
 }
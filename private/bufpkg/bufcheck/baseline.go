@@ -0,0 +1,459 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufprotosource"
+	"github.com/bufbuild/buf/private/pkg/syserror"
+)
+
+const baselineVersion = "v1"
+
+type baselineEntry struct {
+	ruleID     string
+	path       string
+	symbol     string
+	pluginName string
+	policyName string
+	count      int
+}
+
+func newBaselineEntry(
+	ruleID string,
+	path string,
+	symbol string,
+	pluginName string,
+	policyName string,
+	count int,
+) *baselineEntry {
+	return &baselineEntry{
+		ruleID:     ruleID,
+		path:       path,
+		symbol:     symbol,
+		pluginName: pluginName,
+		policyName: policyName,
+		count:      count,
+	}
+}
+
+func (b *baselineEntry) RuleID() string {
+	return b.ruleID
+}
+
+func (b *baselineEntry) Path() string {
+	return b.path
+}
+
+func (b *baselineEntry) Symbol() string {
+	return b.symbol
+}
+
+func (b *baselineEntry) PluginName() string {
+	return b.pluginName
+}
+
+func (b *baselineEntry) PolicyName() string {
+	return b.policyName
+}
+
+func (b *baselineEntry) Count() int {
+	return b.count
+}
+
+func (b *baselineEntry) String() string {
+	var sb strings.Builder
+	_, _ = sb.WriteString(b.ruleID)
+	_, _ = sb.WriteString(" in ")
+	if b.path != "" {
+		_, _ = sb.WriteString(b.path)
+	} else {
+		_, _ = sb.WriteString("<input>")
+	}
+	if b.symbol != "" {
+		_, _ = sb.WriteString(" at ")
+		_, _ = sb.WriteString(b.symbol)
+	}
+	if b.pluginName != "" {
+		_, _ = sb.WriteString(" (")
+		_, _ = sb.WriteString(b.pluginName)
+		_, _ = sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+func (*baselineEntry) isBaselineEntry() {}
+
+// key returns the fingerprint of the BaselineEntry, which excludes the count.
+func (b *baselineEntry) key() string {
+	return strings.Join([]string{b.ruleID, b.path, b.symbol, b.pluginName, b.policyName}, "\x00")
+}
+
+type baseline struct {
+	entries     []BaselineEntry
+	keyToCount  map[string]int
+	keyToEntry  map[string]BaselineEntry
+	orderedKeys []string
+}
+
+func newBaseline(baselineEntries []BaselineEntry) *baseline {
+	keyToCount := make(map[string]int)
+	keyToEntry := make(map[string]BaselineEntry)
+	var orderedKeys []string
+	for _, baselineEntry := range baselineEntries {
+		key := baselineEntryKey(baselineEntry)
+		if _, ok := keyToEntry[key]; !ok {
+			keyToEntry[key] = baselineEntry
+			orderedKeys = append(orderedKeys, key)
+		}
+		keyToCount[key] += baselineEntry.Count()
+	}
+	sort.Slice(
+		orderedKeys,
+		func(i int, j int) bool {
+			return baselineEntryLess(keyToEntry[orderedKeys[i]], keyToEntry[orderedKeys[j]])
+		},
+	)
+	entries := make([]BaselineEntry, 0, len(orderedKeys))
+	for _, key := range orderedKeys {
+		entries = append(entries, baselineEntryWithCount(keyToEntry[key], keyToCount[key]))
+	}
+	return &baseline{
+		entries:     entries,
+		keyToCount:  keyToCount,
+		keyToEntry:  keyToEntry,
+		orderedKeys: orderedKeys,
+	}
+}
+
+func (b *baseline) Entries() []BaselineEntry {
+	return b.entries
+}
+
+func (b *baseline) Filter(
+	fileAnnotations []bufanalysis.FileAnnotation,
+	baselineEntries []BaselineEntry,
+) ([]bufanalysis.FileAnnotation, []BaselineEntry, error) {
+	if len(fileAnnotations) != len(baselineEntries) {
+		return nil, nil, syserror.Newf(
+			"expected %d BaselineEntries for FileAnnotations but got %d",
+			len(fileAnnotations),
+			len(baselineEntries),
+		)
+	}
+	keyToRemainingCount := make(map[string]int, len(b.keyToCount))
+	for key, count := range b.keyToCount {
+		keyToRemainingCount[key] = count
+	}
+	var newFileAnnotations []bufanalysis.FileAnnotation
+	for i, fileAnnotation := range fileAnnotations {
+		key := baselineEntryKey(baselineEntries[i])
+		if keyToRemainingCount[key] > 0 {
+			keyToRemainingCount[key]--
+			continue
+		}
+		newFileAnnotations = append(newFileAnnotations, fileAnnotation)
+	}
+	var staleBaselineEntries []BaselineEntry
+	for _, key := range b.orderedKeys {
+		if remainingCount := keyToRemainingCount[key]; remainingCount > 0 {
+			staleBaselineEntries = append(staleBaselineEntries, baselineEntryWithCount(b.keyToEntry[key], remainingCount))
+		}
+	}
+	return newFileAnnotations, staleBaselineEntries, nil
+}
+
+func (*baseline) isBaseline() {}
+
+func newBaselineEntries(
+	ctx context.Context,
+	image bufimage.Image,
+	fileAnnotations []bufanalysis.FileAnnotation,
+) ([]BaselineEntry, error) {
+	files, err := bufprotosource.NewFiles(ctx, image.Files(), image.Resolver())
+	if err != nil {
+		return nil, err
+	}
+	filePathToFile, err := bufprotosource.FilePathToFile(files...)
+	if err != nil {
+		return nil, err
+	}
+	filePathToSymbolLocations := make(map[string][]*symbolLocation)
+	baselineEntries := make([]BaselineEntry, 0, len(fileAnnotations))
+	for _, fileAnnotation := range fileAnnotations {
+		var path string
+		var symbol string
+		if fileInfo := fileAnnotation.FileInfo(); fileInfo != nil {
+			path = fileInfo.Path()
+			if file, ok := filePathToFile[path]; ok {
+				symbolLocations, ok := filePathToSymbolLocations[path]
+				if !ok {
+					symbolLocations, err = getSymbolLocations(file)
+					if err != nil {
+						return nil, err
+					}
+					filePathToSymbolLocations[path] = symbolLocations
+				}
+				symbol = getInnermostSymbol(symbolLocations, fileAnnotation.StartLine(), fileAnnotation.StartColumn())
+			}
+		}
+		baselineEntries = append(
+			baselineEntries,
+			newBaselineEntry(
+				fileAnnotation.Type(),
+				path,
+				symbol,
+				fileAnnotation.PluginName(),
+				fileAnnotation.PolicyName(),
+				1,
+			),
+		)
+	}
+	return baselineEntries, nil
+}
+
+type symbolLocation struct {
+	fullName string
+	location bufprotosource.Location
+}
+
+// contains returns true if the 1-indexed line and column are within the location.
+//
+// If column is 0, only the line is checked.
+func (s *symbolLocation) contains(line int, column int) bool {
+	if line < s.location.StartLine() || line > s.location.EndLine() {
+		return false
+	}
+	if column == 0 {
+		return true
+	}
+	if line == s.location.StartLine() && column < s.location.StartColumn() {
+		return false
+	}
+	if line == s.location.EndLine() && column > s.location.EndColumn() {
+		return false
+	}
+	return true
+}
+
+// size returns a value that can be used to compare the size of locations that contain each other.
+func (s *symbolLocation) size() (int, int) {
+	return s.location.EndLine() - s.location.StartLine(), s.location.EndColumn() - s.location.StartColumn()
+}
+
+func getSymbolLocations(file bufprotosource.File) ([]*symbolLocation, error) {
+	var symbolLocations []*symbolLocation
+	add := func(namedDescriptor bufprotosource.NamedDescriptor) {
+		if location := namedDescriptor.Location(); location != nil {
+			symbolLocations = append(
+				symbolLocations,
+				&symbolLocation{
+					fullName: namedDescriptor.FullName(),
+					location: location,
+				},
+			)
+		}
+	}
+	if err := bufprotosource.ForEachMessage(
+		func(message bufprotosource.Message) error {
+			add(message)
+			for _, field := range message.Fields() {
+				add(field)
+			}
+			for _, oneof := range message.Oneofs() {
+				add(oneof)
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return nil, err
+	}
+	if err := bufprotosource.ForEachEnum(
+		func(enum bufprotosource.Enum) error {
+			add(enum)
+			for _, enumValue := range enum.Values() {
+				add(enumValue)
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return nil, err
+	}
+	if err := bufprotosource.ForEachExtension(
+		func(extension bufprotosource.Field) error {
+			add(extension)
+			return nil
+		},
+		file,
+	); err != nil {
+		return nil, err
+	}
+	for _, service := range file.Services() {
+		add(service)
+		for _, method := range service.Methods() {
+			add(method)
+		}
+	}
+	return symbolLocations, nil
+}
+
+// getInnermostSymbol returns the full name of the smallest symbol that contains
+// the 1-indexed line and column, or empty if no symbol contains them.
+func getInnermostSymbol(symbolLocations []*symbolLocation, line int, column int) string {
+	if line == 0 {
+		return ""
+	}
+	var innermost *symbolLocation
+	for _, symbolLocation := range symbolLocations {
+		if !symbolLocation.contains(line, column) {
+			continue
+		}
+		if innermost == nil {
+			innermost = symbolLocation
+			continue
+		}
+		lines, columns := symbolLocation.size()
+		innermostLines, innermostColumns := innermost.size()
+		if lines < innermostLines || (lines == innermostLines && columns < innermostColumns) {
+			innermost = symbolLocation
+		}
+	}
+	if innermost == nil {
+		return ""
+	}
+	return innermost.fullName
+}
+
+type externalBaseline struct {
+	Version string                  `json:"version,omitempty"`
+	Entries []externalBaselineEntry `json:"entries,omitempty"`
+}
+
+type externalBaselineEntry struct {
+	Rule   string `json:"rule,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+	Plugin string `json:"plugin,omitempty"`
+	Policy string `json:"policy,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+func readBaseline(reader io.Reader) (Baseline, error) {
+	var externalBaseline externalBaseline
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&externalBaseline); err != nil {
+		return nil, fmt.Errorf("could not read baseline: %w", err)
+	}
+	if externalBaseline.Version != baselineVersion {
+		return nil, fmt.Errorf("unknown baseline version: %q", externalBaseline.Version)
+	}
+	baselineEntries := make([]BaselineEntry, 0, len(externalBaseline.Entries))
+	for _, externalBaselineEntry := range externalBaseline.Entries {
+		if externalBaselineEntry.Rule == "" {
+			return nil, fmt.Errorf("baseline entry has no rule: %+v", externalBaselineEntry)
+		}
+		count := externalBaselineEntry.Count
+		if count <= 0 {
+			count = 1
+		}
+		baselineEntries = append(
+			baselineEntries,
+			newBaselineEntry(
+				externalBaselineEntry.Rule,
+				externalBaselineEntry.Path,
+				externalBaselineEntry.Symbol,
+				externalBaselineEntry.Plugin,
+				externalBaselineEntry.Policy,
+				count,
+			),
+		)
+	}
+	return newBaseline(baselineEntries), nil
+}
+
+func writeBaseline(writer io.Writer, baseline Baseline) error {
+	externalBaseline := externalBaseline{
+		Version: baselineVersion,
+	}
+	for _, baselineEntry := range baseline.Entries() {
+		externalBaselineEntry := externalBaselineEntry{
+			Rule:   baselineEntry.RuleID(),
+			Path:   baselineEntry.Path(),
+			Symbol: baselineEntry.Symbol(),
+			Plugin: baselineEntry.PluginName(),
+			Policy: baselineEntry.PolicyName(),
+		}
+		// We only write the count if there is more than one, to keep the file concise.
+		if count := baselineEntry.Count(); count > 1 {
+			externalBaselineEntry.Count = count
+		}
+		externalBaseline.Entries = append(externalBaseline.Entries, externalBaselineEntry)
+	}
+	data, err := json.MarshalIndent(externalBaseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+func baselineEntryKey(baselineEntry BaselineEntry) string {
+	return newBaselineEntry(
+		baselineEntry.RuleID(),
+		baselineEntry.Path(),
+		baselineEntry.Symbol(),
+		baselineEntry.PluginName(),
+		baselineEntry.PolicyName(),
+		0,
+	).key()
+}
+
+func baselineEntryWithCount(baselineEntry BaselineEntry, count int) BaselineEntry {
+	return newBaselineEntry(
+		baselineEntry.RuleID(),
+		baselineEntry.Path(),
+		baselineEntry.Symbol(),
+		baselineEntry.PluginName(),
+		baselineEntry.PolicyName(),
+		count,
+	)
+}
+
+func baselineEntryLess(a BaselineEntry, b BaselineEntry) bool {
+	if a.Path() != b.Path() {
+		return a.Path() < b.Path()
+	}
+	if a.Symbol() != b.Symbol() {
+		return a.Symbol() < b.Symbol()
+	}
+	if a.RuleID() != b.RuleID() {
+		return a.RuleID() < b.RuleID()
+	}
+	if a.PluginName() != b.PluginName() {
+		return a.PluginName() < b.PluginName()
+	}
+	return a.PolicyName() < b.PolicyName()
+}
@@ -16,12 +16,14 @@ package bufcheck
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 
 	"buf.build/go/bufplugin/check"
 	"buf.build/go/standard/xslices"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufplugin"
@@ -339,3 +341,93 @@ func GetDeprecatedIDToReplacementIDs[R RuleOrCategory](rulesOrCategories []R) (m
 	}
 	return idToReplacementIDs, nil
 }
+
+// BaselineEntry is an entry in a Baseline.
+//
+// A BaselineEntry identifies a FileAnnotation by a stable fingerprint of its rule ID,
+// file path, and the fully-qualified name of the enclosing symbol. Line and column
+// information is deliberately not part of the fingerprint, so that unrelated edits
+// to a file do not invalidate the Baseline.
+type BaselineEntry interface {
+	// Stringer returns the string representation for this BaselineEntry.
+	fmt.Stringer
+
+	// RuleID is the ID of the rule that produced the FileAnnotation.
+	RuleID() string
+	// Path is the path of the file, relative to the root of its module.
+	//
+	// May be empty if the FileAnnotation did not have a file.
+	Path() string
+	// Symbol is the fully-qualified name of the innermost symbol that encloses
+	// the FileAnnotation.
+	//
+	// May be empty if the FileAnnotation was not within a symbol, for example
+	// file-level options.
+	Symbol() string
+	// PluginName is the name of the plugin that produced the FileAnnotation.
+	//
+	// May be empty if the FileAnnotation did not originate from a plugin.
+	PluginName() string
+	// PolicyName is the name of the policy that produced the FileAnnotation.
+	//
+	// May be empty if the FileAnnotation did not originate from a policy.
+	PolicyName() string
+	// Count is the number of FileAnnotations that match this BaselineEntry.
+	//
+	// Always at least 1.
+	Count() int
+
+	isBaselineEntry()
+}
+
+// NewBaselineEntries returns a new BaselineEntry for each of the FileAnnotations.
+//
+// The returned BaselineEntries are in the same order as the FileAnnotations, and each
+// has a Count of 1. The Image is used to resolve the Symbol of each FileAnnotation,
+// and should be the Image that the FileAnnotations were produced for.
+func NewBaselineEntries(
+	ctx context.Context,
+	image bufimage.Image,
+	fileAnnotations []bufanalysis.FileAnnotation,
+) ([]BaselineEntry, error) {
+	return newBaselineEntries(ctx, image, fileAnnotations)
+}
+
+// Baseline is a set of BaselineEntries that represent known FileAnnotations to be ignored.
+type Baseline interface {
+	// Entries returns the BaselineEntries in the Baseline.
+	//
+	// BaselineEntries with the same fingerprint are merged, with their Counts summed.
+	// The BaselineEntries are sorted.
+	Entries() []BaselineEntry
+	// Filter filters out the FileAnnotations that are in the Baseline.
+	//
+	// The BaselineEntries must be the result of NewBaselineEntries for the FileAnnotations,
+	// that is they must be in the same order and of the same length as the FileAnnotations.
+	//
+	// Returns the FileAnnotations that are not in the Baseline, and the BaselineEntries of the
+	// Baseline that no longer match any FileAnnotation. If a BaselineEntry only partially matches,
+	// that is there are fewer matching FileAnnotations than its Count, it is returned with its
+	// Count set to the number of missing matches.
+	Filter(
+		fileAnnotations []bufanalysis.FileAnnotation,
+		baselineEntries []BaselineEntry,
+	) ([]bufanalysis.FileAnnotation, []BaselineEntry, error)
+
+	isBaseline()
+}
+
+// NewBaseline returns a new Baseline for the BaselineEntries.
+func NewBaseline(baselineEntries []BaselineEntry) Baseline {
+	return newBaseline(baselineEntries)
+}
+
+// ReadBaseline reads a Baseline previously written with WriteBaseline.
+func ReadBaseline(reader io.Reader) (Baseline, error) {
+	return readBaseline(reader)
+}
+
+// WriteBaseline writes the Baseline to the Writer.
+func WriteBaseline(writer io.Writer, baseline Baseline) error {
+	return writeBaseline(writer, baseline)
+}
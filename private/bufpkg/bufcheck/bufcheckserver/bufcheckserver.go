@@ -16,6 +16,7 @@ package bufcheckserver
 
 import (
 	"buf.build/go/bufplugin/check"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufcheckserver/internal/bufcheckserverbuild"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufcheckserver/internal/bufcheckserverutil"
 )
@@ -411,4 +412,45 @@ var (
 		},
 		Before: bufcheckserverutil.Before,
 	}
+
+	// ruleIDToSuggestEditsFunc are the functions that suggest edits for the builtin rules
+	// that have mechanical fixes, by rule ID. Rules have the same ID and fixes across versions.
+	ruleIDToSuggestEditsFunc = newRuleIDToSuggestEditsFunc(
+		bufcheckserverbuild.LintEnumPascalCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintEnumValuePrefixRuleSpecBuilder,
+		bufcheckserverbuild.LintEnumValueUpperSnakeCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintEnumZeroValueSuffixRuleSpecBuilder,
+		bufcheckserverbuild.LintFieldLowerSnakeCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintImportUsedRuleSpecBuilder,
+		bufcheckserverbuild.LintMessagePascalCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintOneofLowerSnakeCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintRPCPascalCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintRPCRequestStandardNameRuleSpecBuilder,
+		bufcheckserverbuild.LintRPCResponseStandardNameRuleSpecBuilder,
+		bufcheckserverbuild.LintServicePascalCaseRuleSpecBuilder,
+		bufcheckserverbuild.LintServiceSuffixRuleSpecBuilder,
+	)
 )
+
+// SuggestedEdits returns the edits that the builtin rule that produced the annotation
+// suggests to resolve it.
+//
+// The request is the request to one of the Specs of this package that the annotation
+// was produced for. Returns no edits if the rule has no mechanical fix.
+func SuggestedEdits(request check.Request, annotation check.Annotation) ([]bufanalysis.SuggestedEdit, error) {
+	suggestEditsFunc, ok := ruleIDToSuggestEditsFunc[annotation.RuleID()]
+	if !ok {
+		return nil, nil
+	}
+	return bufcheckserverutil.SuggestedEdits(suggestEditsFunc, request, annotation)
+}
+
+func newRuleIDToSuggestEditsFunc(
+	ruleSpecBuilders ...*bufcheckserverutil.RuleSpecBuilder,
+) map[string]bufcheckserverutil.SuggestEditsFunc {
+	ruleIDToSuggestEditsFunc := make(map[string]bufcheckserverutil.SuggestEditsFunc, len(ruleSpecBuilders))
+	for _, ruleSpecBuilder := range ruleSpecBuilders {
+		ruleIDToSuggestEditsFunc[ruleSpecBuilder.ID] = ruleSpecBuilder.SuggestEdits
+	}
+	return ruleIDToSuggestEditsFunc
+}
@@ -626,38 +626,43 @@ var (
 	}
 	// LintEnumPascalCaseRuleSpecBuilder is a rule spec builder.
 	LintEnumPascalCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "ENUM_PASCAL_CASE",
-		Purpose: "Checks that enums are PascalCase.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintEnumPascalCase,
+		ID:           "ENUM_PASCAL_CASE",
+		Purpose:      "Checks that enums are PascalCase.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintEnumPascalCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintEnumPascalCase,
 	}
 	// LintEnumValuePrefixRuleSpecBuilder is a rule spec builder.
 	LintEnumValuePrefixRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "ENUM_VALUE_PREFIX",
-		Purpose: "Checks that enum values are prefixed with ENUM_NAME_UPPER_SNAKE_CASE.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintEnumValuePrefix,
+		ID:           "ENUM_VALUE_PREFIX",
+		Purpose:      "Checks that enum values are prefixed with ENUM_NAME_UPPER_SNAKE_CASE.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintEnumValuePrefix,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintEnumValuePrefix,
 	}
 	// LintEnumValueUpperSnakeCaseRuleSpecBuilder is a rule spec builder.
 	LintEnumValueUpperSnakeCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "ENUM_VALUE_UPPER_SNAKE_CASE",
-		Purpose: "Checks that enum values are UPPER_SNAKE_CASE.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintEnumValueUpperSnakeCase,
+		ID:           "ENUM_VALUE_UPPER_SNAKE_CASE",
+		Purpose:      "Checks that enum values are UPPER_SNAKE_CASE.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintEnumValueUpperSnakeCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintEnumValueUpperSnakeCase,
 	}
 	// LintEnumZeroValueSuffixRuleSpecBuilder is a rule spec builder.
 	LintEnumZeroValueSuffixRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "ENUM_ZERO_VALUE_SUFFIX",
-		Purpose: `Checks that enum zero values have a consistent suffix (configurable, default suffix is "_UNSPECIFIED").`,
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintEnumZeroValueSuffix,
+		ID:           "ENUM_ZERO_VALUE_SUFFIX",
+		Purpose:      `Checks that enum zero values have a consistent suffix (configurable, default suffix is "_UNSPECIFIED").`,
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintEnumZeroValueSuffix,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintEnumZeroValueSuffix,
 	}
 	// LintFieldLowerSnakeCaseRuleSpecBuilder is a rule spec builder.
 	LintFieldLowerSnakeCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "FIELD_LOWER_SNAKE_CASE",
-		Purpose: "Checks that field names are lower_snake_case.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintFieldLowerSnakeCase,
+		ID:           "FIELD_LOWER_SNAKE_CASE",
+		Purpose:      "Checks that field names are lower_snake_case.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintFieldLowerSnakeCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintFieldLowerSnakeCase,
 	}
 	// LintFieldNoDescriptorRuleSpecBuilder is a rule spec builder.
 	LintFieldNoDescriptorRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
@@ -701,24 +706,27 @@ var (
 	}
 	// LintImportUsedRuleSpecBuilder is a rule spec builder.
 	LintImportUsedRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "IMPORT_USED",
-		Purpose: "Checks that imports are used.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintImportUsed,
+		ID:           "IMPORT_USED",
+		Purpose:      "Checks that imports are used.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintImportUsed,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintImportUsed,
 	}
 	// LintMessagePascalCaseRuleSpecBuilder is a rule spec builder.
 	LintMessagePascalCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "MESSAGE_PASCAL_CASE",
-		Purpose: "Checks that messages are PascalCase.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintMessagePascalCase,
+		ID:           "MESSAGE_PASCAL_CASE",
+		Purpose:      "Checks that messages are PascalCase.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintMessagePascalCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintMessagePascalCase,
 	}
 	// LintOneofLowerSnakeCaseRuleSpecBuilder is a rule spec builder.
 	LintOneofLowerSnakeCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "ONEOF_LOWER_SNAKE_CASE",
-		Purpose: "Checks that oneof names are lower_snake_case.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintOneofLowerSnakeCase,
+		ID:           "ONEOF_LOWER_SNAKE_CASE",
+		Purpose:      "Checks that oneof names are lower_snake_case.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintOneofLowerSnakeCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintOneofLowerSnakeCase,
 	}
 	// LintPackageDefinedRuleSpecBuilder is a rule spec builder.
 	LintPackageDefinedRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
@@ -834,10 +842,11 @@ var (
 	}
 	// LintRPCPascalCaseRuleSpecBuilder is a rule spec builder.
 	LintRPCPascalCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "RPC_PASCAL_CASE",
-		Purpose: "Checks that RPCs are PascalCase.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintRPCPascalCase,
+		ID:           "RPC_PASCAL_CASE",
+		Purpose:      "Checks that RPCs are PascalCase.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintRPCPascalCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintRPCPascalCase,
 	}
 	// LintRPCRequestResponseUniqueRuleSpecBuilder is a rule spec builder.
 	LintRPCRequestResponseUniqueRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
@@ -848,31 +857,35 @@ var (
 	}
 	// LintRPCRequestStandardNameRuleSpecBuilder is a rule spec builder.
 	LintRPCRequestStandardNameRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "RPC_REQUEST_STANDARD_NAME",
-		Purpose: "Checks that RPC request type names are RPCNameRequest or ServiceNameRPCNameRequest (configurable).",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintRPCRequestStandardName,
+		ID:           "RPC_REQUEST_STANDARD_NAME",
+		Purpose:      "Checks that RPC request type names are RPCNameRequest or ServiceNameRPCNameRequest (configurable).",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintRPCRequestStandardName,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintRPCRequestStandardName,
 	}
 	// LintRPCResponseStandardNameRuleSpecBuilder is a rule spec builder.
 	LintRPCResponseStandardNameRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "RPC_RESPONSE_STANDARD_NAME",
-		Purpose: "Checks that RPC response type names are RPCNameResponse or ServiceNameRPCNameResponse (configurable).",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintRPCResponseStandardName,
+		ID:           "RPC_RESPONSE_STANDARD_NAME",
+		Purpose:      "Checks that RPC response type names are RPCNameResponse or ServiceNameRPCNameResponse (configurable).",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintRPCResponseStandardName,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintRPCResponseStandardName,
 	}
 	// LintServicePascalCaseRuleSpecBuilder is a rule spec builder.
 	LintServicePascalCaseRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "SERVICE_PASCAL_CASE",
-		Purpose: "Checks that services are PascalCase.",
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintServicePascalCase,
+		ID:           "SERVICE_PASCAL_CASE",
+		Purpose:      "Checks that services are PascalCase.",
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintServicePascalCase,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintServicePascalCase,
 	}
 	// LintServiceSuffixRuleSpecBuilder is a rule spec builder.
 	LintServiceSuffixRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
-		ID:      "SERVICE_SUFFIX",
-		Purpose: `Checks that services have a consistent suffix (configurable, default suffix is "Service").`,
-		Type:    check.RuleTypeLint,
-		Handler: bufcheckserverhandle.HandleLintServiceSuffix,
+		ID:           "SERVICE_SUFFIX",
+		Purpose:      `Checks that services have a consistent suffix (configurable, default suffix is "Service").`,
+		Type:         check.RuleTypeLint,
+		Handler:      bufcheckserverhandle.HandleLintServiceSuffix,
+		SuggestEdits: bufcheckserverhandle.SuggestEditsLintServiceSuffix,
 	}
 	// LintStablePackageNoImportUnstableRuleSpecBuilder is a rule spec builder.
 	LintStablePackageNoImportUnstableRuleSpecBuilder = &bufcheckserverutil.RuleSpecBuilder{
@@ -0,0 +1,167 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcheckserverhandle
+
+import (
+	"slices"
+
+	"buf.build/go/bufplugin/check"
+	"buf.build/go/standard/xstrings"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufcheckserver/internal/bufcheckserverutil"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/internal/bufcheckopt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The suggest edits functions rename declarations to the names that the handle functions
+// of the same rules expect.
+var (
+	// SuggestEditsLintEnumPascalCase is a suggest edits function.
+	SuggestEditsLintEnumPascalCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestPascalCaseName)
+	// SuggestEditsLintEnumValuePrefix is a suggest edits function.
+	SuggestEditsLintEnumValuePrefix = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestEnumValuePrefixName)
+	// SuggestEditsLintEnumValueUpperSnakeCase is a suggest edits function.
+	SuggestEditsLintEnumValueUpperSnakeCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestUpperSnakeCaseName)
+	// SuggestEditsLintEnumZeroValueSuffix is a suggest edits function.
+	SuggestEditsLintEnumZeroValueSuffix = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestEnumZeroValueSuffixName)
+	// SuggestEditsLintFieldLowerSnakeCase is a suggest edits function.
+	SuggestEditsLintFieldLowerSnakeCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestLowerSnakeCaseName)
+	// SuggestEditsLintImportUsed is a suggest edits function.
+	SuggestEditsLintImportUsed bufcheckserverutil.SuggestEditsFunc = suggestEditsLintImportUsed
+	// SuggestEditsLintMessagePascalCase is a suggest edits function.
+	SuggestEditsLintMessagePascalCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestPascalCaseName)
+	// SuggestEditsLintOneofLowerSnakeCase is a suggest edits function.
+	SuggestEditsLintOneofLowerSnakeCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestLowerSnakeCaseName)
+	// SuggestEditsLintRPCPascalCase is a suggest edits function.
+	SuggestEditsLintRPCPascalCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestPascalCaseName)
+	// SuggestEditsLintRPCRequestStandardName is a suggest edits function.
+	SuggestEditsLintRPCRequestStandardName = newSuggestEditsLintRPCStandardName(
+		bufcheckserverutil.MethodInputTypeSourcePath,
+		protoreflect.MethodDescriptor.Input,
+		"Request",
+	)
+	// SuggestEditsLintRPCResponseStandardName is a suggest edits function.
+	SuggestEditsLintRPCResponseStandardName = newSuggestEditsLintRPCStandardName(
+		bufcheckserverutil.MethodOutputTypeSourcePath,
+		protoreflect.MethodDescriptor.Output,
+		"Response",
+	)
+	// SuggestEditsLintServicePascalCase is a suggest edits function.
+	SuggestEditsLintServicePascalCase = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestPascalCaseName)
+	// SuggestEditsLintServiceSuffix is a suggest edits function.
+	SuggestEditsLintServiceSuffix = bufcheckserverutil.NewRenameSuggestEditsFunc(suggestServiceSuffixName)
+)
+
+func suggestPascalCaseName(_ check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	return xstrings.ToPascalCase(string(descriptor.Name())), nil
+}
+
+func suggestLowerSnakeCaseName(_ check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	return fieldToLowerSnakeCase(string(descriptor.Name())), nil
+}
+
+func suggestUpperSnakeCaseName(_ check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	return fieldToUpperSnakeCase(string(descriptor.Name())), nil
+}
+
+func suggestEnumValuePrefixName(_ check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	enumValue, ok := descriptor.(protoreflect.EnumValueDescriptor)
+	if !ok {
+		return "", nil
+	}
+	enum, ok := enumValue.Parent().(protoreflect.EnumDescriptor)
+	if !ok {
+		return "", nil
+	}
+	return fieldToUpperSnakeCase(string(enum.Name())) + "_" + string(enumValue.Name()), nil
+}
+
+func suggestEnumZeroValueSuffixName(request check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	suffix, err := bufcheckopt.GetEnumZeroValueSuffix(request.Options())
+	if err != nil {
+		return "", err
+	}
+	return string(descriptor.Name()) + suffix, nil
+}
+
+func suggestServiceSuffixName(request check.Request, descriptor protoreflect.Descriptor) (string, error) {
+	suffix, err := bufcheckopt.GetServiceSuffix(request.Options())
+	if err != nil {
+		return "", err
+	}
+	return string(descriptor.Name()) + suffix, nil
+}
+
+func suggestEditsLintImportUsed(
+	_ check.Request,
+	descriptor protoreflect.Descriptor,
+	sourcePath protoreflect.SourcePath,
+) ([]bufanalysis.SuggestedEdit, error) {
+	fileDescriptor, ok := descriptor.(protoreflect.FileDescriptor)
+	if !ok {
+		return nil, nil
+	}
+	index, ok := bufcheckserverutil.FileDependencyIndex(sourcePath)
+	if !ok || index >= fileDescriptor.Imports().Len() {
+		return nil, nil
+	}
+	return []bufanalysis.SuggestedEdit{
+		bufanalysis.NewRemoveImportSuggestedEdit(
+			fileDescriptor.Path(),
+			fileDescriptor.Imports().Get(index).Path(),
+		),
+	}, nil
+}
+
+// newSuggestEditsLintRPCStandardName returns a new SuggestEditsFunc that renames the
+// request or response message of a method to the standard name, as long as no other
+// method uses the message.
+func newSuggestEditsLintRPCStandardName(
+	typeSourcePath protoreflect.SourcePath,
+	getType func(protoreflect.MethodDescriptor) protoreflect.MessageDescriptor,
+	suffix string,
+) bufcheckserverutil.SuggestEditsFunc {
+	return func(
+		request check.Request,
+		descriptor protoreflect.Descriptor,
+		sourcePath protoreflect.SourcePath,
+	) ([]bufanalysis.SuggestedEdit, error) {
+		method, ok := descriptor.(protoreflect.MethodDescriptor)
+		if !ok || !slices.Equal(sourcePath, typeSourcePath) {
+			return nil, nil
+		}
+		if method.Input().FullName() == method.Output().FullName() {
+			return nil, nil
+		}
+		message := getType(method)
+		for _, fileDescriptor := range request.FileDescriptors() {
+			services := fileDescriptor.ProtoreflectFileDescriptor().Services()
+			for i := range services.Len() {
+				methods := services.Get(i).Methods()
+				for j := range methods.Len() {
+					other := methods.Get(j)
+					if other.FullName() != method.FullName() &&
+						(other.Input().FullName() == message.FullName() || other.Output().FullName() == message.FullName()) {
+						return nil, nil
+					}
+				}
+			}
+		}
+		return bufcheckserverutil.RenameSuggestedEdits(
+			message,
+			xstrings.ToPascalCase(string(method.Name()))+suffix,
+		), nil
+	}
+}
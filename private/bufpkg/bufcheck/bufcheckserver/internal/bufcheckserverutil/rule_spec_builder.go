@@ -33,6 +33,10 @@ type RuleSpecBuilder struct {
 	ReplacementIDs []string
 	// Required.
 	Handler check.RuleHandler
+	// SuggestEdits returns the edits that resolve the annotations of the rule.
+	//
+	// Optional. If not set, the rule has no mechanical fix.
+	SuggestEdits SuggestEditsFunc
 }
 
 // Build builds the RuleSpec for the categories.
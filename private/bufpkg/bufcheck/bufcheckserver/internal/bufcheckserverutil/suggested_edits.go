@@ -0,0 +1,211 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcheckserverutil
+
+import (
+	"buf.build/go/bufplugin/check"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// The field numbers of the descriptor protos that contain other declarations.
+const (
+	fileMessageTypeFieldNumber   = 4
+	fileEnumTypeFieldNumber      = 5
+	fileServiceFieldNumber       = 6
+	fileExtensionFieldNumber     = 7
+	messageFieldFieldNumber      = 2
+	messageNestedTypeFieldNumber = 3
+	messageEnumTypeFieldNumber   = 4
+	messageExtensionFieldNumber  = 6
+	messageOneofDeclFieldNumber  = 8
+	enumValueFieldNumber         = 2
+	serviceMethodFieldNumber     = 2
+	declarationNameFieldNumber   = 1
+	fileDependencyFieldNumber    = 3
+	methodInputTypeFieldNumber   = 2
+	methodOutputTypeFieldNumber  = 3
+)
+
+// SuggestEditsFunc returns the edits that resolve an annotation of a rule.
+//
+// The descriptor is the innermost declaration that contains the location of the
+// annotation, and sourcePath is the path of the location relative to the declaration,
+// for example [1] for the name of a message. The request is the request that the
+// annotation was added for.
+//
+// Returns no edits if the annotation has no mechanical fix.
+type SuggestEditsFunc func(
+	request check.Request,
+	descriptor protoreflect.Descriptor,
+	sourcePath protoreflect.SourcePath,
+) ([]bufanalysis.SuggestedEdit, error)
+
+// SuggestedEdits returns the edits that the SuggestEditsFunc suggests for the annotation.
+//
+// Returns no edits if the annotation does not have a location within a file.
+func SuggestedEdits(
+	suggestEditsFunc SuggestEditsFunc,
+	request check.Request,
+	annotation check.Annotation,
+) ([]bufanalysis.SuggestedEdit, error) {
+	fileLocation := annotation.FileLocation()
+	if fileLocation == nil || len(fileLocation.SourcePath()) == 0 {
+		return nil, nil
+	}
+	descriptor, sourcePath := descriptorForSourcePath(
+		fileLocation.FileDescriptor().ProtoreflectFileDescriptor(),
+		fileLocation.SourcePath(),
+	)
+	return suggestEditsFunc(request, descriptor, sourcePath)
+}
+
+// NewRenameSuggestEditsFunc returns a new SuggestEditsFunc for annotations of the name
+// of a declaration, which suggests renaming the declaration to the name returned by f.
+//
+// If f returns an empty name or the current name, no edits are suggested.
+func NewRenameSuggestEditsFunc(
+	f func(request check.Request, descriptor protoreflect.Descriptor) (string, error),
+) SuggestEditsFunc {
+	return func(
+		request check.Request,
+		descriptor protoreflect.Descriptor,
+		sourcePath protoreflect.SourcePath,
+	) ([]bufanalysis.SuggestedEdit, error) {
+		if len(sourcePath) != 1 || sourcePath[0] != declarationNameFieldNumber {
+			return nil, nil
+		}
+		newName, err := f(request, descriptor)
+		if err != nil {
+			return nil, err
+		}
+		return RenameSuggestedEdits(descriptor, newName), nil
+	}
+}
+
+// RenameSuggestedEdits returns the edits that rename the declaration to the new name.
+//
+// Returns no edits if the new name is empty or the current name.
+func RenameSuggestedEdits(descriptor protoreflect.Descriptor, newName string) []bufanalysis.SuggestedEdit {
+	if newName == "" || newName == string(descriptor.Name()) {
+		return nil
+	}
+	return []bufanalysis.SuggestedEdit{
+		bufanalysis.NewRenameSuggestedEdit(
+			siblingFullName(descriptor, string(descriptor.Name())),
+			siblingFullName(descriptor, newName),
+		),
+	}
+}
+
+// MethodInputTypeSourcePath is the source path of the input type of a method, relative
+// to the method.
+var MethodInputTypeSourcePath = protoreflect.SourcePath{methodInputTypeFieldNumber}
+
+// MethodOutputTypeSourcePath is the source path of the output type of a method, relative
+// to the method.
+var MethodOutputTypeSourcePath = protoreflect.SourcePath{methodOutputTypeFieldNumber}
+
+// FileDependencyIndex returns the index of the import at the source path relative to a
+// file, or false if the source path is not of an import.
+func FileDependencyIndex(sourcePath protoreflect.SourcePath) (int, bool) {
+	if len(sourcePath) != 2 || sourcePath[0] != fileDependencyFieldNumber {
+		return 0, false
+	}
+	return int(sourcePath[1]), true
+}
+
+// siblingFullName returns the full name of the declaration with the given name that
+// has the same parent as the descriptor.
+//
+// Enum values are named within their enum rather than as siblings of their enum.
+func siblingFullName(descriptor protoreflect.Descriptor, name string) string {
+	parent := descriptor.Parent()
+	if parent == nil || parent.FullName() == "" {
+		return name
+	}
+	return string(parent.FullName()) + "." + name
+}
+
+// descriptorForSourcePath returns the innermost declaration of the file that contains
+// the source path, and the remainder of the source path relative to the declaration.
+func descriptorForSourcePath(
+	fileDescriptor protoreflect.FileDescriptor,
+	sourcePath protoreflect.SourcePath,
+) (protoreflect.Descriptor, protoreflect.SourcePath) {
+	var descriptor protoreflect.Descriptor = fileDescriptor
+	for len(sourcePath) >= 2 {
+		child := childDescriptor(descriptor, sourcePath[0], int(sourcePath[1]))
+		if child == nil {
+			break
+		}
+		descriptor = child
+		sourcePath = sourcePath[2:]
+	}
+	return descriptor, sourcePath
+}
+
+// childDescriptor returns the child declaration of the descriptor at the field number
+// and index, or nil if there is no such declaration.
+func childDescriptor(descriptor protoreflect.Descriptor, fieldNumber int32, index int) protoreflect.Descriptor {
+	switch t := descriptor.(type) {
+	case protoreflect.FileDescriptor:
+		switch fieldNumber {
+		case fileMessageTypeFieldNumber:
+			return getDescriptor[protoreflect.MessageDescriptor](t.Messages(), index)
+		case fileEnumTypeFieldNumber:
+			return getDescriptor[protoreflect.EnumDescriptor](t.Enums(), index)
+		case fileServiceFieldNumber:
+			return getDescriptor[protoreflect.ServiceDescriptor](t.Services(), index)
+		case fileExtensionFieldNumber:
+			return getDescriptor[protoreflect.ExtensionDescriptor](t.Extensions(), index)
+		}
+	case protoreflect.MessageDescriptor:
+		switch fieldNumber {
+		case messageFieldFieldNumber:
+			return getDescriptor[protoreflect.FieldDescriptor](t.Fields(), index)
+		case messageNestedTypeFieldNumber:
+			return getDescriptor[protoreflect.MessageDescriptor](t.Messages(), index)
+		case messageEnumTypeFieldNumber:
+			return getDescriptor[protoreflect.EnumDescriptor](t.Enums(), index)
+		case messageExtensionFieldNumber:
+			return getDescriptor[protoreflect.ExtensionDescriptor](t.Extensions(), index)
+		case messageOneofDeclFieldNumber:
+			return getDescriptor[protoreflect.OneofDescriptor](t.Oneofs(), index)
+		}
+	case protoreflect.EnumDescriptor:
+		if fieldNumber == enumValueFieldNumber {
+			return getDescriptor[protoreflect.EnumValueDescriptor](t.Values(), index)
+		}
+	case protoreflect.ServiceDescriptor:
+		if fieldNumber == serviceMethodFieldNumber {
+			return getDescriptor[protoreflect.MethodDescriptor](t.Methods(), index)
+		}
+	}
+	return nil
+}
+
+func getDescriptor[T protoreflect.Descriptor](
+	descriptors interface {
+		Len() int
+		Get(int) T
+	},
+	index int,
+) protoreflect.Descriptor {
+	if index < 0 || index >= descriptors.Len() {
+		return nil
+	}
+	return descriptors.Get(index)
+}
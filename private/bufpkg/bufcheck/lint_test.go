@@ -16,6 +16,7 @@ package bufcheck_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -324,6 +325,25 @@ func TestRunFieldLowerSnakeCase(t *testing.T) {
 	)
 }
 
+func TestRunSuggestedEdits(t *testing.T) {
+	t.Parallel()
+	testLintSuggestedEdits(
+		t,
+		"field_lower_snake_case",
+		map[string][]string{
+			"a.proto:8:9":  {`Rename "a.One.Fail" to "a.One.fail"`},
+			"a.proto:10:9": {`Rename "a.One.failThree" to "a.One.fail_three"`},
+		},
+	)
+	testLintSuggestedEdits(
+		t,
+		"import_used",
+		map[string][]string{
+			"a.proto:5:1": {`Remove import "sub/sub1.proto" from "a.proto"`},
+		},
+	)
+}
+
 func TestRunFieldNoDescriptor(t *testing.T) {
 	t.Parallel()
 	testLint(
@@ -1478,6 +1498,33 @@ func testLintWithOptions(
 	imageModifier func(bufimage.Image) bufimage.Image,
 	expectedFileAnnotations ...bufanalysis.FileAnnotation,
 ) {
+	err := testLintWithOptionsErr(
+		t,
+		relDirPath,
+		moduleFullNameString,
+		imageModifier,
+	)
+	if len(expectedFileAnnotations) == 0 {
+		assert.NoError(t, err)
+	} else {
+		var fileAnnotationSet bufanalysis.FileAnnotationSet
+		require.ErrorAs(t, err, &fileAnnotationSet, "error has unexpected type: %T", err)
+		bufanalysistesting.AssertFileAnnotationsEqual(
+			t,
+			expectedFileAnnotations,
+			fileAnnotationSet.FileAnnotations(),
+		)
+	}
+}
+
+// testLintWithOptionsErr runs lint and returns the resulting error.
+func testLintWithOptionsErr(
+	t *testing.T,
+	relDirPath string,
+	// only set if in workspace
+	moduleFullNameString string,
+	imageModifier func(bufimage.Image) bufimage.Image,
+) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second) // Increased timeout for Wasm runtime
 	defer cancel()
 
@@ -1551,22 +1598,39 @@ func testLintWithOptions(
 		}),
 	)
 	require.NoError(t, err)
-	err = client.Lint(
+	return client.Lint(
 		ctx,
 		lintConfig,
 		image,
 		bufcheck.WithPluginConfigs(workspace.PluginConfigs()...),
 		bufcheck.WithPolicyConfigs(workspace.PolicyConfigs()...),
 	)
-	if len(expectedFileAnnotations) == 0 {
-		assert.NoError(t, err)
-	} else {
-		var fileAnnotationSet bufanalysis.FileAnnotationSet
-		require.ErrorAs(t, err, &fileAnnotationSet, "error has unexpected type: %T", err)
-		bufanalysistesting.AssertFileAnnotationsEqual(
-			t,
-			expectedFileAnnotations,
-			fileAnnotationSet.FileAnnotations(),
+}
+
+// testLintSuggestedEdits asserts that the annotations at the given "path:line:column"
+// locations carry the given suggested edits.
+func testLintSuggestedEdits(
+	t *testing.T,
+	relDirPath string,
+	expectedLocationToSuggestedEdits map[string][]string,
+) {
+	err := testLintWithOptionsErr(t, relDirPath, "", nil)
+	var fileAnnotationSet bufanalysis.FileAnnotationSet
+	require.ErrorAs(t, err, &fileAnnotationSet, "error has unexpected type: %T", err)
+	locationToSuggestedEdits := make(map[string][]string)
+	for _, fileAnnotation := range fileAnnotationSet.FileAnnotations() {
+		location := fmt.Sprintf(
+			"%s:%d:%d",
+			fileAnnotation.FileInfo().Path(),
+			fileAnnotation.StartLine(),
+			fileAnnotation.StartColumn(),
 		)
+		if _, ok := expectedLocationToSuggestedEdits[location]; !ok {
+			continue
+		}
+		for _, suggestedEdit := range fileAnnotation.SuggestedEdits() {
+			locationToSuggestedEdits[location] = append(locationToSuggestedEdits[location], suggestedEdit.String())
+		}
 	}
+	assert.Equal(t, expectedLocationToSuggestedEdits, locationToSuggestedEdits)
 }
@@ -25,6 +25,7 @@ import (
 	"buf.build/go/bufplugin/check"
 	"buf.build/go/standard/xlog/xslog"
 	"buf.build/go/standard/xslices"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufcheckserver"
 	"github.com/bufbuild/buf/private/pkg/thread"
 )
 
@@ -108,6 +109,17 @@ func (c *multiClient) Check(ctx context.Context, request check.Request) ([]*anno
 						return newAnnotation(checkAnnotation, delegate.PluginName, delegate.PolicyName)
 					},
 				)
+				if delegate.PluginName == "" {
+					// Only the builtin rules suggest edits, as the check plugin protocol
+					// cannot carry them.
+					for _, annotation := range annotations {
+						suggestedEdits, err := bufcheckserver.SuggestedEdits(delegateRequest, annotation.Annotation)
+						if err != nil {
+							return err
+						}
+						annotation.suggestedEdits = suggestedEdits
+					}
+				}
 				lock.Lock()
 				allAnnotations = append(allAnnotations, annotations...)
 				lock.Unlock()
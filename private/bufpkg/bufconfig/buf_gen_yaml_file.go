@@ -499,7 +499,7 @@ type externalGeneratePluginConfigV2 struct {
 	Revision *int `json:"revision,omitempty" yaml:"revision,omitempty"`
 	// Local is the local path (either relative or absolute) to a binary or other runnable program which
 	// implements the protoc plugin interface. This can be one string (the program) or multiple (remaining
-	// strings are arguments to the program).
+	// strings are arguments to the program). A program with the .wasm extension is run as a Wasm plugin.
 	Local any `json:"local,omitempty" yaml:"local,omitempty"`
 	// ProtocBuiltin is the protoc built-in plugin name, in the form of 'java' instead of 'protoc-gen-java'.
 	ProtocBuiltin *string `json:"protoc_builtin,omitempty" yaml:"protoc_builtin,omitempty"`
@@ -455,6 +455,8 @@ func readBufYAMLFile(
 			rootToExcludes,
 			lintConfig,
 			breakingConfig,
+			// Only v2 buf.yaml files can configure format.
+			DefaultFormatConfig,
 		)
 		if err != nil {
 			return nil, err
@@ -491,6 +493,7 @@ func readBufYAMLFile(
 		// If a module does not have its own lint section, then we use this as the default.
 		defaultExternalLintConfig := externalBufYAMLFile.Lint
 		defaultExternalBreakingConfig := externalBufYAMLFile.Breaking
+		defaultExternalFormatConfig := externalBufYAMLFile.Format
 		var moduleConfigs []ModuleConfig
 		for _, externalModule := range externalModules {
 			dirPath := externalModule.Path
@@ -620,6 +623,14 @@ func readBufYAMLFile(
 			if err != nil {
 				return nil, err
 			}
+			externalFormatConfig := defaultExternalFormatConfig
+			if !externalModule.Format.isEmpty() {
+				externalFormatConfig = externalModule.Format
+			}
+			formatConfig, err := getFormatConfigForExternalFormatV2(externalFormatConfig)
+			if err != nil {
+				return nil, err
+			}
 			moduleConfig, err := newModuleConfig(
 				dirPath,
 				moduleFullName,
@@ -627,6 +638,7 @@ func readBufYAMLFile(
 				rootToExcludes,
 				lintConfig,
 				breakingConfig,
+				formatConfig,
 			)
 			if err != nil {
 				return nil, err
@@ -802,6 +814,9 @@ func writeBufYAMLFile(writer io.Writer, bufYAMLFile BufYAMLFile) error {
 		// takes care of the base case when writing buf.yaml files.
 		stringToExternalLint := make(map[string]externalBufYAMLFileLintV2)
 		stringToExternalBreaking := make(map[string]externalBufYAMLFileBreakingV1Beta1V1V2)
+		// The format config is inferred to be top-level in the same way, independently
+		// of lint and breaking, as it has no paths that are relative to the module.
+		stringToExternalFormat := make(map[string]externalBufYAMLFileFormatV2)
 
 		for _, moduleConfig := range bufYAMLFile.ModuleConfigs() {
 			moduleDirPath := moduleConfig.DirPath()
@@ -849,6 +864,14 @@ func writeBufYAMLFile(writer io.Writer, bufYAMLFile BufYAMLFile) error {
 			stringToExternalBreaking[string(externalBreakingData)] = externalBreaking
 			externalModule.Breaking = externalBreaking
 
+			externalFormat := getExternalFormatV2ForFormatConfig(moduleConfig.FormatConfig())
+			externalFormatData, err := json.Marshal(externalFormat)
+			if err != nil {
+				return syserror.Wrap(err)
+			}
+			stringToExternalFormat[string(externalFormatData)] = externalFormat
+			externalModule.Format = externalFormat
+
 			externalBufYAMLFile.Modules = append(externalBufYAMLFile.Modules, externalModule)
 		}
 
@@ -868,8 +891,18 @@ func writeBufYAMLFile(writer io.Writer, bufYAMLFile BufYAMLFile) error {
 				externalBufYAMLFile.Modules[i].Breaking = externalBufYAMLFileBreakingV1Beta1V1V2{}
 			}
 		}
+		if len(stringToExternalFormat) <= 1 {
+			externalFormat, err := getZeroOrSingleValueForMap(stringToExternalFormat)
+			if err != nil {
+				return syserror.Wrap(err)
+			}
+			externalBufYAMLFile.Format = externalFormat
+			for i := range externalBufYAMLFile.Modules {
+				externalBufYAMLFile.Modules[i].Format = externalBufYAMLFileFormatV2{}
+			}
+		}
 		if len(externalBufYAMLFile.Modules) == 1 && externalBufYAMLFile.Modules[0].Path == "." && len(externalBufYAMLFile.Modules[0].Excludes) == 0 {
-			// We know that lint and breaking will already be top-level from the above if statement.
+			// We know that lint, breaking, and format will already be top-level from the above if statements.
 			externalBufYAMLFile.Name = externalBufYAMLFile.Modules[0].Name
 			externalBufYAMLFile.Modules = []externalBufYAMLFileModuleV2{}
 		}
@@ -1279,6 +1312,46 @@ func getExternalBreakingForBreakingConfig(breakingConfig BreakingConfig, moduleD
 	return externalBreaking
 }
 
+func getFormatConfigForExternalFormatV2(externalFormat externalBufYAMLFileFormatV2) (FormatConfig, error) {
+	if externalFormat.isEmpty() {
+		return DefaultFormatConfig, nil
+	}
+	sortImports := true
+	if externalFormat.SortImports != nil {
+		sortImports = *externalFormat.SortImports
+	}
+	sortOptions := true
+	if externalFormat.SortOptions != nil {
+		sortOptions = *externalFormat.SortOptions
+	}
+	return newFormatConfig(
+		sortImports,
+		sortOptions,
+		externalFormat.AlignFields,
+		externalFormat.MaxLineLength,
+		externalFormat.NormalizeBlankLines,
+		externalFormat.SortEnumValues,
+		externalFormat.SortRPCs,
+	)
+}
+
+func getExternalFormatV2ForFormatConfig(formatConfig FormatConfig) externalBufYAMLFileFormatV2 {
+	externalFormat := externalBufYAMLFileFormatV2{}
+	// Only write sort_imports and sort_options if they differ from the default.
+	if !formatConfig.SortImports() {
+		externalFormat.SortImports = new(bool)
+	}
+	if !formatConfig.SortOptions() {
+		externalFormat.SortOptions = new(bool)
+	}
+	externalFormat.AlignFields = formatConfig.AlignFields()
+	externalFormat.MaxLineLength = formatConfig.MaxLineLength()
+	externalFormat.NormalizeBlankLines = formatConfig.NormalizeBlankLines()
+	externalFormat.SortEnumValues = formatConfig.SortEnumValues()
+	externalFormat.SortRPCs = formatConfig.SortRPCs()
+	return externalFormat
+}
+
 // externalBufYAMLFileV1Beta1V1 represents the v1 or v1beta1 buf.yaml file, which have
 // the same shape EXCEPT build.roots.
 //
@@ -1304,6 +1377,7 @@ type externalBufYAMLFileV2 struct {
 	Deps     []string                               `json:"deps,omitempty" yaml:"deps,omitempty"`
 	Lint     externalBufYAMLFileLintV2              `json:"lint,omitempty" yaml:"lint,omitempty"`
 	Breaking externalBufYAMLFileBreakingV1Beta1V1V2 `json:"breaking,omitempty" yaml:"breaking,omitempty"`
+	Format   externalBufYAMLFileFormatV2            `json:"format,omitempty" yaml:"format,omitempty"`
 	Plugins  []externalBufYAMLFilePluginV2          `json:"plugins,omitempty" yaml:"plugins,omitempty"`
 	Policies []externalBufYAMLFilePolicyV2          `json:"policies,omitempty" yaml:"policies,omitempty"`
 }
@@ -1316,6 +1390,7 @@ type externalBufYAMLFileModuleV2 struct {
 	Excludes []string                               `json:"excludes,omitempty" yaml:"excludes,omitempty"`
 	Lint     externalBufYAMLFileLintV2              `json:"lint,omitempty" yaml:"lint,omitempty"`
 	Breaking externalBufYAMLFileBreakingV1Beta1V1V2 `json:"breaking,omitempty" yaml:"breaking,omitempty"`
+	Format   externalBufYAMLFileFormatV2            `json:"format,omitempty" yaml:"format,omitempty"`
 }
 
 // externalBufYAMLFileBuildV1Beta1V1 represents build configuration within a v1 or
@@ -1423,6 +1498,29 @@ func (eb externalBufYAMLFileBreakingV1Beta1V1V2) isEmpty() bool {
 		!eb.DisableBuiltin
 }
 
+// externalBufYAMLFileFormatV2 represents format configuration within a v2 buf.yaml file.
+//
+// SortImports and SortOptions are pointers as they default to true.
+type externalBufYAMLFileFormatV2 struct {
+	SortImports         *bool `json:"sort_imports,omitempty" yaml:"sort_imports,omitempty"`
+	SortOptions         *bool `json:"sort_options,omitempty" yaml:"sort_options,omitempty"`
+	AlignFields         bool  `json:"align_fields,omitempty" yaml:"align_fields,omitempty"`
+	MaxLineLength       int   `json:"max_line_length,omitempty" yaml:"max_line_length,omitempty"`
+	NormalizeBlankLines bool  `json:"normalize_blank_lines,omitempty" yaml:"normalize_blank_lines,omitempty"`
+	SortEnumValues      bool  `json:"sort_enum_values,omitempty" yaml:"sort_enum_values,omitempty"`
+	SortRPCs            bool  `json:"sort_rpcs,omitempty" yaml:"sort_rpcs,omitempty"`
+}
+
+func (ef externalBufYAMLFileFormatV2) isEmpty() bool {
+	return ef.SortImports == nil &&
+		ef.SortOptions == nil &&
+		!ef.AlignFields &&
+		ef.MaxLineLength == 0 &&
+		!ef.NormalizeBlankLines &&
+		!ef.SortEnumValues &&
+		!ef.SortRPCs
+}
+
 // externalBufYAMLFilePluginV2 represents a single plugin config in a v2 buf.yaml file.
 type externalBufYAMLFilePluginV2 struct {
 	Plugin  any            `json:"plugin,omitempty" yaml:"plugin,omitempty"`
@@ -505,6 +505,114 @@ policies:
         - foo/foo.proto
 `,
 	)
+	testReadWriteBufYAMLFileRoundTrip(
+		t,
+		// input
+		`version: v2
+format:
+  sort_imports: false
+  align_fields: true
+  max_line_length: 100
+modules:
+  - path: proto
+  - path: vendor
+`,
+		// expected output
+		`version: v2
+modules:
+  - path: proto
+  - path: vendor
+format:
+  sort_imports: false
+  align_fields: true
+  max_line_length: 100
+`,
+	)
+	testReadWriteBufYAMLFileRoundTrip(
+		t,
+		// input
+		`version: v2
+format:
+  normalize_blank_lines: true
+modules:
+  - path: proto
+    format:
+      sort_options: false
+      sort_enum_values: true
+      sort_rpcs: true
+  - path: vendor
+`,
+		// expected output
+		`version: v2
+modules:
+  - path: proto
+    format:
+      sort_options: false
+      sort_enum_values: true
+      sort_rpcs: true
+  - path: vendor
+    format:
+      normalize_blank_lines: true
+`,
+	)
+}
+
+func TestBufYAMLFileFormatConfig(t *testing.T) {
+	t.Parallel()
+
+	bufYAMLFile := testReadBufYAMLFile(
+		t,
+		`version: v2
+modules:
+  - path: proto
+  - path: vendor
+    format:
+      sort_imports: false
+      align_fields: true
+      max_line_length: 80
+      normalize_blank_lines: true
+      sort_enum_values: true
+      sort_rpcs: true
+`,
+	)
+	formatConfig0 := bufYAMLFile.ModuleConfigs()[0].FormatConfig()
+	formatConfig1 := bufYAMLFile.ModuleConfigs()[1].FormatConfig()
+	require.Equal(t, DefaultFormatConfig, formatConfig0)
+	require.True(t, formatConfig0.SortImports())
+	require.True(t, formatConfig0.SortOptions())
+	require.False(t, formatConfig0.AlignFields())
+	require.Zero(t, formatConfig0.MaxLineLength())
+	require.False(t, formatConfig1.SortImports())
+	require.True(t, formatConfig1.SortOptions())
+	require.True(t, formatConfig1.AlignFields())
+	require.Equal(t, 80, formatConfig1.MaxLineLength())
+	require.True(t, formatConfig1.NormalizeBlankLines())
+	require.True(t, formatConfig1.SortEnumValues())
+	require.True(t, formatConfig1.SortRPCs())
+
+	bufYAMLFile = testReadBufYAMLFile(
+		t,
+		`version: v1
+`,
+	)
+	require.Equal(t, DefaultFormatConfig, bufYAMLFile.ModuleConfigs()[0].FormatConfig())
+
+	testReadBufYAMLFileFail(
+		t,
+		`version: v2
+format:
+  max_line_length: -1
+`,
+		"format.max_line_length must not be negative",
+	)
+	testReadBufYAMLFileFail(
+		t,
+		`version: v1
+format:
+  align_fields: true
+`,
+		"format",
+	)
 }
 
 func TestBufYAMLFileLintDisabled(t *testing.T) {
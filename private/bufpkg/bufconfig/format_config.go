@@ -0,0 +1,179 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfig
+
+import (
+	"errors"
+)
+
+// DefaultFormatConfig is the default format config.
+//
+// This is the style of buf format for modules without a format section, and is the only
+// style for v1beta1 and v1 buf.yaml files.
+var DefaultFormatConfig FormatConfig = newFormatConfigNoValidate(
+	true,
+	true,
+	false,
+	0,
+	false,
+	false,
+	false,
+)
+
+// FormatConfig is format configuration for a specific Module.
+type FormatConfig interface {
+	// SortImports returns true if imports are sorted.
+	//
+	// If false, imports are kept in the order of the source.
+	SortImports() bool
+	// SortOptions returns true if file options are sorted, with builtin options
+	// before custom options.
+	//
+	// If false, file options are kept in the order of the source.
+	SortOptions() bool
+	// AlignFields returns true if the "=" and numbers of consecutive fields and enum
+	// values are aligned in columns.
+	//
+	// Consecutive declarations are separated by blank lines and by any other declaration.
+	AlignFields() bool
+	// MaxLineLength returns the maximum length of a line that multiple compact options
+	// are written on.
+	//
+	// Compact options that fit within this length are written on a single line, and
+	// compact options that do not fit are written one option per line. If zero, there
+	// is no maximum length, and multiple compact options are always written one option
+	// per line.
+	MaxLineLength() int
+	// NormalizeBlankLines returns true if exactly one blank line is written around
+	// declarations with bodies, such as messages, enums, and services.
+	//
+	// If false, a single blank line is written wherever the source had one or more
+	// blank lines.
+	NormalizeBlankLines() bool
+	// SortEnumValues returns true if the values of enums are sorted by number.
+	//
+	// Values with the number zero are always written first.
+	SortEnumValues() bool
+	// SortRPCs returns true if the RPCs of services are sorted by name.
+	SortRPCs() bool
+
+	isFormatConfig()
+}
+
+// NewFormatConfig returns a new FormatConfig.
+func NewFormatConfig(
+	sortImports bool,
+	sortOptions bool,
+	alignFields bool,
+	maxLineLength int,
+	normalizeBlankLines bool,
+	sortEnumValues bool,
+	sortRPCs bool,
+) (FormatConfig, error) {
+	return newFormatConfig(
+		sortImports,
+		sortOptions,
+		alignFields,
+		maxLineLength,
+		normalizeBlankLines,
+		sortEnumValues,
+		sortRPCs,
+	)
+}
+
+// *** PRIVATE ***
+
+type formatConfig struct {
+	sortImports         bool
+	sortOptions         bool
+	alignFields         bool
+	maxLineLength       int
+	normalizeBlankLines bool
+	sortEnumValues      bool
+	sortRPCs            bool
+}
+
+func newFormatConfig(
+	sortImports bool,
+	sortOptions bool,
+	alignFields bool,
+	maxLineLength int,
+	normalizeBlankLines bool,
+	sortEnumValues bool,
+	sortRPCs bool,
+) (*formatConfig, error) {
+	if maxLineLength < 0 {
+		return nil, errors.New("format.max_line_length must not be negative")
+	}
+	return newFormatConfigNoValidate(
+		sortImports,
+		sortOptions,
+		alignFields,
+		maxLineLength,
+		normalizeBlankLines,
+		sortEnumValues,
+		sortRPCs,
+	), nil
+}
+
+func newFormatConfigNoValidate(
+	sortImports bool,
+	sortOptions bool,
+	alignFields bool,
+	maxLineLength int,
+	normalizeBlankLines bool,
+	sortEnumValues bool,
+	sortRPCs bool,
+) *formatConfig {
+	return &formatConfig{
+		sortImports:         sortImports,
+		sortOptions:         sortOptions,
+		alignFields:         alignFields,
+		maxLineLength:       maxLineLength,
+		normalizeBlankLines: normalizeBlankLines,
+		sortEnumValues:      sortEnumValues,
+		sortRPCs:            sortRPCs,
+	}
+}
+
+func (f *formatConfig) SortImports() bool {
+	return f.sortImports
+}
+
+func (f *formatConfig) SortOptions() bool {
+	return f.sortOptions
+}
+
+func (f *formatConfig) AlignFields() bool {
+	return f.alignFields
+}
+
+func (f *formatConfig) MaxLineLength() int {
+	return f.maxLineLength
+}
+
+func (f *formatConfig) NormalizeBlankLines() bool {
+	return f.normalizeBlankLines
+}
+
+func (f *formatConfig) SortEnumValues() bool {
+	return f.sortEnumValues
+}
+
+func (f *formatConfig) SortRPCs() bool {
+	return f.sortRPCs
+}
+
+func (*formatConfig) isFormatConfig() {}
@@ -97,6 +97,7 @@ type GeneratePluginConfig interface {
 	// This is not empty only when the plugin is local, binary or protoc builtin.
 	Strategy() GenerateStrategy
 	// Path returns the path, including arguments, to invoke the binary plugin.
+	// If the path has the .wasm extension, the plugin is a Wasm plugin.
 	//
 	// This is not empty only when the plugin is local.
 	Path() []string
@@ -44,6 +44,7 @@ func init() {
 		},
 		DefaultLintConfigV1,
 		DefaultBreakingConfigV1,
+		DefaultFormatConfig,
 	)
 	if err != nil {
 		panic(err.Error())
@@ -59,6 +60,7 @@ func init() {
 		},
 		DefaultLintConfigV2,
 		DefaultBreakingConfigV2,
+		DefaultFormatConfig,
 	)
 	if err != nil {
 		panic(err.Error())
@@ -145,6 +147,11 @@ type ModuleConfig interface {
 	//
 	// If this was not set, this will be set to the default breaking configuration.
 	BreakingConfig() BreakingConfig
+	// FormatConfig returns the format configuration.
+	//
+	// If this was not set, this will be set to the default format configuration.
+	// Only v2 buf.yaml files can set the format configuration.
+	FormatConfig() FormatConfig
 
 	isModuleConfig()
 }
@@ -157,6 +164,7 @@ func NewModuleConfig(
 	rootToExcludes map[string][]string,
 	lintConfig LintConfig,
 	breakingConfig BreakingConfig,
+	formatConfig FormatConfig,
 ) (ModuleConfig, error) {
 	return newModuleConfig(
 		dirPath,
@@ -165,6 +173,7 @@ func NewModuleConfig(
 		rootToExcludes,
 		lintConfig,
 		breakingConfig,
+		formatConfig,
 	)
 }
 
@@ -177,6 +186,7 @@ type moduleConfig struct {
 	rootToExcludes map[string][]string
 	lintConfig     LintConfig
 	breakingConfig BreakingConfig
+	formatConfig   FormatConfig
 }
 
 // All validations are syserrors as we only ever read ModuleConfigs.
@@ -187,6 +197,7 @@ func newModuleConfig(
 	rootToExcludes map[string][]string,
 	lintConfig LintConfig,
 	breakingConfig BreakingConfig,
+	formatConfig FormatConfig,
 ) (*moduleConfig, error) {
 	// Returns "." on empty input.
 	dirPath, err := normalpath.NormalizeAndValidate(dirPath)
@@ -199,6 +210,9 @@ func newModuleConfig(
 	if breakingConfig == nil {
 		return nil, errors.New("BreakingConfig was nil")
 	}
+	if formatConfig == nil {
+		return nil, errors.New("FormatConfig was nil")
+	}
 	lintFileVersion := lintConfig.FileVersion()
 	breakingFileVersion := breakingConfig.FileVersion()
 	if lintFileVersion != breakingFileVersion {
@@ -245,6 +259,7 @@ func newModuleConfig(
 		rootToExcludes: newRootToExcludes,
 		lintConfig:     lintConfig,
 		breakingConfig: breakingConfig,
+		formatConfig:   formatConfig,
 	}, nil
 }
 
@@ -272,4 +287,8 @@ func (m *moduleConfig) BreakingConfig() BreakingConfig {
 	return m.breakingConfig
 }
 
+func (m *moduleConfig) FormatConfig() FormatConfig {
+	return m.formatConfig
+}
+
 func (*moduleConfig) isModuleConfig() {}
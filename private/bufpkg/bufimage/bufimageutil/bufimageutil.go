@@ -32,6 +32,10 @@ const (
 	anyFullName = "google.protobuf.Any"
 
 	messageRangeInclusiveMax = 536870911
+
+	// The field numbers reserved for the implementation of Protocol Buffers.
+	implementationReservedRangeStart = 19000
+	implementationReservedRangeEnd   = 19999
 )
 
 var (
@@ -69,6 +73,25 @@ func FreeMessageRangeStrings(
 	return s, nil
 }
 
+// NextFreeFieldNumber returns the smallest field number that is not used by a field,
+// reserved, or part of an extension range of the given message.
+//
+// Field numbers reserved for the implementation of Protocol Buffers are skipped.
+// Returns 0 if there are no free field numbers.
+//
+// Not recursive.
+func NextFreeFieldNumber(message *descriptorpb.DescriptorProto) int32 {
+	for _, freeRange := range freeMessageRanges(message) {
+		if freeRange.start < implementationReservedRangeStart || freeRange.start > implementationReservedRangeEnd {
+			return freeRange.start
+		}
+		if freeRange.end > implementationReservedRangeEnd {
+			return implementationReservedRangeEnd + 1
+		}
+	}
+	return 0
+}
+
 // ImageFilterOption is an option that can be passed to ImageFilteredByTypesWithOptions.
 type ImageFilterOption func(*imageFilterOptions)
 
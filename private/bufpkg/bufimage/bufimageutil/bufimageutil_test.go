@@ -516,6 +516,47 @@ func TestEmptyFiles(t *testing.T) {
 	})
 }
 
+func TestNextFreeFieldNumber(t *testing.T) {
+	t.Parallel()
+	testNextFreeFieldNumber(t, 1, &descriptorpb.DescriptorProto{})
+	testNextFreeFieldNumber(
+		t,
+		4,
+		&descriptorpb.DescriptorProto{
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Number: proto.Int32(1)},
+				{Number: proto.Int32(3)},
+			},
+			ReservedRange: []*descriptorpb.DescriptorProto_ReservedRange{
+				{Start: proto.Int32(2), End: proto.Int32(3)},
+			},
+		},
+	)
+	testNextFreeFieldNumber(
+		t,
+		20000,
+		&descriptorpb.DescriptorProto{
+			ExtensionRange: []*descriptorpb.DescriptorProto_ExtensionRange{
+				{Start: proto.Int32(1), End: proto.Int32(19000)},
+			},
+		},
+	)
+	testNextFreeFieldNumber(
+		t,
+		0,
+		&descriptorpb.DescriptorProto{
+			ExtensionRange: []*descriptorpb.DescriptorProto_ExtensionRange{
+				{Start: proto.Int32(1), End: proto.Int32(messageRangeInclusiveMax + 1)},
+			},
+		},
+	)
+}
+
+func testNextFreeFieldNumber(t *testing.T, expected int32, message *descriptorpb.DescriptorProto) {
+	t.Helper()
+	assert.Equal(t, expected, NextFreeFieldNumber(message))
+}
+
 func getImage(ctx context.Context, logger *slog.Logger, testdataDir string, options ...bufimage.BuildImageOption) (storage.ReadWriteBucket, bufimage.Image, error) {
 	bucket, err := storageos.NewProvider().NewReadWriteBucket(testdataDir)
 	if err != nil {
@@ -20,6 +20,7 @@ import (
 	"io"
 	"log/slog"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"google.golang.org/protobuf/types/pluginpb"
 )
@@ -41,6 +42,13 @@ type ResponseWriter interface {
 		response *pluginpb.CodeGeneratorResponse,
 		pluginOut string,
 	) error
+	// Check compares the responses with the files on disk instead of writing them,
+	// and returns a FileAnnotation for each generated file that does not exist on
+	// disk or whose content differs from the file on disk. Jar and zip files are
+	// compared as a whole.
+	//
+	// No further calls can be made to the ResponseWriter after this call.
+	Check(ctx context.Context, options ...CheckOption) ([]bufanalysis.FileAnnotation, error)
 }
 
 // NewResponseWriter returns a new ResponseWriter.
@@ -67,6 +75,20 @@ func ResponseWriterWithCreateOutDirIfNotExists() ResponseWriterOption {
 	}
 }
 
+// CheckOption is an option for Check.
+type CheckOption func(*checkOptions)
+
+// CheckWithExtraFiles returns a new CheckOption that also returns a FileAnnotation
+// for each file in an output directory that was not generated.
+//
+// This should be set if the output directories are deleted prior to generation,
+// as these files would then be deleted.
+func CheckWithExtraFiles() CheckOption {
+	return func(checkOptions *checkOptions) {
+		checkOptions.extraFiles = true
+	}
+}
+
 // Cleaner deletes output locations prior to generation.
 //
 // This must be done before any interaction with  ResponseWriters, as multiple plugins may output to a single
@@ -0,0 +1,73 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufprotopluginos
+
+import (
+	"path/filepath"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+)
+
+const (
+	checkTypeStale   checkType = "STALE_FILE"
+	checkTypeMissing checkType = "MISSING_FILE"
+	checkTypeExtra   checkType = "EXTRA_FILE"
+)
+
+var checkTypeToMessage = map[checkType]string{
+	checkTypeStale:   "Generated file is out of date.",
+	checkTypeMissing: "Generated file does not exist.",
+	checkTypeExtra:   "File was not generated and would be deleted.",
+}
+
+// checkType is the type of a FileAnnotation returned by Check.
+type checkType string
+
+// newCheckFileAnnotation returns a new FileAnnotation for the file at the path
+// relative to pluginOut. If path is empty, the FileAnnotation is for pluginOut itself.
+func newCheckFileAnnotation(pluginOut string, path string, checkType checkType) bufanalysis.FileAnnotation {
+	externalPath := filepath.Clean(normalpath.Unnormalize(pluginOut))
+	if path != "" {
+		externalPath = filepath.Join(externalPath, normalpath.Unnormalize(path))
+	}
+	return bufanalysis.NewFileAnnotation(
+		&checkFileInfo{
+			path:         normalpath.Normalize(externalPath),
+			externalPath: externalPath,
+		},
+		0,
+		0,
+		0,
+		0,
+		string(checkType),
+		checkTypeToMessage[checkType],
+		"", // pluginName
+		"", // policyName
+	)
+}
+
+type checkFileInfo struct {
+	path         string
+	externalPath string
+}
+
+func (f *checkFileInfo) Path() string {
+	return f.path
+}
+
+func (f *checkFileInfo) ExternalPath() string {
+	return f.externalPath
+}
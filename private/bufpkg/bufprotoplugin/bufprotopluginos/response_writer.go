@@ -15,14 +15,19 @@
 package bufprotopluginos
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufprotoplugin"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/storage"
@@ -67,7 +72,10 @@ type responseWriter struct {
 	// This holds all of the buckets in-memory so that we only write
 	// the results to disk if all of the responses are successful.
 	closers []func() error
-	lock    sync.RWMutex
+	// Cache the functions used to compare all of the responses with
+	// the files on disk, in the same order as closers.
+	checkers []func(ctx context.Context, checkOptions *checkOptions) ([]bufanalysis.FileAnnotation, error)
+	lock     sync.RWMutex
 }
 
 func newResponseWriter(
@@ -111,6 +119,7 @@ func (w *responseWriter) AddResponse(
 	return w.addResponse(
 		ctx,
 		response,
+		pluginOut,
 		absPluginOut,
 		w.createOutDirIfNotExists,
 	)
@@ -133,21 +142,49 @@ func (w *responseWriter) Close() error {
 	// Re-initialize the cached values to be safe.
 	w.readWriteBuckets = make(map[string]storage.ReadWriteBucket)
 	w.closers = nil
+	w.checkers = nil
 	return nil
 }
 
+func (w *responseWriter) Check(
+	ctx context.Context,
+	options ...CheckOption,
+) ([]bufanalysis.FileAnnotation, error) {
+	checkOptions := newCheckOptions()
+	for _, option := range options {
+		option(checkOptions)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var fileAnnotations []bufanalysis.FileAnnotation
+	for _, checkFunc := range w.checkers {
+		checkFileAnnotations, err := checkFunc(ctx, checkOptions)
+		if err != nil {
+			return nil, err
+		}
+		fileAnnotations = append(fileAnnotations, checkFileAnnotations...)
+	}
+	// Re-initialize the cached values to be safe.
+	w.readWriteBuckets = make(map[string]storage.ReadWriteBucket)
+	w.closers = nil
+	w.checkers = nil
+	return fileAnnotations, nil
+}
+
 func (w *responseWriter) addResponse(
 	ctx context.Context,
 	response *pluginpb.CodeGeneratorResponse,
 	pluginOut string,
+	absPluginOut string,
 	createOutDirIfNotExists bool,
 ) error {
-	switch filepath.Ext(pluginOut) {
+	switch filepath.Ext(absPluginOut) {
 	case ".jar":
 		return w.writeZip(
 			ctx,
 			response,
 			pluginOut,
+			absPluginOut,
 			true,
 			createOutDirIfNotExists,
 		)
@@ -156,6 +193,7 @@ func (w *responseWriter) addResponse(
 			ctx,
 			response,
 			pluginOut,
+			absPluginOut,
 			false,
 			createOutDirIfNotExists,
 		)
@@ -164,6 +202,7 @@ func (w *responseWriter) addResponse(
 			ctx,
 			response,
 			pluginOut,
+			absPluginOut,
 			createOutDirIfNotExists,
 		)
 	}
@@ -172,6 +211,7 @@ func (w *responseWriter) addResponse(
 func (w *responseWriter) writeZip(
 	ctx context.Context,
 	response *pluginpb.CodeGeneratorResponse,
+	pluginOut string,
 	outFilePath string,
 	includeManifest bool,
 	createOutDirIfNotExists bool,
@@ -191,18 +231,13 @@ func (w *responseWriter) writeZip(
 		return nil
 	}
 	// OK to use os.Stat instead of os.Lstat here.
-	fileInfo, err := os.Stat(outDirPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			if createOutDirIfNotExists {
-				if err := os.MkdirAll(outDirPath, 0755); err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
+	//
+	// If the directory does not exist and should be created, it is created when
+	// the responseWriter is flushed so that nothing is written before then.
+	if fileInfo, err := os.Stat(outDirPath); err != nil {
+		if !os.IsNotExist(err) || !createOutDirIfNotExists {
+			return err
 		}
-		return err
 	} else if !fileInfo.IsDir() {
 		return fmt.Errorf("not a directory: %s", outDirPath)
 	}
@@ -224,6 +259,11 @@ func (w *responseWriter) writeZip(
 	// can write to the same files (re: insertion points).
 	w.readWriteBuckets[outFilePath] = readWriteBucket
 	w.closers = append(w.closers, func() (retErr error) {
+		if createOutDirIfNotExists {
+			if err := os.MkdirAll(outDirPath, 0755); err != nil {
+				return err
+			}
+		}
 		// We're done writing all of the content into this
 		// readWriteBucket, so we zip it when we flush.
 		file, err := os.Create(outFilePath)
@@ -236,12 +276,16 @@ func (w *responseWriter) writeZip(
 		// protoc does not compress.
 		return storagearchive.Zip(ctx, readWriteBucket, file, false)
 	})
+	w.checkers = append(w.checkers, func(ctx context.Context, _ *checkOptions) ([]bufanalysis.FileAnnotation, error) {
+		return checkZip(ctx, readWriteBucket, pluginOut, outFilePath)
+	})
 	return nil
 }
 
 func (w *responseWriter) writeDirectory(
 	ctx context.Context,
 	response *pluginpb.CodeGeneratorResponse,
+	pluginOut string,
 	outDirPath string,
 	createOutDirIfNotExists bool,
 ) error {
@@ -289,9 +333,137 @@ func (w *responseWriter) writeDirectory(
 		}
 		return nil
 	})
+	w.checkers = append(w.checkers, func(ctx context.Context, checkOptions *checkOptions) ([]bufanalysis.FileAnnotation, error) {
+		return w.checkDirectory(ctx, readWriteBucket, pluginOut, outDirPath, checkOptions.extraFiles)
+	})
 	return nil
 }
 
+// checkDirectory compares the files generated to the directory with the files in
+// the directory on disk.
+//
+// Files on disk within the out directories or files of other responses are not
+// extra files, as they are checked against the files generated to them.
+func (w *responseWriter) checkDirectory(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	pluginOut string,
+	outDirPath string,
+	extraFiles bool,
+) ([]bufanalysis.FileAnnotation, error) {
+	osReadBucket, err := w.storageosProvider.NewReadWriteBucket(
+		outDirPath,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		// None of the generated files exist.
+		osReadBucket = storagemem.NewReadWriteBucket()
+	}
+	var fileAnnotations []bufanalysis.FileAnnotation
+	if err := storage.WalkReadObjects(
+		ctx,
+		readBucket,
+		"",
+		func(readObject storage.ReadObject) error {
+			data, err := io.ReadAll(readObject)
+			if err != nil {
+				return err
+			}
+			osData, err := storage.ReadPath(ctx, osReadBucket, readObject.Path())
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					return err
+				}
+				fileAnnotations = append(
+					fileAnnotations,
+					newCheckFileAnnotation(pluginOut, readObject.Path(), checkTypeMissing),
+				)
+				return nil
+			}
+			if !bytes.Equal(data, osData) {
+				fileAnnotations = append(
+					fileAnnotations,
+					newCheckFileAnnotation(pluginOut, readObject.Path(), checkTypeStale),
+				)
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	if !extraFiles {
+		return fileAnnotations, nil
+	}
+	var nestedOutPaths []string
+	for otherOutPath := range w.readWriteBuckets {
+		relOutPath, err := filepath.Rel(outDirPath, otherOutPath)
+		if err != nil {
+			continue
+		}
+		relOutPath = normalpath.Normalize(relOutPath)
+		if relOutPath == "." || relOutPath == ".." || strings.HasPrefix(relOutPath, "../") {
+			continue
+		}
+		nestedOutPaths = append(nestedOutPaths, relOutPath)
+	}
+	if err := osReadBucket.Walk(
+		ctx,
+		"",
+		func(objectInfo storage.ObjectInfo) error {
+			for _, nestedOutPath := range nestedOutPaths {
+				if normalpath.EqualsOrContainsPath(nestedOutPath, objectInfo.Path(), normalpath.Relative) {
+					return nil
+				}
+			}
+			exists, err := storage.Exists(ctx, readBucket, objectInfo.Path())
+			if err != nil {
+				return err
+			}
+			if !exists {
+				fileAnnotations = append(
+					fileAnnotations,
+					newCheckFileAnnotation(pluginOut, objectInfo.Path(), checkTypeExtra),
+				)
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	return fileAnnotations, nil
+}
+
+// checkZip compares the jar or zip file generated from the bucket with the file on disk.
+func checkZip(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	pluginOut string,
+	outFilePath string,
+) ([]bufanalysis.FileAnnotation, error) {
+	osData, err := os.ReadFile(outFilePath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return []bufanalysis.FileAnnotation{
+			newCheckFileAnnotation(pluginOut, "", checkTypeMissing),
+		}, nil
+	}
+	buffer := bytes.NewBuffer(nil)
+	if err := storagearchive.Zip(ctx, readBucket, buffer, false); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(buffer.Bytes(), osData) {
+		return []bufanalysis.FileAnnotation{
+			newCheckFileAnnotation(pluginOut, "", checkTypeStale),
+		}, nil
+	}
+	return nil, nil
+}
+
 type responseWriterOptions struct {
 	createOutDirIfNotExists bool
 }
@@ -299,3 +471,11 @@ type responseWriterOptions struct {
 func newResponseWriterOptions() *responseWriterOptions {
 	return &responseWriterOptions{}
 }
+
+type checkOptions struct {
+	extraFiles bool
+}
+
+func newCheckOptions() *checkOptions {
+	return &checkOptions{}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufprotopluginstore stores CodeGeneratorResponses.
+package bufprotopluginstore
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// ResponseStore reads and writes CodeGeneratorResponses by key.
+//
+// Keys are lowercase hex-encoded digests of everything that determines a response,
+// such as the CodeGeneratorRequests and the identity of the plugin.
+type ResponseStore interface {
+	// GetResponse gets the CodeGeneratorResponse for the key.
+	//
+	// Returns an error that fulfills fs.ErrNotExist if there is no response for the key.
+	GetResponse(ctx context.Context, key string) (*pluginpb.CodeGeneratorResponse, error)
+	// PutResponse puts the CodeGeneratorResponse for the key.
+	PutResponse(ctx context.Context, key string, response *pluginpb.CodeGeneratorResponse) error
+	// Prune deletes the responses that were last got or put before the given time.
+	//
+	// Returns the number of deleted responses.
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// NewResponseStore returns a new ResponseStore for the given root directory.
+//
+// It is assumed that the ResponseStore has complete control of the directory, and the
+// directory must exist. The locker synchronizes access to the directory between processes.
+//
+// This is typically used to interact with a cache directory.
+func NewResponseStore(
+	logger *slog.Logger,
+	rootDirPath string,
+	locker filelock.Locker,
+) ResponseStore {
+	return newResponseStore(logger, rootDirPath, locker)
+}
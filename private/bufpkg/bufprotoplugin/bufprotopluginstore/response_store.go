@@ -0,0 +1,236 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufprotopluginstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"buf.build/go/standard/xlog/xslog"
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	responseFileExt = ".binpb"
+	tempFileExt     = ".tmp"
+	lockFileExt     = ".lock"
+)
+
+type responseStore struct {
+	logger      *slog.Logger
+	rootDirPath string
+	locker      filelock.Locker
+}
+
+func newResponseStore(
+	logger *slog.Logger,
+	rootDirPath string,
+	locker filelock.Locker,
+) *responseStore {
+	return &responseStore{
+		logger:      logger,
+		rootDirPath: rootDirPath,
+		locker:      locker,
+	}
+}
+
+func (r *responseStore) GetResponse(
+	ctx context.Context,
+	key string,
+) (_ *pluginpb.CodeGeneratorResponse, retErr error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	unlocker, err := r.locker.RLock(ctx, getLockPath(key))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, unlocker.Unlock())
+	}()
+	filePath := r.getFilePath(key)
+	data, err := os.ReadFile(filePath)
+	r.logger.DebugContext(
+		ctx,
+		"response store get",
+		slog.String("key", key),
+		slog.Bool("found", err == nil),
+	)
+	if err != nil {
+		return nil, err
+	}
+	response := &pluginpb.CodeGeneratorResponse{}
+	if err := protoencoding.NewWireUnmarshaler(nil).Unmarshal(data, response); err != nil {
+		// A corrupted response is treated as not found, and is overwritten by the next put.
+		r.logger.DebugContext(ctx, "response store invalid response", slog.String("key", key), xslog.ErrorAttr(err))
+		return nil, &fs.PathError{Op: "read", Path: filePath, Err: fs.ErrNotExist}
+	}
+	// Record the use of the response for Prune. Concurrent readers may race on this,
+	// which is fine as they all set the time to now.
+	now := time.Now()
+	if err := os.Chtimes(filePath, now, now); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (r *responseStore) PutResponse(
+	ctx context.Context,
+	key string,
+	response *pluginpb.CodeGeneratorResponse,
+) (retErr error) {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	data, err := protoencoding.NewWireMarshaler().Marshal(response)
+	if err != nil {
+		return err
+	}
+	unlocker, err := r.locker.Lock(ctx, getLockPath(key))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, unlocker.Unlock())
+	}()
+	r.logger.DebugContext(ctx, "response store put", slog.String("key", key))
+	filePath := r.getFilePath(key)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it, so that a partially-written response
+	// is never read, even if buf is interrupted.
+	file, err := os.CreateTemp(filepath.Dir(filePath), key+".*"+tempFileExt)
+	if err != nil {
+		return err
+	}
+	tempFilePath := file.Name()
+	defer func() {
+		if retErr != nil {
+			retErr = errors.Join(retErr, os.Remove(tempFilePath))
+		}
+	}()
+	if _, err := file.Write(data); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, filePath)
+}
+
+func (r *responseStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	dirEntries, err := os.ReadDir(r.rootDirPath)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		shardCount, err := r.pruneShard(ctx, dirEntry.Name(), before)
+		count += shardCount
+		if err != nil {
+			return count, err
+		}
+	}
+	r.logger.DebugContext(ctx, "response store prune", slog.Int("count", count))
+	return count, nil
+}
+
+// pruneShard prunes the responses in the directory of the shard.
+//
+// Temporary files are deleted if they are older than before as well, as they are
+// left behind if buf is killed while putting a response.
+func (r *responseStore) pruneShard(ctx context.Context, shard string, before time.Time) (_ int, retErr error) {
+	if err := validateKey(shard); err != nil {
+		// Not a directory of this store.
+		return 0, nil
+	}
+	unlocker, err := r.locker.Lock(ctx, getLockPath(shard))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		retErr = errors.Join(retErr, unlocker.Unlock())
+	}()
+	shardDirPath := filepath.Join(r.rootDirPath, shard)
+	dirEntries, err := os.ReadDir(shardDirPath)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		isResponseFile := strings.HasSuffix(name, responseFileExt)
+		if !isResponseFile && !strings.HasSuffix(name, tempFileExt) {
+			continue
+		}
+		fileInfo, err := dirEntry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return count, err
+		}
+		if !fileInfo.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(shardDirPath, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return count, err
+		}
+		if isResponseFile {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getFilePath returns the path of the file of the response for the key.
+//
+// Responses are sharded into directories by the first two characters of their keys.
+func (r *responseStore) getFilePath(key string) string {
+	return filepath.Join(r.rootDirPath, key[:2], key+responseFileExt)
+}
+
+// getLockPath returns the path of the lock file of the shard of the key.
+//
+// There is a lock file per shard rather than per key, so that the number of
+// lock files is bounded.
+func getLockPath(key string) string {
+	return key[:2] + lockFileExt
+}
+
+func validateKey(key string) error {
+	if len(key) < 2 {
+		return fmt.Errorf("invalid response store key %q", key)
+	}
+	for _, c := range key {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("invalid response store key %q", key)
+		}
+	}
+	return nil
+}
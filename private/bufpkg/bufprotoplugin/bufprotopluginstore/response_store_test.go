@@ -0,0 +1,119 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufprotopluginstore
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"github.com/bufbuild/buf/private/pkg/slogtestext"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestResponseStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	responseStore := newTestResponseStore(t)
+	key := "0123456789abcdef"
+	response := &pluginpb.CodeGeneratorResponse{
+		SupportedFeatures: proto.Uint64(uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)),
+		File: []*pluginpb.CodeGeneratorResponse_File{
+			{
+				Name:    proto.String("a/v1/a.txt"),
+				Content: proto.String("content"),
+			},
+		},
+	}
+
+	_, err := responseStore.GetResponse(ctx, key)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.NoError(t, responseStore.PutResponse(ctx, key, response))
+	actualResponse, err := responseStore.GetResponse(ctx, key)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(response, actualResponse))
+
+	// Overwriting a response is allowed.
+	response.File[0].Content = proto.String("other content")
+	require.NoError(t, responseStore.PutResponse(ctx, key, response))
+	actualResponse, err = responseStore.GetResponse(ctx, key)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(response, actualResponse))
+
+	_, err = responseStore.GetResponse(ctx, "../foo")
+	require.Error(t, err)
+	require.Error(t, responseStore.PutResponse(ctx, "A", response))
+}
+
+func TestResponseStoreCorrupted(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	responseStore := newTestResponseStore(t)
+	key := "abcdef"
+	require.NoError(t, responseStore.PutResponse(ctx, key, &pluginpb.CodeGeneratorResponse{}))
+	require.NoError(t, os.WriteFile(responseStore.getFilePath(key), []byte{0xff}, 0600))
+	_, err := responseStore.GetResponse(ctx, key)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestResponseStorePrune(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	responseStore := newTestResponseStore(t)
+	response := &pluginpb.CodeGeneratorResponse{}
+	for _, key := range []string{"aa01", "aa02", "bb01"} {
+		require.NoError(t, responseStore.PutResponse(ctx, key, response))
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	for _, key := range []string{"aa01", "bb01"} {
+		require.NoError(t, os.Chtimes(responseStore.getFilePath(key), lastWeek, lastWeek))
+	}
+	// Getting a response marks it as used.
+	_, err := responseStore.GetResponse(ctx, "bb01")
+	require.NoError(t, err)
+	// A temporary file left behind by an interrupted put.
+	tempFilePath := filepath.Join(responseStore.rootDirPath, "aa", "aa03.123"+tempFileExt)
+	require.NoError(t, os.WriteFile(tempFilePath, nil, 0600))
+	require.NoError(t, os.Chtimes(tempFilePath, lastWeek, lastWeek))
+
+	count, err := responseStore.Prune(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	_, err = responseStore.GetResponse(ctx, "aa01")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = responseStore.GetResponse(ctx, "aa02")
+	require.NoError(t, err)
+	_, err = responseStore.GetResponse(ctx, "bb01")
+	require.NoError(t, err)
+	_, err = os.Stat(tempFilePath)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func newTestResponseStore(t *testing.T) *responseStore {
+	tempDirPath := t.TempDir()
+	rootDirPath := filepath.Join(tempDirPath, "responses")
+	require.NoError(t, os.Mkdir(rootDirPath, 0755))
+	lockDirPath := filepath.Join(tempDirPath, "locks")
+	require.NoError(t, os.Mkdir(lockDirPath, 0755))
+	locker, err := filelock.NewLocker(lockDirPath)
+	require.NoError(t, err)
+	return newResponseStore(slogtestext.NewLogger(t), rootDirPath, locker)
+}
@@ -17,6 +17,8 @@ package protostat
 import (
 	"context"
 	"io"
+	"maps"
+	"slices"
 
 	"github.com/bufbuild/protocompile/ast"
 	"github.com/bufbuild/protocompile/parser"
@@ -39,12 +41,45 @@ type Stats struct {
 	RPCs                  int `json:"rpcs" yaml:"rpcs"`
 	Extensions            int `json:"extensions" yaml:"extensions"`
 	FilesWithSyntaxErrors int `json:"-" yaml:"-"`
+
+	// UnaryRPCs and StreamingRPCs partition RPCs. An RPC is streaming if its request,
+	// response, or both are streams.
+	UnaryRPCs     int `json:"unary_rpcs" yaml:"unary_rpcs"`
+	StreamingRPCs int `json:"streaming_rpcs" yaml:"streaming_rpcs"`
+	// Deprecated is the number of messages, fields, enums, enum values, services,
+	// RPCs and extensions with the deprecated option set to true.
+	Deprecated int `json:"deprecated" yaml:"deprecated"`
+	// MaxMessageDepth is the maximum nesting depth of messages. Top-level messages
+	// have a depth of 1.
+	MaxMessageDepth int `json:"max_message_depth" yaml:"max_message_depth"`
+	// MaxFieldsPerMessage is the maximum number of fields in a single message.
+	MaxFieldsPerMessage int `json:"max_fields_per_message" yaml:"max_fields_per_message"`
+	// FieldsPerMessage is the average number of fields per message.
+	FieldsPerMessage float64 `json:"fields_per_message" yaml:"fields_per_message"`
+	// Commented is the number of messages, fields, enums, enum values, services,
+	// RPCs and extensions that have a leading comment.
+	Commented int `json:"commented" yaml:"commented"`
+	// CommentCoverage is the percentage of messages, fields, enums, enum values,
+	// services, RPCs and extensions that have a leading comment.
+	CommentCoverage float64 `json:"comment_coverage" yaml:"comment_coverage"`
+	// Syntaxes is the number of files per syntax, such as "proto3", or edition, such
+	// as "edition 2023".
+	Syntaxes map[string]int `json:"syntaxes" yaml:"syntaxes"`
+}
+
+// GroupStats represents statistics about a group of Protobuf files, such as the files
+// of a package.
+type GroupStats struct {
+	// Name is the name of the group, such as the package name or file path.
+	Name string `json:"name" yaml:"name"`
+
+	*Stats `yaml:",inline"`
 }
 
 // FileWalker goes through all .proto files for GetStats.
 type FileWalker interface {
 	// Walk will invoke f for all .proto files for GetStats.
-	Walk(ctx context.Context, f func(io.Reader) error) error
+	Walk(ctx context.Context, f func(path string, file io.Reader) error) error
 }
 
 // GetStats gathers some simple statistics about a set of Protobuf files.
@@ -52,6 +87,93 @@ type FileWalker interface {
 // See the packages protostatos and protostatstorage for helpers for the
 // os and storage packages.
 func GetStats(ctx context.Context, fileWalker FileWalker) (*Stats, error) {
+	groupStats, err := getGroupStats(
+		ctx,
+		fileWalker,
+		func(string, *ast.FileNode) string {
+			return ""
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupStats) == 0 {
+		return newStatsBuilder().build(), nil
+	}
+	return groupStats[0].Stats, nil
+}
+
+// GetStatsByPackage gathers statistics about a set of Protobuf files for each package,
+// sorted by package name.
+//
+// Files without a package are grouped under the empty package name.
+func GetStatsByPackage(ctx context.Context, fileWalker FileWalker) ([]*GroupStats, error) {
+	return getGroupStats(
+		ctx,
+		fileWalker,
+		func(_ string, fileNode *ast.FileNode) string {
+			for _, decl := range fileNode.Decls {
+				if packageNode, ok := decl.(*ast.PackageNode); ok {
+					return string(packageNode.Name.AsIdentifier())
+				}
+			}
+			return ""
+		},
+	)
+}
+
+// GetStatsByFile gathers statistics about each of a set of Protobuf files, sorted by
+// file path.
+func GetStatsByFile(ctx context.Context, fileWalker FileWalker) ([]*GroupStats, error) {
+	return getGroupStats(
+		ctx,
+		fileWalker,
+		func(path string, _ *ast.FileNode) string {
+			return path
+		},
+	)
+}
+
+// MergeStats merged multiple stats objects into one single Stats object.
+//
+// A new object is returned.
+func MergeStats(statsSlice ...*Stats) *Stats {
+	resultStats := &Stats{
+		Syntaxes: make(map[string]int),
+	}
+	for _, stats := range statsSlice {
+		resultStats.Files += stats.Files
+		resultStats.FilesWithSyntaxErrors += stats.FilesWithSyntaxErrors
+		resultStats.Packages += stats.Packages
+		resultStats.Types += stats.Types
+		resultStats.Messages += stats.Messages
+		resultStats.Fields += stats.Fields
+		resultStats.Enums += stats.Enums
+		resultStats.EnumValues += stats.EnumValues
+		resultStats.Services += stats.Services
+		resultStats.RPCs += stats.RPCs
+		resultStats.Extensions += stats.Extensions
+		resultStats.UnaryRPCs += stats.UnaryRPCs
+		resultStats.StreamingRPCs += stats.StreamingRPCs
+		resultStats.Deprecated += stats.Deprecated
+		resultStats.MaxMessageDepth = max(resultStats.MaxMessageDepth, stats.MaxMessageDepth)
+		resultStats.MaxFieldsPerMessage = max(resultStats.MaxFieldsPerMessage, stats.MaxFieldsPerMessage)
+		resultStats.Commented += stats.Commented
+		for syntax, files := range stats.Syntaxes {
+			resultStats.Syntaxes[syntax] += files
+		}
+	}
+	computeRatios(resultStats)
+	return resultStats
+}
+
+// getGroupStats gathers statistics about a set of Protobuf files, grouped by the
+// name returned by groupName for each file, and sorted by group name.
+func getGroupStats(
+	ctx context.Context,
+	fileWalker FileWalker,
+	groupName func(path string, fileNode *ast.FileNode) string,
+) ([]*GroupStats, error) {
 	handler := reporter.NewHandler(
 		reporter.NewReporter(
 			func(reporter.ErrorWithPos) error {
@@ -61,141 +183,250 @@ func GetStats(ctx context.Context, fileWalker FileWalker) (*Stats, error) {
 			nil,
 		),
 	)
-	statsBuilder := newStatsBuilder()
+	nameToStatsBuilder := make(map[string]*statsBuilder)
 	if err := fileWalker.Walk(
 		ctx,
-		func(file io.Reader) error {
+		func(path string, file io.Reader) error {
 			// This can return an error and non-nil AST.
-			// We do not need the filePath because we do not report errors.
-			astRoot, err := parser.Parse("", file, handler)
+			astRoot, err := parser.Parse(path, file, handler)
 			if astRoot == nil {
 				// No AST implies an I/O error trying to read the
 				// file contents. No stats to collect.
 				return err
 			}
+			name := groupName(path, astRoot)
+			statsBuilder, ok := nameToStatsBuilder[name]
+			if !ok {
+				statsBuilder = newStatsBuilder()
+				nameToStatsBuilder[name] = statsBuilder
+			}
 			if err != nil {
 				// There was a syntax error, but we still have a partial
 				// AST we can examine.
 				statsBuilder.FilesWithSyntaxErrors++
 			}
-			examineFile(statsBuilder, astRoot)
+			statsBuilder.examineFile(astRoot)
 			return nil
 		},
 	); err != nil {
 		return nil, err
 	}
-	statsBuilder.Packages = len(statsBuilder.packages)
-	return statsBuilder.Stats, nil
+	groupStats := make([]*GroupStats, 0, len(nameToStatsBuilder))
+	for _, name := range slices.Sorted(maps.Keys(nameToStatsBuilder)) {
+		groupStats = append(groupStats, &GroupStats{
+			Name:  name,
+			Stats: nameToStatsBuilder[name].build(),
+		})
+	}
+	return groupStats, nil
 }
 
-// MergeStats merged multiple stats objects into one single Stats object.
-//
-// A new object is returned.
-func MergeStats(statsSlice ...*Stats) *Stats {
-	resultStats := &Stats{}
-	for _, stats := range statsSlice {
-		resultStats.Files += stats.Files
-		resultStats.FilesWithSyntaxErrors += stats.FilesWithSyntaxErrors
-		resultStats.Packages += stats.Packages
-		resultStats.Types += stats.Types
-		resultStats.Messages += stats.Messages
-		resultStats.Fields += stats.Fields
-		resultStats.Enums += stats.Enums
-		resultStats.EnumValues += stats.EnumValues
-		resultStats.Services += stats.Services
-		resultStats.RPCs += stats.RPCs
-		resultStats.Extensions += stats.Extensions
+// computeRatios computes the fields of stats that are derived from its counts.
+func computeRatios(stats *Stats) {
+	stats.FieldsPerMessage = 0
+	if stats.Messages > 0 {
+		stats.FieldsPerMessage = float64(stats.Fields) / float64(stats.Messages)
+	}
+	stats.CommentCoverage = 0
+	elements := stats.Messages + stats.Fields + stats.Enums + stats.EnumValues + stats.Services + stats.RPCs + stats.Extensions
+	if elements > 0 {
+		stats.CommentCoverage = 100 * float64(stats.Commented) / float64(elements)
 	}
-	return resultStats
 }
 
 type statsBuilder struct {
 	*Stats
 
 	packages map[ast.Identifier]struct{}
+	// fileNode is the file currently being examined.
+	fileNode *ast.FileNode
 }
 
 func newStatsBuilder() *statsBuilder {
 	return &statsBuilder{
-		Stats:    &Stats{},
+		Stats: &Stats{
+			Syntaxes: make(map[string]int),
+		},
 		packages: make(map[ast.Identifier]struct{}),
 	}
 }
 
-func examineFile(statsBuilder *statsBuilder, fileNode *ast.FileNode) {
-	statsBuilder.Files++
+func (b *statsBuilder) build() *Stats {
+	b.Packages = len(b.packages)
+	computeRatios(b.Stats)
+	return b.Stats
+}
+
+func (b *statsBuilder) examineFile(fileNode *ast.FileNode) {
+	b.fileNode = fileNode
+	b.Files++
+	switch {
+	case fileNode.Edition != nil:
+		b.Syntaxes["edition "+fileNode.Edition.Edition.AsString()]++
+	case fileNode.Syntax != nil:
+		b.Syntaxes[fileNode.Syntax.Syntax.AsString()]++
+	default:
+		// Files without a syntax declaration are proto2.
+		b.Syntaxes["proto2"]++
+	}
 	for _, decl := range fileNode.Decls {
 		switch decl := decl.(type) {
 		case *ast.PackageNode:
-			statsBuilder.packages[decl.Name.AsIdentifier()] = struct{}{}
+			b.packages[decl.Name.AsIdentifier()] = struct{}{}
 		case *ast.MessageNode:
-			examineMessage(statsBuilder, &decl.MessageBody)
+			b.examineElement(decl, decl.Decls)
+			b.examineMessage(&decl.MessageBody, 1)
 		case *ast.EnumNode:
-			examineEnum(statsBuilder, decl)
+			b.examineEnum(decl)
 		case *ast.ExtendNode:
-			examineExtend(statsBuilder, decl)
+			b.examineExtend(decl, 0)
 		case *ast.ServiceNode:
-			statsBuilder.Services++
+			b.Services++
+			b.examineElement(decl, decl.Decls)
 			for _, decl := range decl.Decls {
-				_, ok := decl.(*ast.RPCNode)
+				rpcNode, ok := decl.(*ast.RPCNode)
 				if ok {
-					statsBuilder.RPCs++
-					statsBuilder.Types++
+					b.RPCs++
+					b.Types++
+					if rpcNode.Input.Stream != nil || rpcNode.Output.Stream != nil {
+						b.StreamingRPCs++
+					} else {
+						b.UnaryRPCs++
+					}
+					b.examineElement(rpcNode, rpcNode.Decls)
 				}
 			}
 		}
 	}
 }
 
-func examineMessage(statsBuilder *statsBuilder, messageBody *ast.MessageBody) {
-	statsBuilder.Messages++
-	statsBuilder.Types++
+// examineMessage examines a message at the given nesting depth.
+func (b *statsBuilder) examineMessage(messageBody *ast.MessageBody, depth int) {
+	b.Messages++
+	b.Types++
+	b.MaxMessageDepth = max(b.MaxMessageDepth, depth)
+	fields := 0
 	for _, decl := range messageBody.Decls {
 		switch decl := decl.(type) {
-		case *ast.FieldNode, *ast.MapFieldNode:
-			statsBuilder.Fields++
+		case *ast.FieldNode:
+			fields++
+			b.Fields++
+			b.examineElement(decl, decl.Options)
+		case *ast.MapFieldNode:
+			fields++
+			b.Fields++
+			b.examineElement(decl, decl.Options)
 		case *ast.GroupNode:
-			statsBuilder.Fields++
-			examineMessage(statsBuilder, &decl.MessageBody)
+			fields++
+			b.Fields++
+			b.examineGroup(decl, depth+1)
 		case *ast.OneofNode:
 			for _, ooDecl := range decl.Decls {
 				switch ooDecl := ooDecl.(type) {
 				case *ast.FieldNode:
-					statsBuilder.Fields++
+					fields++
+					b.Fields++
+					b.examineElement(ooDecl, ooDecl.Options)
 				case *ast.GroupNode:
-					statsBuilder.Fields++
-					examineMessage(statsBuilder, &ooDecl.MessageBody)
+					fields++
+					b.Fields++
+					b.examineGroup(ooDecl, depth+1)
 				}
 			}
 		case *ast.MessageNode:
-			examineMessage(statsBuilder, &decl.MessageBody)
+			b.examineElement(decl, decl.Decls)
+			b.examineMessage(&decl.MessageBody, depth+1)
 		case *ast.EnumNode:
-			examineEnum(statsBuilder, decl)
+			b.examineEnum(decl)
 		case *ast.ExtendNode:
-			examineExtend(statsBuilder, decl)
+			b.examineExtend(decl, depth)
 		}
 	}
+	b.MaxFieldsPerMessage = max(b.MaxFieldsPerMessage, fields)
 }
 
-func examineEnum(statsBuilder *statsBuilder, enumNode *ast.EnumNode) {
-	statsBuilder.Enums++
-	statsBuilder.Types++
+// examineGroup examines a group, which is both a field and a message at the given depth.
+func (b *statsBuilder) examineGroup(groupNode *ast.GroupNode, depth int) {
+	b.examineElement(groupNode, groupNode.Options)
+	b.examineElement(groupNode, groupNode.Decls)
+	b.examineMessage(&groupNode.MessageBody, depth)
+}
+
+func (b *statsBuilder) examineEnum(enumNode *ast.EnumNode) {
+	b.Enums++
+	b.Types++
+	b.examineElement(enumNode, enumNode.Decls)
 	for _, decl := range enumNode.Decls {
-		_, ok := decl.(*ast.EnumValueNode)
+		enumValueNode, ok := decl.(*ast.EnumValueNode)
 		if ok {
-			statsBuilder.EnumValues++
+			b.EnumValues++
+			b.examineElement(enumValueNode, enumValueNode.Options)
 		}
 	}
 }
 
-func examineExtend(statsBuilder *statsBuilder, extendNode *ast.ExtendNode) {
+// examineExtend examines an extend block within a message at the given depth, or at
+// the top level if depth is 0.
+func (b *statsBuilder) examineExtend(extendNode *ast.ExtendNode, depth int) {
 	for _, decl := range extendNode.Decls {
 		switch decl := decl.(type) {
 		case *ast.FieldNode:
-			statsBuilder.Extensions++
+			b.Extensions++
+			b.examineElement(decl, decl.Options)
 		case *ast.GroupNode:
-			statsBuilder.Extensions++
-			examineMessage(statsBuilder, &decl.MessageBody)
+			b.Extensions++
+			b.examineGroup(decl, depth+1)
+		}
+	}
+}
+
+// examineElement counts whether an element is commented or deprecated. The options of
+// the element are either a declaration in decls, or compact options.
+func (b *statsBuilder) examineElement(node ast.Node, options any) {
+	if b.fileNode.NodeInfo(node).LeadingComments().Len() > 0 {
+		b.Commented++
+	}
+	var optionNodes []*ast.OptionNode
+	switch options := options.(type) {
+	case *ast.CompactOptionsNode:
+		if options != nil {
+			optionNodes = options.Options
+		}
+	case []ast.MessageElement:
+		optionNodes = declsToOptionNodes(options)
+	case []ast.EnumElement:
+		optionNodes = declsToOptionNodes(options)
+	case []ast.ServiceElement:
+		optionNodes = declsToOptionNodes(options)
+	case []ast.RPCElement:
+		optionNodes = declsToOptionNodes(options)
+	}
+	for _, optionNode := range optionNodes {
+		if isDeprecatedOption(optionNode) {
+			b.Deprecated++
+			return
 		}
 	}
 }
+
+func declsToOptionNodes[T ast.Node](decls []T) []*ast.OptionNode {
+	var optionNodes []*ast.OptionNode
+	for _, decl := range decls {
+		if optionNode, ok := any(decl).(*ast.OptionNode); ok {
+			optionNodes = append(optionNodes, optionNode)
+		}
+	}
+	return optionNodes
+}
+
+// isDeprecatedOption returns whether the option is "deprecated = true".
+func isDeprecatedOption(optionNode *ast.OptionNode) bool {
+	if optionNode.Name == nil || len(optionNode.Name.Parts) != 1 || optionNode.Name.Parts[0].IsExtension() {
+		return false
+	}
+	if optionNode.Name.Parts[0].Value() != "deprecated" {
+		return false
+	}
+	identValue, ok := optionNode.Val.(ast.IdentValueNode)
+	return ok && identValue.AsIdentifier() == "true"
+}
@@ -0,0 +1,130 @@
+// Copyright 2020-2025 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protostat_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/protostat"
+	"github.com/bufbuild/buf/private/pkg/protostat/protostatstorage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStats(t *testing.T) {
+	t.Parallel()
+	fileWalker := newFileWalker(t)
+
+	stats, err := protostat.GetStats(context.Background(), fileWalker)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&protostat.Stats{
+			Files:               3,
+			Types:               8,
+			Packages:            2,
+			Messages:            5,
+			Fields:              5,
+			Enums:               1,
+			EnumValues:          2,
+			Services:            1,
+			RPCs:                2,
+			UnaryRPCs:           1,
+			StreamingRPCs:       1,
+			Deprecated:          2,
+			MaxMessageDepth:     3,
+			MaxFieldsPerMessage: 3,
+			FieldsPerMessage:    1,
+			Commented:           3,
+			CommentCoverage:     18.75,
+			Syntaxes: map[string]int{
+				"proto3":       2,
+				"edition 2023": 1,
+			},
+		},
+		stats,
+	)
+}
+
+func TestGetStatsByPackage(t *testing.T) {
+	t.Parallel()
+	fileWalker := newFileWalker(t)
+
+	groupStats, err := protostat.GetStatsByPackage(context.Background(), fileWalker)
+	require.NoError(t, err)
+	require.Len(t, groupStats, 2)
+	assert.Equal(t, "a.v1", groupStats[0].Name)
+	assert.Equal(t, 2, groupStats[0].Files)
+	assert.Equal(t, 1, groupStats[0].Packages)
+	assert.Equal(t, 3, groupStats[0].MaxMessageDepth)
+	assert.Equal(t, "b.v1", groupStats[1].Name)
+	assert.Equal(t, 1, groupStats[1].Files)
+	assert.Equal(t, 2, groupStats[1].RPCs)
+
+	groupStats, err = protostat.GetStatsByFile(context.Background(), fileWalker)
+	require.NoError(t, err)
+	require.Len(t, groupStats, 3)
+	assert.Equal(t, "a/v1/a.proto", groupStats[0].Name)
+	assert.Equal(t, "a/v1/nested.proto", groupStats[1].Name)
+	assert.Equal(t, "b/v1/b.proto", groupStats[2].Name)
+	assert.Equal(t, map[string]int{"edition 2023": 1}, groupStats[2].Syntaxes)
+}
+
+func newFileWalker(t *testing.T) protostat.FileWalker {
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"a/v1/a.proto": []byte(`syntax = "proto3";
+package a.v1;
+// Foo is a foo.
+message Foo {
+  // bar is a bar.
+  string bar = 1;
+  int32 baz = 2 [deprecated = true];
+  repeated string qux = 3;
+}
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_ONE = 1;
+}
+`),
+			"a/v1/nested.proto": []byte(`syntax = "proto3";
+package a.v1;
+message Outer {
+  message Middle {
+    message Inner {
+      string value = 1;
+    }
+  }
+}
+`),
+			"b/v1/b.proto": []byte(`edition = "2023";
+package b.v1;
+service BService {
+  option deprecated = true;
+  // Get gets.
+  rpc Get(Request) returns (Request);
+  rpc Watch(Request) returns (stream Request);
+}
+message Request {
+  string name = 1;
+}
+`),
+		},
+	)
+	require.NoError(t, err)
+	return protostatstorage.NewFileWalker(readBucket)
+}
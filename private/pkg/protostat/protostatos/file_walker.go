@@ -32,7 +32,7 @@ func newFileWalker(filenames []string) *fileWalker {
 	}
 }
 
-func (f *fileWalker) Walk(ctx context.Context, fu func(io.Reader) error) error {
+func (f *fileWalker) Walk(ctx context.Context, fu func(string, io.Reader) error) error {
 	for _, filename := range f.filenames {
 		if filepath.Ext(filename) != ".proto" {
 			continue
@@ -41,7 +41,7 @@ func (f *fileWalker) Walk(ctx context.Context, fu func(io.Reader) error) error {
 		if err != nil {
 			return err
 		}
-		if err := fu(file); err != nil {
+		if err := fu(filename, file); err != nil {
 			return errors.Join(err, file.Close())
 		}
 		if err := file.Close(); err != nil {